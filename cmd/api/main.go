@@ -1,34 +1,140 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"net"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 
 	"usrsvc/internal/config"
+	"usrsvc/internal/domain"
+	"usrsvc/internal/events"
+	"usrsvc/internal/jobs"
+	"usrsvc/internal/pii"
+	"usrsvc/internal/pkg/auth"
+	"usrsvc/internal/pkg/cache"
 	"usrsvc/internal/pkg/db"
+	"usrsvc/internal/pkg/keyring"
 	"usrsvc/internal/pkg/log"
+	"usrsvc/internal/pkg/tenant"
 	"usrsvc/internal/repository"
+	tg "usrsvc/internal/transport/grpc"
 	th "usrsvc/internal/transport/http"
 	"usrsvc/internal/usecase"
+	"usrsvc/internal/webhooks"
 )
 
 func main() {
 	_ = godotenv.Load()
 
 	cfg := config.Load()
-	pool, err := db.NewPool(cfg.PGDSN)
-	if err != nil {
-		log.Error.Fatalf("db: %v", err)
+	if len(cfg.PIIFullRoles) > 0 && cfg.TenantJWTSecret == "" && !cfg.TrustedProxy {
+		log.Error.Fatalf("PII_FULL_ROLES: needs TENANT_JWT_SECRET or TRUSTED_PROXY=true to know the caller's role")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		repo  domain.UserRepository
+		audit domain.AuditLog
+		opts  []th.HandlerOption
+		seeds []domain.Customer
+	)
+	switch cfg.Storage {
+	case "memory":
+		ns := repository.DefaultNationalities()
+		if cfg.Fixtures != "" {
+			var err error
+			if ns, seeds, err = repository.LoadFixtures(cfg.Fixtures); err != nil {
+				log.Error.Fatalf("STORAGE_FIXTURES: %v", err)
+			}
+		}
+		repo = repository.NewMemoryUserRepo(ns)
+		log.Info.Printf("storage memory nationalities=%d fixtures=%d (no events, webhooks or change feed)", len(ns), len(seeds))
+	case "sqlite":
+		sdb, err := repository.OpenSQLite(ctx, cfg.SQLitePath)
+		if err != nil {
+			log.Error.Fatalf("sqlite: %v", err)
+		}
+		defer sdb.Close()
+		srepo := repository.NewSqliteUserRepo(sdb)
+		// Fixture customers only seed a new database; nationalities come
+		// from the schema.
+		if _, total, err := srepo.ListCustomers(ctx, "", 1, 0); err != nil {
+			log.Error.Fatalf("sqlite: %v", err)
+		} else if total == 0 && cfg.Fixtures != "" {
+			if _, seeds, err = repository.LoadFixtures(cfg.Fixtures); err != nil {
+				log.Error.Fatalf("STORAGE_FIXTURES: %v", err)
+			}
+		}
+		repo = srepo
+		log.Info.Printf("storage sqlite path=%s fixtures=%d (no events, webhooks or change feed)", cfg.SQLitePath, len(seeds))
+	case "postgres":
+		pool, err := db.NewPool(cfg.PGDSN)
+		if err != nil {
+			log.Error.Fatalf("db: %v", err)
+		}
+		defer pool.Close()
+		repo, audit, opts = postgres(ctx, cfg, pool)
+	default:
+		log.Error.Fatalf("STORAGE: unknown storage %q", cfg.Storage)
 	}
-	defer pool.Close()
 
-	repo := repository.NewPgUserRepo(pool)
-	uc := usecase.NewUserUC(repo)
-	h := th.NewHandler(uc)
+	rules := usecase.DefaultRules()
+	rules.MinCustomerAge = cfg.MinCustomerAge
+	rules.MaxFamily = cfg.MaxFamilyMembers
+	uc := usecase.NewUserUC(repo, usecase.WithRules(rules), usecase.WithAudit(audit))
+	deprecated, err := time.Parse(time.DateOnly, cfg.LegacyDeprecated)
+	if err != nil {
+		log.Error.Fatalf("LEGACY_DEPRECATED: %v", err)
+	}
+	sunset, err := time.Parse(time.DateOnly, cfg.LegacySunset)
+	if err != nil {
+		log.Error.Fatalf("LEGACY_SUNSET: %v", err)
+	}
+	opts = append(opts, th.WithLegacyLifecycle(deprecated, sunset))
+	for i, c := range seeds {
+		if _, err := uc.Create(ctx, c); err != nil {
+			log.Error.Fatalf("STORAGE_FIXTURES: customers[%d]: %v", i, err)
+		}
+	}
+	tenants := tenant.Resolver{Strict: cfg.TenantStrict, DefaultOnly: cfg.Storage != "postgres", JWTSecret: []byte(cfg.TenantJWTSecret), JWTClaim: cfg.TenantJWTClaim}
+	callers := auth.Resolver{JWTSecret: []byte(cfg.TenantJWTSecret), TrustHeaders: cfg.TrustedProxy}
+	policy := pii.Policy{FullRoles: cfg.PIIFullRoles, Audit: audit}
+	opts = append(opts, th.WithTenants(tenants), th.WithCallers(callers), th.WithPII(policy))
+	if cfg.Storage == "postgres" && !callers.Verified() {
+		log.Info.Printf("jobs admin routes off: they need TENANT_JWT_SECRET")
+	}
+	if cfg.Storage == "postgres" && callers.Anonymous() {
+		log.Info.Printf("webhook routes answer 403: they need TENANT_JWT_SECRET or TRUSTED_PROXY")
+	}
+	if len(cfg.PIIFullRoles) > 0 {
+		log.Info.Printf("pii masking full_roles=%v audit=%t trusted_proxy=%t", cfg.PIIFullRoles, audit != nil, cfg.TrustedProxy)
+	}
+	h := th.NewHandler(uc, opts...)
 	r := th.NewRouter(h, cfg.CORSAllow)
 
+	if cfg.GRPCPort != "off" {
+		lis, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+		if err != nil {
+			log.Error.Fatalf("grpc listen: %v", err)
+		}
+		gs := tg.NewServer(uc, tg.WithTenants(tenants), tg.WithCallers(callers), tg.WithPII(policy))
+		defer gs.GracefulStop()
+		go func() {
+			log.Info.Printf("grpc listening on %s", lis.Addr())
+			if err := gs.Serve(lis); err != nil {
+				log.Error.Printf("grpc: %v", err)
+			}
+		}()
+	}
+
 	addr := ":" + cfg.Port
 	log.Info.Printf("listening on %s", addr)
 	if err := http.ListenAndServe(addr, r); err != nil {
@@ -36,3 +142,140 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// postgres starts the Postgres-only background work (cache invalidation,
+// outbox relay, webhook dispatcher, change feed, job scheduler) and returns
+// the repository, the audit log and the handler options it enables.
+func postgres(ctx context.Context, cfg config.Config, pool *pgxpool.Pool) (domain.UserRepository, domain.AuditLog, []th.HandlerOption) {
+	var pgOpts []repository.PgOption
+	cacheOpts := []repository.CachedOption{repository.WithReplicaLag(cfg.ReplicaMaxLag)}
+	if cfg.PIIKeyring != "" {
+		keys, err := keyring.Load(cfg.PIIKeyring)
+		if err != nil {
+			log.Error.Fatalf("PII_KEYRING: %v", err)
+		}
+		pgOpts = append(pgOpts, repository.WithKeyring(keys))
+		cacheOpts = append(cacheOpts, repository.WithSealedEntries(keys))
+		log.Info.Printf("pii keyring primary=%s", keys.Primary())
+	} else {
+		log.Info.Printf("PII_KEYRING not set, customer PII is stored in plaintext")
+	}
+	var hOpts []th.HandlerOption
+	if len(cfg.PGReplicaDSNs) > 0 {
+		var pools []*pgxpool.Pool
+		for i, dsn := range cfg.PGReplicaDSNs {
+			p, err := db.NewPool(dsn)
+			if err != nil {
+				log.Error.Fatalf("PG_REPLICA_DSNS[%d]: %v", i, err)
+			}
+			pools = append(pools, p)
+		}
+		replicas := repository.NewReplicas(pools, cfg.ReplicaMaxLag)
+		go replicas.Monitor(ctx, time.Second)
+		pgOpts = append(pgOpts, repository.WithReplicas(replicas))
+		hOpts = append(hOpts, th.WithReadYourWrites(cfg.ReplicaMaxLag))
+		log.Info.Printf("read replicas=%d max_lag=%s", len(pools), cfg.ReplicaMaxLag)
+	}
+	pgRepo := repository.NewPgUserRepo(pool, pgOpts...)
+	var repo domain.UserRepository = pgRepo
+	if cfg.CacheSize > 0 {
+		cached := repository.NewCachedRepo(repo, cache.NewLRU(cfg.CacheSize), cfg.CacheTTL, repository.NewPgNotifier(pool), cacheOpts...)
+		go repository.ListenInvalidations(ctx, pool, cached.Evict)
+		repo = cached
+	}
+	whRepo := repository.NewPgWebhookRepo(pool)
+	pubs := []events.Publisher{webhooks.Publisher{Store: whRepo}}
+	if pub := newPublisher(cfg.EventsPublisher); pub != nil {
+		pubs = append(pubs, pub)
+	}
+	outbox := repository.NewPgOutbox(pool)
+	go events.NewRelay(outbox, events.Fanout(pubs...)).Run(ctx)
+	dispatcher := webhooks.NewDispatcher(whRepo)
+	dispatcher.MaxAttempts = cfg.WebhookMaxAttempts
+	go dispatcher.Run(ctx)
+	feed := events.NewBroker(cfg.SSEReplay, cfg.SSESubBuffer)
+	go repository.ListenEvents(ctx, pool, cfg.SSEReplay, func(e domain.Event) { _ = feed.Publish(ctx, e) })
+
+	jobStore := repository.NewPgJobStore(pool)
+	sched := jobs.NewScheduler(jobStore, instanceName())
+	purge := func(what string, fn func(context.Context, time.Time) (int64, error)) func(context.Context) error {
+		return func(ctx context.Context) error {
+			n, err := fn(ctx, time.Now().Add(-cfg.PurgeAfter))
+			if err == nil {
+				log.Info.Printf("purge %s ok deleted=%d older_than=%s", what, n, cfg.PurgeAfter)
+			}
+			return err
+		}
+	}
+	addJobs(sched, cfg.JobSpecs, []job{
+		{"purge_outbox", "15 3 * * *", purge("outbox", outbox.Purge)},
+		{"purge_webhook_deliveries", "30 3 * * *", purge("webhook_deliveries", whRepo.PurgeDeliveries)},
+		{"purge_job_runs", "45 3 * * *", purge("job_runs", jobStore.Purge)},
+		{"recompute_stats", "0 4 * * *", pgRepo.RecomputeStats},
+		{"retry_dead_deliveries", "30 4 * * *", func(ctx context.Context) error {
+			n, err := whRepo.RetryDeadDeliveries(ctx, time.Now().Add(-24*time.Hour))
+			if err == nil {
+				log.Info.Printf("retry_dead_deliveries ok queued=%d", n)
+			}
+			return err
+		}},
+	})
+	go sched.Run(ctx)
+
+	return repo, repository.NewPgAuditLog(pool), append(hOpts, th.WithWebhooks(usecase.NewWebhookUC(whRepo)), th.WithEventFeed(feed), th.WithJobs(sched))
+}
+
+type job struct {
+	name, spec string
+	run        func(context.Context) error
+}
+
+// addJobs registers js on s with their specs overridden by JOB_SPECS.
+func addJobs(s *jobs.Scheduler, specs map[string]string, js []job) {
+	known := map[string]bool{}
+	for _, j := range js {
+		known[j.name] = true
+		if v, ok := specs[j.name]; ok {
+			j.spec = v
+		}
+		if j.spec == "off" {
+			log.Info.Printf("job %s off", j.name)
+			continue
+		}
+		if err := s.Add(j.name, j.spec, j.run); err != nil {
+			log.Error.Fatalf("JOB_SPECS: %s: %v", j.name, err)
+		}
+		log.Info.Printf("job %s spec=%q", j.name, j.spec)
+	}
+	for name := range specs {
+		if !known[name] {
+			log.Error.Fatalf("JOB_SPECS: unknown job %q", name)
+		}
+	}
+}
+
+// instanceName tells replicas apart in the job run history.
+func instanceName() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s/%d", host, os.Getpid())
+}
+
+func newPublisher(spec string) events.Publisher {
+	switch {
+	case spec == "":
+		return nil
+	case spec == "stdout":
+		return events.NewWriterPublisher(os.Stdout)
+	case strings.HasPrefix(spec, "file:"):
+		p, _, err := events.NewFilePublisher(strings.TrimPrefix(spec, "file:"))
+		if err != nil {
+			log.Error.Fatalf("EVENTS_PUBLISHER: %v", err)
+		}
+		return p
+	}
+	log.Error.Fatalf("EVENTS_PUBLISHER: unknown publisher %q", spec)
+	return nil
+}
@@ -0,0 +1,80 @@
+// Command backfillphone fills cst_phone_e164 for customers written before
+// migration 0002, which the app only sets on create and update; until then
+// normalized phone searches miss them. Numbers are read in the region of the
+// customer's nationality, as the usecase does, and numbers it cannot read
+// are reported and left alone. It is safe to run again, and while the
+// service runs.
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/joho/godotenv"
+
+	"usrsvc/internal/config"
+	"usrsvc/internal/domain"
+	"usrsvc/internal/pkg/db"
+	"usrsvc/internal/pkg/keyring"
+	"usrsvc/internal/pkg/log"
+	"usrsvc/internal/pkg/phone"
+	"usrsvc/internal/pkg/tenant"
+	"usrsvc/internal/repository"
+)
+
+func main() {
+	tenants := flag.String("tenants", tenant.Default, "comma-separated tenants to process")
+	batch := flag.Int("batch", 500, "rows per transaction")
+	flag.Parse()
+	_ = godotenv.Load()
+
+	cfg := config.Load()
+	pool, err := db.NewPool(cfg.PGDSN)
+	if err != nil {
+		log.Error.Fatalf("db: %v", err)
+	}
+	defer pool.Close()
+	var opts []repository.PgOption
+	if cfg.PIIKeyring != "" {
+		keys, err := keyring.Load(cfg.PIIKeyring)
+		if err != nil {
+			log.Error.Fatalf("PII_KEYRING: %v", err)
+		}
+		opts = append(opts, repository.WithKeyring(keys))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	repo := repository.NewPgUserRepo(pool, opts...)
+	for _, t := range strings.Split(*tenants, ",") {
+		t = strings.TrimSpace(t)
+		tctx := tenant.With(ctx, t)
+		ns, err := repo.ListNationalities(tctx)
+		if err != nil {
+			log.Error.Fatalf("backfill_phone tenant=%s: %v", t, err)
+		}
+		regions := map[int32]string{}
+		for _, n := range ns {
+			if n.Code != nil {
+				regions[n.ID] = *n.Code
+			}
+		}
+		skipped := 0
+		n, err := repo.BackfillPhoneE164(tctx, *batch, func(c domain.Customer) string {
+			e164, err := phone.Normalize(c.PhoneNum, regions[c.NationalityID])
+			if err != nil {
+				skipped++
+				log.Info.Printf("backfill_phone skip tenant=%s id=%d: %v", t, c.ID, err)
+				return ""
+			}
+			return e164
+		})
+		if err != nil {
+			log.Error.Fatalf("backfill_phone tenant=%s rows=%d: %v", t, n, err)
+		}
+		log.Info.Printf("backfill_phone ok tenant=%s rows=%d skipped=%d", t, n, skipped)
+	}
+}
@@ -3,13 +3,71 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Config struct {
-	Port      string
-	PGDSN     string
-	CORSAllow []string
+	Storage    string // "postgres", "sqlite" or "memory"
+	Fixtures   string // seed file for STORAGE=memory or an empty sqlite database
+	SQLitePath string
+	Port       string
+	GRPCPort   string // "off" disables the gRPC listener
+	PGDSN      string
+	CORSAllow  []string
+
+	// PGReplicaDSNs are read replicas for list/get queries. A replica lagging
+	// more than ReplicaMaxLag is skipped, and a session that wrote reads from
+	// the primary for that long.
+	PGReplicaDSNs []string
+	ReplicaMaxLag time.Duration
+
+	// TenantStrict rejects requests without a tenant instead of using the
+	// default one. With TenantJWTSecret set the tenant comes only from the
+	// TenantJWTClaim of an HS256 bearer token.
+	TenantStrict    bool
+	TenantJWTSecret string
+	TenantJWTClaim  string
+
+	// PIIKeyring is the keyring file customer email, phone and dates of birth
+	// are sealed with; empty stores them in plaintext.
+	PIIKeyring string
+	// PIIFullRoles are the caller roles that see customer PII unmasked; any
+	// other caller sees masked values unless it holds the pii:unmask scope.
+	// Empty turns masking off. The caller comes from the sub/role/scope
+	// claims when TenantJWTSecret is set, or from X-Actor/X-Role/X-Scopes
+	// with TrustedProxy; without either it is anonymous, and PIIFullRoles is
+	// refused.
+	PIIFullRoles []string
+	// TrustedProxy trusts the caller headers, for a gateway that sets them and
+	// strips them from clients.
+	TrustedProxy bool
+
+	MinCustomerAge   int
+	MaxFamilyMembers int
+
+	// LegacyDeprecated and LegacySunset are the YYYY-MM-DD dates announced in
+	// the Deprecation and Sunset headers of the unversioned routes.
+	LegacyDeprecated string
+	LegacySunset     string
+
+	CacheSize int // entries in the in-process cache, 0 disables caching
+	CacheTTL  time.Duration
+
+	EventsPublisher string // "", "stdout" or "file:<path>", in addition to webhooks
+
+	WebhookMaxAttempts int
+
+	SSEReplay    int // events kept per instance for Last-Event-ID resume
+	SSESubBuffer int // queued events per SSE client before it is dropped
+
+	// JobSpecs overrides the cron spec of scheduled jobs by name, from
+	// JOB_SPECS="purge_outbox=0 4 * * *;purge_job_runs=off"; "off" disables
+	// a job. PurgeAfter is how long the purge jobs keep published outbox
+	// events, succeeded webhook deliveries and job runs.
+	JobSpecs   map[string]string
+	PurgeAfter time.Duration
 }
 
 func Load() Config {
@@ -24,15 +82,37 @@ func Load() Config {
 		ssl := getenv("DB_SSLMODE", "disable")
 		dsn = fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=%s", user, pass, host, portDB, name, ssl)
 	}
-	var cors []string
-	if s := os.Getenv("CORS_ALLOW_ORIGINS"); s != "" {
-		for _, p := range strings.Split(s, ",") {
-			if v := strings.TrimSpace(p); v != "" {
-				cors = append(cors, v)
-			}
-		}
+	return Config{
+		Storage: getenv("STORAGE", "postgres"), Fixtures: os.Getenv("STORAGE_FIXTURES"), SQLitePath: getenv("SQLITE_PATH", "usrsvc.db"),
+		Port: port, GRPCPort: getenv("GRPC_PORT", "9090"), PGDSN: dsn, CORSAllow: getenvList("CORS_ALLOW_ORIGINS"),
+
+		PGReplicaDSNs: getenvList("PG_REPLICA_DSNS"),
+		ReplicaMaxLag: getenvDuration("PG_REPLICA_MAX_LAG", 5*time.Second),
+
+		TenantStrict:    getenvBool("TENANT_REQUIRED"),
+		TenantJWTSecret: os.Getenv("TENANT_JWT_SECRET"),
+		TenantJWTClaim:  getenv("TENANT_JWT_CLAIM", "tenant_id"),
+
+		PIIKeyring:   os.Getenv("PII_KEYRING"),
+		PIIFullRoles: getenvList("PII_FULL_ROLES"),
+		TrustedProxy: getenvBool("TRUSTED_PROXY"),
+
+		MinCustomerAge:   getenvInt("MIN_CUSTOMER_AGE", 17),
+		MaxFamilyMembers: getenvInt("MAX_FAMILY_MEMBERS", 20),
+		LegacyDeprecated: getenv("LEGACY_DEPRECATED", "2026-10-19"),
+		LegacySunset:     getenv("LEGACY_SUNSET", "2027-04-30"),
+		CacheSize:        getenvInt("CACHE_SIZE", 1000),
+		CacheTTL:         getenvDuration("CACHE_TTL", time.Minute),
+		EventsPublisher:  os.Getenv("EVENTS_PUBLISHER"),
+
+		WebhookMaxAttempts: getenvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+
+		SSEReplay:    getenvInt("SSE_REPLAY", 1000),
+		SSESubBuffer: getenvInt("SSE_CLIENT_BUFFER", 64),
+
+		JobSpecs:   getenvMap("JOB_SPECS"),
+		PurgeAfter: getenvDuration("PURGE_AFTER", 30*24*time.Hour),
 	}
-	return Config{Port: port, PGDSN: dsn, CORSAllow: cors}
 }
 
 func getenv(k, def string) string {
@@ -41,3 +121,43 @@ func getenv(k, def string) string {
 	}
 	return def
 }
+
+func getenvList(k string) []string {
+	var out []string
+	for _, p := range strings.Split(os.Getenv(k), ",") {
+		if v := strings.TrimSpace(p); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// getenvMap reads "k=v;k=v"; values may contain commas, as cron specs do.
+func getenvMap(k string) map[string]string {
+	out := map[string]string{}
+	for _, p := range strings.Split(os.Getenv(k), ";") {
+		if name, v, ok := strings.Cut(p, "="); ok {
+			out[strings.TrimSpace(name)] = strings.TrimSpace(v)
+		}
+	}
+	return out
+}
+
+func getenvBool(k string) bool {
+	v, _ := strconv.ParseBool(os.Getenv(k))
+	return v
+}
+
+func getenvInt(k string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(k)); err == nil {
+		return v
+	}
+	return def
+}
+
+func getenvDuration(k string, def time.Duration) time.Duration {
+	if v, err := time.ParseDuration(os.Getenv(k)); err == nil {
+		return v
+	}
+	return def
+}
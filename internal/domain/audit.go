@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Audit actions.
+const (
+	AuditPIIUnmask = "pii.unmask"
+	AuditExport    = "customer.export"
+	AuditAnonymize = "customer.anonymize"
+)
+
+// AuditEntry records a privileged access to customer data: who (Actor, Role)
+// did what (Action) through which operation, to which customers and why.
+type AuditEntry struct {
+	Action      string
+	Operation   string
+	Actor       string
+	Role        string
+	CustomerIDs []int32
+	Reason      string
+	At          time.Time
+}
+
+//go:generate mockery --name=AuditLog --output=../mocks --case=underscore
+type AuditLog interface {
+	// Record stores e durably; callers must not reveal the data it covers
+	// when it fails.
+	Record(ctx context.Context, e AuditEntry) error
+}
@@ -22,6 +22,7 @@ type Customer struct {
 	Name          string
 	Dob           time.Time
 	PhoneNum      string
+	PhoneE164     string
 	Email         string
 	Family        []FamilyMember
 }
@@ -6,4 +6,6 @@ var (
 	ErrNotFound  = errors.New("not found")
 	ErrInvalidID = errors.New("invalid id")
 	ErrConflict  = errors.New("conflict")
+
+	ErrInvalidPhone = errors.New("invalid phone number")
 )
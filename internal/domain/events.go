@@ -0,0 +1,164 @@
+package domain
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	EventCustomerCreated = "customer.created"
+	EventCustomerUpdated = "customer.updated"
+	EventCustomerDeleted = "customer.deleted"
+	EventCustomerMerged  = "customer.merged"
+)
+
+// DomainEvent is a typed change to one customer; the repository stores it
+// in the outbox in the same transaction as the change itself.
+type DomainEvent interface {
+	EventType() string
+	AggregateID() int32
+}
+
+// Event is the outbox envelope handed to publishers.
+type Event struct {
+	ID         int64           `json:"id"`
+	Type       string          `json:"type"`
+	Tenant     string          `json:"tenant_id"`
+	CustomerID int32           `json:"customer_id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// CustomerCreated marshals the customer the way the API renders it, see
+// MarshalJSON. Redacted leaves out dates of birth, phones and email.
+type CustomerCreated struct {
+	Customer Customer
+	Redacted bool
+}
+
+// CustomerUpdated carries only the fields that changed, keyed by their API
+// (json) names, e.g. {"cst_email": {"old": "a@x.com", "new": "b@x.com"}}.
+type CustomerUpdated struct {
+	CustomerID int32             `json:"customer_id"`
+	Changes    map[string]Change `json:"changes"`
+}
+
+type CustomerDeleted struct {
+	CustomerID int32 `json:"customer_id"`
+}
+
+// Change is one changed field; a Redacted one names the field only.
+type Change struct {
+	Old      any  `json:"old,omitempty"`
+	New      any  `json:"new,omitempty"`
+	Redacted bool `json:"redacted,omitempty"`
+}
+
+func (e CustomerCreated) EventType() string  { return EventCustomerCreated }
+func (e CustomerCreated) AggregateID() int32 { return e.Customer.ID }
+func (e CustomerUpdated) EventType() string  { return EventCustomerUpdated }
+func (e CustomerUpdated) AggregateID() int32 { return e.CustomerID }
+func (e CustomerDeleted) EventType() string  { return EventCustomerDeleted }
+func (e CustomerDeleted) AggregateID() int32 { return e.CustomerID }
+
+// MarshalJSON renders {"customer": {...}} with the API (json) field names.
+func (e CustomerCreated) MarshalJSON() ([]byte, error) {
+	c := e.Customer
+	entry := customerEntry{
+		ID: c.ID, Name: strings.TrimSpace(c.Name), Dob: c.Dob.Format("2006-01-02"), NationalityID: c.NationalityID,
+		PhoneNum: c.PhoneNum, PhoneE164: c.PhoneE164, Email: c.Email, Family: familyEntries(c.Family),
+	}
+	if e.Redacted {
+		entry.Dob, entry.PhoneNum, entry.PhoneE164, entry.Email = "", "", "", ""
+		for i := range entry.Family {
+			entry.Family[i].Dob = ""
+		}
+	}
+	return json.Marshal(struct {
+		Customer customerEntry `json:"customer"`
+	}{entry})
+}
+
+type customerEntry struct {
+	ID            int32         `json:"cst_id"`
+	Name          string        `json:"cst_name"`
+	Dob           string        `json:"cst_dob,omitempty"`
+	NationalityID int32         `json:"nationality_id"`
+	PhoneNum      string        `json:"cst_phoneNum,omitempty"`
+	PhoneE164     string        `json:"cst_phone_e164,omitempty"`
+	Email         string        `json:"cst_email,omitempty"`
+	Family        []familyEntry `json:"family"`
+}
+
+type familyEntry struct {
+	Relation string `json:"fl_relation"`
+	Name     string `json:"fl_name"`
+	Dob      string `json:"fl_dob,omitempty"`
+}
+
+// piiFields are the DiffCustomer fields holding a date of birth, phone or
+// email.
+var piiFields = []string{"cst_dob", "cst_phoneNum", "cst_phone_e164", "cst_email", "family"}
+
+// RedactPII returns ev without dates of birth, phones and emails, for
+// storage that keeps them sealed: customer.created leaves them out and
+// customer.updated names the changed ones without their values. Other events
+// carry none and are returned as they are.
+func RedactPII(ev DomainEvent) DomainEvent {
+	switch e := ev.(type) {
+	case CustomerCreated:
+		e.Redacted = true
+		return e
+	case CustomerUpdated:
+		changes := make(map[string]Change, len(e.Changes))
+		for k, v := range e.Changes {
+			if slices.Contains(piiFields, k) {
+				v = Change{Redacted: true}
+			}
+			changes[k] = v
+		}
+		e.Changes = changes
+		return e
+	}
+	return ev
+}
+
+// DiffCustomer compares the stored fields of two customers. Family is
+// compared as a whole list since members are replaced on every update.
+func DiffCustomer(old, cur Customer) map[string]Change {
+	out := map[string]Change{}
+	add := func(field string, o, n any) {
+		if o != n {
+			out[field] = Change{Old: o, New: n}
+		}
+	}
+	add("nationality_id", old.NationalityID, cur.NationalityID)
+	add("cst_name", strings.TrimSpace(old.Name), strings.TrimSpace(cur.Name))
+	add("cst_dob", old.Dob.Format("2006-01-02"), cur.Dob.Format("2006-01-02"))
+	add("cst_phoneNum", old.PhoneNum, cur.PhoneNum)
+	add("cst_phone_e164", old.PhoneE164, cur.PhoneE164)
+	add("cst_email", old.Email, cur.Email)
+
+	of, nf := familyEntries(old.Family), familyEntries(cur.Family)
+	if len(of) != len(nf) {
+		out["family"] = Change{Old: of, New: nf}
+	} else {
+		for i := range of {
+			if of[i] != nf[i] {
+				out["family"] = Change{Old: of, New: nf}
+				break
+			}
+		}
+	}
+	return out
+}
+
+func familyEntries(fs []FamilyMember) []familyEntry {
+	out := make([]familyEntry, 0, len(fs))
+	for _, f := range fs {
+		out = append(out, familyEntry{Relation: f.Relation, Name: f.Name, Dob: f.Dob.Format("2006-01-02")})
+	}
+	return out
+}
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffCustomer(t *testing.T) {
+	dob := time.Date(1992, 5, 10, 0, 0, 0, 0, time.UTC)
+	base := Customer{ID: 36, NationalityID: 1, Name: "ALFA   ", Dob: dob, PhoneNum: "0811", Email: "a@example.com",
+		Family: []FamilyMember{{ID: 1, Relation: RelationSpouse, Name: "BETA", Dob: dob}}}
+
+	tests := []struct {
+		name   string
+		mutate func(c *Customer)
+		want   []string
+	}{
+		{name: "no_changes_ignores_padding_and_ids", mutate: func(c *Customer) {
+			c.Name = "ALFA"
+			c.Family = []FamilyMember{{ID: 99, Relation: RelationSpouse, Name: "BETA", Dob: dob}}
+		}},
+		{name: "scalar_fields", mutate: func(c *Customer) { c.Email = "b@example.com"; c.Dob = dob.AddDate(0, 0, 1) },
+			want: []string{"cst_dob", "cst_email"}},
+		{name: "family_member_added", mutate: func(c *Customer) {
+			c.Family = append(append([]FamilyMember(nil), c.Family...), FamilyMember{Relation: RelationChild, Name: "GAMMA", Dob: dob})
+		}, want: []string{"family"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cur := base
+			tc.mutate(&cur)
+			got := DiffCustomer(base, cur)
+			keys := make([]string, 0, len(got))
+			for k := range got {
+				keys = append(keys, k)
+			}
+			assert.ElementsMatch(t, tc.want, keys)
+		})
+	}
+
+	d := DiffCustomer(base, Customer{ID: 36, NationalityID: 1, Name: "ALFA", Dob: dob, PhoneNum: "0811", Email: "c@example.com", Family: base.Family})
+	assert.Equal(t, Change{Old: "a@example.com", New: "c@example.com"}, d["cst_email"])
+}
+
+func TestCustomerCreated_MarshalJSON(t *testing.T) {
+	dob := time.Date(1992, 5, 10, 0, 0, 0, 0, time.UTC)
+	b, err := json.Marshal(CustomerCreated{Customer: Customer{ID: 36, NationalityID: 1, Name: "ALFA  ", Dob: dob, PhoneNum: "0811", Email: "a@example.com",
+		Family: []FamilyMember{{ID: 1, CustomerID: 36, Relation: RelationSpouse, Name: "BETA", Dob: dob}}}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"customer":{"cst_id":36,"cst_name":"ALFA","cst_dob":"1992-05-10","nationality_id":1,"cst_phoneNum":"0811",
+		"cst_email":"a@example.com","family":[{"fl_relation":"`+RelationSpouse+`","fl_name":"BETA","fl_dob":"1992-05-10"}]}}`, string(b))
+}
+
+func TestRedactPII(t *testing.T) {
+	dob := time.Date(1992, 5, 10, 0, 0, 0, 0, time.UTC)
+	c := Customer{ID: 36, NationalityID: 1, Name: "ALFA", Dob: dob, PhoneNum: "0811", PhoneE164: "+62811", Email: "a@example.com",
+		Family: []FamilyMember{{Relation: RelationSpouse, Name: "BETA", Dob: dob}}}
+
+	tests := []struct {
+		name string
+		ev   DomainEvent
+		want string
+	}{
+		{name: "created", ev: CustomerCreated{Customer: c},
+			want: `{"customer":{"cst_id":36,"cst_name":"ALFA","nationality_id":1,"family":[{"fl_relation":"` + RelationSpouse + `","fl_name":"BETA"}]}}`},
+		{name: "updated", ev: CustomerUpdated{CustomerID: 36, Changes: map[string]Change{
+			"cst_email": {Old: "a@example.com", New: "b@example.com"}, "cst_name": {Old: "ALFA", New: "ALPHA"}}},
+			want: `{"customer_id":36,"changes":{"cst_email":{"redacted":true},"cst_name":{"old":"ALFA","new":"ALPHA"}}}`},
+		{name: "deleted", ev: CustomerDeleted{CustomerID: 36}, want: `{"customer_id":36}`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := json.Marshal(RedactPII(tc.ev))
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.want, string(b))
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// FieldFamily selects every family member field; family.<name> selects one.
+const FieldFamily = "family"
+
+// CustomerFields are the names a Fields selection may use: the customer's
+// JSON names, and family or family.<member field>.
+var CustomerFields = []string{
+	"cst_id", "cst_name", "cst_dob", "nationality_id", "cst_phoneNum", "cst_phone_e164", "cst_email",
+	FieldFamily, "family.fl_relation", "family.fl_name", "family.fl_dob",
+}
+
+// UnknownFieldError names a field that is not in CustomerFields.
+type UnknownFieldError struct{ Name string }
+
+func (e *UnknownFieldError) Error() string { return "unknown field " + e.Name }
+
+// Fields is a sparse selection of customer fields. The nil Fields selects
+// everything.
+type Fields map[string]bool
+
+// ParseFields reads a comma-separated selection such as
+// "cst_id,cst_name,family.fl_name". An empty s selects everything.
+func ParseFields(s string) (Fields, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	f := Fields{}
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if !slices.Contains(CustomerFields, name) {
+			return nil, &UnknownFieldError{Name: name}
+		}
+		f[name] = true
+	}
+	return f, nil
+}
+
+// Has reports whether name is selected; "family" selects all family.* names.
+func (f Fields) Has(name string) bool {
+	return f == nil || f[name] || (f[FieldFamily] && strings.HasPrefix(name, FieldFamily+"."))
+}
+
+// Family reports whether any family field is selected.
+func (f Fields) Family() bool {
+	if f == nil {
+		return true
+	}
+	for name := range f {
+		if name == FieldFamily || strings.HasPrefix(name, FieldFamily+".") {
+			return true
+		}
+	}
+	return false
+}
+
+type fieldsKey struct{}
+
+// WithFields asks the repository to load only f for reads under ctx. Data it
+// skips is left zero, so it is a hint for rendering, not for writes.
+func WithFields(ctx context.Context, f Fields) context.Context {
+	return context.WithValue(ctx, fieldsKey{}, f)
+}
+
+// FieldsFrom returns the selection of ctx, nil when it has none.
+func FieldsFrom(ctx context.Context) Fields {
+	f, _ := ctx.Value(fieldsKey{}).(Fields)
+	return f
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		in         string
+		has, hasnt []string
+		family     bool
+	}{
+		{in: "", has: []string{"cst_id", "cst_email", "family.fl_dob"}, family: true},
+		{in: "cst_id, cst_name", has: []string{"cst_id", "cst_name"}, hasnt: []string{"cst_email", "family.fl_name"}},
+		{in: "cst_id,family.fl_name", has: []string{"family.fl_name"}, hasnt: []string{"family.fl_dob", "family"}, family: true},
+		{in: "family", has: []string{"family.fl_name", "family.fl_dob"}, hasnt: []string{"cst_id"}, family: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			f, err := ParseFields(tt.in)
+			require.NoError(t, err)
+			for _, name := range tt.has {
+				assert.True(t, f.Has(name), name)
+			}
+			for _, name := range tt.hasnt {
+				assert.False(t, f.Has(name), name)
+			}
+			assert.Equal(t, tt.family, f.Family())
+		})
+	}
+
+	for _, bad := range []string{"cst_password", "cst_id,", "family.cst_name", "fl_name"} {
+		_, err := ParseFields(bad)
+		var unknown *UnknownFieldError
+		assert.ErrorAs(t, err, &unknown, bad)
+	}
+
+	f := Fields{"cst_id": true}
+	assert.Equal(t, f, FieldsFrom(WithFields(context.Background(), f)))
+	assert.Nil(t, FieldsFrom(context.Background()))
+}
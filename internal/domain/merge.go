@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"errors"
+	"strconv"
+)
+
+// ErrMoved is matched by *MovedError: the customer was merged into another.
+var ErrMoved = errors.New("customer merged")
+
+type MovedError struct{ To int32 }
+
+func (e *MovedError) Error() string        { return "customer merged into " + strconv.Itoa(int(e.To)) }
+func (e *MovedError) Is(target error) bool { return target == ErrMoved }
+
+// DuplicateCandidate is another customer that may be the same person.
+// Signals holds the per-signal similarity in [0,1] (name, dob, phone).
+type DuplicateCandidate struct {
+	Customer Customer
+	Score    float64
+	Signals  map[string]float64
+}
+
+// Merge policy values: which side a field is taken from.
+const (
+	KeepTarget = "target"
+	KeepSource = "source"
+)
+
+// MergeFields are the customer fields a MergePolicy may name.
+var MergeFields = []string{"nationality_id", "cst_name", "cst_dob", "cst_phoneNum", "cst_email"}
+
+// MergePolicy maps a field in MergeFields to KeepTarget or KeepSource;
+// missing fields keep the target's value.
+type MergePolicy map[string]string
+
+// Apply returns target with the fields the policy takes from source. The
+// phone number and its E.164 form always travel together.
+func (p MergePolicy) Apply(target, source Customer) Customer {
+	out := target
+	if p["nationality_id"] == KeepSource {
+		out.NationalityID = source.NationalityID
+	}
+	if p["cst_name"] == KeepSource {
+		out.Name = source.Name
+	}
+	if p["cst_dob"] == KeepSource {
+		out.Dob = source.Dob
+	}
+	if p["cst_phoneNum"] == KeepSource {
+		out.PhoneNum, out.PhoneE164 = source.PhoneNum, source.PhoneE164
+	}
+	if p["cst_email"] == KeepSource {
+		out.Email = source.Email
+	}
+	return out
+}
+
+// CustomerMerged is emitted for the source customer; the target gets a
+// CustomerUpdated with whatever the merge changed.
+type CustomerMerged struct {
+	SourceID int32 `json:"source_id"`
+	TargetID int32 `json:"target_id"`
+}
+
+func (e CustomerMerged) EventType() string  { return EventCustomerMerged }
+func (e CustomerMerged) AggregateID() int32 { return e.SourceID }
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+const EventCustomerAnonymized = "customer.anonymized"
+
+// AnonymizedName replaces the names of an anonymized customer and family.
+const AnonymizedName = "ANONYMIZED"
+
+// DataExport is everything stored about one customer, for subject access
+// requests: the customer with family, the ids merged into it and the events
+// recorded for any of them, oldest first.
+type DataExport struct {
+	Customer  Customer
+	MergedIDs []int32
+	Events    []Event
+}
+
+// Anonymize returns c with its PII scrubbed for good. The row keeps its id,
+// nationality and family relations, and dates of birth keep their year, so
+// references and statistics stay intact; the email stays unique per tenant.
+func Anonymize(c Customer) Customer {
+	out := Customer{
+		ID:            c.ID,
+		NationalityID: c.NationalityID,
+		Name:          AnonymizedName,
+		Dob:           yearOnly(c.Dob),
+		Email:         "anonymized-" + strconv.Itoa(int(c.ID)) + "@anonymized.invalid",
+	}
+	for _, f := range c.Family {
+		out.Family = append(out.Family, FamilyMember{ID: f.ID, CustomerID: f.CustomerID, Relation: f.Relation, Name: AnonymizedName, Dob: yearOnly(f.Dob)})
+	}
+	return out
+}
+
+func yearOnly(t time.Time) time.Time { return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC) }
+
+// CustomerAnonymized tells subscribers to scrub their copies of the
+// customer's events and those of the ids merged into it. The events stored
+// before it have their payloads replaced with RedactedPayload.
+type CustomerAnonymized struct {
+	CustomerID int32   `json:"customer_id"`
+	MergedIDs  []int32 `json:"merged_ids,omitempty"`
+}
+
+const RedactedPayload = `{"redacted":true}`
+
+func (e CustomerAnonymized) EventType() string  { return EventCustomerAnonymized }
+func (e CustomerAnonymized) AggregateID() int32 { return e.CustomerID }
+
+type wholeEmailKey struct{}
+
+// WithWholeEmailSearch makes customer searches under ctx match an email only
+// as a whole (case-insensitive), never a fragment of it. Callers who see
+// emails masked get it, since fragment matches would let them recover an
+// email one character at a time.
+func WithWholeEmailSearch(ctx context.Context) context.Context {
+	return context.WithValue(ctx, wholeEmailKey{}, true)
+}
+
+// WholeEmailSearch reports whether ctx came from WithWholeEmailSearch.
+func WholeEmailSearch(ctx context.Context) bool {
+	v, _ := ctx.Value(wholeEmailKey{}).(bool)
+	return v
+}
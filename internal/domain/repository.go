@@ -1,13 +1,55 @@
 //go:generate mockery --name=UserRepository --output=../mocks --case=underscore
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type UserRepository interface {
 	ListCustomers(ctx context.Context, search string, limit, offset int) ([]Customer, int32, error)
 	GetCustomer(ctx context.Context, id int32) (*Customer, error)
+	// GetCustomers returns the customers among ids without their family, in
+	// one query and no particular order; unknown ids are left out.
+	GetCustomers(ctx context.Context, ids []int32) ([]Customer, error)
+	// Families returns the family members of the given customers, in one
+	// query, keyed by customer id.
+	Families(ctx context.Context, customerIDs []int32) (map[int32][]FamilyMember, error)
 	CreateCustomer(ctx context.Context, c Customer) (int32, error)
 	UpdateCustomer(ctx context.Context, id int32, c Customer) error
 	DeleteCustomer(ctx context.Context, id int32) error
 	ListNationalities(ctx context.Context) ([]Nationality, error)
+	// GetNationality returns nil when id is unknown.
+	GetNationality(ctx context.Context, id int32) (*Nationality, error)
+	// DuplicateCandidates returns up to limit customers sharing a cheap
+	// blocking key (dob, phone suffix, name prefix) with c, those matching
+	// the strongest keys first (exact dob and phone suffix, then swapped
+	// day/month, first name, name prefix); scoring is left to the caller.
+	DuplicateCandidates(ctx context.Context, c Customer, limit int) ([]Customer, error)
+	// MergeCustomers moves the source's family to the target, stores merged
+	// as the target's fields, deletes the source and redirects its id.
+	MergeCustomers(ctx context.Context, targetID, sourceID int32, merged Customer) error
+	// Redirect returns the id a merged customer now lives under, or 0.
+	Redirect(ctx context.Context, id int32) (int32, error)
+	// CustomerStats aggregates in the database; f is already normalized.
+	CustomerStats(ctx context.Context, f StatsFilter) (*CustomerStats, error)
+	// ExportCustomer returns nil when the customer does not exist.
+	ExportCustomer(ctx context.Context, id int32) (*DataExport, error)
+	// AnonymizeCustomer replaces the customer and its family with Anonymize
+	// of them in one transaction, redacts the payloads of the events stored
+	// for it and the ids merged into it, records CustomerAnonymized and
+	// returns what is left.
+	AnonymizeCustomer(ctx context.Context, id int32) (*Customer, error)
+}
+
+//go:generate mockery --name=WebhookRepository --output=../mocks --case=underscore
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, w Webhook) (int32, error)
+	ListWebhooks(ctx context.Context) ([]Webhook, error)
+	GetWebhook(ctx context.Context, id int32) (*Webhook, error)
+	DeleteWebhook(ctx context.Context, id int32) error
+	// ListDeliveries returns the newest deliveries of a webhook with their attempt log.
+	ListDeliveries(ctx context.Context, webhookID int32, limit int) ([]Delivery, error)
+	// Redeliver makes a delivery pending again with a fresh attempt budget.
+	Redeliver(ctx context.Context, webhookID int32, deliveryID int64, at time.Time) error
 }
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrRuleViolation = errors.New("business rule violated")
+
+// Rule codes double as message catalog keys in the transport layer.
+const (
+	RuleDobInFuture        = "rule_dob_in_future"
+	RuleMinCustomerAge     = "rule_min_customer_age"
+	RuleUnknownRelation    = "rule_unknown_relation"
+	RuleSingleSpouse       = "rule_single_spouse"
+	RuleChildTooOld        = "rule_child_too_old"
+	RuleParentTooYoung     = "rule_parent_too_young"
+	RuleMaxFamilyMembers   = "rule_max_family_members"
+	RuleWebhookURL         = "rule_webhook_url"
+	RuleWebhookHost        = "rule_webhook_host"
+	RuleMergeSelf          = "rule_merge_self"
+	RuleReasonRequired     = "rule_reason_required"
+	RuleUnknownNationality = "rule_unknown_nationality"
+)
+
+// Relations accepted in FamilyMember.Relation (compared case-insensitively).
+const (
+	RelationSpouse      = "spouse"
+	RelationChild       = "child"
+	RelationParent      = "parent"
+	RelationSibling     = "sibling"
+	RelationGrandparent = "grandparent"
+	RelationGrandchild  = "grandchild"
+	RelationOther       = "other"
+)
+
+var Relations = []string{
+	RelationSpouse, RelationChild, RelationParent, RelationSibling,
+	RelationGrandparent, RelationGrandchild, RelationOther,
+}
+
+// Violation is one broken rule. Field is the request path, e.g.
+// "family[1].fl_dob"; Params fill the message placeholders.
+type Violation struct {
+	Field  string
+	Rule   string
+	Params []string
+}
+
+type RuleError struct{ Violations []Violation }
+
+func (e *RuleError) Error() string {
+	parts := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		parts = append(parts, v.Field+": "+v.Rule)
+	}
+	return ErrRuleViolation.Error() + ": " + strings.Join(parts, ", ")
+}
+
+func (e *RuleError) Is(target error) bool { return target == ErrRuleViolation }
+
+// UnknownNationality is what a repository returns when a write references a
+// nationality that does not exist.
+func UnknownNationality() *RuleError {
+	return &RuleError{Violations: []Violation{{Field: "nationality_id", Rule: RuleUnknownNationality}}}
+}
@@ -0,0 +1,84 @@
+package domain
+
+import "time"
+
+// Stats intervals for the created-customers series.
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+)
+
+// StatsFilter narrows CustomerStats like the list endpoint does; From and To
+// (inclusive dates, UTC) bound only the Created series.
+type StatsFilter struct {
+	Search   string
+	Interval string
+	From, To time.Time
+}
+
+// maxStatsPeriods bounds the Created series: a year of days, five years of
+// weeks, ten of months.
+var maxStatsPeriods = map[string]int{IntervalDay: 366, IntervalWeek: 260, IntervalMonth: 120}
+
+// StatsPeriods returns how many buckets of interval (month when empty) the
+// Created series from..to has, and the most a request may ask for.
+func StatsPeriods(interval string, from, to time.Time) (n, limit int) {
+	day := func(t time.Time) time.Time { return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC) }
+	from, to = day(from), day(to)
+	switch interval {
+	case IntervalDay:
+		n = int(to.Sub(from).Hours()/24) + 1
+	case IntervalWeek:
+		monday := func(t time.Time) time.Time { return t.AddDate(0, 0, -(int(t.Weekday())+6)%7) }
+		n = int(monday(to).Sub(monday(from)).Hours()/24)/7 + 1
+	default:
+		interval = IntervalMonth
+		n = (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month()) + 1
+	}
+	return n, maxStatsPeriods[interval]
+}
+
+// AgeBucket is an inclusive age range in years; Max 0 means open-ended.
+type AgeBucket struct {
+	Label    string
+	Min, Max int
+}
+
+var AgeBuckets = []AgeBucket{
+	{"0-17", 0, 17}, {"18-24", 18, 24}, {"25-34", 25, 34}, {"35-44", 35, 44},
+	{"45-54", 45, 54}, {"55-64", 55, 64}, {"65+", 65, 0},
+}
+
+type CustomerStats struct {
+	Total         int
+	ByNationality []NationalityCount
+	ByAge         []BucketCount // one entry per AgeBuckets, in order
+	FamilySize    []FamilySizeCount
+	Created       []PeriodCount // one entry per interval between From and To
+
+	// The normalized filter the series was computed for.
+	Interval string
+	From, To time.Time
+}
+
+type NationalityCount struct {
+	NationalityID int32
+	Name          string
+	Count         int
+}
+
+type BucketCount struct {
+	Label string
+	Count int
+}
+
+type FamilySizeCount struct {
+	Members int
+	Count   int
+}
+
+type PeriodCount struct {
+	Start time.Time
+	Count int
+}
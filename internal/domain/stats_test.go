@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatsPeriods(t *testing.T) {
+	d := func(y int, m time.Month, day int) time.Time { return time.Date(y, m, day, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		interval string
+		from, to time.Time
+		n, limit int
+	}{
+		{IntervalDay, d(2026, 9, 1), d(2026, 9, 1), 1, 366},
+		{IntervalDay, d(2025, 9, 1), d(2026, 9, 1), 366, 366},
+		{IntervalWeek, d(2026, 9, 6), d(2026, 9, 7), 2, 260},
+		{IntervalWeek, d(2026, 9, 7), d(2026, 9, 13), 1, 260},
+		{IntervalMonth, d(2026, 1, 31), d(2026, 12, 1), 12, 120},
+		{"", d(2016, 1, 1), d(2026, 1, 1), 121, 120},
+	}
+	for _, tc := range tests {
+		n, limit := StatsPeriods(tc.interval, tc.from, tc.to)
+		assert.Equal(t, tc.n, n, "%s %s..%s", tc.interval, tc.from.Format(time.DateOnly), tc.to.Format(time.DateOnly))
+		assert.Equal(t, tc.limit, limit)
+	}
+}
@@ -6,8 +6,33 @@ import "context"
 type UserUsecase interface {
 	List(ctx context.Context, search string, page, size int) ([]Customer, int32, error)
 	Get(ctx context.Context, id int32) (*Customer, error)
+	// GetMany returns the customers among ids in the order asked, without
+	// duplicates and with their family when family is set, and the ids it
+	// did not find, merged-away ones included.
+	GetMany(ctx context.Context, ids []int32, family bool) ([]Customer, []int32, error)
+	// LoadFamilies fills in the family of cs with one repository call.
+	LoadFamilies(ctx context.Context, cs []Customer) error
 	Create(ctx context.Context, c Customer) (int32, error)
 	Update(ctx context.Context, id int32, c Customer) error
 	Delete(ctx context.Context, id int32) error
 	ListNationality(ctx context.Context) ([]Nationality, error)
+	Duplicates(ctx context.Context, id int32, minScore float64, limit int) ([]DuplicateCandidate, error)
+	Merge(ctx context.Context, targetID, sourceID int32, p MergePolicy) (*Customer, error)
+	// Stats defaults Interval to month and the range to the last 12 intervals.
+	Stats(ctx context.Context, f StatsFilter) (*CustomerStats, error)
+	// Export and Anonymize serve data subject requests; both are audited
+	// with the caller and reason, and refused when the record fails.
+	Export(ctx context.Context, id int32, reason string) (*DataExport, error)
+	Anonymize(ctx context.Context, id int32, reason string) (*Customer, error)
+}
+
+//go:generate mockery --name=WebhookUsecase --output=../mocks --case=underscore
+type WebhookUsecase interface {
+	// Create fills in a random secret when w.Secret is empty.
+	Create(ctx context.Context, w Webhook) (*Webhook, error)
+	List(ctx context.Context) ([]Webhook, error)
+	Get(ctx context.Context, id int32) (*Webhook, error)
+	Delete(ctx context.Context, id int32) error
+	Deliveries(ctx context.Context, webhookID int32, limit int) ([]Delivery, error)
+	Redeliver(ctx context.Context, webhookID int32, deliveryID int64) error
 }
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Delivery statuses. DeliveryDead is the dead letter: attempts ran out and
+// only a manual redelivery will try again.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryDead      = "dead"
+)
+
+// EventTypes lists what a webhook may subscribe to.
+var EventTypes = []string{EventCustomerCreated, EventCustomerUpdated, EventCustomerDeleted, EventCustomerMerged, EventCustomerAnonymized}
+
+type Webhook struct {
+	ID         int32
+	URL        string
+	EventTypes []string // empty = every event
+	Secret     string
+	Active     bool
+	CreatedAt  time.Time
+}
+
+// Delivery is one event queued for one webhook; Payload is the JSON of the
+// Event envelope exactly as it is signed and sent.
+type Delivery struct {
+	ID            int64
+	WebhookID     int32
+	EventID       int64
+	EventType     string
+	Payload       json.RawMessage
+	Status        string
+	Attempts      int
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	Log           []DeliveryAttempt
+}
+
+type DeliveryAttempt struct {
+	At         time.Time
+	StatusCode int // 0 when no response was received
+	Error      string
+	Duration   time.Duration
+}
@@ -0,0 +1,20 @@
+package dto
+
+type JobResponse struct {
+	Name    string           `json:"name"`
+	Spec    string           `json:"spec"`
+	NextRun string           `json:"next_run,omitempty"`
+	Runs    []JobRunResponse `json:"runs"`
+}
+
+// JobRunResponse has no finished_at while the run is in progress, or when
+// its instance stopped before recording the end.
+type JobRunResponse struct {
+	ID           int64  `json:"id"`
+	Trigger      string `json:"trigger"`
+	ScheduledFor string `json:"scheduled_for,omitempty"`
+	Instance     string `json:"instance"`
+	StartedAt    string `json:"started_at"`
+	FinishedAt   string `json:"finished_at,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
@@ -0,0 +1,12 @@
+package dto
+
+type MergeRequest struct {
+	SourceID int32             `json:"source_id" validate:"required,gt=0"`
+	Policy   map[string]string `json:"policy" validate:"dive,keys,oneof=nationality_id cst_name cst_dob cst_phoneNum cst_email,endkeys,oneof=target source"`
+}
+
+type DuplicateResponse struct {
+	Customer CustomerListItem   `json:"customer"`
+	Score    float64            `json:"score"`
+	Signals  map[string]float64 `json:"signals"`
+}
@@ -0,0 +1,7 @@
+package dto
+
+type NationalityResponse struct {
+	ID   int32   `json:"id"`
+	Name string  `json:"name"`
+	Code *string `json:"code"`
+}
@@ -0,0 +1,22 @@
+package dto
+
+type AnonymizeRequest struct {
+	Reason string `json:"reason" validate:"required"`
+}
+
+// DataExportResponse is the subject access bundle: the customer with family,
+// the ids merged into it and every stored event about any of them.
+type DataExportResponse struct {
+	ExportedAt string           `json:"exported_at"`
+	Customer   CustomerResponse `json:"customer"`
+	MergedIDs  []int32          `json:"merged_ids"`
+	Events     []EventResponse  `json:"events"`
+}
+
+type EventResponse struct {
+	ID         int64  `json:"id"`
+	Type       string `json:"type"`
+	CustomerID int32  `json:"customer_id"`
+	OccurredAt string `json:"occurred_at"`
+	Payload    any    `json:"payload"`
+}
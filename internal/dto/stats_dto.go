@@ -0,0 +1,38 @@
+package dto
+
+type CustomerStatsResponse struct {
+	Total         int                `json:"total"`
+	ByNationality []NationalityCount `json:"by_nationality"`
+	ByAge         []AgeBucketCount   `json:"by_age"`
+	FamilySize    []FamilySizeCount  `json:"family_size"`
+	Created       CreatedSeries      `json:"created"`
+}
+
+type NationalityCount struct {
+	NationalityID int32  `json:"nationality_id"`
+	Name          string `json:"name"`
+	Count         int    `json:"count"`
+}
+
+type AgeBucketCount struct {
+	Bucket string `json:"bucket"`
+	Count  int    `json:"count"`
+}
+
+type FamilySizeCount struct {
+	Members int `json:"members"`
+	Count   int `json:"count"`
+}
+
+type CreatedSeries struct {
+	Interval string        `json:"interval"`
+	From     string        `json:"from"`
+	To       string        `json:"to"`
+	Buckets  []PeriodCount `json:"buckets"`
+}
+
+// PeriodCount.Start is the first day (YYYY-MM-DD, UTC) of the interval.
+type PeriodCount struct {
+	Start string `json:"start"`
+	Count int    `json:"count"`
+}
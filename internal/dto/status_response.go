@@ -0,0 +1,5 @@
+package dto
+
+type StatusResponse struct {
+	Status string `json:"status"`
+}
@@ -6,10 +6,23 @@ type CustomerListItem struct {
 	CstDob        string `json:"cst_dob"`
 	NationalityID int32  `json:"nationality_id"`
 	CstPhoneNum   string `json:"cst_phoneNum"`
+	CstPhoneE164  string `json:"cst_phone_e164,omitempty"`
 	CstEmail      string `json:"cst_email"`
+	// Family is only present with include=family.
+	Family []FamilyMemberResponse `json:"family,omitzero"`
 }
 
+// CustomerListResponse also answers batch gets, where Total counts the
+// customers found and Missing lists the ids that were not.
 type CustomerListResponse struct {
-	Data  []CustomerListItem `json:"data"`
-	Total int                `json:"total"`
+	Data    []CustomerListItem `json:"data"`
+	Total   int                `json:"total"`
+	Missing []int32            `json:"missing,omitzero"`
+}
+
+// BatchGetRequest is the body of POST /v1/users:batchGet, the same as
+// GET /v1/users?ids=1,2,3&include=family.
+type BatchGetRequest struct {
+	IDs     []int32  `json:"ids" validate:"required,min=1,max=100,dive,min=1"`
+	Include []string `json:"include" validate:"dive,oneof=family"`
 }
@@ -12,6 +12,7 @@ type CustomerResponse struct {
 	CstDob        string                 `json:"cst_dob"`
 	NationalityID int32                  `json:"nationality_id"`
 	CstPhoneNum   string                 `json:"cst_phoneNum"`
+	CstPhoneE164  string                 `json:"cst_phone_e164,omitempty"`
 	CstEmail      string                 `json:"cst_email"`
 	Family        []FamilyMemberResponse `json:"family"`
 }
@@ -0,0 +1,35 @@
+package dto
+
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" validate:"required,url"`
+	EventTypes []string `json:"event_types" validate:"dive,oneof=customer.created customer.updated customer.deleted customer.merged customer.anonymized"`
+	Secret     string   `json:"secret" validate:"omitempty,min=16"`
+}
+
+// WebhookResponse carries the secret only in the create response.
+type WebhookResponse struct {
+	ID         int32    `json:"id"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Active     bool     `json:"active"`
+	CreatedAt  string   `json:"created_at"`
+	Secret     string   `json:"secret,omitempty"`
+}
+
+type DeliveryResponse struct {
+	ID            int64                     `json:"id"`
+	EventID       int64                     `json:"event_id"`
+	EventType     string                    `json:"event_type"`
+	Status        string                    `json:"status"`
+	Attempts      int                       `json:"attempts"`
+	NextAttemptAt string                    `json:"next_attempt_at"`
+	CreatedAt     string                    `json:"created_at"`
+	Log           []DeliveryAttemptResponse `json:"log"`
+}
+
+type DeliveryAttemptResponse struct {
+	At         string `json:"at"`
+	StatusCode *int   `json:"status_code"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
@@ -0,0 +1,131 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+
+	"usrsvc/internal/domain"
+)
+
+// Broker fans live events out to subscribers and keeps the last size events
+// for Last-Event-ID resume. Publish never blocks on a subscriber: one whose
+// buffer is full is dropped, and is expected to reconnect and resume.
+type Broker struct {
+	size      int // replay buffer length
+	subBuffer int // per-subscriber queue length
+
+	mu   sync.Mutex
+	buf  []domain.Event // ring, oldest at head once full
+	head int
+	seen map[int64]bool
+	subs map[*Subscription]struct{}
+}
+
+type Subscription struct {
+	C       <-chan domain.Event // closed when the subscriber is dropped
+	c       chan domain.Event
+	dropped bool
+}
+
+func NewBroker(size, subBuffer int) *Broker {
+	if size <= 0 {
+		size = 1000
+	}
+	if subBuffer <= 0 {
+		subBuffer = 64
+	}
+	return &Broker{size: size, subBuffer: subBuffer, seen: map[int64]bool{}, subs: map[*Subscription]struct{}{}}
+}
+
+// Publish implements Publisher. Events already in the replay buffer are
+// ignored, so feeding the broker at-least-once is fine. A
+// customer.anonymized event redacts the buffered events it covers first, so
+// a resuming client never gets the scrubbed values.
+func (b *Broker) Publish(_ context.Context, e domain.Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.seen[e.ID] {
+		return nil
+	}
+	if e.Type == domain.EventCustomerAnonymized {
+		b.redact(e)
+	}
+	if len(b.buf) < b.size {
+		b.buf = append(b.buf, e)
+	} else {
+		delete(b.seen, b.buf[b.head].ID)
+		b.buf[b.head] = e
+		b.head = (b.head + 1) % b.size
+	}
+	b.seen[e.ID] = true
+
+	for s := range b.subs {
+		select {
+		case s.c <- e:
+		default:
+			s.dropped = true
+			close(s.c)
+			delete(b.subs, s)
+		}
+	}
+	return nil
+}
+
+// redact replaces the payloads of the buffered events of the anonymized
+// customer and the ids merged into it with domain.RedactedPayload.
+func (b *Broker) redact(anon domain.Event) {
+	var a domain.CustomerAnonymized
+	_ = json.Unmarshal(anon.Payload, &a) // unreadable: the customer's own events only
+	ids := append([]int32{anon.CustomerID}, a.MergedIDs...)
+	for i, e := range b.buf {
+		if e.Tenant == anon.Tenant && slices.Contains(ids, e.CustomerID) {
+			b.buf[i].Payload = json.RawMessage(domain.RedactedPayload)
+		}
+	}
+}
+
+// Subscribe registers a subscriber. With resume set it also returns the
+// buffered events after lastID; complete is false when lastID is no longer
+// buffered and events may have been missed.
+func (b *Broker) Subscribe(lastID int64, resume bool) (s *Subscription, replay []domain.Event, complete bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := make(chan domain.Event, b.subBuffer)
+	s = &Subscription{C: c, c: c}
+	b.subs[s] = struct{}{}
+	if !resume {
+		return s, nil, true
+	}
+	ordered := append(append([]domain.Event(nil), b.buf[b.head:]...), b.buf[:b.head]...)
+	for i, e := range ordered {
+		if e.ID == lastID {
+			return s, ordered[i+1:], true
+		}
+	}
+	return s, ordered, false
+}
+
+func (b *Broker) Unsubscribe(s *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[s]; ok {
+		delete(b.subs, s)
+		close(s.c)
+	}
+}
+
+// Dropped reports whether s was cut off for falling behind.
+func (b *Broker) Dropped(s *Subscription) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return s.dropped
+}
+
+// Subscribers is the number of live subscribers.
+func (b *Broker) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
@@ -0,0 +1,104 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"usrsvc/internal/domain"
+)
+
+func ids(evs []domain.Event) []int64 {
+	out := make([]int64, 0, len(evs))
+	for _, e := range evs {
+		out = append(out, e.ID)
+	}
+	return out
+}
+
+func TestBroker_Replay(t *testing.T) {
+	ctx := context.Background()
+	b := NewBroker(3, 8)
+	for _, id := range []int64{1, 2, 3, 4, 5} {
+		require.NoError(t, b.Publish(ctx, domain.Event{ID: id}))
+	}
+
+	tests := []struct {
+		name     string
+		lastID   int64
+		resume   bool
+		want     []int64
+		complete bool
+	}{
+		{"live_only", 0, false, []int64{}, true},
+		{"resume_mid_buffer", 3, true, []int64{4, 5}, true},
+		{"resume_at_head", 5, true, []int64{}, true},
+		{"evicted_id_replays_all", 1, true, []int64{3, 4, 5}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, replay, complete := b.Subscribe(tt.lastID, tt.resume)
+			defer b.Unsubscribe(s)
+			assert.Equal(t, tt.want, ids(replay))
+			assert.Equal(t, tt.complete, complete)
+		})
+	}
+}
+
+func TestBroker_Fanout(t *testing.T) {
+	ctx := context.Background()
+	b := NewBroker(10, 2)
+	fast, _, _ := b.Subscribe(0, false)
+	slow, _, _ := b.Subscribe(0, false)
+
+	for _, id := range []int64{1, 2, 2, 3} {
+		require.NoError(t, b.Publish(ctx, domain.Event{ID: id}))
+		if id != 2 {
+			continue
+		}
+		// fast keeps up; slow never reads
+		for len(fast.C) > 0 {
+			<-fast.C
+		}
+	}
+	assert.Equal(t, int64(3), (<-fast.C).ID)
+	assert.False(t, b.Dropped(fast))
+
+	got := []int64{}
+	for e := range slow.C {
+		got = append(got, e.ID)
+	}
+	assert.Equal(t, []int64{1, 2}, got, "duplicate 2 is ignored, 3 overflows and closes the channel")
+	assert.True(t, b.Dropped(slow))
+	assert.Equal(t, 1, b.Subscribers())
+
+	b.Unsubscribe(fast)
+	b.Unsubscribe(slow)
+	_, ok := <-fast.C
+	assert.False(t, ok)
+	assert.Equal(t, 0, b.Subscribers())
+}
+
+func TestBroker_AnonymizedRedactsReplay(t *testing.T) {
+	ctx := context.Background()
+	b := NewBroker(10, 8)
+	ev := func(id int64, tenant string, customer int32) domain.Event {
+		return domain.Event{ID: id, Type: domain.EventCustomerUpdated, Tenant: tenant, CustomerID: customer, Payload: json.RawMessage(`{"cst_email":"a@x.io"}`)}
+	}
+	for _, e := range []domain.Event{ev(1, "default", 36), ev(2, "default", 40), ev(3, "acme", 36), ev(4, "default", 7)} {
+		require.NoError(t, b.Publish(ctx, e))
+	}
+	require.NoError(t, b.Publish(ctx, domain.Event{ID: 5, Type: domain.EventCustomerAnonymized, Tenant: "default", CustomerID: 36,
+		Payload: json.RawMessage(`{"customer_id":36,"merged_ids":[40]}`)}))
+
+	s, replay, _ := b.Subscribe(0, true)
+	defer b.Unsubscribe(s)
+	redacted := map[int64]bool{}
+	for _, e := range replay {
+		redacted[e.ID] = string(e.Payload) == domain.RedactedPayload
+	}
+	assert.Equal(t, map[int64]bool{1: true, 2: true, 3: false, 4: false, 5: false}, redacted)
+}
@@ -0,0 +1,63 @@
+// Package events relays domain events from the outbox to publishers.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+
+	"usrsvc/internal/domain"
+)
+
+// Publisher delivers one event. Returning an error makes the relay retry
+// the event later; publishers must tolerate seeing an event more than once.
+type Publisher interface {
+	Publish(ctx context.Context, e domain.Event) error
+}
+
+// WriterPublisher writes one JSON object per line, for local use.
+type WriterPublisher struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterPublisher(w io.Writer) *WriterPublisher { return &WriterPublisher{w: w} }
+
+// NewFilePublisher appends to path, creating it if needed.
+func NewFilePublisher(path string) (*WriterPublisher, io.Closer, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewWriterPublisher(f), f, nil
+}
+
+func (p *WriterPublisher) Publish(_ context.Context, e domain.Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err = p.w.Write(append(b, '\n'))
+	return err
+}
+
+// Fanout publishes to every p in order and fails if any of them fails, so
+// the relay retries the event for all of them.
+func Fanout(ps ...Publisher) Publisher { return fanout(ps) }
+
+type fanout []Publisher
+
+func (f fanout) Publish(ctx context.Context, e domain.Event) error {
+	var errs []error
+	for _, p := range f {
+		if err := p.Publish(ctx, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,84 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/pkg/log"
+)
+
+// Outcome is what a relay pass did with the events it was handed.
+type Outcome struct {
+	Published []int64
+	Failed    map[int64]string
+}
+
+// Store is the outbox as seen by the relay.
+type Store interface {
+	// Drain hands up to limit unpublished events, oldest first, to fn and
+	// records its outcome. It returns false without calling fn when another
+	// relay currently owns the outbox.
+	Drain(ctx context.Context, limit int, fn func([]domain.Event) Outcome) (bool, error)
+}
+
+// Relay polls the outbox and publishes events. Delivery is at-least-once:
+// an event is marked published only after Publish returns nil. Events of one
+// customer are published in order; after a failure the rest of that
+// customer's events wait for the next pass, others carry on.
+type Relay struct {
+	Store     Store
+	Publisher Publisher
+	Interval  time.Duration
+	Batch     int
+}
+
+func NewRelay(s Store, p Publisher) *Relay {
+	return &Relay{Store: s, Publisher: p, Interval: time.Second, Batch: 100}
+}
+
+// Run polls until ctx is done.
+func (r *Relay) Run(ctx context.Context) {
+	t := time.NewTicker(r.Interval)
+	defer t.Stop()
+	for {
+		for {
+			n, err := r.Once(ctx)
+			if err != nil && ctx.Err() == nil {
+				log.Error.Printf("outbox relay err=%v", err)
+			}
+			if err != nil || n < r.Batch {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Once runs a single pass and returns how many events were published.
+func (r *Relay) Once(ctx context.Context) (int, error) {
+	var published int
+	_, err := r.Store.Drain(ctx, r.Batch, func(evs []domain.Event) Outcome {
+		out := Outcome{Failed: map[int64]string{}}
+		blocked := map[int32]bool{}
+		for _, e := range evs {
+			if blocked[e.CustomerID] {
+				continue
+			}
+			if err := r.Publisher.Publish(ctx, e); err != nil {
+				blocked[e.CustomerID] = true
+				out.Failed[e.ID] = err.Error()
+				log.Error.Printf("outbox publish_err id=%d type=%s customer=%d err=%v", e.ID, e.Type, e.CustomerID, err)
+				continue
+			}
+			out.Published = append(out.Published, e.ID)
+		}
+		published = len(out.Published)
+		return out
+	})
+	return published, err
+}
@@ -0,0 +1,136 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"usrsvc/internal/domain"
+)
+
+// memStore is an outbox kept in a slice; published events are removed.
+type memStore struct {
+	pending  []domain.Event
+	attempts map[int64]int
+	busy     bool
+}
+
+func (s *memStore) Drain(_ context.Context, limit int, fn func([]domain.Event) Outcome) (bool, error) {
+	if s.busy {
+		return false, nil
+	}
+	batch := s.pending
+	if len(batch) > limit {
+		batch = batch[:limit]
+	}
+	if len(batch) == 0 {
+		return true, nil
+	}
+	out := fn(append([]domain.Event(nil), batch...))
+	done := map[int64]bool{}
+	for _, id := range out.Published {
+		done[id] = true
+		s.attempts[id]++
+	}
+	for id := range out.Failed {
+		s.attempts[id]++
+	}
+	var rest []domain.Event
+	for _, e := range s.pending {
+		if !done[e.ID] {
+			rest = append(rest, e)
+		}
+	}
+	s.pending = rest
+	return true, nil
+}
+
+type flakyPublisher struct {
+	fail map[int64]int // remaining failures per event id
+	seen []int64
+}
+
+func (p *flakyPublisher) Publish(_ context.Context, e domain.Event) error {
+	if p.fail[e.ID] > 0 {
+		p.fail[e.ID]--
+		return errors.New("downstream unavailable")
+	}
+	p.seen = append(p.seen, e.ID)
+	return nil
+}
+
+func TestRelay(t *testing.T) {
+	ctx := context.Background()
+	ev := func(id int64, cust int32) domain.Event {
+		return domain.Event{ID: id, CustomerID: cust, Type: domain.EventCustomerUpdated}
+	}
+
+	t.Run("failure_blocks_only_that_customer", func(t *testing.T) {
+		s := &memStore{pending: []domain.Event{ev(1, 7), ev(2, 8), ev(3, 7), ev(4, 8)}, attempts: map[int64]int{}}
+		p := &flakyPublisher{fail: map[int64]int{1: 1}}
+		r := NewRelay(s, p)
+
+		n, err := r.Once(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+		assert.Equal(t, []int64{2, 4}, p.seen)
+
+		n, err = r.Once(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+		assert.Equal(t, []int64{2, 4, 1, 3}, p.seen, "customer 7 still in order")
+		assert.Empty(t, s.pending)
+		assert.Equal(t, 2, s.attempts[1])
+		assert.Equal(t, 1, s.attempts[3])
+	})
+
+	t.Run("batches_until_empty", func(t *testing.T) {
+		s := &memStore{attempts: map[int64]int{}}
+		for i := int64(1); i <= 5; i++ {
+			s.pending = append(s.pending, ev(i, int32(i)))
+		}
+		p := &flakyPublisher{}
+		r := NewRelay(s, p)
+		r.Batch = 2
+
+		total := 0
+		for {
+			n, err := r.Once(ctx)
+			require.NoError(t, err)
+			total += n
+			if n < r.Batch {
+				break
+			}
+		}
+		assert.Equal(t, 5, total)
+		assert.Equal(t, []int64{1, 2, 3, 4, 5}, p.seen)
+	})
+
+	t.Run("skips_when_another_relay_owns_outbox", func(t *testing.T) {
+		s := &memStore{pending: []domain.Event{ev(1, 7)}, attempts: map[int64]int{}, busy: true}
+		p := &flakyPublisher{}
+		n, err := NewRelay(s, p).Once(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+		assert.Empty(t, p.seen)
+	})
+}
+
+func TestWriterPublisher(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewWriterPublisher(&buf)
+	require.NoError(t, p.Publish(context.Background(), domain.Event{ID: 1, Type: domain.EventCustomerDeleted, CustomerID: 7, Payload: json.RawMessage(`{"customer_id":7}`)}))
+	require.NoError(t, p.Publish(context.Background(), domain.Event{ID: 2, Type: domain.EventCustomerDeleted, CustomerID: 8, Payload: json.RawMessage(`{}`)}))
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	var got domain.Event
+	require.NoError(t, json.Unmarshal(lines[0], &got))
+	assert.Equal(t, int32(7), got.CustomerID)
+	assert.JSONEq(t, `{"customer_id":7}`, string(got.Payload))
+}
@@ -0,0 +1,142 @@
+package jobs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule says when a job is due next.
+type Schedule interface {
+	// Next returns the first activation strictly after t.
+	Next(t time.Time) time.Time
+}
+
+// Parse reads a five-field cron spec (minute hour day-of-month month
+// day-of-week; each field *, n, a-b, with an optional /step, and lists of
+// those), one of @hourly, @daily, @weekly or @monthly, or "@every <duration>".
+// Specs are evaluated in UTC.
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	switch spec {
+	case "@hourly":
+		spec = "0 * * * *"
+	case "@daily", "@midnight":
+		spec = "0 0 * * *"
+	case "@weekly":
+		spec = "0 0 * * 0"
+	case "@monthly":
+		spec = "0 0 1 * *"
+	}
+	if d, ok := strings.CutPrefix(spec, "@every "); ok {
+		every, err := time.ParseDuration(strings.TrimSpace(d))
+		if err != nil || every < time.Second {
+			return nil, fmt.Errorf("cron %q: @every needs a duration of at least 1s", spec)
+		}
+		return interval(every), nil
+	}
+
+	fs := strings.Fields(spec)
+	if len(fs) != 5 {
+		return nil, fmt.Errorf("cron %q: want 5 fields, got %d", spec, len(fs))
+	}
+	var c cron
+	for i, f := range []struct {
+		dst      *uint64
+		min, max int
+	}{{&c.minute, 0, 59}, {&c.hour, 0, 23}, {&c.dom, 1, 31}, {&c.month, 1, 12}, {&c.dow, 0, 7}} {
+		bits, err := parseField(fs[i], f.min, f.max)
+		if err != nil {
+			return nil, fmt.Errorf("cron %q: field %d: %w", spec, i+1, err)
+		}
+		*f.dst = bits
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1 // 7 is Sunday too
+	}
+	c.domAny, c.dowAny = fs[2] == "*", fs[4] == "*"
+	return c, nil
+}
+
+func parseField(f string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(f, ",") {
+		rng, step := part, 1
+		if r, s, ok := strings.Cut(part, "/"); ok {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("bad step %q", s)
+			}
+			rng, step = r, n
+		}
+		lo, hi := min, max
+		if rng != "*" {
+			a, b, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = strconv.Atoi(a); err != nil {
+				return 0, fmt.Errorf("bad value %q", a)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(b); err != nil {
+					return 0, fmt.Errorf("bad value %q", b)
+				}
+			} else if step > 1 {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+type cron struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+// day follows cron: when both day fields are restricted, either may match.
+func (c cron) day(t time.Time) bool {
+	dom, dow := c.dom&(1<<t.Day()) != 0, c.dow&(1<<t.Weekday()) != 0
+	switch {
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	}
+	return dom || dow
+}
+
+func (c cron) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	for limit := t.AddDate(5, 0, 0); t.Before(limit); {
+		y, m, d := t.Date()
+		switch {
+		case c.month&(1<<m) == 0:
+			t = time.Date(y, m+1, 1, 0, 0, 0, 0, time.UTC)
+		case !c.day(t):
+			t = time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC)
+		case c.hour&(1<<t.Hour()) == 0:
+			t = time.Date(y, m, d, t.Hour()+1, 0, 0, 0, time.UTC)
+		case c.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{} // e.g. 30 February
+}
+
+// interval fires on whole multiples of itself, so every replica computes the
+// same activation times.
+type interval time.Duration
+
+func (i interval) Next(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Duration(i)).Add(time.Duration(i))
+}
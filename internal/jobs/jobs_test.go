@@ -0,0 +1,191 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	at := func(s string) time.Time {
+		v, err := time.Parse("2006-01-02 15:04", s)
+		require.NoError(t, err)
+		return v
+	}
+	tests := []struct {
+		spec, from, want string
+	}{
+		{"* * * * *", "2026-03-01 10:00", "2026-03-01 10:01"},
+		{"*/15 * * * *", "2026-03-01 10:14", "2026-03-01 10:15"},
+		{"5/20 * * * *", "2026-03-01 10:26", "2026-03-01 10:45"},
+		{"30 3 * * *", "2026-03-01 03:30", "2026-03-02 03:30"},
+		{"0 9-17/4 * * *", "2026-03-01 13:00", "2026-03-01 17:00"},
+		{"0 0 1,15 * *", "2026-03-02 00:00", "2026-03-15 00:00"},
+		{"0 0 * * 1", "2026-03-01 00:00", "2026-03-02 00:00"}, // Sunday -> Monday
+		{"0 0 * * 7", "2026-03-02 00:00", "2026-03-08 00:00"},
+		{"0 0 13 * 5", "2026-03-01 00:00", "2026-03-06 00:00"}, // either day field
+		{"0 0 29 2 *", "2026-03-01 00:00", "2028-02-29 00:00"},
+		{"@daily", "2026-12-31 23:59", "2027-01-01 00:00"},
+		{"@monthly", "2026-03-01 00:00", "2026-04-01 00:00"},
+		{"@every 10m", "2026-03-01 10:07", "2026-03-01 10:10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := Parse(tt.spec)
+			require.NoError(t, err)
+			assert.Equal(t, at(tt.want), s.Next(at(tt.from)))
+		})
+	}
+
+	s, err := Parse("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, s.Next(at("2026-01-01 00:00")).IsZero(), "never due")
+
+	for _, bad := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *", "@every 1ms", "@yearly"} {
+		_, err := Parse(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+// memStore keeps runs in a slice and the job locks in a set.
+type memStore struct {
+	mu     sync.Mutex
+	locked map[string]bool
+	runs   []Run
+}
+
+func newMemStore() *memStore { return &memStore{locked: map[string]bool{}} }
+
+func (s *memStore) Lock(ctx context.Context, job string, fn func(context.Context) error) (bool, error) {
+	s.mu.Lock()
+	if s.locked[job] {
+		s.mu.Unlock()
+		return false, nil
+	}
+	s.locked[job] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.locked, job)
+		s.mu.Unlock()
+	}()
+	return true, fn(ctx)
+}
+
+func (s *memStore) Start(_ context.Context, r *Run) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, o := range s.runs {
+		if !r.ScheduledFor.IsZero() && o.Job == r.Job && o.ScheduledFor.Equal(r.ScheduledFor) {
+			return false, nil
+		}
+	}
+	r.ID = int64(len(s.runs) + 1)
+	s.runs = append(s.runs, *r)
+	return true, nil
+}
+
+func (s *memStore) Finish(_ context.Context, id int64, at time.Time, errMsg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runs[id-1].FinishedAt, s.runs[id-1].Error = at, errMsg
+	return nil
+}
+
+func (s *memStore) Recent(_ context.Context, job string, limit int) ([]Run, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Run
+	for i := len(s.runs) - 1; i >= 0 && len(out) < limit; i-- {
+		if s.runs[i].Job == job {
+			out = append(out, s.runs[i])
+		}
+	}
+	return out, nil
+}
+
+func TestScheduler(t *testing.T) {
+	ctx := context.Background()
+	slot := time.Date(2026, 3, 1, 3, 0, 0, 0, time.UTC)
+
+	t.Run("slot_runs_once_across_replicas", func(t *testing.T) {
+		store := newMemStore()
+		var calls int
+		a, b := NewScheduler(store, "a"), NewScheduler(store, "b")
+		for _, s := range []*Scheduler{a, b} {
+			require.NoError(t, s.Add("purge", "@daily", func(context.Context) error { calls++; return nil }))
+		}
+		a.execute(ctx, a.jobs["purge"], slot, TriggerSchedule)
+		b.execute(ctx, b.jobs["purge"], slot, TriggerSchedule)
+		assert.Equal(t, 1, calls)
+		require.Len(t, store.runs, 1)
+		assert.Equal(t, "a", store.runs[0].Instance)
+		assert.False(t, store.runs[0].FinishedAt.IsZero())
+
+		b.execute(ctx, b.jobs["purge"], slot.AddDate(0, 0, 1), TriggerSchedule)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("locked_job_is_skipped", func(t *testing.T) {
+		store := newMemStore()
+		s := NewScheduler(store, "a")
+		var calls int
+		require.NoError(t, s.Add("purge", "@daily", func(context.Context) error { calls++; return nil }))
+		store.locked["purge"] = true
+		s.execute(ctx, s.jobs["purge"], time.Time{}, TriggerManual)
+		assert.Zero(t, calls)
+		assert.Empty(t, store.runs)
+	})
+
+	t.Run("errors_and_panics_are_recorded", func(t *testing.T) {
+		store := newMemStore()
+		s := NewScheduler(store, "a")
+		require.NoError(t, s.Add("fails", "@daily", func(context.Context) error { return errors.New("db gone") }))
+		require.NoError(t, s.Add("panics", "@daily", func(context.Context) error { panic("boom") }))
+		s.execute(ctx, s.jobs["fails"], time.Time{}, TriggerManual)
+		s.execute(ctx, s.jobs["panics"], time.Time{}, TriggerManual)
+		require.Len(t, store.runs, 2)
+		assert.Equal(t, "db gone", store.runs[0].Error)
+		assert.Equal(t, "panic: boom", store.runs[1].Error)
+	})
+
+	t.Run("trigger_and_status", func(t *testing.T) {
+		store := newMemStore()
+		s := NewScheduler(store, "a")
+		done := make(chan struct{})
+		require.NoError(t, s.Add("purge", "0 3 * * *", func(context.Context) error { close(done); return nil }))
+		require.NoError(t, s.Add("other", "@hourly", func(context.Context) error { return nil }))
+		require.Error(t, s.Add("purge", "@daily", nil))
+		require.Error(t, s.Add("bad", "@yearly", nil))
+		require.ErrorIs(t, s.Trigger("nope"), ErrUnknownJob)
+
+		ctx, cancel := context.WithCancel(ctx)
+		stopped := make(chan struct{})
+		go func() { s.Run(ctx); close(stopped) }()
+		require.NoError(t, s.Trigger("purge"))
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("manual run did not start")
+		}
+		cancel()
+		<-stopped
+
+		st, err := s.Status(context.Background())
+		require.NoError(t, err)
+		require.Len(t, st, 2)
+		assert.Equal(t, "other", st[0].Name)
+		assert.Empty(t, st[0].Runs)
+		assert.Equal(t, "purge", st[1].Name)
+		assert.Equal(t, 3, st[1].Next.Hour())
+		require.Len(t, st[1].Runs, 1)
+		assert.Equal(t, TriggerManual, st[1].Runs[0].Trigger)
+		assert.True(t, st[1].Runs[0].ScheduledFor.IsZero())
+		assert.False(t, st[1].Runs[0].FinishedAt.IsZero())
+	})
+}
@@ -0,0 +1,232 @@
+// Package jobs runs recurring maintenance inside the API process. Every
+// replica runs the same scheduler; a cluster-wide lock per job and a claim on
+// each scheduled slot make sure a due job runs on exactly one of them.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"usrsvc/internal/pkg/log"
+)
+
+// ScopeAdmin lets a caller see job status and trigger runs.
+const ScopeAdmin = "jobs:admin"
+
+// What started a run.
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+var (
+	ErrUnknownJob = errors.New("jobs: unknown job")
+	ErrBusy       = errors.New("jobs: trigger queue is full")
+)
+
+// Run is one execution of a job. FinishedAt is zero while it runs, or when
+// its replica died before recording the end.
+type Run struct {
+	ID           int64
+	Job          string
+	Trigger      string
+	ScheduledFor time.Time // zero for manual runs
+	Instance     string
+	StartedAt    time.Time
+	FinishedAt   time.Time
+	Error        string
+}
+
+// Store is the run history and the cluster-wide job lock.
+type Store interface {
+	// Lock calls fn while holding job's lock on every replica. It returns
+	// false without calling fn when another replica holds it.
+	Lock(ctx context.Context, job string, fn func(context.Context) error) (bool, error)
+	// Start records r and sets its ID. For a scheduled run it returns false
+	// when the slot was already claimed, by this or another replica.
+	Start(ctx context.Context, r *Run) (bool, error)
+	Finish(ctx context.Context, id int64, at time.Time, errMsg string) error
+	// Recent returns up to limit runs of job, newest first.
+	Recent(ctx context.Context, job string, limit int) ([]Run, error)
+}
+
+// Status is a registered job as this replica schedules it.
+type Status struct {
+	Name string
+	Spec string
+	Next time.Time
+	Runs []Run
+}
+
+type job struct {
+	name, spec string
+	sched      Schedule
+	run        func(context.Context) error
+	next       time.Time
+}
+
+// Scheduler runs registered jobs when they are due and on Trigger.
+type Scheduler struct {
+	Store    Store
+	Instance string
+	History  int // runs per job returned by Status
+
+	mu      sync.Mutex
+	jobs    map[string]*job
+	trigger chan string
+	now     func() time.Time
+}
+
+func NewScheduler(s Store, instance string) *Scheduler {
+	return &Scheduler{
+		Store: s, Instance: instance, History: 10,
+		jobs: map[string]*job{}, trigger: make(chan string, 16), now: time.Now,
+	}
+}
+
+// Add registers fn under name. Call it before Run.
+func (s *Scheduler) Add(name, spec string, fn func(context.Context) error) error {
+	sched, err := Parse(spec)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[name]; ok {
+		return fmt.Errorf("jobs: %q registered twice", name)
+	}
+	s.jobs[name] = &job{name: name, spec: spec, sched: sched, run: fn}
+	return nil
+}
+
+// Run schedules until ctx is done, then waits for the runs it started.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	start := func(j *job, slot time.Time, trigger string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.execute(ctx, j, slot, trigger)
+		}()
+	}
+
+	s.mu.Lock()
+	for _, j := range s.jobs {
+		j.next = j.sched.Next(s.now())
+	}
+	s.mu.Unlock()
+	for {
+		timer := time.NewTimer(s.untilNext())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case name := <-s.trigger:
+			timer.Stop()
+			s.mu.Lock()
+			j := s.jobs[name]
+			s.mu.Unlock()
+			start(j, time.Time{}, TriggerManual)
+		case <-timer.C:
+			now := s.now()
+			s.mu.Lock()
+			for _, j := range s.jobs {
+				if !j.next.IsZero() && !j.next.After(now) {
+					start(j, j.next, TriggerSchedule)
+					j.next = j.sched.Next(now)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *Scheduler) untilNext() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := time.Hour
+	for _, j := range s.jobs {
+		if j.next.IsZero() {
+			continue
+		}
+		if until := j.next.Sub(s.now()); until < d {
+			d = until
+		}
+	}
+	return max(d, 0)
+}
+
+// Trigger queues a manual run of name on this replica. The run is skipped
+// when another replica is running the job.
+func (s *Scheduler) Trigger(name string) error {
+	s.mu.Lock()
+	_, ok := s.jobs[name]
+	s.mu.Unlock()
+	if !ok {
+		return ErrUnknownJob
+	}
+	select {
+	case s.trigger <- name:
+		return nil
+	default:
+		return ErrBusy
+	}
+}
+
+// Status lists the registered jobs by name with their latest runs on any
+// replica.
+func (s *Scheduler) Status(ctx context.Context) ([]Status, error) {
+	s.mu.Lock()
+	out := make([]Status, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		out = append(out, Status{Name: j.name, Spec: j.spec, Next: j.next})
+	}
+	s.mu.Unlock()
+	sort.Slice(out, func(i, k int) bool { return out[i].Name < out[k].Name })
+	for i := range out {
+		runs, err := s.Store.Recent(ctx, out[i].Name, s.History)
+		if err != nil {
+			return nil, err
+		}
+		out[i].Runs = runs
+	}
+	return out, nil
+}
+
+func (s *Scheduler) execute(ctx context.Context, j *job, slot time.Time, trigger string) {
+	locked, err := s.Store.Lock(ctx, j.name, func(ctx context.Context) error {
+		r := Run{Job: j.name, Trigger: trigger, ScheduledFor: slot, Instance: s.Instance, StartedAt: s.now()}
+		claimed, err := s.Store.Start(ctx, &r)
+		if err != nil || !claimed {
+			return err
+		}
+		var msg string
+		if err := safeRun(ctx, j.run); err != nil {
+			msg = err.Error()
+			log.Error.Printf("job %s run=%d trigger=%s err=%v", j.name, r.ID, trigger, err)
+		} else {
+			log.Info.Printf("job %s ok run=%d trigger=%s took=%s", j.name, r.ID, trigger, s.now().Sub(r.StartedAt))
+		}
+		return s.Store.Finish(context.WithoutCancel(ctx), r.ID, s.now(), msg)
+	})
+	switch {
+	case err != nil && ctx.Err() == nil:
+		log.Error.Printf("job %s trigger=%s store_err=%v", j.name, trigger, err)
+	case !locked && err == nil:
+		log.Info.Printf("job %s trigger=%s skipped, running elsewhere", j.name, trigger)
+	}
+}
+
+func safeRun(ctx context.Context, fn func(context.Context) error) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return fn(ctx)
+}
@@ -12,7 +12,7 @@ func CORS(allow []string) func(http.Handler) http.Handler {
 				if origin == "" { origin = "*" }
 				w.Header().Set("Access-Control-Allow-Origin", origin)
 				w.Header().Set("Vary", "Origin")
-				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Read-Primary, X-Tenant-ID, X-Actor, X-Role, X-Scopes")
 				w.Header().Set("Access-Control-Allow-Methods", "GET,POST,PUT,DELETE,OPTIONS")
 			}
 			if r.Method == "OPTIONS" { w.WriteHeader(204); return }
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Deprecated marks every response as deprecated (RFC 9745) with a Sunset
+// date (RFC 8594) and links to the same path under successorPrefix.
+func Deprecated(since, sunset time.Time, successorPrefix string) func(http.Handler) http.Handler {
+	dep := "@" + strconv.FormatInt(since.Unix(), 10)
+	sun := sunset.UTC().Format(http.TimeFormat)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", dep)
+			w.Header().Set("Sunset", sun)
+			w.Header().Add("Link", "<"+successorPrefix+r.URL.Path+`>; rel="successor-version"`)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
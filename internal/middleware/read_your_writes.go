@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"usrsvc/internal/pkg/db"
+)
+
+const (
+	// ReadPrimaryHeader set to "true" sends all of a request's reads to the
+	// primary database.
+	ReadPrimaryHeader = "X-Read-Primary"
+	readPrimaryCookie = "usrsvc_read_primary"
+)
+
+// ReadYourWrites gives every request a db.Session. A request that writes
+// reads from the primary for the rest of the request and gets a cookie that
+// keeps the client's reads on the primary for window, long enough for a
+// healthy replica to catch up. X-Read-Primary: true does the same on demand.
+func ReadYourWrites(window time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			primary := strings.EqualFold(r.Header.Get(ReadPrimaryHeader), "true") || pinnedUntil(r).After(time.Now())
+			ctx, s := db.WithSession(r.Context(), primary)
+			next.ServeHTTP(&sessionWriter{ResponseWriter: w, s: s, window: window}, r.WithContext(ctx))
+		})
+	}
+}
+
+func pinnedUntil(r *http.Request) time.Time {
+	c, err := r.Cookie(readPrimaryCookie)
+	if err != nil {
+		return time.Time{}
+	}
+	ms, _ := strconv.ParseInt(c.Value, 10, 64)
+	return time.UnixMilli(ms)
+}
+
+// sessionWriter sets the cookie once the handler starts its response, by
+// which time any write has happened.
+type sessionWriter struct {
+	http.ResponseWriter
+	s       *db.Session
+	window  time.Duration
+	written bool
+}
+
+func (w *sessionWriter) WriteHeader(code int) {
+	if !w.written {
+		w.written = true
+		if w.window > 0 && w.s.Wrote() {
+			until := time.Now().Add(w.window)
+			http.SetCookie(w.ResponseWriter, &http.Cookie{
+				Name: readPrimaryCookie, Value: strconv.FormatInt(until.UnixMilli(), 10), Path: "/",
+				Expires: until, HttpOnly: true, SameSite: http.SameSiteLaxMode,
+			})
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *sessionWriter) Write(b []byte) (int, error) {
+	if !w.written {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *sessionWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
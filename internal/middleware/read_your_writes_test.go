@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"usrsvc/internal/pkg/db"
+)
+
+func TestReadYourWrites(t *testing.T) {
+	var primary bool
+	h := ReadYourWrites(5 * time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primary = db.PrimaryOnly(r.Context())
+		if r.Method == http.MethodPost {
+			db.MarkWritten(r.Context())
+		}
+		w.Write([]byte("ok"))
+	}))
+	serve := func(method string, mod func(*http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/v1/users", nil)
+		if mod != nil {
+			mod(req)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(http.MethodGet, nil)
+	assert.False(t, primary)
+	assert.Empty(t, rec.Result().Cookies())
+
+	serve(http.MethodGet, func(r *http.Request) { r.Header.Set(ReadPrimaryHeader, "TRUE") })
+	assert.True(t, primary, "header")
+
+	rec = serve(http.MethodPost, nil)
+	require.Len(t, rec.Result().Cookies(), 1, "a write pins the client")
+	c := rec.Result().Cookies()[0]
+	assert.Equal(t, readPrimaryCookie, c.Name)
+	assert.True(t, c.HttpOnly)
+
+	serve(http.MethodGet, func(r *http.Request) { r.AddCookie(c) })
+	assert.True(t, primary, "cookie within the window")
+
+	expired := strconv.FormatInt(time.Now().Add(-time.Second).UnixMilli(), 10)
+	serve(http.MethodGet, func(r *http.Request) { r.AddCookie(&http.Cookie{Name: readPrimaryCookie, Value: expired}) })
+	assert.False(t, primary, "cookie past the window")
+
+	off := ReadYourWrites(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		db.MarkWritten(r.Context())
+		w.WriteHeader(http.StatusCreated)
+		assert.NoError(t, http.NewResponseController(w).Flush(), "Unwrap keeps Flush reachable")
+	}))
+	rec = httptest.NewRecorder()
+	off.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/users", nil))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Empty(t, rec.Result().Cookies(), "no window, no cookie")
+}
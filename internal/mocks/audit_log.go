@@ -0,0 +1,47 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	domain "usrsvc/internal/domain"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// AuditLog is an autogenerated mock type for the AuditLog type
+type AuditLog struct {
+	mock.Mock
+}
+
+// Record provides a mock function with given fields: ctx, e
+func (_m *AuditLog) Record(ctx context.Context, e domain.AuditEntry) error {
+	ret := _m.Called(ctx, e)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Record")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.AuditEntry) error); ok {
+		r0 = rf(ctx, e)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewAuditLog creates a new instance of AuditLog. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAuditLog(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *AuditLog {
+	mock := &AuditLog{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -14,6 +14,36 @@ type UserRepository struct {
 	mock.Mock
 }
 
+// AnonymizeCustomer provides a mock function with given fields: ctx, id
+func (_m *UserRepository) AnonymizeCustomer(ctx context.Context, id int32) (*domain.Customer, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AnonymizeCustomer")
+	}
+
+	var r0 *domain.Customer
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32) (*domain.Customer, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int32) *domain.Customer); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Customer)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int32) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateCustomer provides a mock function with given fields: ctx, c
 func (_m *UserRepository) CreateCustomer(ctx context.Context, c domain.Customer) (int32, error) {
 	ret := _m.Called(ctx, c)
@@ -42,6 +72,36 @@ func (_m *UserRepository) CreateCustomer(ctx context.Context, c domain.Customer)
 	return r0, r1
 }
 
+// CustomerStats provides a mock function with given fields: ctx, f
+func (_m *UserRepository) CustomerStats(ctx context.Context, f domain.StatsFilter) (*domain.CustomerStats, error) {
+	ret := _m.Called(ctx, f)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CustomerStats")
+	}
+
+	var r0 *domain.CustomerStats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.StatsFilter) (*domain.CustomerStats, error)); ok {
+		return rf(ctx, f)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.StatsFilter) *domain.CustomerStats); ok {
+		r0 = rf(ctx, f)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.CustomerStats)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.StatsFilter) error); ok {
+		r1 = rf(ctx, f)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DeleteCustomer provides a mock function with given fields: ctx, id
 func (_m *UserRepository) DeleteCustomer(ctx context.Context, id int32) error {
 	ret := _m.Called(ctx, id)
@@ -60,6 +120,96 @@ func (_m *UserRepository) DeleteCustomer(ctx context.Context, id int32) error {
 	return r0
 }
 
+// DuplicateCandidates provides a mock function with given fields: ctx, c, limit
+func (_m *UserRepository) DuplicateCandidates(ctx context.Context, c domain.Customer, limit int) ([]domain.Customer, error) {
+	ret := _m.Called(ctx, c, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DuplicateCandidates")
+	}
+
+	var r0 []domain.Customer
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Customer, int) ([]domain.Customer, error)); ok {
+		return rf(ctx, c, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Customer, int) []domain.Customer); ok {
+		r0 = rf(ctx, c, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Customer)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Customer, int) error); ok {
+		r1 = rf(ctx, c, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExportCustomer provides a mock function with given fields: ctx, id
+func (_m *UserRepository) ExportCustomer(ctx context.Context, id int32) (*domain.DataExport, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExportCustomer")
+	}
+
+	var r0 *domain.DataExport
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32) (*domain.DataExport, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int32) *domain.DataExport); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.DataExport)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int32) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Families provides a mock function with given fields: ctx, customerIDs
+func (_m *UserRepository) Families(ctx context.Context, customerIDs []int32) (map[int32][]domain.FamilyMember, error) {
+	ret := _m.Called(ctx, customerIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Families")
+	}
+
+	var r0 map[int32][]domain.FamilyMember
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []int32) (map[int32][]domain.FamilyMember, error)); ok {
+		return rf(ctx, customerIDs)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []int32) map[int32][]domain.FamilyMember); ok {
+		r0 = rf(ctx, customerIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[int32][]domain.FamilyMember)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []int32) error); ok {
+		r1 = rf(ctx, customerIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetCustomer provides a mock function with given fields: ctx, id
 func (_m *UserRepository) GetCustomer(ctx context.Context, id int32) (*domain.Customer, error) {
 	ret := _m.Called(ctx, id)
@@ -90,6 +240,66 @@ func (_m *UserRepository) GetCustomer(ctx context.Context, id int32) (*domain.Cu
 	return r0, r1
 }
 
+// GetCustomers provides a mock function with given fields: ctx, ids
+func (_m *UserRepository) GetCustomers(ctx context.Context, ids []int32) ([]domain.Customer, error) {
+	ret := _m.Called(ctx, ids)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCustomers")
+	}
+
+	var r0 []domain.Customer
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []int32) ([]domain.Customer, error)); ok {
+		return rf(ctx, ids)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []int32) []domain.Customer); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Customer)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []int32) error); ok {
+		r1 = rf(ctx, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetNationality provides a mock function with given fields: ctx, id
+func (_m *UserRepository) GetNationality(ctx context.Context, id int32) (*domain.Nationality, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetNationality")
+	}
+
+	var r0 *domain.Nationality
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32) (*domain.Nationality, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int32) *domain.Nationality); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Nationality)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int32) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListCustomers provides a mock function with given fields: ctx, search, limit, offset
 func (_m *UserRepository) ListCustomers(ctx context.Context, search string, limit int, offset int) ([]domain.Customer, int32, error) {
 	ret := _m.Called(ctx, search, limit, offset)
@@ -157,6 +367,52 @@ func (_m *UserRepository) ListNationalities(ctx context.Context) ([]domain.Natio
 	return r0, r1
 }
 
+// MergeCustomers provides a mock function with given fields: ctx, targetID, sourceID, merged
+func (_m *UserRepository) MergeCustomers(ctx context.Context, targetID int32, sourceID int32, merged domain.Customer) error {
+	ret := _m.Called(ctx, targetID, sourceID, merged)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MergeCustomers")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32, int32, domain.Customer) error); ok {
+		r0 = rf(ctx, targetID, sourceID, merged)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Redirect provides a mock function with given fields: ctx, id
+func (_m *UserRepository) Redirect(ctx context.Context, id int32) (int32, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Redirect")
+	}
+
+	var r0 int32
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32) (int32, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int32) int32); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(int32)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int32) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UpdateCustomer provides a mock function with given fields: ctx, id, c
 func (_m *UserRepository) UpdateCustomer(ctx context.Context, id int32, c domain.Customer) error {
 	ret := _m.Called(ctx, id, c)
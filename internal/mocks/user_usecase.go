@@ -14,6 +14,36 @@ type UserUsecase struct {
 	mock.Mock
 }
 
+// Anonymize provides a mock function with given fields: ctx, id, reason
+func (_m *UserUsecase) Anonymize(ctx context.Context, id int32, reason string) (*domain.Customer, error) {
+	ret := _m.Called(ctx, id, reason)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Anonymize")
+	}
+
+	var r0 *domain.Customer
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32, string) (*domain.Customer, error)); ok {
+		return rf(ctx, id, reason)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int32, string) *domain.Customer); ok {
+		r0 = rf(ctx, id, reason)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Customer)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int32, string) error); ok {
+		r1 = rf(ctx, id, reason)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Create provides a mock function with given fields: ctx, c
 func (_m *UserUsecase) Create(ctx context.Context, c domain.Customer) (int32, error) {
 	ret := _m.Called(ctx, c)
@@ -60,6 +90,66 @@ func (_m *UserUsecase) Delete(ctx context.Context, id int32) error {
 	return r0
 }
 
+// Duplicates provides a mock function with given fields: ctx, id, minScore, limit
+func (_m *UserUsecase) Duplicates(ctx context.Context, id int32, minScore float64, limit int) ([]domain.DuplicateCandidate, error) {
+	ret := _m.Called(ctx, id, minScore, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Duplicates")
+	}
+
+	var r0 []domain.DuplicateCandidate
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32, float64, int) ([]domain.DuplicateCandidate, error)); ok {
+		return rf(ctx, id, minScore, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int32, float64, int) []domain.DuplicateCandidate); ok {
+		r0 = rf(ctx, id, minScore, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.DuplicateCandidate)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int32, float64, int) error); ok {
+		r1 = rf(ctx, id, minScore, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Export provides a mock function with given fields: ctx, id, reason
+func (_m *UserUsecase) Export(ctx context.Context, id int32, reason string) (*domain.DataExport, error) {
+	ret := _m.Called(ctx, id, reason)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Export")
+	}
+
+	var r0 *domain.DataExport
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32, string) (*domain.DataExport, error)); ok {
+		return rf(ctx, id, reason)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int32, string) *domain.DataExport); ok {
+		r0 = rf(ctx, id, reason)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.DataExport)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int32, string) error); ok {
+		r1 = rf(ctx, id, reason)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Get provides a mock function with given fields: ctx, id
 func (_m *UserUsecase) Get(ctx context.Context, id int32) (*domain.Customer, error) {
 	ret := _m.Called(ctx, id)
@@ -90,6 +180,45 @@ func (_m *UserUsecase) Get(ctx context.Context, id int32) (*domain.Customer, err
 	return r0, r1
 }
 
+// GetMany provides a mock function with given fields: ctx, ids, family
+func (_m *UserUsecase) GetMany(ctx context.Context, ids []int32, family bool) ([]domain.Customer, []int32, error) {
+	ret := _m.Called(ctx, ids, family)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetMany")
+	}
+
+	var r0 []domain.Customer
+	var r1 []int32
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, []int32, bool) ([]domain.Customer, []int32, error)); ok {
+		return rf(ctx, ids, family)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []int32, bool) []domain.Customer); ok {
+		r0 = rf(ctx, ids, family)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Customer)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []int32, bool) []int32); ok {
+		r1 = rf(ctx, ids, family)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]int32)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, []int32, bool) error); ok {
+		r2 = rf(ctx, ids, family)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // List provides a mock function with given fields: ctx, search, page, size
 func (_m *UserUsecase) List(ctx context.Context, search string, page int, size int) ([]domain.Customer, int32, error) {
 	ret := _m.Called(ctx, search, page, size)
@@ -157,6 +286,84 @@ func (_m *UserUsecase) ListNationality(ctx context.Context) ([]domain.Nationalit
 	return r0, r1
 }
 
+// LoadFamilies provides a mock function with given fields: ctx, cs
+func (_m *UserUsecase) LoadFamilies(ctx context.Context, cs []domain.Customer) error {
+	ret := _m.Called(ctx, cs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LoadFamilies")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []domain.Customer) error); ok {
+		r0 = rf(ctx, cs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Merge provides a mock function with given fields: ctx, targetID, sourceID, p
+func (_m *UserUsecase) Merge(ctx context.Context, targetID int32, sourceID int32, p domain.MergePolicy) (*domain.Customer, error) {
+	ret := _m.Called(ctx, targetID, sourceID, p)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Merge")
+	}
+
+	var r0 *domain.Customer
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32, int32, domain.MergePolicy) (*domain.Customer, error)); ok {
+		return rf(ctx, targetID, sourceID, p)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int32, int32, domain.MergePolicy) *domain.Customer); ok {
+		r0 = rf(ctx, targetID, sourceID, p)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Customer)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int32, int32, domain.MergePolicy) error); ok {
+		r1 = rf(ctx, targetID, sourceID, p)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Stats provides a mock function with given fields: ctx, f
+func (_m *UserUsecase) Stats(ctx context.Context, f domain.StatsFilter) (*domain.CustomerStats, error) {
+	ret := _m.Called(ctx, f)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Stats")
+	}
+
+	var r0 *domain.CustomerStats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.StatsFilter) (*domain.CustomerStats, error)); ok {
+		return rf(ctx, f)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.StatsFilter) *domain.CustomerStats); ok {
+		r0 = rf(ctx, f)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.CustomerStats)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.StatsFilter) error); ok {
+		r1 = rf(ctx, f)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Update provides a mock function with given fields: ctx, id, c
 func (_m *UserUsecase) Update(ctx context.Context, id int32, c domain.Customer) error {
 	ret := _m.Called(ctx, id, c)
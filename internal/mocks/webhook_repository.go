@@ -0,0 +1,185 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	domain "usrsvc/internal/domain"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// WebhookRepository is an autogenerated mock type for the WebhookRepository type
+type WebhookRepository struct {
+	mock.Mock
+}
+
+// CreateWebhook provides a mock function with given fields: ctx, w
+func (_m *WebhookRepository) CreateWebhook(ctx context.Context, w domain.Webhook) (int32, error) {
+	ret := _m.Called(ctx, w)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateWebhook")
+	}
+
+	var r0 int32
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Webhook) (int32, error)); ok {
+		return rf(ctx, w)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Webhook) int32); ok {
+		r0 = rf(ctx, w)
+	} else {
+		r0 = ret.Get(0).(int32)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Webhook) error); ok {
+		r1 = rf(ctx, w)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteWebhook provides a mock function with given fields: ctx, id
+func (_m *WebhookRepository) DeleteWebhook(ctx context.Context, id int32) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteWebhook")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetWebhook provides a mock function with given fields: ctx, id
+func (_m *WebhookRepository) GetWebhook(ctx context.Context, id int32) (*domain.Webhook, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWebhook")
+	}
+
+	var r0 *domain.Webhook
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32) (*domain.Webhook, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int32) *domain.Webhook); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Webhook)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int32) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListDeliveries provides a mock function with given fields: ctx, webhookID, limit
+func (_m *WebhookRepository) ListDeliveries(ctx context.Context, webhookID int32, limit int) ([]domain.Delivery, error) {
+	ret := _m.Called(ctx, webhookID, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListDeliveries")
+	}
+
+	var r0 []domain.Delivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32, int) ([]domain.Delivery, error)); ok {
+		return rf(ctx, webhookID, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int32, int) []domain.Delivery); ok {
+		r0 = rf(ctx, webhookID, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Delivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int32, int) error); ok {
+		r1 = rf(ctx, webhookID, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListWebhooks provides a mock function with given fields: ctx
+func (_m *WebhookRepository) ListWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListWebhooks")
+	}
+
+	var r0 []domain.Webhook
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]domain.Webhook, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.Webhook); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Webhook)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Redeliver provides a mock function with given fields: ctx, webhookID, deliveryID, at
+func (_m *WebhookRepository) Redeliver(ctx context.Context, webhookID int32, deliveryID int64, at time.Time) error {
+	ret := _m.Called(ctx, webhookID, deliveryID, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Redeliver")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32, int64, time.Time) error); ok {
+		r0 = rf(ctx, webhookID, deliveryID, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewWebhookRepository creates a new instance of WebhookRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewWebhookRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *WebhookRepository {
+	mock := &WebhookRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,185 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	domain "usrsvc/internal/domain"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// WebhookUsecase is an autogenerated mock type for the WebhookUsecase type
+type WebhookUsecase struct {
+	mock.Mock
+}
+
+// Create provides a mock function with given fields: ctx, w
+func (_m *WebhookUsecase) Create(ctx context.Context, w domain.Webhook) (*domain.Webhook, error) {
+	ret := _m.Called(ctx, w)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Create")
+	}
+
+	var r0 *domain.Webhook
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Webhook) (*domain.Webhook, error)); ok {
+		return rf(ctx, w)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Webhook) *domain.Webhook); ok {
+		r0 = rf(ctx, w)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Webhook)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Webhook) error); ok {
+		r1 = rf(ctx, w)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Delete provides a mock function with given fields: ctx, id
+func (_m *WebhookUsecase) Delete(ctx context.Context, id int32) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Deliveries provides a mock function with given fields: ctx, webhookID, limit
+func (_m *WebhookUsecase) Deliveries(ctx context.Context, webhookID int32, limit int) ([]domain.Delivery, error) {
+	ret := _m.Called(ctx, webhookID, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Deliveries")
+	}
+
+	var r0 []domain.Delivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32, int) ([]domain.Delivery, error)); ok {
+		return rf(ctx, webhookID, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int32, int) []domain.Delivery); ok {
+		r0 = rf(ctx, webhookID, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Delivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int32, int) error); ok {
+		r1 = rf(ctx, webhookID, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Get provides a mock function with given fields: ctx, id
+func (_m *WebhookUsecase) Get(ctx context.Context, id int32) (*domain.Webhook, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Get")
+	}
+
+	var r0 *domain.Webhook
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32) (*domain.Webhook, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int32) *domain.Webhook); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Webhook)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int32) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// List provides a mock function with given fields: ctx
+func (_m *WebhookUsecase) List(ctx context.Context) ([]domain.Webhook, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for List")
+	}
+
+	var r0 []domain.Webhook
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]domain.Webhook, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.Webhook); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Webhook)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Redeliver provides a mock function with given fields: ctx, webhookID, deliveryID
+func (_m *WebhookUsecase) Redeliver(ctx context.Context, webhookID int32, deliveryID int64) error {
+	ret := _m.Called(ctx, webhookID, deliveryID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Redeliver")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int32, int64) error); ok {
+		r0 = rf(ctx, webhookID, deliveryID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewWebhookUsecase creates a new instance of WebhookUsecase. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewWebhookUsecase(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *WebhookUsecase {
+	mock := &WebhookUsecase{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
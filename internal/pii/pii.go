@@ -0,0 +1,110 @@
+// Package pii shapes customer PII for the caller before it reaches a
+// response: full values for privileged roles, masked ones (a***@x.com,
+// +62****1234, 1992-**-**) for everyone else, and full values behind an audit
+// record for callers holding ScopeUnmask.
+package pii
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/pkg/auth"
+	"usrsvc/internal/pkg/log"
+	"usrsvc/internal/pkg/phone"
+	"usrsvc/internal/pkg/tenant"
+)
+
+// ScopeUnmask lets a caller whose role sees masked values see full ones; every
+// such response is audited.
+const ScopeUnmask = "pii:unmask"
+
+// ScopeErase lets a caller that sees full values anonymize a customer.
+const ScopeErase = "pii:erase"
+
+// Policy decides per caller. With FullRoles empty masking is off and every
+// caller sees full values.
+type Policy struct {
+	FullRoles []string
+	Audit     domain.AuditLog // nil leaves the log line as the only record
+}
+
+// For returns the Masker for ctx's caller answering op about the customers
+// ids. An unmasking caller gets an error, and must not get the data, when
+// the audit record cannot be stored.
+func (p Policy) For(ctx context.Context, op string, ids ...int32) (Masker, error) {
+	c := auth.From(ctx)
+	if len(p.FullRoles) == 0 || slices.Contains(p.FullRoles, c.Role) {
+		return Masker{}, nil
+	}
+	if !c.HasScope(ScopeUnmask) {
+		return Masker{masked: true}, nil
+	}
+	e := domain.AuditEntry{Action: domain.AuditPIIUnmask, Operation: op, Actor: c.Subject, Role: c.Role, CustomerIDs: ids, At: time.Now()}
+	t, _ := tenant.From(ctx)
+	log.Info.Printf("audit action=%s op=%s tenant=%s actor=%q role=%q ids=%v", e.Action, op, t, c.Subject, c.Role, ids)
+	if p.Audit != nil {
+		if err := p.Audit.Record(ctx, e); err != nil {
+			return Masker{masked: true}, err
+		}
+	}
+	return Masker{}, nil
+}
+
+// Masks reports whether ctx's caller sees masked values, holding neither a
+// full role nor ScopeUnmask. It records nothing.
+func (p Policy) Masks(ctx context.Context) bool {
+	c := auth.From(ctx)
+	return len(p.FullRoles) > 0 && !slices.Contains(p.FullRoles, c.Role) && !c.HasScope(ScopeUnmask)
+}
+
+// Masker renders PII fields for one caller. The zero Masker shows them as is.
+type Masker struct{ masked bool }
+
+func (m Masker) Masked() bool { return m.masked }
+
+// Email keeps the first character and the domain: a***@x.com.
+func (m Masker) Email(s string) string {
+	if !m.masked || s == "" {
+		return s
+	}
+	at := strings.LastIndexByte(s, '@')
+	if at <= 0 {
+		return "***"
+	}
+	_, n := utf8.DecodeRuneInString(s)
+	return s[:n] + "***" + s[at:]
+}
+
+// Phone keeps an E.164 number's country code and the last four digits:
+// +62****1234. Other numbers keep only the last four digits.
+func (m Masker) Phone(s string) string {
+	if !m.masked || s == "" {
+		return s
+	}
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+	prefix := ""
+	if cc := phone.CountryCode(s); cc != "" {
+		prefix = "+" + cc
+	}
+	if len(digits) < 4 {
+		return prefix + "****"
+	}
+	return prefix + "****" + digits[len(digits)-4:]
+}
+
+// Date formats t as YYYY-MM-DD, masked to the year: 1992-**-**.
+func (m Masker) Date(t time.Time) string {
+	if !m.masked {
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006") + "-**-**"
+}
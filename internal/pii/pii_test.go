@@ -0,0 +1,80 @@
+package pii
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/mocks"
+	"usrsvc/internal/pkg/auth"
+)
+
+func TestMasker(t *testing.T) {
+	m := Masker{masked: true}
+	tests := []struct {
+		name      string
+		got, want string
+	}{
+		{"email", m.Email("alpha@x.com"), "a***@x.com"},
+		{"email_multibyte", m.Email("élan@x.com"), "é***@x.com"},
+		{"email_no_at", m.Email("alpha"), "***"},
+		{"email_empty", m.Email(""), ""},
+		{"e164", m.Phone("+6281234561234"), "+62****1234"},
+		{"e164_us", m.Phone("+14155552671"), "+1****2671"},
+		{"raw_phone", m.Phone("0812-3456-1234"), "****1234"},
+		{"short_phone", m.Phone("12"), "****"},
+		{"phone_empty", m.Phone(""), ""},
+		{"date", m.Date(time.Date(1992, 5, 10, 0, 0, 0, 0, time.UTC)), "1992-**-**"},
+		{"full_email", Masker{}.Email("alpha@x.com"), "alpha@x.com"},
+		{"full_phone", Masker{}.Phone("+6281234561234"), "+6281234561234"},
+		{"full_date", Masker{}.Date(time.Date(1992, 5, 10, 0, 0, 0, 0, time.UTC)), "1992-05-10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) { assert.Equal(t, tt.want, tt.got) })
+	}
+}
+
+func TestPolicy_For(t *testing.T) {
+	agent := auth.Caller{Subject: "u1", Role: "agent"}
+	unmask := auth.Caller{Subject: "u2", Role: "agent", Scopes: []string{ScopeUnmask}}
+	tests := []struct {
+		name       string
+		fullRoles  []string
+		caller     auth.Caller
+		auditErr   error
+		wantMasked bool
+		wantAudit  bool
+		wantErr    bool
+	}{
+		{name: "masking_off", caller: agent},
+		{name: "full_role", fullRoles: []string{"admin"}, caller: auth.Caller{Role: "admin"}},
+		{name: "masked_role", fullRoles: []string{"admin"}, caller: agent, wantMasked: true},
+		{name: "no_role", fullRoles: []string{"admin"}, wantMasked: true},
+		{name: "unmask_scope", fullRoles: []string{"admin"}, caller: unmask, wantAudit: true},
+		{name: "audit_fails", fullRoles: []string{"admin"}, caller: unmask, auditErr: errors.New("db down"), wantMasked: true, wantAudit: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := mocks.NewAuditLog(t)
+			if tt.wantAudit {
+				a.On("Record", mock.Anything, mock.MatchedBy(func(e domain.AuditEntry) bool {
+					return e.Action == domain.AuditPIIUnmask && e.Operation == "get_user" && e.Actor == "u2" && e.Role == "agent" &&
+						assert.ObjectsAreEqual([]int32{36}, e.CustomerIDs)
+				})).Return(tt.auditErr).Once()
+			}
+			m, err := Policy{FullRoles: tt.fullRoles, Audit: a}.For(auth.With(context.Background(), tt.caller), "get_user", 36)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantMasked, m.Masked())
+		})
+	}
+}
@@ -0,0 +1,72 @@
+// Package auth carries who is calling: the subject, its role and its scopes.
+package auth
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+var ErrUnauthorized = errors.New("auth: invalid or missing token")
+
+var now = time.Now
+
+type Caller struct {
+	Subject string
+	Role    string
+	Scopes  []string
+}
+
+func (c Caller) HasScope(scope string) bool { return slices.Contains(c.Scopes, scope) }
+
+type ctxKey struct{}
+
+func With(ctx context.Context, c Caller) context.Context {
+	return context.WithValue(ctx, ctxKey{}, c)
+}
+
+// From returns ctx's caller, the zero Caller when there is none.
+func From(ctx context.Context) Caller {
+	c, _ := ctx.Value(ctxKey{}).(Caller)
+	return c
+}
+
+// Resolver reads the caller from the sub, role and scope (space-separated)
+// claims of an HS256 bearer token when JWTSecret is set. Otherwise, with
+// TrustHeaders, it takes the X-Actor, X-Role and X-Scopes headers, which only
+// a gateway that sets them and strips them from clients may be trusted with;
+// without either every caller is anonymous.
+type Resolver struct {
+	JWTSecret    []byte
+	TrustHeaders bool
+}
+
+// Verified reports whether callers prove who they are with a signed token,
+// rather than asserting it in headers.
+func (r Resolver) Verified() bool { return len(r.JWTSecret) > 0 }
+
+// Anonymous reports whether every caller resolves to the zero Caller, so no
+// route that needs a scope can be reached.
+func (r Resolver) Anonymous() bool { return !r.Verified() && !r.TrustHeaders }
+
+func (r Resolver) Resolve(actor, role, scopes, authorization string) (Caller, error) {
+	switch {
+	case len(r.JWTSecret) > 0:
+		tok, ok := BearerToken(authorization)
+		if !ok {
+			return Caller{}, ErrUnauthorized
+		}
+		claims, err := VerifyHS256(tok, r.JWTSecret, now())
+		if err != nil {
+			return Caller{}, ErrUnauthorized
+		}
+		actor, _ = claims["sub"].(string)
+		role, _ = claims["role"].(string)
+		scopes, _ = claims["scope"].(string)
+	case !r.TrustHeaders:
+		actor, role, scopes = "", "", ""
+	}
+	return Caller{Subject: strings.TrimSpace(actor), Role: strings.TrimSpace(role), Scopes: strings.Fields(scopes)}, nil
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sign(claims map[string]any, secret string) string {
+	seg := func(v any) string {
+		b, _ := json.Marshal(v)
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	head := seg(map[string]string{"alg": "HS256", "typ": "JWT"}) + "." + seg(claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(head))
+	return head + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	now = func() time.Time { return time.Unix(1_800_000_000, 0) }
+	defer func() { now = time.Now }()
+	jwt := Resolver{JWTSecret: []byte("s3cret"), TrustHeaders: true}
+	agent := "Bearer " + sign(map[string]any{"sub": "u1", "role": "agent", "scope": "users:read pii:unmask"}, "s3cret")
+
+	tests := []struct {
+		name                       string
+		r                          Resolver
+		actor, role, scopes, authz string
+		want                       Caller
+		err                        error
+	}{
+		{name: "anonymous", want: Caller{Scopes: []string{}}},
+		{name: "headers", r: Resolver{TrustHeaders: true}, actor: " u1 ", role: "agent", scopes: "a  b", want: Caller{Subject: "u1", Role: "agent", Scopes: []string{"a", "b"}}},
+		{name: "headers_untrusted", actor: "u1", role: "admin", scopes: "pii:unmask", want: Caller{Scopes: []string{}}},
+		{name: "jwt", r: jwt, authz: agent, want: Caller{Subject: "u1", Role: "agent", Scopes: []string{"users:read", "pii:unmask"}}},
+		{name: "jwt_ignores_headers", r: jwt, role: "admin", scopes: "pii:unmask", authz: "Bearer " + sign(map[string]any{"sub": "u2"}, "s3cret"), want: Caller{Subject: "u2", Scopes: []string{}}},
+		{name: "jwt_missing", r: jwt, role: "admin", err: ErrUnauthorized},
+		{name: "jwt_bad_signature", r: jwt, authz: "Bearer " + sign(map[string]any{"role": "admin"}, "other"), err: ErrUnauthorized},
+		{name: "jwt_expired", r: jwt, authz: "Bearer " + sign(map[string]any{"role": "admin", "exp": 1_700_000_000}, "s3cret"), err: ErrUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.r.Resolve(tt.actor, tt.role, tt.scopes, tt.authz)
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+	assert.True(t, jwt.Verified())
+	assert.False(t, Resolver{TrustHeaders: true}.Verified())
+	assert.False(t, jwt.Anonymous())
+	assert.False(t, Resolver{TrustHeaders: true}.Anonymous())
+	assert.True(t, Resolver{}.Anonymous())
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// VerifyHS256 checks an HS256 compact JWT at time at and returns its claims.
+// exp and nbf are honoured when present.
+func VerifyHS256(token string, secret []byte, at time.Time) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("jwt: malformed")
+	}
+	var hdr struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &hdr); err != nil {
+		return nil, err
+	}
+	if hdr.Alg != "HS256" {
+		return nil, fmt.Errorf("jwt: unsupported alg %q", hdr.Alg)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return nil, errors.New("jwt: bad signature")
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	t := at.Unix()
+	if exp, ok := claims["exp"].(float64); ok && t >= int64(exp) {
+		return nil, errors.New("jwt: expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && t < int64(nbf) {
+		return nil, errors.New("jwt: not yet valid")
+	}
+	return claims, nil
+}
+
+// BearerToken returns the token of an "Authorization: Bearer" value.
+func BearerToken(authorization string) (string, bool) {
+	tok, ok := strings.CutPrefix(authorization, "Bearer ")
+	return strings.TrimSpace(tok), ok
+}
+
+func decodeSegment(s string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
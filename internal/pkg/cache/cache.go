@@ -0,0 +1,17 @@
+// Package cache holds the byte-oriented cache port used by the repository
+// decorator and its in-process LRU implementation. A shared backend (Redis,
+// memcached) only has to implement Cache.
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+type Cache interface {
+	// Get reports ok=false on a miss or an expired entry.
+	Get(ctx context.Context, key string) (val []byte, ok bool)
+	// Set stores val for ttl; ttl <= 0 means no expiry.
+	Set(ctx context.Context, key string, val []byte, ttl time.Duration)
+	Delete(ctx context.Context, keys ...string)
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// LRU is a size-bounded in-memory Cache. Expired entries are dropped lazily
+// on access or when they reach the back of the list.
+type LRU struct {
+	mu    sync.Mutex
+	cap   int
+	ll    *list.List
+	items map[string]*list.Element
+	now   func() time.Time
+}
+
+type entry struct {
+	key     string
+	val     []byte
+	expires time.Time
+}
+
+func NewLRU(capacity int) *LRU {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &LRU{cap: capacity, ll: list.New(), items: map[string]*list.Element{}, now: time.Now}
+}
+
+func (c *LRU) Get(_ context.Context, key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*entry)
+	if !e.expires.IsZero() && !c.now().Before(e.expires) {
+		c.remove(el)
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return e.val, true
+}
+
+func (c *LRU) Set(_ context.Context, key string, val []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var exp time.Time
+	if ttl > 0 {
+		exp = c.now().Add(ttl)
+	}
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry)
+		e.val, e.expires = val, exp
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.ll.PushFront(&entry{key: key, val: val, expires: exp})
+	for c.ll.Len() > c.cap {
+		c.remove(c.ll.Back())
+	}
+}
+
+func (c *LRU) Delete(_ context.Context, keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, k := range keys {
+		if el, ok := c.items[k]; ok {
+			c.remove(el)
+		}
+	}
+}
+
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+func (c *LRU) remove(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*entry).key)
+}
@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("evicts_least_recently_used", func(t *testing.T) {
+		c := NewLRU(2)
+		c.Set(ctx, "a", []byte("1"), 0)
+		c.Set(ctx, "b", []byte("2"), 0)
+		_, _ = c.Get(ctx, "a")
+		c.Set(ctx, "c", []byte("3"), 0)
+
+		_, ok := c.Get(ctx, "b")
+		assert.False(t, ok)
+		v, ok := c.Get(ctx, "a")
+		assert.True(t, ok)
+		assert.Equal(t, []byte("1"), v)
+		assert.Equal(t, 2, c.Len())
+	})
+
+	t.Run("ttl_expires", func(t *testing.T) {
+		now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		c := NewLRU(10)
+		c.now = func() time.Time { return now }
+		c.Set(ctx, "a", []byte("1"), time.Minute)
+
+		_, ok := c.Get(ctx, "a")
+		assert.True(t, ok)
+		now = now.Add(time.Minute)
+		_, ok = c.Get(ctx, "a")
+		assert.False(t, ok)
+		assert.Equal(t, 0, c.Len())
+	})
+
+	t.Run("overwrite_and_delete", func(t *testing.T) {
+		c := NewLRU(10)
+		c.Set(ctx, "a", []byte("1"), 0)
+		c.Set(ctx, "a", []byte("2"), 0)
+		v, _ := c.Get(ctx, "a")
+		assert.Equal(t, []byte("2"), v)
+
+		c.Delete(ctx, "a", "missing")
+		_, ok := c.Get(ctx, "a")
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Session scopes read-your-writes for one request: once it wrote, or when
+// the caller asked for it, reads under its context skip the replicas.
+type Session struct {
+	primary bool
+	wrote   atomic.Bool
+}
+
+type sessionKey struct{}
+
+// WithSession starts a session; primary pins all of its reads to the primary.
+func WithSession(ctx context.Context, primary bool) (context.Context, *Session) {
+	s := &Session{primary: primary}
+	return context.WithValue(ctx, sessionKey{}, s), s
+}
+
+// ReadPrimary pins reads under the returned context to the primary without
+// affecting the rest of the session.
+func ReadPrimary(ctx context.Context) context.Context {
+	ctx, _ = WithSession(ctx, true)
+	return ctx
+}
+
+// MarkWritten pins the rest of ctx's session to the primary. Without a
+// session it is a no-op.
+func MarkWritten(ctx context.Context) {
+	if s, ok := ctx.Value(sessionKey{}).(*Session); ok {
+		s.wrote.Store(true)
+	}
+}
+
+// PrimaryOnly reports whether reads under ctx must go to the primary.
+func PrimaryOnly(ctx context.Context) bool {
+	s, ok := ctx.Value(sessionKey{}).(*Session)
+	return ok && (s.primary || s.wrote.Load())
+}
+
+// Wrote reports whether the session wrote through MarkWritten.
+func (s *Session) Wrote() bool { return s.wrote.Load() }
@@ -0,0 +1,28 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSession(t *testing.T) {
+	ctx := context.Background()
+	assert.False(t, PrimaryOnly(ctx))
+	MarkWritten(ctx) // no session, no-op
+	assert.False(t, PrimaryOnly(ctx))
+
+	sctx, s := WithSession(ctx, false)
+	assert.False(t, PrimaryOnly(sctx))
+	assert.True(t, PrimaryOnly(ReadPrimary(sctx)))
+	assert.False(t, PrimaryOnly(sctx), "ReadPrimary does not pin the session")
+
+	MarkWritten(context.WithValue(sctx, struct{}{}, 1))
+	assert.True(t, s.Wrote())
+	assert.True(t, PrimaryOnly(sctx), "reads after a write go to the primary")
+
+	pctx, s := WithSession(ctx, true)
+	assert.True(t, PrimaryOnly(pctx))
+	assert.False(t, s.Wrote())
+}
@@ -0,0 +1,124 @@
+package i18n
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_trans "github.com/go-playground/validator/v10/translations/en"
+	id_trans "github.com/go-playground/validator/v10/translations/id"
+	"golang.org/x/text/language"
+
+	"usrsvc/internal/pkg/log"
+)
+
+// Fallback is the locale used when Accept-Language matches nothing we ship.
+const Fallback = "en"
+
+var (
+	uni = ut.New(en.New(), en.New(), id.New())
+
+	valOnce sync.Once
+	val     *validator.Validate
+)
+
+// AddCatalog registers key/text pairs for a locale. Texts may use {0}, {1}
+// placeholders. Unknown locales are ignored.
+func AddCatalog(locale string, msgs map[string]string) error {
+	tr, ok := uni.GetTranslator(locale)
+	if !ok {
+		return nil
+	}
+	for k, v := range msgs {
+		if err := tr.Add(k, v, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Match picks the best supported translator for an Accept-Language header
+// value, falling back to English.
+func Match(acceptLanguage string) ut.Translator {
+	tags, _, _ := language.ParseAcceptLanguage(acceptLanguage)
+	locales := make([]string, 0, len(tags)*2)
+	for _, t := range tags {
+		base, _ := t.Base()
+		locales = append(locales, strings.ReplaceAll(t.String(), "-", "_"), base.String())
+	}
+	tr, _ := uni.FindTranslator(locales...)
+	return tr
+}
+
+// FromRequest is Match applied to the request's Accept-Language header.
+func FromRequest(r *http.Request) ut.Translator {
+	if r == nil {
+		return uni.GetFallback()
+	}
+	return Match(r.Header.Get("Accept-Language"))
+}
+
+// T translates key, falling back to the English text and then to the key
+// itself when no catalog has it.
+func T(tr ut.Translator, key string, params ...string) string {
+	if tr != nil {
+		if s, err := tr.T(key, params...); err == nil {
+			return s
+		}
+	}
+	if s, err := uni.GetFallback().T(key, params...); err == nil {
+		return s
+	}
+	return key
+}
+
+// Validator returns the shared validator with JSON field names and the
+// default en/id translations registered.
+func Validator() *validator.Validate {
+	valOnce.Do(func() {
+		val = validator.New()
+		val.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return f.Name
+			}
+			return name
+		})
+		enTr, _ := uni.GetTranslator("en")
+		idTr, _ := uni.GetTranslator("id")
+		if err := en_trans.RegisterDefaultTranslations(val, enTr); err != nil {
+			log.Error.Printf("i18n register_en err=%v", err)
+		}
+		if err := id_trans.RegisterDefaultTranslations(val, idTr); err != nil {
+			log.Error.Printf("i18n register_id err=%v", err)
+		}
+	})
+	return val
+}
+
+// FieldErrors turns a validator error into a field path → message map,
+// e.g. {"family[0].fl_name": "fl_name wajib diisi"}. It returns nil for
+// errors that are not validation errors.
+func FieldErrors(tr ut.Translator, err error) map[string]string {
+	ve, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return nil
+	}
+	out := make(map[string]string, len(ve))
+	for _, fe := range ve {
+		path := fe.Namespace()
+		if i := strings.IndexByte(path, '.'); i >= 0 {
+			path = path[i+1:]
+		}
+		out[path] = fe.Translate(tr)
+	}
+	return out
+}
@@ -0,0 +1,70 @@
+package i18n
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		header, want string
+	}{
+		{"id", "id"},
+		{"id-ID,en;q=0.5", "id"},
+		{"fr-FR, en-GB;q=0.8", "en"},
+		{"en;q=0.2, id;q=0.9", "id"},
+		{"fr", Fallback},
+		{"", Fallback},
+		{"not a header;;", Fallback},
+	}
+	for _, tc := range tests {
+		t.Run(tc.header, func(t *testing.T) {
+			assert.Equal(t, tc.want, Match(tc.header).Locale())
+		})
+	}
+}
+
+func TestFromRequest(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept-Language", "id")
+	assert.Equal(t, "id", FromRequest(r).Locale())
+	assert.Equal(t, Fallback, FromRequest(nil).Locale())
+}
+
+func TestT(t *testing.T) {
+	require.NoError(t, AddCatalog("en", map[string]string{"test_greet": "hello {0}", "test_en_only": "english"}))
+	require.NoError(t, AddCatalog("id", map[string]string{"test_greet": "halo {0}"}))
+	require.NoError(t, AddCatalog("xx", map[string]string{"test_greet": "ignored"}))
+
+	assert.Equal(t, "halo budi", T(Match("id"), "test_greet", "budi"))
+	assert.Equal(t, "hello budi", T(Match("en"), "test_greet", "budi"))
+	assert.Equal(t, "english", T(Match("id"), "test_en_only"), "falls back to English")
+	assert.Equal(t, "english", T(nil, "test_en_only"))
+	assert.Equal(t, "no such key", T(Match("id"), "no such key"), "unknown keys are literal text")
+}
+
+func TestFieldErrors(t *testing.T) {
+	type member struct {
+		Name string `json:"fl_name" validate:"required"`
+	}
+	type body struct {
+		Email  string   `json:"cst_email" validate:"required,email"`
+		Family []member `json:"family" validate:"dive"`
+		Secret string   `json:"-" validate:"required"`
+	}
+	err := Validator().Struct(body{Email: "nope", Family: []member{{}}})
+	require.Error(t, err)
+
+	got := FieldErrors(Match("id"), err)
+	require.Len(t, got, 3)
+	assert.Contains(t, got, "cst_email")
+	assert.Contains(t, got["family[0].fl_name"], "wajib diisi")
+	assert.Contains(t, got, "Secret", "json:\"-\" falls back to the Go name")
+	assert.Contains(t, FieldErrors(Match("en"), err)["family[0].fl_name"], "is a required field")
+
+	assert.Nil(t, FieldErrors(Match("en"), errors.New("boom")))
+}
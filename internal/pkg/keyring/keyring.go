@@ -0,0 +1,196 @@
+// Package keyring encrypts single values under envelope encryption: every
+// value gets a fresh data key, which is wrapped by the keyring's primary key
+// and stored next to the ciphertext together with that key's id, so keys can
+// be rotated without touching values that are not rewritten.
+package keyring
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const (
+	version = 1
+	keySize = 32 // AES-256
+)
+
+var (
+	ErrUnknownKey = errors.New("keyring: unknown key id")
+	ErrMalformed  = errors.New("keyring: malformed ciphertext")
+)
+
+// File is the JSON layout of a keyring file. Keys and IndexKey are base64
+// encoded 32-byte secrets; Primary names the key new values are sealed with.
+type File struct {
+	Primary  string            `json:"primary"`
+	Keys     map[string]string `json:"keys"`
+	IndexKey string            `json:"index_key"`
+}
+
+type Keyring struct {
+	primary string
+	keys    map[string]cipher.AEAD
+	index   []byte
+}
+
+// Load reads a keyring file.
+func Load(path string) (*Keyring, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f File
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("keyring %s: %w", path, err)
+	}
+	keys := map[string][]byte{}
+	for id, s := range f.Keys {
+		if keys[id], err = base64.StdEncoding.DecodeString(s); err != nil {
+			return nil, fmt.Errorf("keyring %s: key %q: %w", path, id, err)
+		}
+	}
+	index, err := base64.StdEncoding.DecodeString(f.IndexKey)
+	if err != nil {
+		return nil, fmt.Errorf("keyring %s: index_key: %w", path, err)
+	}
+	k, err := New(f.Primary, keys, index)
+	if err != nil {
+		return nil, fmt.Errorf("keyring %s: %w", path, err)
+	}
+	return k, nil
+}
+
+func New(primary string, keys map[string][]byte, indexKey []byte) (*Keyring, error) {
+	k := &Keyring{primary: primary, keys: map[string]cipher.AEAD{}, index: indexKey}
+	for id, key := range keys {
+		if id == "" || len(id) > 255 {
+			return nil, fmt.Errorf("key id %q: must be 1-255 bytes", id)
+		}
+		if len(key) != keySize {
+			return nil, fmt.Errorf("key %q: want %d bytes, got %d", id, keySize, len(key))
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		k.keys[id] = aead
+	}
+	if _, ok := k.keys[primary]; !ok {
+		return nil, fmt.Errorf("primary key %q: %w", primary, ErrUnknownKey)
+	}
+	if len(indexKey) != keySize {
+		return nil, fmt.Errorf("index key: want %d bytes, got %d", keySize, len(indexKey))
+	}
+	return k, nil
+}
+
+// Primary is the id of the key new values are sealed with.
+func (k *Keyring) Primary() string { return k.primary }
+
+// Seal encrypts plaintext for field; the field name is authenticated, so a
+// value copied into another column does not open.
+//
+// Layout: version | len(kid) | kid | wrap nonce | wrapped data key | nonce | ciphertext.
+func (k *Keyring) Seal(field string, plaintext []byte) ([]byte, error) {
+	dek := make([]byte, keySize)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, err
+	}
+	data, err := newAEAD(dek)
+	if err != nil {
+		return nil, err
+	}
+	kek := k.keys[k.primary]
+	out := append([]byte{version, byte(len(k.primary))}, k.primary...)
+	out, err = seal(kek, out, dek, []byte(field))
+	if err != nil {
+		return nil, err
+	}
+	return seal(data, out, plaintext, []byte(field))
+}
+
+// Open decrypts a value sealed for field under any key of the keyring.
+func (k *Keyring) Open(field string, sealed []byte) ([]byte, error) {
+	kid, rest, err := split(sealed)
+	if err != nil {
+		return nil, err
+	}
+	kek, ok := k.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, kid)
+	}
+	wrapped := kek.NonceSize() + keySize + kek.Overhead()
+	if len(rest) < wrapped {
+		return nil, ErrMalformed
+	}
+	dek, err := open(kek, rest[:wrapped], []byte(field))
+	if err != nil {
+		return nil, err
+	}
+	data, err := newAEAD(dek)
+	if err != nil {
+		return nil, err
+	}
+	return open(data, rest[wrapped:], []byte(field))
+}
+
+// KeyID returns the id of the key sealed was written with.
+func KeyID(sealed []byte) (string, error) {
+	kid, _, err := split(sealed)
+	return kid, err
+}
+
+// BlindIndex is a keyed hash of value for equality lookups on sealed
+// columns. It is deterministic, so callers normalize value first; field
+// separates the hashes of different columns.
+func (k *Keyring) BlindIndex(field, value string) []byte {
+	m := hmac.New(sha256.New, k.index)
+	m.Write([]byte(field))
+	m.Write([]byte{0})
+	m.Write([]byte(value))
+	return m.Sum(nil)
+}
+
+func split(sealed []byte) (kid string, rest []byte, err error) {
+	if len(sealed) < 2 || sealed[0] != version || len(sealed) < 2+int(sealed[1]) {
+		return "", nil, ErrMalformed
+	}
+	n := 2 + int(sealed[1])
+	return string(sealed[2:n]), sealed[n:], nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	b, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(b)
+}
+
+func seal(a cipher.AEAD, dst, plaintext, ad []byte) ([]byte, error) {
+	nonce := make([]byte, a.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	dst = append(dst, nonce...)
+	return a.Seal(dst, nonce, plaintext, ad), nil
+}
+
+func open(a cipher.AEAD, b, ad []byte) ([]byte, error) {
+	if len(b) < a.NonceSize() {
+		return nil, ErrMalformed
+	}
+	out, err := a.Open(nil, b[:a.NonceSize()], b[a.NonceSize():], ad)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	return out, nil
+}
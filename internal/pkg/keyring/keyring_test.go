@@ -0,0 +1,108 @@
+package keyring
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func key(b byte) []byte { return bytes.Repeat([]byte{b}, keySize) }
+
+func TestKeyring_SealOpen(t *testing.T) {
+	old, err := New("k1", map[string][]byte{"k1": key(1)}, key(9))
+	require.NoError(t, err)
+	rotated, err := New("k2", map[string][]byte{"k1": key(1), "k2": key(2)}, key(9))
+	require.NoError(t, err)
+
+	sealed, err := old.Seal("cst_email", []byte("a@x.io"))
+	require.NoError(t, err)
+	again, err := old.Seal("cst_email", []byte("a@x.io"))
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again, "fresh data key and nonce per value")
+	assert.NotContains(t, string(sealed), "a@x.io")
+
+	tests := []struct {
+		name   string
+		k      *Keyring
+		field  string
+		sealed []byte
+		want   string
+		err    error
+	}{
+		{"same_key", old, "cst_email", sealed, "a@x.io", nil},
+		{"after_rotation", rotated, "cst_email", sealed, "a@x.io", nil},
+		{"other_field", old, "cst_phone", sealed, "", ErrMalformed},
+		{"tampered", old, "cst_email", append(append([]byte{}, sealed[:len(sealed)-1]...), sealed[len(sealed)-1]^1), "", ErrMalformed},
+		{"truncated", old, "cst_email", sealed[:10], "", ErrMalformed},
+		{"empty", old, "cst_email", nil, "", ErrMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.k.Open(tt.field, tt.sealed)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+
+	t.Run("unknown_key", func(t *testing.T) {
+		s, err := rotated.Seal("cst_email", []byte("a@x.io"))
+		require.NoError(t, err)
+		kid, err := KeyID(s)
+		require.NoError(t, err)
+		assert.Equal(t, "k2", kid)
+		_, err = old.Open("cst_email", s)
+		assert.ErrorIs(t, err, ErrUnknownKey)
+	})
+}
+
+func TestKeyring_BlindIndex(t *testing.T) {
+	k, err := New("k1", map[string][]byte{"k1": key(1)}, key(9))
+	require.NoError(t, err)
+	rotated, err := New("k2", map[string][]byte{"k2": key(2)}, key(9))
+	require.NoError(t, err)
+	other, err := New("k1", map[string][]byte{"k1": key(1)}, key(8))
+	require.NoError(t, err)
+
+	assert.Equal(t, k.BlindIndex("cst_email", "a@x.io"), rotated.BlindIndex("cst_email", "a@x.io"), "independent of the data keys")
+	assert.NotEqual(t, k.BlindIndex("cst_email", "a@x.io"), k.BlindIndex("cst_email", "b@x.io"))
+	assert.NotEqual(t, k.BlindIndex("cst_email", "a@x.io"), k.BlindIndex("cst_phone", "a@x.io"))
+	assert.NotEqual(t, k.BlindIndex("cst_email", "a@x.io"), other.BlindIndex("cst_email", "a@x.io"))
+}
+
+func TestLoad(t *testing.T) {
+	b64 := func(b []byte) string { return base64.StdEncoding.EncodeToString(b) }
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"ok", `{"primary":"k2","keys":{"k1":"` + b64(key(1)) + `","k2":"` + b64(key(2)) + `"},"index_key":"` + b64(key(9)) + `"}`, ""},
+		{"unknown_primary", `{"primary":"k3","keys":{"k1":"` + b64(key(1)) + `"},"index_key":"` + b64(key(9)) + `"}`, "unknown key id"},
+		{"short_key", `{"primary":"k1","keys":{"k1":"` + b64(key(1)[:16]) + `"},"index_key":"` + b64(key(9)) + `"}`, "want 32 bytes"},
+		{"no_index_key", `{"primary":"k1","keys":{"k1":"` + b64(key(1)) + `"}}`, "index key"},
+		{"not_base64", `{"primary":"k1","keys":{"k1":"%%"},"index_key":"` + b64(key(9)) + `"}`, `key "k1"`},
+		{"not_json", `primary=k1`, "invalid character"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "keyring.json")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o600))
+			k, err := Load(path)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "k2", k.Primary())
+		})
+	}
+}
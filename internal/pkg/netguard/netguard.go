@@ -0,0 +1,79 @@
+// Package netguard keeps requests to caller-supplied URLs, such as webhooks,
+// off loopback, private, link-local and other non-public addresses.
+package netguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+var ErrForbidden = errors.New("address is not public")
+
+// reserved are non-public ranges that netip has no predicate for.
+var reserved = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// Public reports whether ip is a publicly routable unicast address.
+func Public(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, p := range reserved {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckHost resolves host and fails unless every address it has is public.
+func CheckHost(ctx context.Context, host string) error {
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return err
+	}
+	for _, ip := range ips {
+		if !Public(ip) {
+			return fmt.Errorf("%s resolves to %s: %w", host, ip, ErrForbidden)
+		}
+	}
+	return nil
+}
+
+// Control is a net.Dialer Control func that refuses non-public addresses. It
+// sees the address actually dialled, so a name that resolved to a public
+// address when checked and to a private one later is still refused.
+func Control(_, address string, _ syscall.RawConn) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !Public(ap.Addr()) {
+		return fmt.Errorf("dial %s: %w", ap.Addr(), ErrForbidden)
+	}
+	return nil
+}
+
+// Client returns an http.Client that dials through Control, redirects
+// included. It ignores proxy settings, which would otherwise be dialled
+// instead of the target.
+func Client() *http.Client {
+	d := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: Control}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = nil
+	t.DialContext = d.DialContext
+	return &http.Client{Transport: t}
+}
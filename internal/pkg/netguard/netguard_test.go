@@ -0,0 +1,53 @@
+package netguard
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublic(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.215.14", true},
+		{"2606:2800:21f:cb07:6820:80da:af6b:8b2c", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"0.0.0.0", false},
+		{"100.64.0.1", false},
+		{"224.0.0.1", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.ip, func(t *testing.T) {
+			assert.Equal(t, tc.want, Public(netip.MustParseAddr(tc.ip)))
+		})
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	ctx := context.Background()
+	assert.ErrorIs(t, CheckHost(ctx, "169.254.169.254"), ErrForbidden)
+	assert.ErrorIs(t, CheckHost(ctx, "localhost"), ErrForbidden)
+	assert.NoError(t, CheckHost(ctx, "93.184.215.14"))
+}
+
+func TestClient_RefusesLoopback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	_, err := Client().Get(srv.URL)
+	assert.ErrorIs(t, err, ErrForbidden)
+}
@@ -0,0 +1,64 @@
+package phone
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nyaruka/phonenumbers"
+)
+
+var ErrInvalid = errors.New("invalid phone number")
+
+var looksLikePhone = regexp.MustCompile(`^\+?[0-9][0-9 ().-]{5,}$`)
+
+// Normalize parses raw using region (ISO 3166 alpha-2, e.g. "ID") for
+// numbers written without a country code and returns the E.164 form.
+func Normalize(raw, region string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", ErrInvalid
+	}
+	region = strings.ToUpper(strings.TrimSpace(region))
+	// "62812..." is how many people write an Indonesian number without the
+	// plus; let the parser see it as international when the region's
+	// country code is already there.
+	if !strings.HasPrefix(raw, "+") && region != "" {
+		d := digits(raw)
+		if cc := phonenumbers.GetCountryCodeForRegion(region); cc > 0 && strings.HasPrefix(d, strconv.Itoa(cc)) {
+			if n, err := phonenumbers.Parse("+"+d, region); err == nil && phonenumbers.IsValidNumber(n) {
+				return phonenumbers.Format(n, phonenumbers.E164), nil
+			}
+		}
+	}
+	n, err := phonenumbers.Parse(raw, region)
+	if err != nil || !phonenumbers.IsValidNumber(n) {
+		return "", ErrInvalid
+	}
+	return phonenumbers.Format(n, phonenumbers.E164), nil
+}
+
+// LooksLike reports whether s is plausibly a phone number rather than a
+// name fragment or email.
+func LooksLike(s string) bool { return looksLikePhone.MatchString(strings.TrimSpace(s)) }
+
+func digits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// CountryCode returns the calling code of an E.164 number ("62" for
+// +62812...), or "" when it is not one.
+func CountryCode(e164 string) string {
+	n, err := phonenumbers.Parse(e164, "")
+	if err != nil || !strings.HasPrefix(e164, "+") {
+		return ""
+	}
+	return strconv.Itoa(int(n.GetCountryCode()))
+}
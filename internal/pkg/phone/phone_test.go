@@ -0,0 +1,46 @@
+package phone
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		region  string
+		want    string
+		wantErr bool
+	}{
+		{name: "national_with_trunk_zero", raw: "081234567890", region: "ID", want: "+6281234567890"},
+		{name: "international_formatted", raw: "+62 812-3456-7890", region: "ID", want: "+6281234567890"},
+		{name: "country_code_without_plus", raw: "6281234567890", region: "ID", want: "+6281234567890"},
+		{name: "international_ignores_region", raw: "+60 12-345 6789", region: "ID", want: "+60123456789"},
+		{name: "lowercase_region", raw: "012-345 6789", region: "my", want: "+60123456789"},
+		{name: "no_region_needs_plus", raw: "081234567890", region: "", wantErr: true},
+		{name: "too_short", raw: "0811", region: "ID", wantErr: true},
+		{name: "garbage", raw: "call me", region: "ID", wantErr: true},
+		{name: "empty", raw: "  ", region: "ID", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Normalize(tc.raw, tc.region)
+			if tc.wantErr {
+				assert.ErrorIs(t, err, ErrInvalid)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestLooksLike(t *testing.T) {
+	assert.True(t, LooksLike("+62 812-3456-7890"))
+	assert.True(t, LooksLike("0812345678"))
+	assert.False(t, LooksLike("AL"))
+	assert.False(t, LooksLike("alfa@example.com"))
+	assert.False(t, LooksLike("12"))
+}
@@ -0,0 +1,83 @@
+// Package tenant carries the tenant of a request through its context and
+// resolves it from an X-Tenant-ID header or a signed JWT claim.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"usrsvc/internal/pkg/auth"
+)
+
+// Default is the tenant of requests that name none, and of all rows that
+// existed before tenancy.
+const Default = "default"
+
+var (
+	ErrMissing      = errors.New("tenant: missing")
+	ErrInvalid      = errors.New("tenant: invalid id")
+	ErrUnauthorized = errors.New("tenant: invalid or missing token")
+	ErrUnsupported  = errors.New("tenant: storage serves the default tenant only")
+)
+
+var now = time.Now
+
+var validID = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+type ctxKey struct{}
+
+func With(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+func From(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(ctxKey{}).(string)
+	return id, ok
+}
+
+// Resolver picks a request's tenant. With JWTSecret set the tenant comes only
+// from the HS256 token's JWTClaim and the header is ignored; otherwise from
+// the header, falling back to Default unless Strict. DefaultOnly refuses
+// every other tenant, for storage without tenant isolation.
+type Resolver struct {
+	Strict      bool
+	DefaultOnly bool
+	JWTSecret   []byte
+	JWTClaim    string // default "tenant_id"
+}
+
+// Resolve takes the X-Tenant-ID and Authorization values of a request.
+func (r Resolver) Resolve(header, authorization string) (string, error) {
+	id := strings.TrimSpace(header)
+	if len(r.JWTSecret) > 0 {
+		tok, ok := auth.BearerToken(authorization)
+		if !ok {
+			return "", ErrUnauthorized
+		}
+		claim := r.JWTClaim
+		if claim == "" {
+			claim = "tenant_id"
+		}
+		claims, err := auth.VerifyHS256(tok, r.JWTSecret, now())
+		if err != nil {
+			return "", ErrUnauthorized
+		}
+		if id, _ = claims[claim].(string); id == "" {
+			return "", ErrUnauthorized
+		}
+	}
+	switch {
+	case id == "" && r.Strict:
+		return "", ErrMissing
+	case id == "":
+		return Default, nil
+	case !validID.MatchString(id):
+		return "", ErrInvalid
+	case r.DefaultOnly && id != Default:
+		return "", ErrUnsupported
+	}
+	return id, nil
+}
@@ -0,0 +1,70 @@
+package tenant
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sign(t *testing.T, secret string, alg string, claims map[string]any) string {
+	t.Helper()
+	seg := func(v any) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	head := seg(map[string]string{"alg": alg, "typ": "JWT"}) + "." + seg(claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(head))
+	return head + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	now = func() time.Time { return time.Unix(1_800_000_000, 0) }
+	defer func() { now = time.Now }()
+	jwt := Resolver{JWTSecret: []byte("s3cret")}
+	bearer := func(claims map[string]any) string { return "Bearer " + sign(t, "s3cret", "HS256", claims) }
+
+	tests := []struct {
+		name          string
+		r             Resolver
+		header, authz string
+		want          string
+		err           error
+	}{
+		{"default", Resolver{}, "", "", Default, nil},
+		{"header", Resolver{}, " acme ", "", "acme", nil},
+		{"strict_missing", Resolver{Strict: true}, "", "", "", ErrMissing},
+		{"strict_header", Resolver{Strict: true}, "acme", "", "acme", nil},
+		{"invalid_id", Resolver{}, "Acme Corp", "", "", ErrInvalid},
+		{"default_only", Resolver{DefaultOnly: true}, "", "", Default, nil},
+		{"default_only_header", Resolver{DefaultOnly: true}, "acme", "", "", ErrUnsupported},
+		{"default_only_jwt", Resolver{DefaultOnly: true, JWTSecret: []byte("s3cret")}, "", bearer(map[string]any{"tenant_id": "acme"}), "", ErrUnsupported},
+		{"jwt", jwt, "", bearer(map[string]any{"tenant_id": "acme"}), "acme", nil},
+		{"jwt_ignores_header", jwt, "other", bearer(map[string]any{"tenant_id": "acme"}), "acme", nil},
+		{"jwt_custom_claim", Resolver{JWTSecret: []byte("s3cret"), JWTClaim: "org"}, "", bearer(map[string]any{"org": "acme"}), "acme", nil},
+		{"jwt_missing", jwt, "acme", "", "", ErrUnauthorized},
+		{"jwt_no_claim", jwt, "", bearer(map[string]any{"sub": "u1"}), "", ErrUnauthorized},
+		{"jwt_bad_signature", jwt, "", "Bearer " + sign(t, "other", "HS256", map[string]any{"tenant_id": "acme"}), "", ErrUnauthorized},
+		{"jwt_alg_none", jwt, "", "Bearer " + sign(t, "s3cret", "none", map[string]any{"tenant_id": "acme"}), "", ErrUnauthorized},
+		{"jwt_expired", jwt, "", bearer(map[string]any{"tenant_id": "acme", "exp": 1_700_000_000}), "", ErrUnauthorized},
+		{"jwt_not_before", jwt, "", bearer(map[string]any{"tenant_id": "acme", "nbf": 1_900_000_000}), "", ErrUnauthorized},
+		{"jwt_valid_window", jwt, "", bearer(map[string]any{"tenant_id": "acme", "nbf": 1_700_000_000, "exp": 1_900_000_000}), "acme", nil},
+		{"jwt_malformed", jwt, "", "Bearer abc.def", "", ErrUnauthorized},
+		{"jwt_invalid_id", jwt, "", bearer(map[string]any{"tenant_id": "ACME!"}), "", ErrInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.r.Resolve(tt.header, tt.authz)
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/pkg/cache"
+	"usrsvc/internal/pkg/db"
+	"usrsvc/internal/pkg/keyring"
+	"usrsvc/internal/pkg/log"
+	"usrsvc/internal/pkg/tenant"
+)
+
+// Keys are per tenant: ids are global but a tenant must never be served
+// another tenant's cached row, and nationality names can be overridden.
+func tenantKey(ctx context.Context) string {
+	t, _ := tenant.From(ctx)
+	return "usrsvc:" + t + ":"
+}
+
+func customerKey(ctx context.Context, id int32) string {
+	return tenantKey(ctx) + "customer:" + strconv.Itoa(int(id))
+}
+
+func nationalitiesKey(ctx context.Context) string { return tenantKey(ctx) + "nationalities" }
+
+// Invalidator tells other instances that cached keys are stale.
+type Invalidator interface {
+	Invalidate(ctx context.Context, keys ...string) error
+}
+
+// CachedRepo is a read-through cache in front of another UserRepository.
+// GetCustomer and ListNationalities are cached; list queries pass through.
+// Concurrent misses for one key share a single load. Misses read where the
+// session's other reads go, replicas included, except that for replicaLag
+// after a key is evicted its misses read the primary: a lagging replica
+// could still serve the row from before the write.
+type CachedRepo struct {
+	next       domain.UserRepository
+	c          cache.Cache
+	ttl        time.Duration
+	inv        Invalidator
+	replicaLag time.Duration
+	keys       *keyring.Keyring
+	sf         singleflight.Group
+
+	// gen is bumped on every eviction so a load that started before a write
+	// does not store the value it read; evicted is when that happened.
+	mu      sync.Mutex
+	gen     map[string]uint64
+	evicted map[string]time.Time
+}
+
+type CachedOption func(*CachedRepo)
+
+// WithReplicaLag sets how long after an eviction misses skip the replicas;
+// use the replicas' maximum lag.
+func WithReplicaLag(d time.Duration) CachedOption { return func(r *CachedRepo) { r.replicaLag = d } }
+
+// WithSealedEntries seals every cached value with k, bound to its key, and
+// opens it on a hit. Customers are cached decrypted otherwise, so with a
+// keyring on the repository this keeps plaintext PII out of a shared cache.
+func WithSealedEntries(k *keyring.Keyring) CachedOption { return func(r *CachedRepo) { r.keys = k } }
+
+// NewCachedRepo wraps next. inv may be nil for a single instance.
+func NewCachedRepo(next domain.UserRepository, c cache.Cache, ttl time.Duration, inv Invalidator, opts ...CachedOption) *CachedRepo {
+	r := &CachedRepo{next: next, c: c, ttl: ttl, inv: inv, gen: map[string]uint64{}, evicted: map[string]time.Time{}}
+	for _, o := range opts {
+		o(r)
+	}
+	return r
+}
+
+func (r *CachedRepo) ListCustomers(ctx context.Context, search string, limit, offset int) ([]domain.Customer, int32, error) {
+	return r.next.ListCustomers(ctx, search, limit, offset)
+}
+
+// GetCustomer caches whole customers. A read with a domain.WithFields
+// projection skips the cache and reaches next with the projection, so the
+// repository still reads only the selected columns.
+func (r *CachedRepo) GetCustomer(ctx context.Context, id int32) (*domain.Customer, error) {
+	if domain.FieldsFrom(ctx) != nil {
+		return r.next.GetCustomer(ctx, id)
+	}
+	var c *domain.Customer
+	key := customerKey(ctx, id)
+	err := r.load(ctx, key, &c, func() (any, error) {
+		return r.next.GetCustomer(domain.WithFields(r.fill(ctx, key), nil), id)
+	})
+	return c, err
+}
+
+// GetCustomers and Families serve pages of customers, which are not cached.
+func (r *CachedRepo) GetCustomers(ctx context.Context, ids []int32) ([]domain.Customer, error) {
+	return r.next.GetCustomers(ctx, ids)
+}
+
+func (r *CachedRepo) Families(ctx context.Context, customerIDs []int32) (map[int32][]domain.FamilyMember, error) {
+	return r.next.Families(ctx, customerIDs)
+}
+
+func (r *CachedRepo) ListNationalities(ctx context.Context) ([]domain.Nationality, error) {
+	var ns []domain.Nationality
+	key := nationalitiesKey(ctx)
+	err := r.load(ctx, key, &ns, func() (any, error) { return r.next.ListNationalities(r.fill(ctx, key)) })
+	return ns, err
+}
+
+// GetNationality picks from the cached ListNationalities, which is small.
+func (r *CachedRepo) GetNationality(ctx context.Context, id int32) (*domain.Nationality, error) {
+	ns, err := r.ListNationalities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range ns {
+		if n.ID == id {
+			return &n, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *CachedRepo) CreateCustomer(ctx context.Context, c domain.Customer) (int32, error) {
+	return r.next.CreateCustomer(ctx, c)
+}
+
+func (r *CachedRepo) UpdateCustomer(ctx context.Context, id int32, c domain.Customer) error {
+	err := r.next.UpdateCustomer(ctx, id, c)
+	r.written(ctx, customerKey(ctx, id))
+	return err
+}
+
+func (r *CachedRepo) DeleteCustomer(ctx context.Context, id int32) error {
+	err := r.next.DeleteCustomer(ctx, id)
+	r.written(ctx, customerKey(ctx, id))
+	return err
+}
+
+func (r *CachedRepo) DuplicateCandidates(ctx context.Context, c domain.Customer, limit int) ([]domain.Customer, error) {
+	return r.next.DuplicateCandidates(ctx, c, limit)
+}
+
+func (r *CachedRepo) MergeCustomers(ctx context.Context, targetID, sourceID int32, merged domain.Customer) error {
+	err := r.next.MergeCustomers(ctx, targetID, sourceID, merged)
+	r.written(ctx, customerKey(ctx, targetID), customerKey(ctx, sourceID))
+	return err
+}
+
+func (r *CachedRepo) Redirect(ctx context.Context, id int32) (int32, error) {
+	return r.next.Redirect(ctx, id)
+}
+
+func (r *CachedRepo) CustomerStats(ctx context.Context, f domain.StatsFilter) (*domain.CustomerStats, error) {
+	return r.next.CustomerStats(ctx, f)
+}
+
+func (r *CachedRepo) ExportCustomer(ctx context.Context, id int32) (*domain.DataExport, error) {
+	return r.next.ExportCustomer(ctx, id)
+}
+
+func (r *CachedRepo) AnonymizeCustomer(ctx context.Context, id int32) (*domain.Customer, error) {
+	c, err := r.next.AnonymizeCustomer(ctx, id)
+	r.written(ctx, customerKey(ctx, id))
+	return c, err
+}
+
+// Evict drops keys from this instance's view of the cache.
+func (r *CachedRepo) Evict(ctx context.Context, keys ...string) {
+	now := time.Now()
+	r.mu.Lock()
+	for _, k := range keys {
+		r.gen[k]++
+		r.evicted[k] = now
+		r.sf.Forget(k)
+	}
+	r.mu.Unlock()
+	r.c.Delete(ctx, keys...)
+}
+
+// fill returns the context a miss on key reads under: the primary while key
+// was evicted less than replicaLag ago, else wherever ctx's reads go.
+func (r *CachedRepo) fill(ctx context.Context, key string) context.Context {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	at, ok := r.evicted[key]
+	if !ok {
+		return ctx
+	}
+	if time.Since(at) < r.replicaLag {
+		return db.ReadPrimary(ctx)
+	}
+	delete(r.evicted, key)
+	return ctx
+}
+
+// written evicts even when the write failed: a failed commit may still
+// have changed the row, and a spurious miss is cheap.
+func (r *CachedRepo) written(ctx context.Context, keys ...string) {
+	r.Evict(ctx, keys...)
+	if r.inv == nil {
+		return
+	}
+	if err := r.inv.Invalidate(ctx, keys...); err != nil {
+		log.Error.Printf("cache invalidate_notify err=%v keys=%v", err, keys)
+	}
+}
+
+// load decodes the cached value for key into dst, or calls fetch once per
+// key across concurrent callers and caches a non-nil result. Values are
+// stored encoded so callers never share mutable state.
+func (r *CachedRepo) load(ctx context.Context, key string, dst any, fetch func() (any, error)) error {
+	if b, ok := r.c.Get(ctx, key); ok {
+		b, err := r.open(key, b)
+		if err == nil {
+			if err = json.Unmarshal(b, dst); err == nil {
+				return nil
+			}
+		}
+		r.c.Delete(ctx, key)
+	}
+	b, err, _ := r.sf.Do(key, func() (any, error) {
+		r.mu.Lock()
+		gen := r.gen[key]
+		r.mu.Unlock()
+
+		v, err := fetch()
+		if err != nil {
+			return nil, err
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if string(b) == "null" || r.gen[key] != gen {
+			return b, nil
+		}
+		sealed, err := r.seal(key, b)
+		if err != nil {
+			log.Error.Printf("cache seal err=%v key=%s", err, key)
+			return b, nil
+		}
+		r.c.Set(ctx, key, sealed, r.ttl)
+		return b, nil
+	})
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b.([]byte), dst)
+}
+
+func (r *CachedRepo) seal(key string, b []byte) ([]byte, error) {
+	if r.keys == nil {
+		return b, nil
+	}
+	return r.keys.Seal(key, b)
+}
+
+func (r *CachedRepo) open(key string, b []byte) ([]byte, error) {
+	if r.keys == nil {
+		return b, nil
+	}
+	return r.keys.Open(key, b)
+}
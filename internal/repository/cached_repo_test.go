@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/mocks"
+	"usrsvc/internal/pkg/cache"
+	"usrsvc/internal/pkg/db"
+	"usrsvc/internal/pkg/tenant"
+)
+
+type recordingInvalidator struct{ keys []string }
+
+func (r *recordingInvalidator) Invalidate(_ context.Context, keys ...string) error {
+	r.keys = append(r.keys, keys...)
+	return nil
+}
+
+func TestCachedRepo(t *testing.T) {
+	ctx := tenant.With(context.Background(), "acme")
+	dob := time.Date(1992, 5, 10, 0, 0, 0, 0, time.UTC)
+	cust := &domain.Customer{ID: 36, Name: "ALFA", Dob: dob, Family: []domain.FamilyMember{{ID: 1, Name: "BETA", Dob: dob}}}
+
+	t.Run("get_hits_cache_after_first_load", func(t *testing.T) {
+		m := new(mocks.UserRepository)
+		m.On("GetCustomer", mock.Anything, int32(36)).Return(cust, nil).Once()
+		r := NewCachedRepo(m, cache.NewLRU(10), time.Minute, nil)
+
+		for i := 0; i < 3; i++ {
+			got, err := r.GetCustomer(ctx, 36)
+			require.NoError(t, err)
+			assert.Equal(t, cust, got)
+		}
+		got, _ := r.GetCustomer(ctx, 36)
+		got.Family[0].Name = "mutated"
+		again, _ := r.GetCustomer(ctx, 36)
+		assert.Equal(t, "BETA", again.Family[0].Name)
+		m.AssertExpectations(t)
+	})
+
+	t.Run("not_found_and_errors_not_cached", func(t *testing.T) {
+		m := new(mocks.UserRepository)
+		m.On("GetCustomer", mock.Anything, int32(9)).Return(nil, nil).Twice()
+		m.On("ListNationalities", mock.Anything).Return(nil, assert.AnError).Once()
+		m.On("ListNationalities", mock.Anything).Return([]domain.Nationality{{ID: 1, Name: "Indonesia"}}, nil).Once()
+		r := NewCachedRepo(m, cache.NewLRU(10), time.Minute, nil)
+
+		for i := 0; i < 2; i++ {
+			got, err := r.GetCustomer(ctx, 9)
+			require.NoError(t, err)
+			assert.Nil(t, got)
+		}
+		_, err := r.ListNationalities(ctx)
+		assert.ErrorIs(t, err, assert.AnError)
+		for i := 0; i < 2; i++ {
+			ns, err := r.ListNationalities(ctx)
+			require.NoError(t, err)
+			assert.Len(t, ns, 1)
+		}
+		n, err := r.GetNationality(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "Indonesia", n.Name, "served from the cached list")
+		n, err = r.GetNationality(ctx, 2)
+		require.NoError(t, err)
+		assert.Nil(t, n)
+		m.AssertExpectations(t)
+	})
+
+	t.Run("concurrent_misses_load_once", func(t *testing.T) {
+		release := make(chan struct{})
+		m := new(mocks.UserRepository)
+		m.On("ListNationalities", mock.Anything).
+			Run(func(mock.Arguments) { <-release }).
+			Return([]domain.Nationality{{ID: 1, Name: "Indonesia"}}, nil).Once()
+		r := NewCachedRepo(m, cache.NewLRU(10), time.Minute, nil)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ns, err := r.ListNationalities(ctx)
+				assert.NoError(t, err)
+				assert.Len(t, ns, 1)
+			}()
+		}
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+		m.AssertExpectations(t)
+	})
+
+	t.Run("writes_invalidate_locally_and_remotely", func(t *testing.T) {
+		m := new(mocks.UserRepository)
+		m.On("GetCustomer", mock.Anything, int32(36)).Return(cust, nil).Times(3)
+		m.On("UpdateCustomer", mock.Anything, int32(36), mock.Anything).Return(nil).Once()
+		m.On("DeleteCustomer", mock.Anything, int32(36)).Return(domain.ErrNotFound).Once()
+		inv := &recordingInvalidator{}
+		r := NewCachedRepo(m, cache.NewLRU(10), time.Minute, inv)
+
+		_, _ = r.GetCustomer(ctx, 36)
+		require.NoError(t, r.UpdateCustomer(ctx, 36, *cust))
+		_, _ = r.GetCustomer(ctx, 36)
+		assert.ErrorIs(t, r.DeleteCustomer(ctx, 36), domain.ErrNotFound)
+		_, _ = r.GetCustomer(ctx, 36)
+
+		assert.Equal(t, []string{"usrsvc:acme:customer:36", "usrsvc:acme:customer:36"}, inv.keys)
+		m.AssertExpectations(t)
+	})
+
+	t.Run("load_racing_a_write_is_not_stored", func(t *testing.T) {
+		loading, release := make(chan struct{}), make(chan struct{})
+		m := new(mocks.UserRepository)
+		m.On("GetCustomer", mock.Anything, int32(36)).
+			Run(func(mock.Arguments) { close(loading); <-release }).
+			Return(cust, nil).Once()
+		m.On("GetCustomer", mock.Anything, int32(36)).Return(&domain.Customer{ID: 36, Name: "NEW"}, nil).Once()
+		m.On("UpdateCustomer", mock.Anything, int32(36), mock.Anything).Return(nil).Once()
+		r := NewCachedRepo(m, cache.NewLRU(10), time.Minute, nil)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			got, _ := r.GetCustomer(ctx, 36)
+			assert.Equal(t, "ALFA", got.Name)
+		}()
+		<-loading
+		require.NoError(t, r.UpdateCustomer(ctx, 36, domain.Customer{Name: "NEW"}))
+		close(release)
+		<-done
+
+		got, err := r.GetCustomer(ctx, 36)
+		require.NoError(t, err)
+		assert.Equal(t, "NEW", got.Name)
+		m.AssertExpectations(t)
+	})
+
+	t.Run("evict_from_remote_notification", func(t *testing.T) {
+		m := new(mocks.UserRepository)
+		m.On("ListNationalities", mock.Anything).Return([]domain.Nationality{}, nil).Twice()
+		r := NewCachedRepo(m, cache.NewLRU(10), time.Minute, nil)
+
+		_, _ = r.ListNationalities(ctx)
+		_, _ = r.ListNationalities(ctx)
+		r.Evict(ctx, nationalitiesKey(ctx))
+		_, _ = r.ListNationalities(ctx)
+		m.AssertExpectations(t)
+	})
+	t.Run("tenants_do_not_share_entries", func(t *testing.T) {
+		other := tenant.With(context.Background(), "globex")
+		m := new(mocks.UserRepository)
+		m.On("GetCustomer", mock.Anything, int32(36)).Return(cust, nil).Once()
+		m.On("GetCustomer", mock.Anything, int32(36)).Return(nil, nil).Once()
+		r := NewCachedRepo(m, cache.NewLRU(10), time.Minute, nil)
+
+		got, _ := r.GetCustomer(ctx, 36)
+		assert.Equal(t, cust, got)
+		got, err := r.GetCustomer(other, 36)
+		require.NoError(t, err)
+		assert.Nil(t, got, "another tenant misses and loads its own view")
+		m.AssertExpectations(t)
+	})
+	t.Run("misses_follow_replica_routing", func(t *testing.T) {
+		var primary []bool
+		m := new(mocks.UserRepository)
+		m.On("GetCustomer", mock.Anything, int32(36)).Run(func(a mock.Arguments) {
+			primary = append(primary, db.PrimaryOnly(a.Get(0).(context.Context)))
+		}).Return(cust, nil)
+		r := NewCachedRepo(m, cache.NewLRU(10), time.Minute, nil, WithReplicaLag(time.Hour))
+
+		_, _ = r.GetCustomer(ctx, 36)
+		r.Evict(ctx, customerKey(ctx, 36))
+		_, _ = r.GetCustomer(ctx, 36)
+		pinned, _ := db.WithSession(ctx, true)
+		r.Evict(ctx, customerKey(ctx, 36))
+		r.evicted[customerKey(ctx, 36)] = time.Now().Add(-2 * time.Hour)
+		_, _ = r.GetCustomer(pinned, 36)
+		r.Evict(ctx, customerKey(ctx, 36))
+		r.evicted[customerKey(ctx, 36)] = time.Now().Add(-2 * time.Hour)
+		_, _ = r.GetCustomer(ctx, 36)
+		assert.Equal(t, []bool{false, true, true, false}, primary, "replica, just evicted, pinned session, lag passed")
+	})
+	t.Run("sealed_entries", func(t *testing.T) {
+		m := new(mocks.UserRepository)
+		m.On("GetCustomer", mock.Anything, int32(36)).Return(cust, nil).Once()
+		lru := cache.NewLRU(10)
+		r := NewCachedRepo(m, lru, time.Minute, nil, WithSealedEntries(testKeyring(t, "k1")))
+
+		for i := 0; i < 2; i++ {
+			got, err := r.GetCustomer(ctx, 36)
+			require.NoError(t, err)
+			assert.Equal(t, cust, got)
+		}
+		raw, ok := lru.Get(ctx, customerKey(ctx, 36))
+		require.True(t, ok)
+		assert.NotContains(t, string(raw), "ALFA", "the shared cache holds no plaintext")
+
+		lru.Set(ctx, customerKey(ctx, 37), raw, time.Minute)
+		m.On("GetCustomer", mock.Anything, int32(37)).Return(nil, nil).Once()
+		got, err := r.GetCustomer(ctx, 37)
+		require.NoError(t, err)
+		assert.Nil(t, got, "an entry copied to another key does not open")
+		m.AssertExpectations(t)
+	})
+}
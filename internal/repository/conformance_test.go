@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/pkg/tenant"
+	"usrsvc/internal/repository/repotest"
+	"usrsvc/migrations"
+)
+
+func TestConformance(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		repotest.UserRepository(t, func(t *testing.T) domain.UserRepository {
+			return NewMemoryUserRepo(DefaultNationalities())
+		})
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		repotest.UserRepository(t, func(t *testing.T) domain.UserRepository { return newSqliteRepo(t) })
+	})
+	t.Run("postgres", func(t *testing.T) {
+		dsn := os.Getenv("PG_DSN")
+		if dsn == "" {
+			t.Skip("PG_DSN not set")
+		}
+		repotest.UserRepository(t, func(t *testing.T) domain.UserRepository {
+			return NewPgUserRepo(appPool(t, newPgSchema(t, dsn)))
+		})
+	})
+	t.Run("postgres_encrypted", func(t *testing.T) {
+		dsn := os.Getenv("PG_DSN")
+		if dsn == "" {
+			t.Skip("PG_DSN not set")
+		}
+		repotest.UserRepository(t, func(t *testing.T) domain.UserRepository {
+			return NewPgUserRepo(appPool(t, newPgSchema(t, dsn)), WithKeyring(testKeyring(t, "k1")))
+		}, repotest.SealedPII())
+	})
+}
+
+// newPgSchema migrates a throwaway schema and returns a pool whose
+// search_path points at it, so the suite never touches existing data.
+func newPgSchema(t *testing.T, dsn string) *pgxpool.Pool {
+	t.Helper()
+	ctx := context.Background()
+	schema := fmt.Sprintf("usrsvc_test_%d", time.Now().UnixNano())
+
+	admin, err := pgx.Connect(ctx, dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		admin.Exec(context.Background(), `DROP SCHEMA `+schema+` CASCADE`)
+		admin.Close(context.Background())
+	})
+	_, err = admin.Exec(ctx, `CREATE SCHEMA `+schema)
+	require.NoError(t, err)
+
+	cfg, err := pgxpool.ParseConfig(dsn)
+	require.NoError(t, err)
+	cfg.ConnConfig.RuntimeParams["search_path"] = schema
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	names, err := fs.Glob(migrations.Postgres, "*.up.sql")
+	require.NoError(t, err)
+	sort.Strings(names)
+	for _, name := range names {
+		b, err := migrations.Postgres.ReadFile(name)
+		require.NoError(t, err)
+		_, err = pool.Exec(ctx, string(b))
+		require.NoError(t, err, name)
+	}
+	for _, n := range DefaultNationalities() {
+		_, err := pool.Exec(ctx, `INSERT INTO nationality (nationality_name,nationality_code) VALUES ($1,$2)`, n.Name, n.Code)
+		require.NoError(t, err)
+	}
+	return pool
+}
+
+// appPool returns a pool on pool's schema that runs as a role without
+// BYPASSRLS, as the service does, since superusers see every tenant's rows.
+// It skips t when the role cannot be set up.
+func appPool(t *testing.T, pool *pgxpool.Pool) *pgxpool.Pool {
+	ctx := context.Background()
+	schema := pool.Config().ConnConfig.RuntimeParams["search_path"]
+	role := schema + "_app"
+	for _, q := range []string{
+		`CREATE ROLE ` + role + ` NOLOGIN NOBYPASSRLS`,
+		`GRANT USAGE ON SCHEMA ` + schema + ` TO ` + role,
+		`GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA ` + schema + ` TO ` + role,
+		`GRANT USAGE ON ALL SEQUENCES IN SCHEMA ` + schema + ` TO ` + role,
+		`GRANT ` + role + ` TO CURRENT_USER`,
+	} {
+		if _, err := pool.Exec(ctx, q); err != nil {
+			t.Skipf("cannot set up role: %v", err)
+		}
+	}
+	t.Cleanup(func() { pool.Exec(context.Background(), `DROP OWNED BY `+role+`; DROP ROLE `+role) })
+
+	cfg := pool.Config()
+	cfg.AfterConnect = func(ctx context.Context, c *pgx.Conn) error {
+		_, err := c.Exec(ctx, `SET ROLE `+role)
+		return err
+	}
+	app, err := pgxpool.NewWithConfig(ctx, cfg)
+	require.NoError(t, err)
+	t.Cleanup(app.Close)
+	return app
+}
+
+func TestPgTenantIsolation(t *testing.T) {
+	dsn := os.Getenv("PG_DSN")
+	if dsn == "" {
+		t.Skip("PG_DSN not set")
+	}
+	ctx := context.Background()
+	app := appPool(t, newPgSchema(t, dsn))
+	r := NewPgUserRepo(app)
+
+	acme, globex := tenant.With(ctx, "acme"), tenant.With(ctx, "globex")
+	ns, err := r.ListNationalities(acme)
+	require.NoError(t, err)
+	c := domain.Customer{NationalityID: ns[0].ID, Name: "ALPHA", Dob: time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC), PhoneNum: "0811", Email: "a@x.io"}
+	id, err := r.CreateCustomer(acme, c)
+	require.NoError(t, err)
+	other, err := r.CreateCustomer(globex, c)
+	require.NoError(t, err, "emails are unique per tenant")
+
+	got, err := r.GetCustomer(globex, id)
+	require.NoError(t, err)
+	require.Nil(t, got)
+	require.ErrorIs(t, r.UpdateCustomer(globex, id, c), domain.ErrNotFound)
+	require.ErrorIs(t, r.DeleteCustomer(globex, id), domain.ErrNotFound)
+	require.ErrorIs(t, r.MergeCustomers(globex, other, id, c), domain.ErrNotFound)
+	_, total, err := r.ListCustomers(acme, "", 10, 0)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, total)
+
+	_, err = r.GetCustomer(ctx, id)
+	require.ErrorIs(t, err, tenant.ErrMissing)
+	var n int
+	require.NoError(t, app.QueryRow(ctx, `SELECT count(*) FROM customer`).Scan(&n))
+	require.Zero(t, n, "no rows without app.tenant_id")
+
+	audit := NewPgAuditLog(app)
+	require.NoError(t, audit.Record(acme, domain.AuditEntry{Action: domain.AuditPIIUnmask, Operation: "get_user", Actor: "ops", Role: "agent", CustomerIDs: []int32{id}, At: time.Now()}))
+	require.NoError(t, withTenant(acme, app, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		for _, q := range []string{`UPDATE audit_log SET actor='x'`, `DELETE FROM audit_log`} {
+			tag, err := tx.Exec(ctx, q)
+			require.NoError(t, err)
+			require.Zero(t, tag.RowsAffected(), "audit_log is append-only")
+		}
+		return tx.QueryRow(ctx, `SELECT count(*) FROM audit_log`).Scan(&n)
+	}))
+	require.Equal(t, 1, n)
+	require.NoError(t, withTenant(globex, app, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		return tx.QueryRow(ctx, `SELECT count(*) FROM audit_log`).Scan(&n)
+	}))
+	require.Zero(t, n)
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"usrsvc/internal/domain"
+)
+
+// Fixtures is the seed file for MemoryUserRepo. Customers use the field
+// names of the create request; nationalities default to
+// DefaultNationalities when omitted.
+type Fixtures struct {
+	Nationalities []struct {
+		ID   int32   `json:"id"`
+		Name string  `json:"name"`
+		Code *string `json:"code"`
+	} `json:"nationalities"`
+	Customers []struct {
+		NationalityID int32  `json:"nationality_id"`
+		Name          string `json:"cst_name"`
+		Dob           string `json:"cst_dob"`
+		PhoneNum      string `json:"cst_phoneNum"`
+		Email         string `json:"cst_email"`
+		Family        []struct {
+			Relation string `json:"fl_relation"`
+			Name     string `json:"fl_name"`
+			Dob      string `json:"fl_dob"`
+		} `json:"family"`
+	} `json:"customers"`
+}
+
+// LoadFixtures reads a Fixtures file. Customers are returned unsaved so the
+// caller can create them through the usecase (phone normalization, rules).
+func LoadFixtures(path string) ([]domain.Nationality, []domain.Customer, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var f Fixtures
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	ns := DefaultNationalities()
+	if len(f.Nationalities) > 0 {
+		ns = ns[:0]
+		for _, n := range f.Nationalities {
+			ns = append(ns, domain.Nationality{ID: n.ID, Name: n.Name, Code: n.Code})
+		}
+	}
+	cs := make([]domain.Customer, 0, len(f.Customers))
+	for i, fc := range f.Customers {
+		dob, err := time.Parse("2006-01-02", fc.Dob)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: customers[%d].cst_dob: %w", path, i, err)
+		}
+		c := domain.Customer{NationalityID: fc.NationalityID, Name: fc.Name, Dob: dob, PhoneNum: fc.PhoneNum, Email: fc.Email}
+		for j, ff := range fc.Family {
+			fdob, err := time.Parse("2006-01-02", ff.Dob)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: customers[%d].family[%d].fl_dob: %w", path, i, j, err)
+			}
+			c.Family = append(c.Family, domain.FamilyMember{Relation: ff.Relation, Name: ff.Name, Dob: fdob})
+		}
+		cs = append(cs, c)
+	}
+	return ns, cs, nil
+}
@@ -0,0 +1,549 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/pkg/tenant"
+)
+
+// MemoryUserRepo is a domain.UserRepository kept in process memory, for
+// local runs and front-end CI. It follows the Postgres repository: serial
+// ids, case-insensitive unique emails, family rows deleted with their
+// customer, the same search and ordering. It does not write outbox events.
+// It holds one tenant's data and refuses other tenants than tenant.Default.
+type MemoryUserRepo struct {
+	mu            sync.RWMutex
+	customers     map[int32]*memCustomer
+	nationalities []domain.Nationality
+	redirects     map[int32]int32
+	nextCustomer  int32
+	nextFamily    int32
+
+	now func() time.Time
+}
+
+type memCustomer struct {
+	c         domain.Customer
+	createdAt time.Time
+}
+
+func NewMemoryUserRepo(ns []domain.Nationality) *MemoryUserRepo {
+	return &MemoryUserRepo{
+		customers:     map[int32]*memCustomer{},
+		nationalities: append([]domain.Nationality(nil), ns...),
+		redirects:     map[int32]int32{},
+		now:           time.Now,
+	}
+}
+
+// DefaultNationalities mirrors db/seeds/nationality.sql.
+func DefaultNationalities() []domain.Nationality {
+	code := func(s string) *string { return &s }
+	return []domain.Nationality{
+		{ID: 1, Name: "Indonesia", Code: code("ID")},
+		{ID: 2, Name: "Malaysia", Code: code("MY")},
+		{ID: 3, Name: "Singapore", Code: code("SG")},
+		{ID: 4, Name: "Thailand", Code: code("TH")},
+		{ID: 5, Name: "Philippines", Code: code("PH")},
+	}
+}
+
+func (r *MemoryUserRepo) ListCustomers(ctx context.Context, search string, limit, offset int) ([]domain.Customer, int32, error) {
+	if err := defaultTenantOnly(ctx); err != nil {
+		return nil, 0, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	matched := r.match(ctx, search)
+	sort.Slice(matched, func(i, j int) bool { return matched[i].c.ID > matched[j].c.ID })
+
+	total := int32(len(matched))
+	if offset > len(matched) {
+		offset = len(matched)
+	}
+	matched = matched[offset:]
+	if limit >= 0 && len(matched) > limit {
+		matched = matched[:limit]
+	}
+	out := make([]domain.Customer, 0, len(matched))
+	for _, m := range matched {
+		c := m.c
+		c.Family = nil
+		out = append(out, c)
+	}
+	return out, total, nil
+}
+
+// match applies searchCond: an empty term, a name or email fragment
+// (case-insensitive; the whole email under domain.WithWholeEmailSearch) or
+// the exact E.164 phone.
+func (r *MemoryUserRepo) match(ctx context.Context, search string) []*memCustomer {
+	s := strings.ToLower(strings.TrimSpace(search))
+	email := func(e string) bool { return strings.Contains(e, s) }
+	if domain.WholeEmailSearch(ctx) {
+		email = func(e string) bool { return e == s }
+	}
+	var out []*memCustomer
+	for _, m := range r.customers {
+		if s == "" || strings.Contains(strings.ToLower(m.c.Name), s) ||
+			email(strings.ToLower(m.c.Email)) || (m.c.PhoneE164 != "" && m.c.PhoneE164 == strings.TrimSpace(search)) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func (r *MemoryUserRepo) GetCustomer(ctx context.Context, id int32) (*domain.Customer, error) {
+	if err := defaultTenantOnly(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	m, ok := r.customers[id]
+	if !ok {
+		return nil, nil
+	}
+	c := copyCustomer(m.c)
+	return &c, nil
+}
+
+func (r *MemoryUserRepo) GetCustomers(ctx context.Context, ids []int32) ([]domain.Customer, error) {
+	if err := defaultTenantOnly(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []domain.Customer
+	for _, id := range ids {
+		if m, ok := r.customers[id]; ok {
+			c := m.c
+			c.Family = nil
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (r *MemoryUserRepo) Families(ctx context.Context, customerIDs []int32) (map[int32][]domain.FamilyMember, error) {
+	if err := defaultTenantOnly(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := map[int32][]domain.FamilyMember{}
+	for _, id := range customerIDs {
+		if m, ok := r.customers[id]; ok && len(m.c.Family) > 0 {
+			out[id] = copyCustomer(m.c).Family
+		}
+	}
+	return out, nil
+}
+
+func (r *MemoryUserRepo) CreateCustomer(ctx context.Context, c domain.Customer) (int32, error) {
+	if err := defaultTenantOnly(ctx); err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.check(0, c); err != nil {
+		return 0, err
+	}
+	// Postgres burns a sequence value even when the insert fails later; only
+	// successful inserts matter to callers, so ids are simply increasing here.
+	r.nextCustomer++
+	c.ID = r.nextCustomer
+	c.Family = r.numberFamily(c.ID, c.Family)
+	r.customers[c.ID] = &memCustomer{c: copyCustomer(c), createdAt: r.now()}
+	return c.ID, nil
+}
+
+func (r *MemoryUserRepo) UpdateCustomer(ctx context.Context, id int32, c domain.Customer) error {
+	if err := defaultTenantOnly(ctx); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.customers[id]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	if err := r.check(id, c); err != nil {
+		return err
+	}
+	c.ID = id
+	c.Family = r.numberFamily(id, c.Family)
+	m.c = copyCustomer(c)
+	return nil
+}
+
+func (r *MemoryUserRepo) DeleteCustomer(ctx context.Context, id int32) error {
+	if err := defaultTenantOnly(ctx); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.customers[id]; !ok {
+		return domain.ErrNotFound
+	}
+	r.delete(id)
+	return nil
+}
+
+// delete drops the customer with its family and, like ON DELETE CASCADE on
+// customer_redirect.new_id, the redirects pointing at it.
+func (r *MemoryUserRepo) delete(id int32) {
+	delete(r.customers, id)
+	for from, to := range r.redirects {
+		if to == id {
+			delete(r.redirects, from)
+		}
+	}
+}
+
+func (r *MemoryUserRepo) ListNationalities(ctx context.Context) ([]domain.Nationality, error) {
+	if err := defaultTenantOnly(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := append([]domain.Nationality(nil), r.nationalities...)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+func (r *MemoryUserRepo) GetNationality(ctx context.Context, id int32) (*domain.Nationality, error) {
+	if err := defaultTenantOnly(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, n := range r.nationalities {
+		if n.ID == id {
+			return &n, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *MemoryUserRepo) DuplicateCandidates(ctx context.Context, c domain.Customer, limit int) ([]domain.Customer, error) {
+	if err := defaultTenantOnly(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	swapped := c.Dob
+	if d := c.Dob.Day(); d <= 12 {
+		swapped = time.Date(c.Dob.Year(), time.Month(d), int(c.Dob.Month()), 0, 0, 0, 0, time.UTC)
+	}
+	suffix := phoneSuffix(c.PhoneNum)
+	name := strings.ToLower(strings.TrimSpace(c.Name))
+	first, _, _ := strings.Cut(name, " ")
+
+	var out []domain.Customer
+	strength := map[int32]int{}
+	for _, m := range r.customers {
+		o := m.c
+		oname := strings.ToLower(strings.TrimSpace(o.Name))
+		ofirst, _, _ := strings.Cut(oname, " ")
+		if o.ID == c.ID {
+			continue
+		}
+		s := blockingStrength(sameDay(o.Dob, c.Dob), suffix != "" && phoneSuffix(o.PhoneNum) == suffix,
+			sameDay(o.Dob, swapped), ofirst == first, prefix3(oname) == prefix3(name))
+		if s > 0 {
+			o.Family = nil
+			out = append(out, o)
+			strength[o.ID] = s
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if a, b := strength[out[i].ID], strength[out[j].ID]; a != b {
+			return a > b
+		}
+		return out[i].ID < out[j].ID
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (r *MemoryUserRepo) MergeCustomers(ctx context.Context, targetID, sourceID int32, merged domain.Customer) error {
+	if err := defaultTenantOnly(ctx); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	target, ok1 := r.customers[targetID]
+	source, ok2 := r.customers[sourceID]
+	if !ok1 || !ok2 {
+		return domain.ErrNotFound
+	}
+	if err := r.check(targetID, merged, sourceID); err != nil {
+		return err
+	}
+
+	key := func(f domain.FamilyMember) string {
+		return strings.ToLower(strings.TrimSpace(f.Relation)) + "|" + strings.ToLower(strings.TrimSpace(f.Name)) + "|" + f.Dob.Format("2006-01-02")
+	}
+	have := map[string]bool{}
+	for _, f := range target.c.Family {
+		have[key(f)] = true
+	}
+	family := target.c.Family
+	for _, f := range source.c.Family {
+		if !have[key(f)] {
+			f.CustomerID = targetID
+			family = append(family, f)
+		}
+	}
+	sort.Slice(family, func(i, j int) bool { return family[i].ID < family[j].ID })
+
+	for from, to := range r.redirects {
+		if to == sourceID {
+			r.redirects[from] = targetID
+		}
+	}
+	r.delete(sourceID)
+	r.redirects[sourceID] = targetID
+
+	merged.ID = targetID
+	merged.Family = family
+	target.c = copyCustomer(merged)
+	return nil
+}
+
+// ExportCustomer has no events to return: the memory repository keeps none.
+func (r *MemoryUserRepo) ExportCustomer(ctx context.Context, id int32) (*domain.DataExport, error) {
+	if err := defaultTenantOnly(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	m, ok := r.customers[id]
+	if !ok {
+		return nil, nil
+	}
+	x := &domain.DataExport{Customer: copyCustomer(m.c), MergedIDs: []int32{}, Events: []domain.Event{}}
+	for from, to := range r.redirects {
+		if to == id {
+			x.MergedIDs = append(x.MergedIDs, from)
+		}
+	}
+	sort.Slice(x.MergedIDs, func(i, j int) bool { return x.MergedIDs[i] < x.MergedIDs[j] })
+	return x, nil
+}
+
+func (r *MemoryUserRepo) AnonymizeCustomer(ctx context.Context, id int32) (*domain.Customer, error) {
+	if err := defaultTenantOnly(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.customers[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	anon := domain.Anonymize(m.c)
+	if err := r.check(id, anon); err != nil {
+		return nil, err
+	}
+	m.c = copyCustomer(anon)
+	return &anon, nil
+}
+
+func (r *MemoryUserRepo) Redirect(ctx context.Context, id int32) (int32, error) {
+	if err := defaultTenantOnly(ctx); err != nil {
+		return 0, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.redirects[id], nil
+}
+
+func (r *MemoryUserRepo) CustomerStats(ctx context.Context, f domain.StatsFilter) (*domain.CustomerStats, error) {
+	if err := defaultTenantOnly(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	matched := r.match(ctx, f.Search)
+	out := &domain.CustomerStats{Total: len(matched), ByAge: make([]domain.BucketCount, len(domain.AgeBuckets))}
+	for i, ab := range domain.AgeBuckets {
+		out.ByAge[i].Label = ab.Label
+	}
+
+	byNat := map[int32]int{}
+	byFamily := map[int]int{}
+	byPeriod := map[time.Time]int{}
+	today := truncDay(r.now())
+	start, end := truncInterval(f.Interval, f.From), f.To.AddDate(0, 0, 1)
+	for _, m := range matched {
+		byNat[m.c.NationalityID]++
+		byFamily[len(m.c.Family)]++
+		if i := ageBucket(ageOn(m.c.Dob, today)); i >= 0 {
+			out.ByAge[i].Count++
+		}
+		if at := m.createdAt.UTC(); !at.Before(start) && at.Before(end) {
+			byPeriod[truncInterval(f.Interval, at)]++
+		}
+	}
+
+	for _, n := range r.nationalities {
+		if c := byNat[n.ID]; c > 0 {
+			out.ByNationality = append(out.ByNationality, domain.NationalityCount{NationalityID: n.ID, Name: n.Name, Count: c})
+		}
+	}
+	sort.SliceStable(out.ByNationality, func(i, j int) bool {
+		a, b := out.ByNationality[i], out.ByNationality[j]
+		return a.Count > b.Count || (a.Count == b.Count && a.NationalityID < b.NationalityID)
+	})
+	for n, c := range byFamily {
+		out.FamilySize = append(out.FamilySize, domain.FamilySizeCount{Members: n, Count: c})
+	}
+	sort.Slice(out.FamilySize, func(i, j int) bool { return out.FamilySize[i].Members < out.FamilySize[j].Members })
+	for t := start; !t.After(truncInterval(f.Interval, f.To)); t = nextInterval(f.Interval, t) {
+		out.Created = append(out.Created, domain.PeriodCount{Start: t, Count: byPeriod[t]})
+	}
+	return out, nil
+}
+
+// check enforces the constraints Postgres would: the nationality exists and
+// no other customer (besides ignore) has the email, compared case-insensitively.
+func (r *MemoryUserRepo) check(id int32, c domain.Customer, ignore ...int32) error {
+	known := false
+	for _, n := range r.nationalities {
+		known = known || n.ID == c.NationalityID
+	}
+	if !known {
+		return domain.UnknownNationality()
+	}
+	email := strings.ToLower(c.Email)
+	for oid, m := range r.customers {
+		if oid == id || (len(ignore) > 0 && oid == ignore[0]) {
+			continue
+		}
+		if strings.ToLower(m.c.Email) == email {
+			return domain.ErrConflict
+		}
+	}
+	return nil
+}
+
+func (r *MemoryUserRepo) numberFamily(id int32, fs []domain.FamilyMember) []domain.FamilyMember {
+	out := make([]domain.FamilyMember, len(fs))
+	for i, f := range fs {
+		r.nextFamily++
+		f.ID, f.CustomerID = r.nextFamily, id
+		out[i] = f
+	}
+	return out
+}
+
+func copyCustomer(c domain.Customer) domain.Customer {
+	c.Family = append([]domain.FamilyMember(nil), c.Family...)
+	return c
+}
+
+func phoneSuffix(s string) string {
+	d := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+	if len(d) < 7 {
+		return ""
+	}
+	return d[len(d)-7:]
+}
+
+// blockingStrength ranks a duplicate candidate by the blocking keys it
+// shares, so that the candidate limit drops the weakest matches; the
+// Postgres and SQLite queries use the same weights.
+func blockingStrength(dob, phone, swappedDob, firstName, namePrefix bool) int {
+	s := 0
+	for _, k := range []struct {
+		hit    bool
+		weight int
+	}{{dob, 8}, {phone, 8}, {swappedDob, 4}, {firstName, 2}, {namePrefix, 1}} {
+		if k.hit {
+			s += k.weight
+		}
+	}
+	return s
+}
+
+// defaultTenantOnly guards the backends without tenant isolation: contexts
+// for another tenant than tenant.Default get tenant.ErrUnsupported rather
+// than the default tenant's rows. Contexts without a tenant are the default.
+func defaultTenantOnly(ctx context.Context) error {
+	if id, ok := tenant.From(ctx); ok && id != tenant.Default {
+		return fmt.Errorf("%w: %s", tenant.ErrUnsupported, id)
+	}
+	return nil
+}
+
+func prefix3(s string) string {
+	if len(s) > 3 {
+		return s[:3]
+	}
+	return s
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+func truncDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// truncInterval matches date_trunc: weeks start on Monday.
+func truncInterval(interval string, t time.Time) time.Time {
+	t = truncDay(t)
+	switch interval {
+	case domain.IntervalWeek:
+		return t.AddDate(0, 0, -(int(t.Weekday())+6)%7)
+	case domain.IntervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return t
+}
+
+func nextInterval(interval string, t time.Time) time.Time {
+	switch interval {
+	case domain.IntervalWeek:
+		return t.AddDate(0, 0, 7)
+	case domain.IntervalMonth:
+		return t.AddDate(0, 1, 0)
+	}
+	return t.AddDate(0, 0, 1)
+}
+
+// ageOn is age() in whole years.
+func ageOn(dob, day time.Time) int {
+	a := day.Year() - dob.Year()
+	if day.Month() < dob.Month() || (day.Month() == dob.Month() && day.Day() < dob.Day()) {
+		a--
+	}
+	return a
+}
+
+func ageBucket(age int) int {
+	for i, ab := range domain.AgeBuckets {
+		if age >= ab.Min && (ab.Max == 0 || age <= ab.Max) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"usrsvc/internal/domain"
+)
+
+func TestMemoryUserRepo(t *testing.T) {
+	ctx := context.Background()
+	dob := time.Date(1992, 5, 10, 0, 0, 0, 0, time.UTC)
+	cust := func(name, email string, family ...domain.FamilyMember) domain.Customer {
+		return domain.Customer{NationalityID: 1, Name: name, Dob: dob, PhoneNum: "0811", PhoneE164: "+62811" + name[:1], Email: email, Family: family}
+	}
+	spouse := domain.FamilyMember{Relation: "spouse", Name: "BETA", Dob: dob}
+
+	t.Run("crud_ids_and_family", func(t *testing.T) {
+		r := NewMemoryUserRepo(DefaultNationalities())
+		id1, err := r.CreateCustomer(ctx, cust("ALFA", "a@x.com", spouse))
+		require.NoError(t, err)
+		id2, err := r.CreateCustomer(ctx, cust("BRAVO", "b@x.com", spouse, spouse))
+		require.NoError(t, err)
+		assert.Equal(t, []int32{1, 2}, []int32{id1, id2})
+
+		got, err := r.GetCustomer(ctx, id2)
+		require.NoError(t, err)
+		require.Len(t, got.Family, 2)
+		assert.Equal(t, []int32{2, 3}, []int32{got.Family[0].ID, got.Family[1].ID})
+		assert.Equal(t, id2, got.Family[0].CustomerID)
+
+		got.Family[0].Name = "mutated"
+		again, _ := r.GetCustomer(ctx, id2)
+		assert.Equal(t, "BETA", again.Family[0].Name, "callers get copies")
+
+		require.NoError(t, r.UpdateCustomer(ctx, id1, cust("ALFA", "A@x.com")))
+		got, _ = r.GetCustomer(ctx, id1)
+		assert.Empty(t, got.Family, "update replaces the family")
+		assert.ErrorIs(t, r.UpdateCustomer(ctx, 99, cust("X", "x@x.com")), domain.ErrNotFound)
+
+		require.NoError(t, r.DeleteCustomer(ctx, id1))
+		got, err = r.GetCustomer(ctx, id1)
+		assert.NoError(t, err)
+		assert.Nil(t, got)
+		assert.ErrorIs(t, r.DeleteCustomer(ctx, id1), domain.ErrNotFound)
+
+		id3, _ := r.CreateCustomer(ctx, cust("CHARLIE", "c@x.com"))
+		assert.Equal(t, int32(3), id3, "ids are never reused")
+	})
+
+	t.Run("constraints", func(t *testing.T) {
+		r := NewMemoryUserRepo(DefaultNationalities())
+		id, _ := r.CreateCustomer(ctx, cust("ALFA", "a@x.com"))
+		other, _ := r.CreateCustomer(ctx, cust("BRAVO", "b@x.com"))
+
+		_, err := r.CreateCustomer(ctx, cust("X", "A@X.COM"))
+		assert.ErrorIs(t, err, domain.ErrConflict)
+		assert.ErrorIs(t, r.UpdateCustomer(ctx, other, cust("BRAVO", "a@X.com")), domain.ErrConflict)
+		assert.NoError(t, r.UpdateCustomer(ctx, id, cust("ALFA", "A@x.com")), "own email in another case")
+
+		bad := cust("X", "x@x.com")
+		bad.NationalityID = 42
+		_, err = r.CreateCustomer(ctx, bad)
+		assert.ErrorIs(t, err, domain.ErrRuleViolation)
+	})
+
+	t.Run("list_search_order_paging", func(t *testing.T) {
+		r := NewMemoryUserRepo(DefaultNationalities())
+		for _, n := range []string{"ALFA", "ALBERT", "BRAVO", "CALVIN"} {
+			_, err := r.CreateCustomer(ctx, cust(n, n+"@x.com"))
+			require.NoError(t, err)
+		}
+		tests := []struct {
+			search        string
+			limit, offset int
+			want          []int32
+			total         int32
+		}{
+			{"", 10, 0, []int32{4, 3, 2, 1}, 4},
+			{"al", 10, 0, []int32{4, 2, 1}, 3},
+			{"al", 1, 1, []int32{2}, 3},
+			{"bravo@X.com", 10, 0, []int32{3}, 1},
+			{"BRAVO@", 10, 0, []int32{3}, 1},
+			{"+62811C", 10, 0, []int32{4}, 1},
+			{"+62811", 10, 0, []int32{}, 0},
+			{"", 10, 9, []int32{}, 4},
+		}
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%q_%d_%d", tt.search, tt.limit, tt.offset), func(t *testing.T) {
+				rows, total, err := r.ListCustomers(ctx, tt.search, tt.limit, tt.offset)
+				require.NoError(t, err)
+				ids := []int32{}
+				for _, c := range rows {
+					ids = append(ids, c.ID)
+					assert.Nil(t, c.Family)
+				}
+				assert.Equal(t, tt.want, ids)
+				assert.Equal(t, tt.total, total)
+			})
+		}
+	})
+
+	t.Run("merge_and_redirect", func(t *testing.T) {
+		r := NewMemoryUserRepo(DefaultNationalities())
+		kid := domain.FamilyMember{Relation: "child", Name: "CACA", Dob: dob.AddDate(25, 0, 0)}
+		target, _ := r.CreateCustomer(ctx, cust("ALFA", "a@x.com", spouse))
+		source, _ := r.CreateCustomer(ctx, cust("ALFA S", "s@x.com", domain.FamilyMember{Relation: "Spouse", Name: "beta", Dob: dob}, kid))
+		older, _ := r.CreateCustomer(ctx, cust("OLD", "o@x.com"))
+		require.NoError(t, r.MergeCustomers(ctx, source, older, cust("ALFA S", "s@x.com")))
+
+		merged := cust("ALFA", "s@x.com")
+		require.NoError(t, r.MergeCustomers(ctx, target, source, merged))
+		got, _ := r.GetCustomer(ctx, target)
+		assert.Equal(t, "s@x.com", got.Email)
+		require.Len(t, got.Family, 2, "duplicate spouse stays behind")
+		assert.Equal(t, "CACA", got.Family[1].Name)
+
+		for _, id := range []int32{source, older} {
+			to, err := r.Redirect(ctx, id)
+			require.NoError(t, err)
+			assert.Equal(t, target, to)
+		}
+		assert.ErrorIs(t, r.MergeCustomers(ctx, target, source, merged), domain.ErrNotFound)
+
+		require.NoError(t, r.DeleteCustomer(ctx, target))
+		to, _ := r.Redirect(ctx, source)
+		assert.Zero(t, to, "redirects go with their target")
+	})
+
+	t.Run("duplicate_candidates", func(t *testing.T) {
+		r := NewMemoryUserRepo(DefaultNationalities())
+		a := cust("Budi Santoso", "a@x.com")
+		a.PhoneNum = "0812-3456-7890"
+		id, _ := r.CreateCustomer(ctx, a)
+		swapped := cust("Siti", "b@x.com")
+		swapped.Dob = time.Date(1992, 10, 5, 0, 0, 0, 0, time.UTC)
+		r.CreateCustomer(ctx, swapped)
+		phone := cust("Agus", "c@x.com")
+		phone.Dob, phone.PhoneNum = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC), "+62 812 3456 7890"
+		r.CreateCustomer(ctx, phone)
+		stranger := cust("Zed", "d@x.com")
+		stranger.Dob, stranger.PhoneNum = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC), "0999"
+		r.CreateCustomer(ctx, stranger)
+
+		a.ID = id
+		got, err := r.DuplicateCandidates(ctx, a, 10)
+		require.NoError(t, err)
+		ids := []int32{}
+		for _, c := range got {
+			ids = append(ids, c.ID)
+		}
+		assert.Equal(t, []int32{3, 2}, ids, "a phone match ranks above a swapped dob")
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		r := NewMemoryUserRepo(DefaultNationalities())
+		day := func(m time.Month, d int) time.Time { return time.Date(2026, m, d, 9, 0, 0, 0, time.UTC) }
+		for i, at := range []time.Time{day(9, 1), day(9, 2), day(9, 9), day(10, 1)} {
+			r.now = func() time.Time { return at }
+			c := cust("ALFA", fmt.Sprintf("%d@x.com", i))
+			if i == 3 {
+				c.NationalityID, c.Dob, c.Family = 2, time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC), []domain.FamilyMember{spouse}
+			}
+			_, err := r.CreateCustomer(ctx, c)
+			require.NoError(t, err)
+		}
+		r.now = func() time.Time { return day(10, 18) }
+
+		st, err := r.CustomerStats(ctx, domain.StatsFilter{Interval: domain.IntervalWeek,
+			From: time.Date(2026, 9, 2, 0, 0, 0, 0, time.UTC), To: time.Date(2026, 9, 14, 0, 0, 0, 0, time.UTC)})
+		require.NoError(t, err)
+		assert.Equal(t, 4, st.Total)
+		assert.Equal(t, []domain.NationalityCount{{NationalityID: 1, Name: "Indonesia", Count: 3}, {NationalityID: 2, Name: "Malaysia", Count: 1}}, st.ByNationality)
+		assert.Equal(t, 1, st.ByAge[0].Count)
+		assert.Equal(t, 3, st.ByAge[2].Count, "34 years old")
+		assert.Equal(t, []domain.FamilySizeCount{{Members: 0, Count: 3}, {Members: 1, Count: 1}}, st.FamilySize)
+		assert.Equal(t, []domain.PeriodCount{
+			{Start: time.Date(2026, 8, 31, 0, 0, 0, 0, time.UTC), Count: 2},
+			{Start: time.Date(2026, 9, 7, 0, 0, 0, 0, time.UTC), Count: 1},
+			{Start: time.Date(2026, 9, 14, 0, 0, 0, 0, time.UTC), Count: 0},
+		}, st.Created)
+	})
+
+	t.Run("concurrent_creates", func(t *testing.T) {
+		r := NewMemoryUserRepo(DefaultNationalities())
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = r.CreateCustomer(ctx, cust("ALFA", fmt.Sprintf("%d@x.com", i%25)))
+			}()
+		}
+		wg.Wait()
+		_, total, _ := r.ListCustomers(ctx, "", 100, 0)
+		assert.Equal(t, int32(25), total)
+	})
+}
+
+func TestLoadFixtures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "f.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"customers":[{"nationality_id":1,"cst_name":"ALFA","cst_dob":"1992-05-10",
+		"cst_phoneNum":"0811","cst_email":"a@x.com","family":[{"fl_relation":"spouse","fl_name":"BETA","fl_dob":"1993-07-01"}]}]}`), 0o600))
+	ns, cs, err := LoadFixtures(path)
+	require.NoError(t, err)
+	assert.Len(t, ns, 5)
+	require.Len(t, cs, 1)
+	assert.Equal(t, time.Date(1993, 7, 1, 0, 0, 0, 0, time.UTC), cs[0].Family[0].Dob)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"nationalities":[{"id":9,"name":"Nowhere"}],"customers":[{"cst_dob":"10-05-1992"}]}`), 0o600))
+	_, _, err = LoadFixtures(path)
+	assert.ErrorContains(t, err, "customers[0].cst_dob")
+
+	ns, _, err = LoadFixtures("../../fixtures/dev.json")
+	require.NoError(t, err, "the shipped fixtures parse")
+	assert.Len(t, ns, 5)
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"usrsvc/internal/domain"
+)
+
+// PgAuditLog appends domain.AuditEntry rows to audit_log under the context's
+// tenant.
+type PgAuditLog struct{ db *pgxpool.Pool }
+
+func NewPgAuditLog(db *pgxpool.Pool) *PgAuditLog { return &PgAuditLog{db: db} }
+
+func (a *PgAuditLog) Record(ctx context.Context, e domain.AuditEntry) error {
+	ids := e.CustomerIDs
+	if ids == nil {
+		ids = []int32{}
+	}
+	return withTenant(ctx, a.db, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `INSERT INTO audit_log (action,operation,actor,role,customer_ids,reason,at) VALUES ($1,$2,$3,$4,$5,$6,$7)`,
+			e.Action, e.Operation, e.Actor, e.Role, ids, e.Reason, e.At)
+		return err
+	})
+}
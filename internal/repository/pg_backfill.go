@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"usrsvc/internal/domain"
+)
+
+// BackfillPhoneE164 fills the E.164 phone of ctx's tenant's customers that
+// have none, which is every row written before migration 0002 and not saved
+// since, batch rows per transaction. normalize returns "" for numbers it
+// cannot read; those rows are left as they are. Sealed rows get a sealed
+// value and blind index and need the keyring. Like ReencryptPII it writes no
+// events. It returns the number of rows filled.
+func (r *PgUserRepo) BackfillPhoneE164(ctx context.Context, batch int, normalize func(domain.Customer) string) (int, error) {
+	total := 0
+	for _, sealed := range []bool{false, true} {
+		if sealed && r.keys == nil {
+			var left bool
+			err := withTenant(ctx, r.db, pgx.TxOptions{AccessMode: pgx.ReadOnly}, func(tx pgx.Tx) error {
+				return tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM customer
+					WHERE pii_kid IS NOT NULL AND cst_phone_e164_enc IS NULL)`).Scan(&left)
+			})
+			if err == nil && left {
+				err = errNoKeyring
+			}
+			return total, err
+		}
+		for last := int32(0); ; {
+			n, seen := 0, 0
+			err := withTenant(ctx, r.db, pgx.TxOptions{}, func(tx pgx.Tx) error {
+				rows, err := tx.Query(ctx, `SELECT `+customerCols+` FROM customer
+					WHERE (pii_kid IS NOT NULL) = $1 AND cst_phone_e164 IS NULL AND cst_phone_e164_enc IS NULL
+					AND cst_id > $2 ORDER BY cst_id LIMIT $3 FOR UPDATE`, sealed, last, batch)
+				if err != nil {
+					return err
+				}
+				cs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.Customer, error) { return r.scanCustomer(row) })
+				if err != nil {
+					return err
+				}
+				for _, c := range cs {
+					last = c.ID
+					e164 := normalize(c)
+					if e164 == "" {
+						continue
+					}
+					args := []any{e164, nil, nil}
+					if sealed {
+						enc, err := r.keys.Seal("cst_phone_e164", []byte(e164))
+						if err != nil {
+							return err
+						}
+						args = []any{nil, enc, r.index("cst_phone_e164", e164)}
+					}
+					if _, err := tx.Exec(ctx, `UPDATE customer SET cst_phone_e164=$1, cst_phone_e164_enc=$2, cst_phone_e164_bidx=$3
+						WHERE cst_id=$4`, append(args, c.ID)...); err != nil {
+						return fmt.Errorf("cst_id=%d: %w", c.ID, mapErr(err))
+					}
+					n++
+				}
+				seen = len(cs)
+				return nil
+			})
+			if err != nil {
+				return total, err
+			}
+			total += n
+			if seen < batch {
+				break
+			}
+		}
+	}
+	return total, nil
+}
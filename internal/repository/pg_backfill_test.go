@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/pkg/tenant"
+)
+
+func TestPgUserRepo_BackfillPhoneE164(t *testing.T) {
+	dsn := os.Getenv("PG_DSN")
+	if dsn == "" {
+		t.Skip("PG_DSN not set")
+	}
+	pool := newPgSchema(t, dsn)
+	ctx := tenant.With(context.Background(), tenant.Default)
+	plain, sealed := NewPgUserRepo(pool), NewPgUserRepo(pool, WithKeyring(testKeyring(t, "k1")))
+
+	ns, err := plain.ListNationalities(ctx)
+	require.NoError(t, err)
+	c := domain.Customer{NationalityID: ns[0].ID, Name: "ALPHA", Dob: time.Date(1990, 1, 2, 0, 0, 0, 0, time.UTC), PhoneNum: "0812-1", Email: "a@x.io"}
+	old, err := plain.CreateCustomer(ctx, c)
+	require.NoError(t, err)
+	c.PhoneNum, c.Email = "0812-2", "b@x.io"
+	oldSealed, err := sealed.CreateCustomer(ctx, c)
+	require.NoError(t, err)
+	c.PhoneNum, c.Email = "junk", "c@x.io"
+	unreadable, err := plain.CreateCustomer(ctx, c)
+	require.NoError(t, err)
+	_, err = pool.Exec(ctx, `UPDATE customer SET cst_phone_e164=NULL, cst_phone_e164_enc=NULL, cst_phone_e164_bidx=NULL`)
+	require.NoError(t, err)
+
+	normalize := func(c domain.Customer) string {
+		switch c.PhoneNum {
+		case "0812-1":
+			return "+628121"
+		case "0812-2":
+			return "+628122"
+		}
+		return ""
+	}
+	_, err = plain.BackfillPhoneE164(ctx, 1, normalize)
+	assert.ErrorIs(t, err, errNoKeyring, "sealed rows need the keyring")
+
+	n, err := sealed.BackfillPhoneE164(ctx, 1, normalize)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "the plaintext row was already filled by the first run")
+	for id, want := range map[int32]string{old: "+628121", oldSealed: "+628122", unreadable: ""} {
+		got, err := sealed.GetCustomer(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, want, got.PhoneE164, id)
+	}
+	rows, _, err := sealed.ListCustomers(ctx, "+628122", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, rows, 1, "found through the blind index")
+	assert.Equal(t, oldSealed, rows[0].ID)
+
+	n, err = sealed.BackfillPhoneE164(ctx, 10, normalize)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
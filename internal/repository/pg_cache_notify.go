@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// cacheChannel carries space-separated cache keys between instances.
+const cacheChannel = "usrsvc_cache"
+
+type PgNotifier struct{ db *pgxpool.Pool }
+
+func NewPgNotifier(db *pgxpool.Pool) *PgNotifier { return &PgNotifier{db: db} }
+
+func (n *PgNotifier) Invalidate(ctx context.Context, keys ...string) error {
+	_, err := n.db.Exec(ctx, `SELECT pg_notify($1, $2)`, cacheChannel, strings.Join(keys, " "))
+	return err
+}
+
+// ListenInvalidations holds one pool connection on LISTEN and calls evict for
+// every notification until ctx is done, reconnecting after errors. Entries
+// cached while disconnected still expire by TTL.
+func ListenInvalidations(ctx context.Context, db *pgxpool.Pool, evict func(ctx context.Context, keys ...string)) {
+	listener{
+		channel: cacheChannel,
+		onNotify: func(ctx context.Context, _ *pgx.Conn, payload string) error {
+			evict(ctx, strings.Fields(payload)...)
+			return nil
+		},
+	}.run(ctx, db)
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"usrsvc/internal/domain"
+)
+
+// eventsChannel is notified with the outbox id by the outbox_notify trigger.
+const eventsChannel = "usrsvc_events"
+
+// ListenEvents feeds publish with every committed outbox event, on every
+// instance, until ctx is done. On connect it first loads up to backfill
+// events: the newest ones at startup, and those it may have missed while
+// disconnected after that.
+func ListenEvents(ctx context.Context, db *pgxpool.Pool, backfill int, publish func(domain.Event)) {
+	var last int64
+	emit := func(evs []domain.Event) {
+		for _, e := range evs {
+			last = max(last, e.ID)
+			publish(e)
+		}
+	}
+	listener{
+		channel: eventsChannel,
+		onListen: func(ctx context.Context, conn *pgx.Conn) error {
+			evs, err := queryEvents(ctx, conn,
+				`SELECT id,event_type,tenant_id,customer_id,occurred_at,payload FROM outbox
+				 WHERE id > $1 ORDER BY id DESC LIMIT $2`, last, backfill)
+			if err != nil {
+				return err
+			}
+			slices.Reverse(evs)
+			emit(evs)
+			return nil
+		},
+		onNotify: func(ctx context.Context, conn *pgx.Conn, payload string) error {
+			id, err := strconv.ParseInt(payload, 10, 64)
+			if err != nil {
+				return nil
+			}
+			evs, err := queryEvents(ctx, conn,
+				`SELECT id,event_type,tenant_id,customer_id,occurred_at,payload FROM outbox WHERE id=$1`, id)
+			if err != nil {
+				return err
+			}
+			emit(evs)
+			return nil
+		},
+	}.run(ctx, db)
+}
+
+func queryEvents(ctx context.Context, q querier, sql string, args ...any) ([]domain.Event, error) {
+	rows, err := q.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []domain.Event
+	for rows.Next() {
+		var e domain.Event
+		if err := rows.Scan(&e.ID, &e.Type, &e.Tenant, &e.CustomerID, &e.OccurredAt, &e.Payload); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"usrsvc/internal/jobs"
+)
+
+// jobLockSQL derives a job's session-level advisory lock key from its name;
+// the prefix keeps it clear of outboxLockKey and anything else in the
+// database.
+const jobLockSQL = `hashtextextended('usrsvc.job:' || $1, 0)`
+
+// PgJobStore is jobs.Store over the job_run table. It is not tenant scoped:
+// jobs work across tenants.
+type PgJobStore struct{ db *pgxpool.Pool }
+
+func NewPgJobStore(db *pgxpool.Pool) *PgJobStore { return &PgJobStore{db: db} }
+
+// Lock holds the advisory lock on a connection of its own for as long as fn
+// runs. Should the connection die, Postgres drops the lock with it.
+func (s *PgJobStore) Lock(ctx context.Context, job string, fn func(context.Context) error) (bool, error) {
+	conn, err := s.db.Acquire(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Release()
+	var locked bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock(`+jobLockSQL+`)`, job).Scan(&locked); err != nil {
+		return false, err
+	}
+	if !locked {
+		return false, nil
+	}
+	defer func() {
+		if _, err := conn.Exec(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock(`+jobLockSQL+`)`, job); err != nil {
+			// Never hand a connection that may still hold the lock back to the pool.
+			conn.Hijack().Close(context.WithoutCancel(ctx))
+		}
+	}()
+	return true, fn(ctx)
+}
+
+func (s *PgJobStore) Start(ctx context.Context, r *jobs.Run) (bool, error) {
+	var slot *time.Time
+	if !r.ScheduledFor.IsZero() {
+		slot = &r.ScheduledFor
+	}
+	rows, err := s.db.Query(ctx,
+		`INSERT INTO job_run (job,triggered_by,scheduled_for,instance,started_at) VALUES ($1,$2,$3,$4,$5)
+		 ON CONFLICT (job, scheduled_for) DO NOTHING RETURNING id`,
+		r.Job, r.Trigger, slot, r.Instance, r.StartedAt)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return false, rows.Err()
+	}
+	return true, rows.Scan(&r.ID)
+}
+
+func (s *PgJobStore) Finish(ctx context.Context, id int64, at time.Time, errMsg string) error {
+	_, err := s.db.Exec(ctx, `UPDATE job_run SET finished_at=$2, error=$3 WHERE id=$1`, id, at, errMsg)
+	return err
+}
+
+func (s *PgJobStore) Recent(ctx context.Context, job string, limit int) ([]jobs.Run, error) {
+	rows, err := s.db.Query(ctx,
+		`SELECT id,job,triggered_by,scheduled_for,instance,started_at,finished_at,error FROM job_run
+		 WHERE job=$1 ORDER BY id DESC LIMIT $2`, job, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []jobs.Run
+	for rows.Next() {
+		var (
+			r              jobs.Run
+			slot, finished *time.Time
+		)
+		if err := rows.Scan(&r.ID, &r.Job, &r.Trigger, &slot, &r.Instance, &r.StartedAt, &finished, &r.Error); err != nil {
+			return nil, err
+		}
+		if slot != nil {
+			r.ScheduledFor = *slot
+		}
+		if finished != nil {
+			r.FinishedAt = *finished
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// Purge deletes the runs that started before before.
+func (s *PgJobStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := s.db.Exec(ctx, `DELETE FROM job_run WHERE started_at < $1`, before)
+	return tag.RowsAffected(), err
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"usrsvc/internal/jobs"
+)
+
+func TestPgJobStore(t *testing.T) {
+	dsn := os.Getenv("PG_DSN")
+	if dsn == "" {
+		t.Skip("PG_DSN not set")
+	}
+	ctx := context.Background()
+	s := NewPgJobStore(newPgSchema(t, dsn))
+	slot := time.Date(2026, 3, 1, 3, 0, 0, 0, time.UTC)
+
+	ran, err := s.Lock(ctx, "purge", func(ctx context.Context) error {
+		other, err := s.Lock(ctx, "purge", func(context.Context) error { t.Fatal("lock is shared"); return nil })
+		require.NoError(t, err)
+		assert.False(t, other, "another connection cannot take the lock")
+		free, err := s.Lock(ctx, "other", func(context.Context) error { return nil })
+		require.NoError(t, err)
+		assert.True(t, free, "locks are per job")
+
+		r := jobs.Run{Job: "purge", Trigger: jobs.TriggerSchedule, ScheduledFor: slot, Instance: "a", StartedAt: time.Now()}
+		claimed, err := s.Start(ctx, &r)
+		require.NoError(t, err)
+		require.True(t, claimed)
+		return s.Finish(ctx, r.ID, time.Now(), "boom")
+	})
+	require.NoError(t, err)
+	require.True(t, ran)
+	ran, err = s.Lock(ctx, "purge", func(context.Context) error { return nil })
+	require.NoError(t, err)
+	assert.True(t, ran, "released after fn")
+
+	again := jobs.Run{Job: "purge", Trigger: jobs.TriggerSchedule, ScheduledFor: slot, Instance: "b", StartedAt: time.Now()}
+	claimed, err := s.Start(ctx, &again)
+	require.NoError(t, err)
+	assert.False(t, claimed, "slot already ran")
+	for range 2 {
+		manual := jobs.Run{Job: "purge", Trigger: jobs.TriggerManual, Instance: "b", StartedAt: time.Now()}
+		claimed, err := s.Start(ctx, &manual)
+		require.NoError(t, err)
+		assert.True(t, claimed)
+	}
+
+	runs, err := s.Recent(ctx, "purge", 10)
+	require.NoError(t, err)
+	require.Len(t, runs, 3)
+	assert.True(t, runs[0].ScheduledFor.IsZero())
+	assert.True(t, runs[0].FinishedAt.IsZero())
+	assert.True(t, runs[2].ScheduledFor.Equal(slot))
+	assert.False(t, runs[2].FinishedAt.IsZero())
+	assert.Equal(t, "boom", runs[2].Error)
+
+	n, err := s.Purge(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, n)
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"usrsvc/internal/pkg/log"
+)
+
+// listener runs on a dedicated connection: onListen right after LISTEN
+// (and after every reconnect), onNotify for each notification.
+type listener struct {
+	channel  string
+	onListen func(ctx context.Context, conn *pgx.Conn) error
+	onNotify func(ctx context.Context, conn *pgx.Conn, payload string) error
+}
+
+// run keeps l listening until ctx is done, reconnecting after errors.
+func (l listener) run(ctx context.Context, db *pgxpool.Pool) {
+	for ctx.Err() == nil {
+		if err := l.once(ctx, db); err != nil && ctx.Err() == nil {
+			log.Error.Printf("listen channel=%s err=%v", l.channel, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+		}
+	}
+}
+
+func (l listener) once(ctx context.Context, db *pgxpool.Pool) error {
+	pc, err := db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// Take the connection out of the pool so its LISTEN never leaks to
+	// other queries.
+	conn := pc.Hijack()
+	defer conn.Close(context.Background())
+	if _, err := conn.Exec(ctx, "LISTEN "+l.channel); err != nil {
+		return err
+	}
+	log.Info.Printf("listen ok channel=%s", l.channel)
+	if l.onListen != nil {
+		if err := l.onListen(ctx, conn); err != nil {
+			return err
+		}
+	}
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		if err := l.onNotify(ctx, conn, n.Payload); err != nil {
+			return err
+		}
+	}
+}
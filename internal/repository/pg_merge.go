@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"usrsvc/internal/domain"
+)
+
+func (r *PgUserRepo) DuplicateCandidates(ctx context.Context, c domain.Customer, limit int) ([]domain.Customer, error) {
+	// Blocking keys only narrow the scan; scoring happens in the usecase.
+	swapped := c.Dob
+	if d := c.Dob.Day(); d <= 12 {
+		swapped = time.Date(c.Dob.Year(), time.Month(d), int(c.Dob.Month()), 0, 0, 0, 0, time.UTC)
+	}
+	suffix := phoneSuffix(c.PhoneNum)
+	name := strings.ToLower(strings.TrimSpace(c.Name))
+	prefix := name
+	if len(prefix) > 3 {
+		prefix = prefix[:3]
+	}
+	first, _, _ := strings.Cut(name, " ")
+
+	var out []domain.Customer
+	err := withTenant(ctx, r.db, readOnly, func(tx pgx.Tx) error {
+		// Sealed rows block on the blind indexes ($8-$10), plaintext ones on
+		// the columns. The weights are those of blockingStrength.
+		rows, err := tx.Query(ctx,
+			`SELECT `+customerCols+`
+			 FROM customer
+			 WHERE cst_id <> $1 AND (
+			   cst_dob_bidx IN ($8,$9) OR cst_phone_sfx_bidx = $10
+			   OR (pii_kid IS NULL AND (cst_dob IN ($2,$3)
+			     OR ($4 <> '' AND right(regexp_replace(cst_phoneNum,'[^0-9]','','g'),7) = $4)))
+			   OR lower(left(btrim(cst_name),3)) = $5
+			   OR lower(split_part(btrim(cst_name),' ',1)) = $6)
+			 ORDER BY
+			   CASE WHEN cst_dob_bidx = $8 OR (pii_kid IS NULL AND cst_dob = $2) THEN 8 ELSE 0 END
+			   + CASE WHEN cst_phone_sfx_bidx = $10 OR (pii_kid IS NULL AND $4 <> ''
+			       AND right(regexp_replace(cst_phoneNum,'[^0-9]','','g'),7) = $4) THEN 8 ELSE 0 END
+			   + CASE WHEN cst_dob_bidx = $9 OR (pii_kid IS NULL AND cst_dob = $3) THEN 4 ELSE 0 END
+			   + CASE WHEN lower(split_part(btrim(cst_name),' ',1)) = $6 THEN 2 ELSE 0 END
+			   + CASE WHEN lower(left(btrim(cst_name),3)) = $5 THEN 1 ELSE 0 END DESC,
+			   cst_id
+			 LIMIT $7`,
+			c.ID, c.Dob, swapped, suffix, prefix, first, limit,
+			r.index("cst_dob", dobIndex(c.Dob)), r.index("cst_dob", dobIndex(swapped)), r.index("cst_phone_sfx", suffix))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			o, err := r.scanCustomer(rows)
+			if err != nil {
+				return err
+			}
+			out = append(out, o)
+		}
+		return rows.Err()
+	})
+	return out, err
+}
+
+func (r *PgUserRepo) MergeCustomers(ctx context.Context, targetID, sourceID int32, merged domain.Customer) error {
+	tx, err := beginTenant(ctx, r.db, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock in id order so two merges of the same pair cannot deadlock.
+	var locked int
+	if err := tx.QueryRow(ctx,
+		`SELECT COUNT(*) FROM (SELECT cst_id FROM customer WHERE cst_id IN ($1,$2) ORDER BY cst_id FOR UPDATE) t`,
+		targetID, sourceID).Scan(&locked); err != nil {
+		return err
+	}
+	if locked != 2 {
+		return domain.ErrNotFound
+	}
+	before, err := r.getCustomer(ctx, tx, targetID, nil, "")
+	if err != nil {
+		return err
+	}
+
+	// Move family members the target does not already have; the rest go
+	// with the source row (ON DELETE CASCADE).
+	if _, err := tx.Exec(ctx,
+		`UPDATE family_list s SET cst_id=$1
+		 WHERE s.cst_id=$2 AND NOT EXISTS (
+		   SELECT 1 FROM family_list t WHERE t.cst_id=$1
+		   AND lower(btrim(t.fl_relation))=lower(btrim(s.fl_relation))
+		   AND lower(btrim(t.fl_name))=lower(btrim(s.fl_name)) AND (t.fl_dob=s.fl_dob OR t.fl_dob_bidx=s.fl_dob_bidx))`,
+		targetID, sourceID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE customer_redirect SET new_id=$1 WHERE new_id=$2`, targetID, sourceID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM customer WHERE cst_id=$1`, sourceID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO customer_redirect (old_id,new_id) VALUES ($1,$2)`, sourceID, targetID); err != nil {
+		return err
+	}
+	if err := r.updateCustomer(ctx, tx, targetID, merged); err != nil {
+		return err
+	}
+
+	after, err := r.getCustomer(ctx, tx, targetID, nil, "")
+	if err != nil {
+		return err
+	}
+	if err := r.insertEvent(ctx, tx, domain.CustomerMerged{SourceID: sourceID, TargetID: targetID}); err != nil {
+		return err
+	}
+	if changes := domain.DiffCustomer(*before, *after); len(changes) > 0 {
+		if err := r.insertEvent(ctx, tx, domain.CustomerUpdated{CustomerID: targetID, Changes: changes}); err != nil {
+			return err
+		}
+	}
+	return commitWrite(ctx, tx)
+}
+
+func (r *PgUserRepo) Redirect(ctx context.Context, id int32) (int32, error) {
+	var to int32
+	err := withTenant(ctx, r.db, readOnly, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, `SELECT new_id FROM customer_redirect WHERE old_id=$1`, id).Scan(&to)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	})
+	return to, err
+}
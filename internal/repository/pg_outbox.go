@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/events"
+)
+
+// outboxLockKey is the transaction-level advisory lock that keeps a single
+// relay draining at a time, which is what preserves per-customer order.
+const outboxLockKey = 0x75737276_0001
+
+type PgOutbox struct{ db *pgxpool.Pool }
+
+func NewPgOutbox(db *pgxpool.Pool) *PgOutbox { return &PgOutbox{db: db} }
+
+func (o *PgOutbox) Drain(ctx context.Context, limit int, fn func([]domain.Event) events.Outcome) (bool, error) {
+	tx, err := o.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var locked bool
+	if err := tx.QueryRow(ctx, `SELECT pg_try_advisory_xact_lock($1)`, int64(outboxLockKey)).Scan(&locked); err != nil {
+		return false, err
+	}
+	if !locked {
+		return false, nil
+	}
+
+	rows, err := tx.Query(ctx,
+		`SELECT id,event_type,tenant_id,customer_id,occurred_at,payload FROM outbox
+		 WHERE published_at IS NULL ORDER BY id LIMIT $1`, limit)
+	if err != nil {
+		return true, err
+	}
+	var evs []domain.Event
+	for rows.Next() {
+		var e domain.Event
+		if err := rows.Scan(&e.ID, &e.Type, &e.Tenant, &e.CustomerID, &e.OccurredAt, &e.Payload); err != nil {
+			rows.Close()
+			return true, err
+		}
+		evs = append(evs, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return true, err
+	}
+	if len(evs) == 0 {
+		return true, nil
+	}
+
+	out := fn(evs)
+	if len(out.Published) > 0 {
+		if _, err := tx.Exec(ctx, `UPDATE outbox SET published_at=now(), attempts=attempts+1 WHERE id = ANY($1)`, out.Published); err != nil {
+			return true, err
+		}
+	}
+	for id, reason := range out.Failed {
+		if _, err := tx.Exec(ctx, `UPDATE outbox SET attempts=attempts+1, last_error=$2 WHERE id=$1`, id, reason); err != nil {
+			return true, err
+		}
+	}
+	return true, tx.Commit(ctx)
+}
+
+// Purge deletes the events published before before. Unpublished events are
+// kept however old they are.
+func (o *PgOutbox) Purge(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := o.db.Exec(ctx, `DELETE FROM outbox WHERE published_at < $1`, before)
+	return tag.RowsAffected(), err
+}
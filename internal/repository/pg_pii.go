@@ -0,0 +1,316 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/pkg/keyring"
+)
+
+// WithKeyring seals customer PII (email, phone, dates of birth) with k.
+// Without it the repository writes plaintext and cannot read sealed rows.
+func WithKeyring(k *keyring.Keyring) PgOption { return func(r *PgUserRepo) { r.keys = k } }
+
+var errNoKeyring = errors.New("customer PII is encrypted but no keyring is configured")
+
+// customerColumns is what scanCustomer reads, in order: both the plaintext
+// and the sealed form, of which a row has exactly one. Each column belongs to
+// a domain.CustomerFields name and is replaced by none when that field is not
+// selected, so the scan stays positional; cst_id is always read.
+var customerColumns = []struct{ field, col, none string }{
+	{"cst_id", "cst_id", ""},
+	{"nationality_id", "nationality_id", "0"},
+	{"cst_name", "cst_name", "''"},
+	{"cst_dob", "cst_dob", "NULL::date"},
+	{"cst_phoneNum", "cst_phoneNum", "NULL::text"},
+	{"cst_phone_e164", "COALESCE(cst_phone_e164,'')", "''"},
+	{"cst_email", "cst_email", "NULL::text"},
+	{"cst_dob", "cst_dob_enc", "NULL::bytea"},
+	{"cst_phoneNum", "cst_phone_enc", "NULL::bytea"},
+	{"cst_phone_e164", "cst_phone_e164_enc", "NULL::bytea"},
+	{"cst_email", "cst_email_enc", "NULL::bytea"},
+}
+
+// customerCols reads the whole customer.
+var customerCols = customerSelect(nil)
+
+// customerSelect returns the select list of customerColumns for f.
+func customerSelect(f domain.Fields) string {
+	cols := make([]string, len(customerColumns))
+	for i, c := range customerColumns {
+		cols[i] = c.col
+		if c.none != "" && !f.Has(c.field) {
+			cols[i] = c.none
+		}
+	}
+	return strings.Join(cols, ",")
+}
+
+// piiCols are the columns sealCustomer's args fill, in order. cst_birth_year
+// stays plaintext either way, for the stats age buckets.
+const piiCols = `cst_dob,cst_phoneNum,cst_phone_e164,cst_email,pii_kid,
+	cst_dob_enc,cst_phone_enc,cst_phone_e164_enc,cst_email_enc,
+	cst_dob_bidx,cst_phone_sfx_bidx,cst_phone_e164_bidx,cst_email_bidx,cst_birth_year`
+
+// piiParams returns "$from,...,$from+13" for piiCols.
+func piiParams(from int) string {
+	ps := make([]string, 14)
+	for i := range ps {
+		ps[i] = fmt.Sprintf("$%d", from+i)
+	}
+	return strings.Join(ps, ",")
+}
+
+// Blind index normalization: lookups hash the search term the same way.
+func emailIndex(email string) string { return strings.ToLower(strings.TrimSpace(email)) }
+func dobIndex(dob time.Time) string  { return dob.Format(dateLayout) }
+
+// index returns the blind index of value for field, or nil (SQL NULL, which
+// matches nothing) without a keyring or for an empty value.
+func (r *PgUserRepo) index(field, value string) []byte {
+	if r.keys == nil || value == "" {
+		return nil
+	}
+	return r.keys.BlindIndex(field, value)
+}
+
+// sealCustomer returns the values for piiCols.
+func (r *PgUserRepo) sealCustomer(c domain.Customer) ([]any, error) {
+	e164 := any(nil)
+	if c.PhoneE164 != "" {
+		e164 = c.PhoneE164
+	}
+	if r.keys == nil {
+		return []any{c.Dob, c.PhoneNum, e164, c.Email, nil, nil, nil, nil, nil, nil, nil, nil, nil, c.Dob.Year()}, nil
+	}
+	sealed := make([][]byte, 4)
+	for i, v := range []struct{ field, value string }{
+		{"cst_dob", dobIndex(c.Dob)}, {"cst_phone", c.PhoneNum}, {"cst_phone_e164", c.PhoneE164}, {"cst_email", c.Email},
+	} {
+		if v.value == "" && v.field == "cst_phone_e164" {
+			continue
+		}
+		var err error
+		if sealed[i], err = r.keys.Seal(v.field, []byte(v.value)); err != nil {
+			return nil, err
+		}
+	}
+	return []any{nil, nil, nil, nil, r.keys.Primary(),
+		sealed[0], sealed[1], sealed[2], sealed[3],
+		r.index("cst_dob", dobIndex(c.Dob)), r.index("cst_phone_sfx", phoneSuffix(c.PhoneNum)),
+		r.index("cst_phone_e164", c.PhoneE164), r.index("cst_email", emailIndex(c.Email)), c.Dob.Year()}, nil
+}
+
+// scanCustomer scans a row of customerSelect, opening sealed values. Fields
+// that were not selected stay zero.
+func (r *PgUserRepo) scanCustomer(row pgx.Row) (domain.Customer, error) {
+	var (
+		c                    domain.Customer
+		dob                  *time.Time
+		phone, email         *string
+		dobE, phE, e164E, eE []byte
+	)
+	if err := row.Scan(&c.ID, &c.NationalityID, &c.Name, &dob, &phone, &c.PhoneE164, &email, &dobE, &phE, &e164E, &eE); err != nil {
+		return c, err
+	}
+	var err error
+	if c.Dob, err = r.dob("cst_dob", dob, dobE); err != nil {
+		return c, err
+	}
+	if c.PhoneNum, err = r.text("cst_phone", phone, phE); err != nil {
+		return c, err
+	}
+	if e164E != nil {
+		if c.PhoneE164, err = r.open("cst_phone_e164", e164E); err != nil {
+			return c, err
+		}
+	}
+	c.Email, err = r.text("cst_email", email, eE)
+	return c, err
+}
+
+// sealDob returns fl_dob, pii_kid, fl_dob_enc and fl_dob_bidx for a family
+// member's date of birth.
+func (r *PgUserRepo) sealDob(dob time.Time) ([]any, error) {
+	if r.keys == nil {
+		return []any{dob, nil, nil, nil}, nil
+	}
+	sealed, err := r.keys.Seal("fl_dob", []byte(dobIndex(dob)))
+	if err != nil {
+		return nil, err
+	}
+	return []any{nil, r.keys.Primary(), sealed, r.index("fl_dob", dobIndex(dob))}, nil
+}
+
+func (r *PgUserRepo) open(field string, sealed []byte) (string, error) {
+	if sealed == nil {
+		return "", nil
+	}
+	if r.keys == nil {
+		return "", errNoKeyring
+	}
+	b, err := r.keys.Open(field, sealed)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", field, err)
+	}
+	return string(b), nil
+}
+
+func (r *PgUserRepo) openDob(field string, sealed []byte) (time.Time, error) {
+	s, err := r.open(field, sealed)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(dateLayout, s)
+}
+
+// dob returns a date of birth from its plaintext or sealed column, zero when
+// neither was read.
+func (r *PgUserRepo) dob(field string, plain *time.Time, sealed []byte) (time.Time, error) {
+	switch {
+	case sealed != nil:
+		return r.openDob(field, sealed)
+	case plain != nil:
+		return *plain, nil
+	}
+	return time.Time{}, nil
+}
+
+// text is dob for string columns.
+func (r *PgUserRepo) text(field string, plain *string, sealed []byte) (string, error) {
+	if sealed == nil && plain != nil {
+		return *plain, nil
+	}
+	return r.open(field, sealed)
+}
+
+// legacyEmailTaken reports whether a row other than id that is still
+// plaintext holds email; the unique indexes on the two forms cannot see each
+// other.
+func (r *PgUserRepo) legacyEmailTaken(ctx context.Context, tx pgx.Tx, id int32, email string) error {
+	if r.keys == nil {
+		return nil
+	}
+	var taken bool
+	if err := tx.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM customer WHERE pii_kid IS NULL AND lower(cst_email)=$1 AND cst_id<>$2)`,
+		emailIndex(email), id).Scan(&taken); err != nil {
+		return err
+	}
+	if taken {
+		return domain.ErrConflict
+	}
+	return nil
+}
+
+// ReencryptPII rewrites every customer and family member of ctx's tenant
+// that is not sealed with the primary key, batch rows per transaction:
+// plaintext rows are sealed and rows under an older key, or sealed before
+// migration 0014 added cst_birth_year, are sealed again. With decrypt set it writes everything back as plaintext instead, which the
+// down migration requires. Values are unchanged, so no events are written.
+// It returns the number of rows rewritten.
+func (r *PgUserRepo) ReencryptPII(ctx context.Context, batch int, decrypt bool) (int, error) {
+	if r.keys == nil {
+		return 0, errNoKeyring
+	}
+	target := any(r.keys.Primary())
+	if decrypt {
+		target = nil
+	}
+	plain := *r
+	plain.keys = nil
+	w := r
+	if decrypt {
+		w = &plain
+	}
+
+	total := 0
+	for last := int32(0); ; {
+		n := 0
+		err := withTenant(ctx, r.db, pgx.TxOptions{}, func(tx pgx.Tx) error {
+			rows, err := tx.Query(ctx, `SELECT `+customerCols+` FROM customer
+				WHERE (pii_kid IS DISTINCT FROM $1 OR (pii_kid IS NOT NULL AND cst_birth_year IS NULL)) AND cst_id > $2 ORDER BY cst_id LIMIT $3 FOR UPDATE`, target, last, batch)
+			if err != nil {
+				return err
+			}
+			cs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.Customer, error) { return r.scanCustomer(row) })
+			if err != nil {
+				return err
+			}
+			for _, c := range cs {
+				args, err := w.sealCustomer(c)
+				if err != nil {
+					return err
+				}
+				args = append(args, c.ID)
+				if _, err := tx.Exec(ctx, `UPDATE customer SET (`+piiCols+`) = (`+piiParams(1)+`) WHERE cst_id=`+fmt.Sprintf("$%d", len(args)),
+					args...); err != nil {
+					return fmt.Errorf("cst_id=%d: %w", c.ID, mapErr(err))
+				}
+				last = c.ID
+			}
+			n = len(cs)
+			return nil
+		})
+		if err != nil {
+			return total, err
+		}
+		total += n
+		if n < batch {
+			break
+		}
+	}
+
+	for last := int32(0); ; {
+		n := 0
+		err := withTenant(ctx, r.db, pgx.TxOptions{}, func(tx pgx.Tx) error {
+			rows, err := tx.Query(ctx, `SELECT fl_id, fl_dob, fl_dob_enc FROM family_list
+				WHERE pii_kid IS DISTINCT FROM $1 AND fl_id > $2 ORDER BY fl_id LIMIT $3 FOR UPDATE`, target, last, batch)
+			if err != nil {
+				return err
+			}
+			type member struct {
+				id    int32
+				plain *time.Time
+				enc   []byte
+			}
+			ms, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (m member, err error) {
+				err = row.Scan(&m.id, &m.plain, &m.enc)
+				return m, err
+			})
+			if err != nil {
+				return err
+			}
+			for _, m := range ms {
+				dob, err := r.dob("fl_dob", m.plain, m.enc)
+				if err != nil {
+					return fmt.Errorf("fl_id=%d: %w", m.id, err)
+				}
+				args, err := w.sealDob(dob)
+				if err != nil {
+					return err
+				}
+				if _, err := tx.Exec(ctx, `UPDATE family_list SET (fl_dob,pii_kid,fl_dob_enc,fl_dob_bidx) = ($1,$2,$3,$4) WHERE fl_id=$5`,
+					append(args, m.id)...); err != nil {
+					return fmt.Errorf("fl_id=%d: %w", m.id, err)
+				}
+				last = m.id
+			}
+			n = len(ms)
+			return nil
+		})
+		if err != nil {
+			return total, err
+		}
+		total += n
+		if n < batch {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/pkg/keyring"
+	"usrsvc/internal/pkg/tenant"
+)
+
+// testKeyring holds k1 and k2 with primary as the key new values use.
+func testKeyring(t *testing.T, primary string) *keyring.Keyring {
+	t.Helper()
+	k, err := keyring.New(primary, map[string][]byte{
+		"k1": bytes.Repeat([]byte{1}, 32),
+		"k2": bytes.Repeat([]byte{2}, 32),
+	}, bytes.Repeat([]byte{9}, 32))
+	require.NoError(t, err)
+	return k
+}
+
+func TestPgUserRepo_PII(t *testing.T) {
+	dsn := os.Getenv("PG_DSN")
+	if dsn == "" {
+		t.Skip("PG_DSN not set")
+	}
+	pool := newPgSchema(t, dsn)
+	ctx := tenant.With(context.Background(), tenant.Default)
+	plain, k1, k2 := NewPgUserRepo(pool), NewPgUserRepo(pool, WithKeyring(testKeyring(t, "k1"))), NewPgUserRepo(pool, WithKeyring(testKeyring(t, "k2")))
+
+	ns, err := plain.ListNationalities(ctx)
+	require.NoError(t, err)
+	dob := time.Date(1990, 1, 2, 0, 0, 0, 0, time.UTC)
+	c := domain.Customer{NationalityID: ns[0].ID, Name: "ALPHA", Dob: dob, PhoneNum: "0812-3456-7890", PhoneE164: "+6281234567890", Email: "Alpha@X.io",
+		Family: []domain.FamilyMember{{Relation: "child", Name: "BETA", Dob: dob.AddDate(30, 0, 0)}}}
+	legacy, err := plain.CreateCustomer(ctx, c)
+	require.NoError(t, err)
+
+	raw := func(id int32) (kid *string, email *string, famKid *string) {
+		t.Helper()
+		require.NoError(t, pool.QueryRow(ctx, `SELECT c.pii_kid, c.cst_email, f.pii_kid FROM customer c JOIN family_list f USING (cst_id) WHERE c.cst_id=$1`, id).
+			Scan(&kid, &email, &famKid))
+		return
+	}
+	same := func(r *PgUserRepo, id int32) {
+		t.Helper()
+		got, err := r.GetCustomer(ctx, id)
+		require.NoError(t, err)
+		require.NotNil(t, got)
+		assert.Equal(t, c.Email, got.Email)
+		assert.Equal(t, c.PhoneNum, got.PhoneNum)
+		assert.Equal(t, c.PhoneE164, got.PhoneE164)
+		assert.True(t, dob.Equal(got.Dob))
+		require.Len(t, got.Family, 1)
+		assert.True(t, c.Family[0].Dob.Equal(got.Family[0].Dob))
+	}
+	sparse := func(r *PgUserRepo, id int32) {
+		t.Helper()
+		fctx := domain.WithFields(ctx, domain.Fields{"cst_name": true, "cst_email": true, "family.fl_name": true})
+		got, err := r.GetCustomer(fctx, id)
+		require.NoError(t, err)
+		require.NotNil(t, got)
+		assert.Equal(t, c.Name, got.Name)
+		assert.Equal(t, c.Email, got.Email)
+		assert.Empty(t, got.PhoneNum+got.PhoneE164)
+		assert.True(t, got.Dob.IsZero())
+		require.Len(t, got.Family, 1)
+		assert.Equal(t, domain.FamilyMember{ID: got.Family[0].ID, CustomerID: id, Name: "BETA"}, got.Family[0])
+
+		rows, err := r.GetCustomers(domain.WithFields(ctx, domain.Fields{"cst_dob": true}), []int32{id})
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		assert.True(t, dob.Equal(rows[0].Dob))
+		assert.Empty(t, rows[0].Name+rows[0].Email)
+		assert.Nil(t, rows[0].Family)
+	}
+	found := func(r *PgUserRepo, search string, id int32) {
+		t.Helper()
+		rows, _, err := r.ListCustomers(ctx, search, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, rows, 1, search)
+		assert.Equal(t, id, rows[0].ID)
+	}
+
+	t.Run("plaintext_rows_readable_and_unique", func(t *testing.T) {
+		same(k1, legacy)
+		sparse(k1, legacy)
+		found(k1, "alpha@x.io", legacy)
+		dup := c
+		dup.Email = " ALPHA@x.io"
+		_, err := k1.CreateCustomer(ctx, dup)
+		assert.ErrorIs(t, err, domain.ErrConflict)
+	})
+
+	t.Run("new_rows_sealed", func(t *testing.T) {
+		d := c
+		d.Email, d.PhoneE164 = "gamma@x.io", "+6281111111111"
+		id, err := k1.CreateCustomer(ctx, d)
+		require.NoError(t, err)
+		kid, email, famKid := raw(id)
+		assert.Equal(t, "k1", *kid)
+		assert.Nil(t, email)
+		assert.Equal(t, "k1", *famKid)
+		found(k1, "GAMMA@x.io", id)
+		found(k1, "+6281111111111", id)
+		d.Email = "gamma2@x.io"
+		require.NoError(t, k1.UpdateCustomer(ctx, id, d))
+		var payloads string
+		require.NoError(t, pool.QueryRow(ctx, `SELECT string_agg(payload::text, ' ') FROM outbox WHERE customer_id=$1`, id).Scan(&payloads))
+		assert.Contains(t, payloads, `"redacted": true`)
+		for _, v := range []string{"gamma", "0812", "1990-01-02"} {
+			assert.NotContains(t, payloads, v, "events of a sealed row carry no PII")
+		}
+		_, err = k1.CreateCustomer(ctx, d)
+		assert.ErrorIs(t, err, domain.ErrConflict)
+		_, err = plain.GetCustomer(ctx, id)
+		assert.ErrorIs(t, err, errNoKeyring)
+
+		dups, err := k1.DuplicateCandidates(ctx, domain.Customer{Name: "ZED", Dob: dob, PhoneNum: "x"}, 10)
+		require.NoError(t, err)
+		assert.Len(t, dups, 2, "dob blind index and plaintext dob")
+		require.NoError(t, k1.DeleteCustomer(ctx, id))
+	})
+
+	t.Run("reencrypt_and_rotate", func(t *testing.T) {
+		n, err := k1.ReencryptPII(ctx, 1, false)
+		require.NoError(t, err)
+		assert.Equal(t, 2, n, "customer and family member")
+		kid, email, famKid := raw(legacy)
+		assert.Equal(t, "k1", *kid)
+		assert.Nil(t, email)
+		assert.Equal(t, "k1", *famKid)
+		same(k1, legacy)
+		sparse(k1, legacy)
+
+		n, err = k2.ReencryptPII(ctx, 10, false)
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+		kid, _, _ = raw(legacy)
+		assert.Equal(t, "k2", *kid)
+		n, err = k2.ReencryptPII(ctx, 10, false)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+		found(k2, "alpha@x.io", legacy)
+
+		stats, err := k2.CustomerStats(ctx, domain.StatsFilter{Interval: domain.IntervalMonth, From: time.Now(), To: time.Now()})
+		require.NoError(t, err)
+		aged := 0
+		for _, b := range stats.ByAge {
+			aged += b.Count
+		}
+		assert.Equal(t, 1, aged)
+
+		var year *int
+		require.NoError(t, pool.QueryRow(ctx, `UPDATE customer SET cst_birth_year=NULL WHERE cst_id=$1 RETURNING cst_birth_year`, legacy).Scan(&year))
+		stats, err = k2.CustomerStats(ctx, domain.StatsFilter{Interval: domain.IntervalMonth, From: time.Now(), To: time.Now()})
+		require.NoError(t, err)
+		aged = 0
+		for _, b := range stats.ByAge {
+			aged += b.Count
+		}
+		assert.Equal(t, 1, aged, "a row sealed before cst_birth_year is decrypted")
+		n, err = k2.ReencryptPII(ctx, 10, false)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n, "the row without a birth year is sealed again")
+		require.NoError(t, pool.QueryRow(ctx, `SELECT cst_birth_year FROM customer WHERE cst_id=$1`, legacy).Scan(&year))
+		assert.Equal(t, 1990, *year)
+	})
+
+	t.Run("decrypt", func(t *testing.T) {
+		n, err := k2.ReencryptPII(ctx, 10, true)
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+		kid, email, _ := raw(legacy)
+		assert.Nil(t, kid)
+		assert.Equal(t, c.Email, *email)
+		same(plain, legacy)
+
+		stats, err := plain.CustomerStats(ctx, domain.StatsFilter{Interval: domain.IntervalMonth, From: time.Now(), To: time.Now()})
+		require.NoError(t, err)
+		aged := 0
+		for _, b := range stats.ByAge {
+			aged += b.Count
+		}
+		assert.Equal(t, 1, aged, "plaintext dates of birth are bucketed in SQL")
+	})
+}
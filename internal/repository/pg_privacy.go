@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+
+	"usrsvc/internal/domain"
+)
+
+// ExportCustomer reads from the primary so the export includes the latest
+// writes and events.
+func (r *PgUserRepo) ExportCustomer(ctx context.Context, id int32) (*domain.DataExport, error) {
+	var x *domain.DataExport
+	err := withTenant(ctx, r.db, readOnly, func(tx pgx.Tx) error {
+		c, err := r.getCustomer(ctx, tx, id, nil, "")
+		if err != nil || c == nil {
+			return err
+		}
+		x = &domain.DataExport{Customer: *c}
+		if x.MergedIDs, err = mergedInto(ctx, tx, id); err != nil {
+			return err
+		}
+		x.Events, err = queryEvents(ctx, tx,
+			`SELECT id,event_type,tenant_id,customer_id,occurred_at,payload FROM outbox
+			 WHERE tenant_id=app_tenant() AND customer_id=ANY($1) ORDER BY id`, append([]int32{id}, x.MergedIDs...))
+		if x.Events == nil {
+			x.Events = []domain.Event{}
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+func (r *PgUserRepo) AnonymizeCustomer(ctx context.Context, id int32) (*domain.Customer, error) {
+	tx, err := beginTenant(ctx, r.db, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	c, err := r.getCustomer(ctx, tx, id, nil, "FOR UPDATE")
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, domain.ErrNotFound
+	}
+	anon := domain.Anonymize(*c)
+	if err := r.updateCustomer(ctx, tx, id, anon); err != nil {
+		return nil, err
+	}
+	for _, f := range anon.Family {
+		dob, err := r.sealDob(f.Dob)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(ctx, `UPDATE family_list SET fl_name=$1,(fl_dob,pii_kid,fl_dob_enc,fl_dob_bidx) = ($2,$3,$4,$5) WHERE fl_id=$6`,
+			append(append([]any{f.Name}, dob...), f.ID)...); err != nil {
+			return nil, err
+		}
+	}
+
+	merged, err := mergedInto(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	ids := append([]int32{id}, merged...)
+	if _, err := tx.Exec(ctx, `UPDATE webhook_delivery d SET payload=jsonb_set(d.payload,'{payload}',$2::jsonb)
+		FROM outbox o WHERE o.id=d.event_id AND o.tenant_id=app_tenant() AND o.customer_id=ANY($1)`, ids, domain.RedactedPayload); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE outbox SET payload=$2::jsonb WHERE tenant_id=app_tenant() AND customer_id=ANY($1)`, ids, domain.RedactedPayload); err != nil {
+		return nil, err
+	}
+	if err := r.insertEvent(ctx, tx, domain.CustomerAnonymized{CustomerID: id, MergedIDs: merged}); err != nil {
+		return nil, err
+	}
+	if err := commitWrite(ctx, tx); err != nil {
+		return nil, err
+	}
+	return &anon, nil
+}
+
+// mergedInto returns the ids redirected to id, in order.
+func mergedInto(ctx context.Context, q querier, id int32) ([]int32, error) {
+	rows, err := q.Query(ctx, `SELECT old_id FROM customer_redirect WHERE new_id=$1 ORDER BY old_id`, id)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int32])
+	if ids == nil {
+		ids = []int32{}
+	}
+	return ids, err
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/pkg/tenant"
+)
+
+func TestPgUserRepo_Anonymize(t *testing.T) {
+	dsn := os.Getenv("PG_DSN")
+	if dsn == "" {
+		t.Skip("PG_DSN not set")
+	}
+	pool := newPgSchema(t, dsn)
+	ctx := tenant.With(context.Background(), tenant.Default)
+	r := NewPgUserRepo(pool, WithKeyring(testKeyring(t, "k1")))
+
+	ns, err := r.ListNationalities(ctx)
+	require.NoError(t, err)
+	c := domain.Customer{NationalityID: ns[0].ID, Name: "ALPHA", Dob: time.Date(1990, 1, 2, 0, 0, 0, 0, time.UTC), PhoneNum: "0811", Email: "a@x.io",
+		Family: []domain.FamilyMember{{Relation: "child", Name: "BETA", Dob: time.Date(2015, 3, 4, 0, 0, 0, 0, time.UTC)}}}
+	id, err := r.CreateCustomer(ctx, c)
+	require.NoError(t, err)
+	c.Email = "b@x.io"
+	require.NoError(t, r.UpdateCustomer(ctx, id, c))
+
+	var hook int32
+	require.NoError(t, pool.QueryRow(ctx, `INSERT INTO webhook (url,secret,tenant_id) VALUES ('https://h.example','s3cret','default') RETURNING id`).Scan(&hook))
+	_, err = pool.Exec(ctx, `INSERT INTO webhook_delivery (webhook_id,event_id,event_type,payload)
+		SELECT $1,id,event_type,jsonb_build_object('id',id,'payload',payload) FROM outbox WHERE customer_id=$2`, hook, id)
+	require.NoError(t, err)
+
+	x, err := r.ExportCustomer(ctx, id)
+	require.NoError(t, err)
+	require.Len(t, x.Events, 2)
+	assert.Contains(t, string(x.Events[0].Payload), "a@x.io")
+
+	_, err = r.AnonymizeCustomer(ctx, id)
+	require.NoError(t, err)
+	x, err = r.ExportCustomer(ctx, id)
+	require.NoError(t, err)
+	require.Len(t, x.Events, 3)
+	for _, e := range x.Events[:2] {
+		assert.JSONEq(t, domain.RedactedPayload, string(e.Payload), e.Type)
+	}
+	assert.Equal(t, domain.EventCustomerAnonymized, x.Events[2].Type)
+
+	var leaked int
+	require.NoError(t, pool.QueryRow(ctx, `SELECT count(*) FROM webhook_delivery WHERE payload::text LIKE '%x.io%'`).Scan(&leaked))
+	assert.Zero(t, leaked, "webhook deliveries are redacted too")
+	var kid *string
+	require.NoError(t, pool.QueryRow(ctx, `SELECT pii_kid FROM customer WHERE cst_id=$1`, id).Scan(&kid))
+	require.NotNil(t, kid, "the scrubbed values are sealed like any other")
+}
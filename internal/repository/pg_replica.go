@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"usrsvc/internal/pkg/log"
+)
+
+// replicaLag is how far the replica's replayed state trails the primary. An
+// idle primary commits nothing, so a replica that replayed everything it
+// received counts as current however old its last replayed commit is.
+const replicaLag = `SELECT CASE
+	  WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	  ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+	END::float8`
+
+// Replicas routes reads round-robin over the replicas that passed their last
+// lag check. A replica starts unhealthy until Monitor has checked it.
+type Replicas struct {
+	maxLag time.Duration
+	nodes  []*replica
+	next   atomic.Uint32
+}
+
+type replica struct {
+	pool    *pgxpool.Pool
+	name    string
+	healthy atomic.Bool
+}
+
+func NewReplicas(pools []*pgxpool.Pool, maxLag time.Duration) *Replicas {
+	rs := &Replicas{maxLag: maxLag}
+	for _, p := range pools {
+		rs.nodes = append(rs.nodes, &replica{pool: p, name: p.Config().ConnConfig.Host})
+	}
+	return rs
+}
+
+// Monitor checks every replica's lag every interval until ctx is done.
+func (rs *Replicas) Monitor(ctx context.Context, every time.Duration) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		for _, n := range rs.nodes {
+			rs.check(ctx, n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (rs *Replicas) check(ctx context.Context, n *replica) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	var secs float64
+	err := n.pool.QueryRow(ctx, replicaLag).Scan(&secs)
+	lag := time.Duration(secs * float64(time.Second))
+	ok := err == nil && lag <= rs.maxLag
+	if n.healthy.Swap(ok) != ok {
+		log.Info.Printf("replica %s healthy=%t lag=%s err=%v", n.name, ok, lag, err)
+	}
+}
+
+// pick returns the next healthy replica, or nil to use the primary.
+func (rs *Replicas) pick() *replica {
+	for range rs.nodes {
+		n := rs.nodes[int(rs.next.Add(1))%len(rs.nodes)]
+		if n.healthy.Load() {
+			return n
+		}
+	}
+	return nil
+}
+
+// failed takes n out of rotation until its next successful check.
+func (n *replica) failed(op string, err error) {
+	n.healthy.Store(false)
+	log.Error.Printf("%s replica=%s err=%v, retrying on primary", op, n.name, err)
+}
+
+// unavailable reports whether err says the server could not serve the query
+// at all: no connection, a dropped one, or SQLSTATE class 08 (connection) or
+// 57P (shutdown, cannot connect now). Anything else the primary would answer
+// the same way.
+func unavailable(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return strings.HasPrefix(pgErr.Code, "08") || strings.HasPrefix(pgErr.Code, "57P")
+	}
+	var netErr net.Error
+	var connErr *pgconn.ConnectError
+	return pgconn.SafeToRetry(err) || errors.As(err, &netErr) || errors.As(err, &connErr)
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"usrsvc/internal/pkg/db"
+)
+
+func TestPgUserRepo_route(t *testing.T) {
+	ctx := context.Background()
+	pool := func(host string) *pgxpool.Pool {
+		p, err := pgxpool.New(ctx, "postgres://u@"+host+":1/x") // connects lazily
+		require.NoError(t, err)
+		t.Cleanup(p.Close)
+		return p
+	}
+	primary, a, b := pool("primary"), pool("a"), pool("b")
+	rs := NewReplicas([]*pgxpool.Pool{a, b}, time.Second)
+	r := NewPgUserRepo(primary, WithReplicas(rs))
+	down := &pgconn.PgError{Code: "57P01"}
+	fails := func(err error) func(context.Context, *pgxpool.Pool) []*pgxpool.Pool {
+		return func(ctx context.Context, fail *pgxpool.Pool) []*pgxpool.Pool {
+			var got []*pgxpool.Pool
+			_ = r.route(ctx, "test", func(p *pgxpool.Pool) error {
+				got = append(got, p)
+				if p == fail {
+					return err
+				}
+				return nil
+			})
+			return got
+		}
+	}
+	route := fails(down)
+
+	assert.Equal(t, []*pgxpool.Pool{primary}, route(ctx, nil), "replicas start unchecked")
+
+	rs.nodes[0].healthy.Store(true)
+	rs.nodes[1].healthy.Store(true)
+	first, second := route(ctx, nil), route(ctx, nil)
+	assert.ElementsMatch(t, []*pgxpool.Pool{a, b}, append(first, second...), "round robin")
+
+	sctx, _ := db.WithSession(ctx, false)
+	db.MarkWritten(sctx)
+	assert.Equal(t, []*pgxpool.Pool{primary}, route(sctx, nil), "read your writes")
+	assert.Equal(t, []*pgxpool.Pool{primary}, route(db.ReadPrimary(ctx), nil))
+
+	rs.nodes[1].healthy.Store(false)
+	for _, err := range []error{assert.AnError, pgx.ErrNoRows, &pgconn.PgError{Code: "42P01"}} {
+		assert.Equal(t, []*pgxpool.Pool{a}, fails(err)(ctx, a), "query errors are the caller's: %v", err)
+	}
+	assert.True(t, rs.nodes[0].healthy.Load())
+	assert.Equal(t, []*pgxpool.Pool{a, primary}, route(ctx, a), "falls back when unavailable")
+	assert.False(t, rs.nodes[0].healthy.Load(), "a failing replica leaves the rotation")
+	assert.Equal(t, []*pgxpool.Pool{primary}, route(ctx, nil))
+
+	rs.nodes[1].healthy.Store(true)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, []*pgxpool.Pool{b}, route(ctx, nil), "only healthy replicas")
+	}
+}
+
+func Test_unavailable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "connection_failure", err: &pgconn.PgError{Code: "08006"}, want: true},
+		{name: "admin_shutdown", err: &pgconn.PgError{Code: "57P01"}, want: true},
+		{name: "cannot_connect_now", err: &pgconn.PgError{Code: "57P03"}, want: true},
+		{name: "net_error", err: fmt.Errorf("read: %w", &net.OpError{Op: "read", Err: errors.New("connection reset")}), want: true},
+		{name: "undefined_table", err: &pgconn.PgError{Code: "42P01"}},
+		{name: "query_canceled", err: &pgconn.PgError{Code: "57014"}},
+		{name: "no_rows", err: pgx.ErrNoRows},
+		{name: "other", err: assert.AnError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, unavailable(tt.err))
+		})
+	}
+}
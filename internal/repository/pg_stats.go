@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"usrsvc/internal/domain"
+)
+
+// createdSeries counts customers matching cond per interval ($4) from $5 to
+// $6 (dates, UTC), including empty intervals.
+func createdSeries(cond string) string {
+	return `WITH c AS (
+	  SELECT date_trunc($4::text, created_at AT TIME ZONE 'UTC') AS start, COUNT(*) AS n
+	  FROM customer
+	  WHERE created_at >= date_trunc($4::text, $5::timestamp) AT TIME ZONE 'UTC'
+	    AND created_at < ($6::timestamp + interval '1 day') AT TIME ZONE 'UTC'
+	    AND ` + cond + `
+	  GROUP BY 1)
+	SELECT g.start, COALESCE(c.n, 0)
+	FROM generate_series(date_trunc($4::text, $5::timestamp), date_trunc($4::text, $6::timestamp), ('1 ' || $4::text)::interval) AS g(start)
+	LEFT JOIN c USING (start)
+	ORDER BY g.start`
+}
+
+// CustomerStats runs every aggregate in one round trip inside a read-only
+// snapshot, so the numbers agree with each other. Ages are bucketed in SQL:
+// plaintext rows by date of birth, sealed rows by the age their plaintext
+// cst_birth_year reaches this year. Only rows sealed before cst_birth_year
+// existed are opened and bucketed here.
+func (r *PgUserRepo) CustomerStats(ctx context.Context, f domain.StatsFilter) (*domain.CustomerStats, error) {
+	search, cond := r.searchArgs(f.Search), searchCond(ctx)
+	out := &domain.CustomerStats{ByAge: make([]domain.BucketCount, len(domain.AgeBuckets))}
+	for i, ab := range domain.AgeBuckets {
+		out.ByAge[i].Label = ab.Label
+	}
+
+	err := withTenant(ctx, r.db, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly}, func(tx pgx.Tx) error {
+		b := &pgx.Batch{}
+		b.Queue(`SELECT COUNT(*) FROM customer WHERE `+cond, search...).
+			QueryRow(func(row pgx.Row) error { return row.Scan(&out.Total) })
+
+		b.Queue(`SELECT n.nationality_id, COALESCE(o.nationality_name, n.nationality_name), COUNT(*)
+		         FROM customer JOIN nationality n USING (nationality_id)
+		         LEFT JOIN nationality_override o USING (nationality_id)
+		         WHERE `+cond+`
+		         GROUP BY 1, 2 ORDER BY 3 DESC, 1`, search...).
+			Query(func(rows pgx.Rows) error {
+				for rows.Next() {
+					var nc domain.NationalityCount
+					if err := rows.Scan(&nc.NationalityID, &nc.Name, &nc.Count); err != nil {
+						return err
+					}
+					out.ByNationality = append(out.ByNationality, nc)
+				}
+				return rows.Err()
+			})
+
+		b.Queue(`SELECT b, COUNT(*) FROM (
+		           SELECT `+ageBucketExpr+` AS b
+		           FROM (SELECT CASE WHEN cst_dob IS NOT NULL THEN date_part('year', age(current_date, cst_dob))::int
+		                        ELSE date_part('year', current_date)::int - cst_birth_year END AS a
+		                 FROM customer WHERE (cst_dob IS NOT NULL OR cst_birth_year IS NOT NULL) AND `+cond+`) s
+		         ) t WHERE b IS NOT NULL GROUP BY b`, search...).
+			Query(func(rows pgx.Rows) error {
+				for rows.Next() {
+					var i, n int
+					if err := rows.Scan(&i, &n); err != nil {
+						return err
+					}
+					out.ByAge[i].Count += n
+				}
+				return rows.Err()
+			})
+
+		today := truncDay(time.Now())
+		b.Queue(`SELECT cst_dob_enc FROM customer WHERE cst_dob_enc IS NOT NULL AND cst_birth_year IS NULL AND `+cond, search...).
+			Query(func(rows pgx.Rows) error {
+				for rows.Next() {
+					var enc []byte
+					if err := rows.Scan(&enc); err != nil {
+						return err
+					}
+					dob, err := r.openDob("cst_dob", enc)
+					if err != nil {
+						return err
+					}
+					if i := ageBucket(ageOn(dob, today)); i >= 0 {
+						out.ByAge[i].Count++
+					}
+				}
+				return rows.Err()
+			})
+
+		b.Queue(`SELECT members, COUNT(*) FROM (
+		           SELECT (SELECT COUNT(*) FROM family_list f WHERE f.cst_id = customer.cst_id) AS members
+		           FROM customer WHERE `+cond+`
+		         ) s GROUP BY members ORDER BY members`, search...).
+			Query(func(rows pgx.Rows) error {
+				for rows.Next() {
+					var fs domain.FamilySizeCount
+					if err := rows.Scan(&fs.Members, &fs.Count); err != nil {
+						return err
+					}
+					out.FamilySize = append(out.FamilySize, fs)
+				}
+				return rows.Err()
+			})
+
+		b.Queue(createdSeries(cond), append(search, f.Interval, f.From, f.To)...).
+			Query(func(rows pgx.Rows) error {
+				for rows.Next() {
+					var pc domain.PeriodCount
+					if err := rows.Scan(&pc.Start, &pc.Count); err != nil {
+						return err
+					}
+					out.Created = append(out.Created, pc)
+				}
+				return rows.Err()
+			})
+
+		return tx.SendBatch(ctx, b).Close()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RecomputeStats refreshes the planner statistics of the customer tables,
+// which the search, stats and duplicate queries are planned on. Autovacuum
+// can lag behind a bulk import or a reencrypt run.
+func (r *PgUserRepo) RecomputeStats(ctx context.Context) error {
+	_, err := r.db.Exec(ctx, `ANALYZE customer, family_list`)
+	return err
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"usrsvc/internal/pkg/tenant"
+)
+
+var readOnly = pgx.TxOptions{AccessMode: pgx.ReadOnly}
+
+// beginTenant starts a transaction on p with app.tenant_id set for its
+// duration (SET LOCAL); row-level security keeps it to that tenant's rows.
+func beginTenant(ctx context.Context, p *pgxpool.Pool, opts pgx.TxOptions) (pgx.Tx, error) {
+	t, ok := tenant.From(ctx)
+	if !ok {
+		return nil, tenant.ErrMissing
+	}
+	tx, err := p.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `SELECT set_config('app.tenant_id', $1, true)`, t); err != nil {
+		tx.Rollback(ctx)
+		return nil, err
+	}
+	return tx, nil
+}
+
+// withTenant runs fn in a beginTenant transaction and commits if it succeeds.
+func withTenant(ctx context.Context, p *pgxpool.Pool, opts pgx.TxOptions, fn func(tx pgx.Tx) error) error {
+	tx, err := beginTenant(ctx, p, opts)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
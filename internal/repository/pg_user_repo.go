@@ -2,159 +2,420 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"strings"
+	"time"
 
 	"usrsvc/internal/domain"
+	"usrsvc/internal/pkg/db"
+	"usrsvc/internal/pkg/keyring"
 )
 
-type PgUserRepo struct{ db *pgxpool.Pool }
+type PgUserRepo struct {
+	db       *pgxpool.Pool
+	replicas *Replicas
+	keys     *keyring.Keyring
+}
 
-func NewPgUserRepo(db *pgxpool.Pool) *PgUserRepo { return &PgUserRepo{db: db} }
+type PgOption func(*PgUserRepo)
 
-func (r *PgUserRepo) ListCustomers(ctx context.Context, search string, limit, offset int) ([]domain.Customer, int32, error) {
+// WithReplicas sends ListCustomers, GetCustomer and ListNationalities to rs.
+func WithReplicas(rs *Replicas) PgOption { return func(r *PgUserRepo) { r.replicas = rs } }
 
-	q := `SELECT cst_id, nationality_id, cst_name, cst_dob, cst_phoneNum, cst_email
-	      FROM customer WHERE ($1='' OR cst_name ILIKE '%'||$1||'%' OR cst_email ILIKE '%'||$1||'%')
-	      ORDER BY cst_id DESC LIMIT $2 OFFSET $3`
-	rows, err := r.db.Query(ctx, q, strings.TrimSpace(search), limit, offset)
-	if err != nil {
-		return nil, 0, err
+// fragmentSearch matches $1 against name and email fragments, or exactly
+// against the normalized phone when the usecase passes an E.164 number.
+// Sealed rows match the whole email or phone through the blind indexes in $2
+// and $3 (searchArgs). wholeEmailSearch is the same with plaintext emails
+// matched whole too, see domain.WithWholeEmailSearch.
+const (
+	fragmentSearch = `($1='' OR cst_name ILIKE '%'||$1||'%'
+	OR cst_email_bidx=$2 OR cst_phone_e164_bidx=$3
+	OR (pii_kid IS NULL AND (cst_email ILIKE '%'||$1||'%' OR cst_phone_e164=$1)))`
+	wholeEmailSearch = `($1='' OR cst_name ILIKE '%'||$1||'%'
+	OR cst_email_bidx=$2 OR cst_phone_e164_bidx=$3
+	OR (pii_kid IS NULL AND (lower(cst_email)=lower($1) OR cst_phone_e164=$1)))`
+)
+
+// searchCond returns the search condition for ctx's caller.
+func searchCond(ctx context.Context) string {
+	if domain.WholeEmailSearch(ctx) {
+		return wholeEmailSearch
 	}
-	defer rows.Close()
+	return fragmentSearch
+}
 
-	var out []domain.Customer
-	for rows.Next() {
-		var c domain.Customer
-		if err := rows.Scan(&c.ID, &c.NationalityID, &c.Name, &c.Dob, &c.PhoneNum, &c.Email); err != nil {
-			return nil, 0, err
+func (r *PgUserRepo) searchArgs(search string) []any {
+	search = strings.TrimSpace(search)
+	return []any{search, r.index("cst_email", emailIndex(search)), r.index("cst_phone_e164", search)}
+}
+
+func NewPgUserRepo(pool *pgxpool.Pool, opts ...PgOption) *PgUserRepo {
+	r := &PgUserRepo{db: pool}
+	for _, o := range opts {
+		o(r)
+	}
+	return r
+}
+
+func (r *PgUserRepo) ListCustomers(ctx context.Context, search string, limit, offset int) ([]domain.Customer, int32, error) {
+	var (
+		out   []domain.Customer
+		total int32
+	)
+	err := r.read(ctx, "list_customers", func(q querier) error {
+		args := r.searchArgs(search)
+		rows, err := q.Query(ctx, `SELECT `+customerSelect(domain.FieldsFrom(ctx))+`
+		      FROM customer WHERE `+searchCond(ctx)+`
+		      ORDER BY cst_id DESC LIMIT $4 OFFSET $5`, append(args, limit, offset)...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		out = nil
+		for rows.Next() {
+			c, err := r.scanCustomer(rows)
+			if err != nil {
+				return err
+			}
+			out = append(out, c)
+		}
+		if err := rows.Err(); err != nil {
+			return err
 		}
-		out = append(out, c)
+		return q.QueryRow(ctx, `SELECT COUNT(*) FROM customer WHERE `+searchCond(ctx), args...).Scan(&total)
+	})
+	if err != nil {
+		return nil, 0, err
 	}
-	var total int32
-	_ = r.db.QueryRow(ctx, `SELECT COUNT(*) FROM customer WHERE ($1='' OR cst_name ILIKE '%'||$1||'%' OR cst_email ILIKE '%'||$1||'%')`, strings.TrimSpace(search)).Scan(&total)
 	return out, total, nil
 }
 
 func (r *PgUserRepo) GetCustomer(ctx context.Context, id int32) (*domain.Customer, error) {
+	var c *domain.Customer
+	err := r.read(ctx, "get_customer", func(q querier) (err error) {
+		c, err = r.getCustomer(ctx, q, id, domain.FieldsFrom(ctx), "")
+		return err
+	})
+	return c, err
+}
+
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
 
-	var c domain.Customer
-	err := r.db.QueryRow(ctx, `SELECT cst_id,nationality_id,cst_name,cst_dob,cst_phoneNum,cst_email FROM customer WHERE cst_id=$1`, id).
-		Scan(&c.ID, &c.NationalityID, &c.Name, &c.Dob, &c.PhoneNum, &c.Email)
+// getCustomer loads the f fields of a customer with family through q, all of
+// them for a nil f; lock is appended to the customer select (e.g. "FOR
+// UPDATE" inside a write transaction).
+func (r *PgUserRepo) getCustomer(ctx context.Context, q querier, id int32, f domain.Fields, lock string) (*domain.Customer, error) {
+	c, err := r.scanCustomer(q.QueryRow(ctx, `SELECT `+customerSelect(f)+` FROM customer WHERE cst_id=$1 `+lock, id))
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
+	if !f.Family() {
+		return &c, nil
+	}
+
+	fams, err := r.families(ctx, q, []int32{id}, f)
+	c.Family = fams[id]
+	return &c, err
+}
+
+func (r *PgUserRepo) GetCustomers(ctx context.Context, ids []int32) ([]domain.Customer, error) {
+	var out []domain.Customer
+	err := r.read(ctx, "get_customers", func(q querier) error {
+		rows, err := q.Query(ctx, `SELECT `+customerSelect(domain.FieldsFrom(ctx))+` FROM customer WHERE cst_id = ANY($1)`, ids)
+		if err != nil {
+			return err
+		}
+		out, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.Customer, error) { return r.scanCustomer(row) })
+		return err
+	})
+	return out, err
+}
+
+func (r *PgUserRepo) Families(ctx context.Context, customerIDs []int32) (map[int32][]domain.FamilyMember, error) {
+	var out map[int32][]domain.FamilyMember
+	err := r.read(ctx, "families", func(q querier) (err error) {
+		out, err = r.families(ctx, q, customerIDs, domain.FieldsFrom(ctx))
+		return err
+	})
+	return out, err
+}
 
-	rows, err := r.db.Query(ctx, `SELECT fl_id,cst_id,fl_relation,fl_name,fl_dob FROM family_list WHERE cst_id=$1 ORDER BY fl_id`, id)
+// familyColumns are customerColumns for family_list, after fl_id and cst_id.
+var familyColumns = []struct{ field, col, none string }{
+	{"family.fl_relation", "fl_relation", "''"},
+	{"family.fl_name", "fl_name", "''"},
+	{"family.fl_dob", "fl_dob", "NULL::date"},
+	{"family.fl_dob", "fl_dob_enc", "NULL::bytea"},
+}
+
+func (r *PgUserRepo) families(ctx context.Context, q querier, ids []int32, f domain.Fields) (map[int32][]domain.FamilyMember, error) {
+	cols := []string{"fl_id", "cst_id"}
+	for _, c := range familyColumns {
+		if f.Has(c.field) {
+			cols = append(cols, c.col)
+		} else {
+			cols = append(cols, c.none)
+		}
+	}
+	rows, err := q.Query(ctx, `SELECT `+strings.Join(cols, ",")+` FROM family_list WHERE cst_id = ANY($1) ORDER BY fl_id`, ids)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
+	out := map[int32][]domain.FamilyMember{}
 	for rows.Next() {
-		var f domain.FamilyMember
-		if err := rows.Scan(&f.ID, &f.CustomerID, &f.Relation, &f.Name, &f.Dob); err != nil {
+		var (
+			f     domain.FamilyMember
+			plain *time.Time
+			enc   []byte
+		)
+		if err := rows.Scan(&f.ID, &f.CustomerID, &f.Relation, &f.Name, &plain, &enc); err != nil {
 			return nil, err
 		}
-		c.Family = append(c.Family, f)
+		if f.Dob, err = r.dob("fl_dob", plain, enc); err != nil {
+			return nil, err
+		}
+		out[f.CustomerID] = append(out[f.CustomerID], f)
 	}
-	return &c, nil
+	return out, rows.Err()
 }
 
 func (r *PgUserRepo) CreateCustomer(ctx context.Context, c domain.Customer) (int32, error) {
-
-	tx, err := r.db.Begin(ctx)
+	tx, err := beginTenant(ctx, r.db, pgx.TxOptions{})
 	if err != nil {
 		return 0, err
 	}
 	defer tx.Rollback(ctx)
 
+	if err := r.legacyEmailTaken(ctx, tx, 0, c.Email); err != nil {
+		return 0, err
+	}
+	pii, err := r.sealCustomer(c)
+	if err != nil {
+		return 0, err
+	}
 	var id int32
 	if err := tx.QueryRow(ctx,
-		`INSERT INTO customer (nationality_id,cst_name,cst_dob,cst_phoneNum,cst_email)
-		 VALUES ($1,$2,$3,$4,$5) RETURNING cst_id`,
-		c.NationalityID, c.Name, c.Dob, c.PhoneNum, c.Email,
+		`INSERT INTO customer (nationality_id,cst_name,`+piiCols+`)
+		 VALUES ($1,$2,`+piiParams(3)+`) RETURNING cst_id`,
+		append([]any{c.NationalityID, c.Name}, pii...)...,
 	).Scan(&id); err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23505" {
-			return 0, domain.ErrConflict
-		}
+		return 0, mapErr(err)
+	}
+	if err := r.insertFamily(ctx, tx, id, c.Family); err != nil {
 		return 0, err
 	}
 
-	for _, f := range c.Family {
-		if _, err := tx.Exec(ctx,
-			`INSERT INTO family_list (cst_id,fl_relation,fl_name,fl_dob)
-			 VALUES ($1,$2,$3,$4)`,
-			id, f.Relation, f.Name, f.Dob,
-		); err != nil {
-			return 0, err
-		}
+	c.ID = id
+	c.Family = append([]domain.FamilyMember(nil), c.Family...)
+	for i := range c.Family {
+		c.Family[i].CustomerID = id
 	}
-
-	if err := tx.Commit(ctx); err != nil {
+	if err := r.insertEvent(ctx, tx, domain.CustomerCreated{Customer: c}); err != nil {
+		return 0, err
+	}
+	if err := commitWrite(ctx, tx); err != nil {
 		return 0, err
 	}
 	return id, nil
 }
 
 func (r *PgUserRepo) UpdateCustomer(ctx context.Context, id int32, c domain.Customer) error {
-
-	tx, err := r.db.Begin(ctx)
+	tx, err := beginTenant(ctx, r.db, pgx.TxOptions{})
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback(ctx)
 
-	if _, err := tx.Exec(ctx,
-		`UPDATE customer SET nationality_id=$1,cst_name=$2,cst_dob=$3,cst_phoneNum=$4,cst_email=$5 WHERE cst_id=$6`,
-		c.NationalityID, c.Name, c.Dob, c.PhoneNum, c.Email, id); err != nil {
+	old, err := r.getCustomer(ctx, tx, id, nil, "FOR UPDATE")
+	if err != nil {
+		return err
+	}
+	if old == nil {
+		return domain.ErrNotFound
+	}
+	if err := r.updateCustomer(ctx, tx, id, c); err != nil {
 		return err
 	}
 
 	if _, err := tx.Exec(ctx, `DELETE FROM family_list WHERE cst_id=$1`, id); err != nil {
 		return err
 	}
-	for _, f := range c.Family {
-		if _, err := tx.Exec(ctx,
-			`INSERT INTO family_list (cst_id,fl_relation,fl_name,fl_dob) VALUES ($1,$2,$3,$4)`,
-			id, f.Relation, f.Name, f.Dob); err != nil {
+	if err := r.insertFamily(ctx, tx, id, c.Family); err != nil {
+		return err
+	}
+	if changes := domain.DiffCustomer(*old, c); len(changes) > 0 {
+		if err := r.insertEvent(ctx, tx, domain.CustomerUpdated{CustomerID: id, Changes: changes}); err != nil {
 			return err
 		}
 	}
+	return commitWrite(ctx, tx)
+}
+
+// updateCustomer overwrites the customer row of id with c, leaving family.
+func (r *PgUserRepo) updateCustomer(ctx context.Context, tx pgx.Tx, id int32, c domain.Customer) error {
+	if err := r.legacyEmailTaken(ctx, tx, id, c.Email); err != nil {
+		return err
+	}
+	pii, err := r.sealCustomer(c)
+	if err != nil {
+		return err
+	}
+	args := append(append([]any{c.NationalityID, c.Name}, pii...), id)
+	_, err = tx.Exec(ctx, `UPDATE customer SET nationality_id=$1,cst_name=$2,(`+piiCols+`) = (`+piiParams(3)+`) WHERE cst_id=`+fmt.Sprintf("$%d", len(args)),
+		args...)
+	return mapErr(err)
+}
+
+func (r *PgUserRepo) insertFamily(ctx context.Context, tx pgx.Tx, id int32, family []domain.FamilyMember) error {
+	for _, f := range family {
+		dob, err := r.sealDob(f.Dob)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO family_list (cst_id,fl_relation,fl_name,fl_dob,pii_kid,fl_dob_enc,fl_dob_bidx)
+			 VALUES ($1,$2,$3,$4,$5,$6,$7)`,
+			append([]any{id, f.Relation, f.Name}, dob...)...); err != nil {
+			return mapErr(err)
+		}
+	}
 	return nil
 }
 
 func (r *PgUserRepo) DeleteCustomer(ctx context.Context, id int32) error {
-	tag, err := r.db.Exec(ctx, `DELETE FROM customer WHERE cst_id=$1`, id)
+	tx, err := beginTenant(ctx, r.db, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `DELETE FROM customer WHERE cst_id=$1`, id)
 	if err != nil {
 		return err
 	}
 	if tag.RowsAffected() == 0 {
 		return domain.ErrNotFound
 	}
-	return nil
+	if err := r.insertEvent(ctx, tx, domain.CustomerDeleted{CustomerID: id}); err != nil {
+		return err
+	}
+	return commitWrite(ctx, tx)
 }
 
 func (r *PgUserRepo) ListNationalities(ctx context.Context) ([]domain.Nationality, error) {
-	rows, err := r.db.Query(context.Background(),
-		`SELECT nationality_id,nationality_name,nationality_code FROM nationality ORDER BY nationality_name`)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
 	var out []domain.Nationality
-	for rows.Next() {
-		var n domain.Nationality
-		if err := rows.Scan(&n.ID, &n.Name, &n.Code); err != nil {
-			return nil, err
+	err := r.read(ctx, "list_nationalities", func(q querier) error {
+		rows, err := q.Query(ctx,
+			`SELECT n.nationality_id, COALESCE(o.nationality_name, n.nationality_name) AS name, n.nationality_code
+			 FROM nationality n LEFT JOIN nationality_override o USING (nationality_id)
+			 ORDER BY name`)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		out = nil
+		for rows.Next() {
+			var n domain.Nationality
+			if err := rows.Scan(&n.ID, &n.Name, &n.Code); err != nil {
+				return err
+			}
+			out = append(out, n)
+		}
+		return rows.Err()
+	})
+	return out, err
+}
+
+func (r *PgUserRepo) GetNationality(ctx context.Context, id int32) (*domain.Nationality, error) {
+	var n *domain.Nationality
+	err := r.read(ctx, "get_nationality", func(q querier) error {
+		var v domain.Nationality
+		err := q.QueryRow(ctx,
+			`SELECT n.nationality_id, COALESCE(o.nationality_name, n.nationality_name), n.nationality_code
+			 FROM nationality n LEFT JOIN nationality_override o USING (nationality_id)
+			 WHERE n.nationality_id=$1`, id).Scan(&v.ID, &v.Name, &v.Code)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
 		}
-		out = append(out, n)
+		n = &v
+		return err
+	})
+	return n, err
+}
+
+// read runs fn in a read-only tenant transaction on a healthy replica unless
+// ctx is pinned to the primary, and on the primary when there is none or the
+// replica is unavailable.
+func (r *PgUserRepo) read(ctx context.Context, op string, fn func(q querier) error) error {
+	return r.route(ctx, op, func(p *pgxpool.Pool) error {
+		return withTenant(ctx, p, readOnly, func(tx pgx.Tx) error { return fn(tx) })
+	})
+}
+
+// commitWrite commits a customer write and then pins the rest of ctx's
+// session to the primary; a write that rolled back leaves it on the replicas.
+func commitWrite(ctx context.Context, tx pgx.Tx) error {
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	db.MarkWritten(ctx)
+	return nil
+}
+
+func (r *PgUserRepo) route(ctx context.Context, op string, fn func(p *pgxpool.Pool) error) error {
+	if r.replicas != nil && !db.PrimaryOnly(ctx) {
+		if n := r.replicas.pick(); n != nil {
+			err := fn(n.pool)
+			if err == nil || ctx.Err() != nil || !unavailable(err) {
+				return err
+			}
+			n.failed(op, err)
+		}
+	}
+	return fn(r.db)
+}
+
+// insertEvent writes ev to the outbox. With a keyring the PII it would
+// carry stays out, see domain.RedactPII.
+func (r *PgUserRepo) insertEvent(ctx context.Context, tx pgx.Tx, ev domain.DomainEvent) error {
+	if r.keys != nil {
+		ev = domain.RedactPII(ev)
+	}
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `INSERT INTO outbox (event_type,customer_id,payload) VALUES ($1,$2,$3)`,
+		ev.EventType(), ev.AggregateID(), payload)
+	return err
+}
+
+// mapErr translates Postgres errors into domain errors; every write path goes
+// through it so create and update report conflicts the same way.
+func mapErr(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+	switch {
+	case pgErr.Code == "23505":
+		return domain.ErrConflict
+	case pgErr.Code == "23503" && strings.Contains(pgErr.ConstraintName, "nationality"):
+		return domain.UnknownNationality()
 	}
-	return out, nil
+	return err
 }
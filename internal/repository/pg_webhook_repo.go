@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/pkg/tenant"
+	"usrsvc/internal/webhooks"
+)
+
+// PgWebhookRepo stores subscriptions and their delivery queue. It serves both
+// domain.WebhookRepository, scoped to the context's tenant, and webhooks.Store,
+// which works across tenants; a subscription only gets its tenant's events.
+type PgWebhookRepo struct{ db *pgxpool.Pool }
+
+func NewPgWebhookRepo(db *pgxpool.Pool) *PgWebhookRepo { return &PgWebhookRepo{db: db} }
+
+const webhookCols = `id,url,event_types,secret,active,created_at`
+
+func scanWebhook(row pgx.Row) (domain.Webhook, error) {
+	var w domain.Webhook
+	err := row.Scan(&w.ID, &w.URL, &w.EventTypes, &w.Secret, &w.Active, &w.CreatedAt)
+	return w, err
+}
+
+func (r *PgWebhookRepo) CreateWebhook(ctx context.Context, w domain.Webhook) (int32, error) {
+	if w.EventTypes == nil {
+		w.EventTypes = []string{}
+	}
+	t, ok := tenant.From(ctx)
+	if !ok {
+		return 0, tenant.ErrMissing
+	}
+	var id int32
+	err := r.db.QueryRow(ctx,
+		`INSERT INTO webhook (url,event_types,secret,active,created_at,tenant_id) VALUES ($1,$2,$3,$4,$5,$6) RETURNING id`,
+		w.URL, w.EventTypes, w.Secret, w.Active, w.CreatedAt, t).Scan(&id)
+	return id, err
+}
+
+func (r *PgWebhookRepo) ListWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	t, ok := tenant.From(ctx)
+	if !ok {
+		return nil, tenant.ErrMissing
+	}
+	rows, err := r.db.Query(ctx, `SELECT `+webhookCols+` FROM webhook WHERE tenant_id=$1 ORDER BY id`, t)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []domain.Webhook
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, w)
+	}
+	return out, rows.Err()
+}
+
+func (r *PgWebhookRepo) GetWebhook(ctx context.Context, id int32) (*domain.Webhook, error) {
+	t, ok := tenant.From(ctx)
+	if !ok {
+		return nil, tenant.ErrMissing
+	}
+	w, err := scanWebhook(r.db.QueryRow(ctx, `SELECT `+webhookCols+` FROM webhook WHERE id=$1 AND tenant_id=$2`, id, t))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+func (r *PgWebhookRepo) DeleteWebhook(ctx context.Context, id int32) error {
+	t, ok := tenant.From(ctx)
+	if !ok {
+		return tenant.ErrMissing
+	}
+	tag, err := r.db.Exec(ctx, `DELETE FROM webhook WHERE id=$1 AND tenant_id=$2`, id, t)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *PgWebhookRepo) ListDeliveries(ctx context.Context, webhookID int32, limit int) ([]domain.Delivery, error) {
+	t, ok := tenant.From(ctx)
+	if !ok {
+		return nil, tenant.ErrMissing
+	}
+	rows, err := r.db.Query(ctx,
+		`SELECT d.id,d.webhook_id,d.event_id,d.event_type,d.payload,d.status,d.attempts,d.next_attempt_at,d.created_at
+		 FROM webhook_delivery d JOIN webhook w ON w.id = d.webhook_id
+		 WHERE d.webhook_id=$1 AND w.tenant_id=$3 ORDER BY d.id DESC LIMIT $2`, webhookID, limit, t)
+	if err != nil {
+		return nil, err
+	}
+	var out []domain.Delivery
+	idx := map[int64]int{}
+	var ids []int64
+	for rows.Next() {
+		var d domain.Delivery
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &d.Payload, &d.Status, &d.Attempts, &d.NextAttemptAt, &d.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		idx[d.ID] = len(out)
+		ids = append(ids, d.ID)
+		out = append(out, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(ids) == 0 {
+		return out, err
+	}
+
+	rows, err = r.db.Query(ctx,
+		`SELECT delivery_id,attempted_at,COALESCE(status_code,0),error,duration_ms
+		 FROM webhook_attempt WHERE delivery_id = ANY($1) ORDER BY id`, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			id int64
+			a  domain.DeliveryAttempt
+			ms int64
+		)
+		if err := rows.Scan(&id, &a.At, &a.StatusCode, &a.Error, &ms); err != nil {
+			return nil, err
+		}
+		a.Duration = time.Duration(ms) * time.Millisecond
+		out[idx[id]].Log = append(out[idx[id]].Log, a)
+	}
+	return out, rows.Err()
+}
+
+func (r *PgWebhookRepo) Redeliver(ctx context.Context, webhookID int32, deliveryID int64, at time.Time) error {
+	t, ok := tenant.From(ctx)
+	if !ok {
+		return tenant.ErrMissing
+	}
+	tag, err := r.db.Exec(ctx,
+		`UPDATE webhook_delivery SET status='pending', attempts=0, next_attempt_at=$3
+		 WHERE id=$1 AND webhook_id=$2 AND webhook_id IN (SELECT id FROM webhook WHERE tenant_id=$4)`, deliveryID, webhookID, at, t)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *PgWebhookRepo) Enqueue(ctx context.Context, e domain.Event) error {
+	// Receivers get the outbox envelope exactly as it was published.
+	env, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(ctx,
+		`INSERT INTO webhook_delivery (webhook_id,event_id,event_type,payload)
+		 SELECT id,$1,$2,$3 FROM webhook
+		 WHERE active AND tenant_id=$4 AND (cardinality(event_types)=0 OR $2 = ANY(event_types))
+		 ON CONFLICT (webhook_id,event_id) DO NOTHING`, e.ID, e.Type, env, e.Tenant)
+	return err
+}
+
+func (r *PgWebhookRepo) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]webhooks.Job, error) {
+	rows, err := r.db.Query(ctx,
+		`UPDATE webhook_delivery d SET next_attempt_at = now() + make_interval(secs => $2)
+		 FROM webhook w
+		 WHERE w.id = d.webhook_id AND d.id IN (
+		   SELECT id FROM webhook_delivery
+		   WHERE status='pending' AND next_attempt_at <= now()
+		   ORDER BY next_attempt_at LIMIT $1 FOR UPDATE SKIP LOCKED)
+		 RETURNING d.id,d.webhook_id,d.event_id,d.event_type,d.payload,d.attempts,w.url,w.secret`,
+		limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []webhooks.Job
+	for rows.Next() {
+		var j webhooks.Job
+		d := &j.Delivery
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &d.Payload, &d.Attempts, &j.URL, &j.Secret); err != nil {
+			return nil, err
+		}
+		d.Status = domain.DeliveryPending
+		out = append(out, j)
+	}
+	return out, rows.Err()
+}
+
+func (r *PgWebhookRepo) RecordAttempt(ctx context.Context, deliveryID int64, a domain.DeliveryAttempt, status string, next time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var code *int
+	if a.StatusCode != 0 {
+		code = &a.StatusCode
+	}
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO webhook_attempt (delivery_id,attempted_at,status_code,error,duration_ms) VALUES ($1,$2,$3,$4,$5)`,
+		deliveryID, a.At, code, a.Error, a.Duration.Milliseconds()); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx,
+		`UPDATE webhook_delivery SET status=$2, attempts=attempts+1, next_attempt_at=$3 WHERE id=$1`,
+		deliveryID, status, next); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// PurgeDeliveries deletes succeeded deliveries created before before, with
+// their attempt logs. Pending and dead deliveries are kept.
+func (r *PgWebhookRepo) PurgeDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM webhook_delivery WHERE status='succeeded' AND created_at < $1`, before)
+	return tag.RowsAffected(), err
+}
+
+// RetryDeadDeliveries queues dead deliveries created after since, across
+// tenants, for another full attempt budget. A receiver that was down for
+// longer than the backoff covers gets its events once it is back; since
+// bounds how often one delivery is retried this way.
+func (r *PgWebhookRepo) RetryDeadDeliveries(ctx context.Context, since time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx,
+		`UPDATE webhook_delivery d SET status='pending', attempts=0, next_attempt_at=now()
+		 FROM webhook w
+		 WHERE w.id=d.webhook_id AND w.active AND d.status='dead' AND d.created_at >= $1`, since)
+	return tag.RowsAffected(), err
+}
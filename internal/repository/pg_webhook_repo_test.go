@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/pkg/tenant"
+)
+
+func TestPgWebhookRepo_RetryDeadDeliveries(t *testing.T) {
+	dsn := os.Getenv("PG_DSN")
+	if dsn == "" {
+		t.Skip("PG_DSN not set")
+	}
+	pool := newPgSchema(t, dsn)
+	ctx := tenant.With(context.Background(), tenant.Default)
+	r := NewPgWebhookRepo(pool)
+	active, err := r.CreateWebhook(ctx, domain.Webhook{URL: "https://a.example", Secret: "s", Active: true, CreatedAt: time.Now()})
+	require.NoError(t, err)
+	inactive, err := r.CreateWebhook(ctx, domain.Webhook{URL: "https://b.example", Secret: "s", CreatedAt: time.Now()})
+	require.NoError(t, err)
+
+	insert := func(webhookID int32, event int64, status string, age time.Duration) {
+		t.Helper()
+		_, err := pool.Exec(ctx, `INSERT INTO webhook_delivery (webhook_id,event_id,event_type,payload,status,attempts,created_at)
+			VALUES ($1,$2,'customer.updated','{}',$3,8,$4)`, webhookID, event, status, time.Now().Add(-age))
+		require.NoError(t, err)
+	}
+	insert(active, 1, "dead", time.Hour)
+	insert(active, 2, "dead", 48*time.Hour)
+	insert(active, 3, "succeeded", time.Hour)
+	insert(inactive, 4, "dead", time.Hour)
+
+	n, err := r.RetryDeadDeliveries(ctx, time.Now().Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	var status string
+	var attempts int
+	require.NoError(t, pool.QueryRow(ctx, `SELECT status, attempts FROM webhook_delivery WHERE event_id=1`).Scan(&status, &attempts))
+	assert.Equal(t, "pending", status)
+	assert.Zero(t, attempts)
+
+	require.NoError(t, NewPgUserRepo(pool).RecomputeStats(ctx))
+}
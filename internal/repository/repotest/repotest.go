@@ -0,0 +1,453 @@
+// Package repotest is a conformance suite for domain.UserRepository. A backend
+// passes when it behaves like PgUserRepo as far as the usecase can observe.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/pkg/tenant"
+)
+
+// Option adjusts the suite to what a backend cannot do.
+type Option func(*options)
+
+type options struct{ sealed bool }
+
+// SealedPII is for backends that encrypt emails at rest: they find a
+// customer by the whole email only, not by a fragment of it.
+func SealedPII() Option { return func(o *options) { o.sealed = true } }
+
+// UserRepository runs the suite. newRepo must return an empty repository
+// (no customers) with at least two nationalities. It runs as tenant.Default,
+// and as another tenant that must not see its rows.
+func UserRepository(t *testing.T, newRepo func(t *testing.T) domain.UserRepository, opts ...Option) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	ctx := tenant.With(context.Background(), tenant.Default)
+	dob := time.Date(1992, 5, 10, 0, 0, 0, 0, time.UTC)
+	spouse := domain.FamilyMember{Relation: "spouse", Name: "BETA", Dob: time.Date(1993, 7, 1, 0, 0, 0, 0, time.UTC)}
+	child := domain.FamilyMember{Relation: "child", Name: "CACA", Dob: time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC)}
+
+	setup := func(t *testing.T) (domain.UserRepository, []domain.Nationality, func(name, email string, family ...domain.FamilyMember) domain.Customer) {
+		r := newRepo(t)
+		ns, err := r.ListNationalities(ctx)
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, len(ns), 2, "newRepo must seed nationalities")
+		_, total, err := r.ListCustomers(ctx, "", 1, 0)
+		require.NoError(t, err)
+		require.Zero(t, total, "newRepo must start empty")
+		cust := func(name, email string, family ...domain.FamilyMember) domain.Customer {
+			return domain.Customer{NationalityID: ns[0].ID, Name: name, Dob: dob, PhoneNum: "0811", PhoneE164: "+62811" + name[:1], Email: email, Family: family}
+		}
+		return r, ns, cust
+	}
+	ids := func(cs []domain.Customer) []int32 {
+		out := []int32{}
+		for _, c := range cs {
+			out = append(out, c.ID)
+		}
+		return out
+	}
+
+	t.Run("nationalities_by_name", func(t *testing.T) {
+		_, ns, _ := setup(t)
+		for i := 1; i < len(ns); i++ {
+			assert.LessOrEqual(t, ns[i-1].Name, ns[i].Name)
+		}
+	})
+
+	t.Run("get_nationality", func(t *testing.T) {
+		r, ns, _ := setup(t)
+		got, err := r.GetNationality(ctx, ns[1].ID)
+		require.NoError(t, err)
+		assert.Equal(t, &ns[1], got)
+		got, err = r.GetNationality(ctx, 9999)
+		require.NoError(t, err)
+		assert.Nil(t, got)
+	})
+
+	t.Run("create_and_get", func(t *testing.T) {
+		r, ns, cust := setup(t)
+		c := cust("ALFA", "a@x.com", spouse, child)
+		c.NationalityID = ns[1].ID
+		id, err := r.CreateCustomer(ctx, c)
+		require.NoError(t, err)
+		assert.NotZero(t, id)
+
+		got, err := r.GetCustomer(ctx, id)
+		require.NoError(t, err)
+		require.NotNil(t, got)
+		assert.Equal(t, id, got.ID)
+		assert.Equal(t, ns[1].ID, got.NationalityID)
+		assert.Equal(t, []string{"ALFA", "0811", "+62811A", "a@x.com"}, []string{got.Name, got.PhoneNum, got.PhoneE164, got.Email})
+		assert.True(t, dob.Equal(got.Dob), "dob %s", got.Dob)
+
+		require.Len(t, got.Family, 2, "family in insertion order")
+		assert.Equal(t, []string{"spouse", "child"}, []string{got.Family[0].Relation, got.Family[1].Relation})
+		assert.Equal(t, "CACA", got.Family[1].Name)
+		assert.True(t, child.Dob.Equal(got.Family[1].Dob))
+		for _, f := range got.Family {
+			assert.NotZero(t, f.ID)
+			assert.Equal(t, id, f.CustomerID)
+		}
+		assert.NotEqual(t, got.Family[0].ID, got.Family[1].ID)
+
+		noPhone := cust("BRAVO", "b@x.com")
+		noPhone.PhoneE164 = ""
+		id2, err := r.CreateCustomer(ctx, noPhone)
+		require.NoError(t, err)
+		assert.Greater(t, id2, id, "ids increase")
+		got, _ = r.GetCustomer(ctx, id2)
+		assert.Equal(t, "", got.PhoneE164)
+		assert.Empty(t, got.Family)
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		r, _, cust := setup(t)
+		got, err := r.GetCustomer(ctx, 4242)
+		assert.NoError(t, err, "a missing customer is not an error")
+		assert.Nil(t, got)
+		assert.ErrorIs(t, r.UpdateCustomer(ctx, 4242, cust("X", "x@x.com")), domain.ErrNotFound)
+		assert.ErrorIs(t, r.DeleteCustomer(ctx, 4242), domain.ErrNotFound)
+		assert.ErrorIs(t, r.MergeCustomers(ctx, 4242, 4243, cust("X", "x@x.com")), domain.ErrNotFound)
+		to, err := r.Redirect(ctx, 4242)
+		assert.NoError(t, err)
+		assert.Zero(t, to)
+
+		id, err := r.CreateCustomer(ctx, cust("ALFA", "a@x.com"))
+		require.NoError(t, err)
+		require.NoError(t, r.DeleteCustomer(ctx, id))
+		assert.ErrorIs(t, r.DeleteCustomer(ctx, id), domain.ErrNotFound, "second delete")
+		assert.ErrorIs(t, r.UpdateCustomer(ctx, id, cust("ALFA", "a@x.com")), domain.ErrNotFound, "update after delete")
+	})
+
+	t.Run("email_conflict", func(t *testing.T) {
+		r, _, cust := setup(t)
+		id, err := r.CreateCustomer(ctx, cust("ALFA", "a@x.com", spouse))
+		require.NoError(t, err)
+		other, err := r.CreateCustomer(ctx, cust("BRAVO", "b@x.com"))
+		require.NoError(t, err)
+
+		for _, email := range []string{"a@x.com", "A@X.COM"} {
+			_, err := r.CreateCustomer(ctx, cust("X", email, child))
+			assert.ErrorIs(t, err, domain.ErrConflict, email)
+		}
+		_, total, _ := r.ListCustomers(ctx, "", 10, 0)
+		assert.Equal(t, int32(2), total, "a conflicting create leaves nothing behind")
+
+		assert.ErrorIs(t, r.UpdateCustomer(ctx, other, cust("BRAVO", "a@X.com", child)), domain.ErrConflict)
+		got, _ := r.GetCustomer(ctx, other)
+		assert.Equal(t, "b@x.com", got.Email)
+		assert.Empty(t, got.Family, "a conflicting update changes nothing")
+
+		assert.NoError(t, r.UpdateCustomer(ctx, id, cust("ALFA", "A@x.com", spouse)), "own email in another case")
+
+	})
+
+	t.Run("unknown_nationality", func(t *testing.T) {
+		r, _, cust := setup(t)
+		id, err := r.CreateCustomer(ctx, cust("ALFA", "a@x.com"))
+		require.NoError(t, err)
+
+		bad := cust("X", "x@x.com", child)
+		bad.NationalityID = 4242
+		_, err = r.CreateCustomer(ctx, bad)
+		var re *domain.RuleError
+		require.ErrorAs(t, err, &re, "create")
+		assert.Equal(t, []domain.Violation{{Field: "nationality_id", Rule: domain.RuleUnknownNationality}}, re.Violations)
+		_, total, _ := r.ListCustomers(ctx, "", 10, 0)
+		assert.Equal(t, int32(1), total, "a failed create leaves nothing behind")
+
+		bad.Email = "a@x.com"
+		require.ErrorAs(t, r.UpdateCustomer(ctx, id, bad), &re, "update")
+		assert.Equal(t, "nationality_id", re.Violations[0].Field)
+		got, _ := r.GetCustomer(ctx, id)
+		assert.Equal(t, "ALFA", got.Name, "a failed update changes nothing")
+	})
+
+	t.Run("family_replace_and_cascade", func(t *testing.T) {
+		r, ns, cust := setup(t)
+		id, err := r.CreateCustomer(ctx, cust("ALFA", "a@x.com", spouse))
+		require.NoError(t, err)
+		before, _ := r.GetCustomer(ctx, id)
+
+		upd := cust("ALFA B", "a2@x.com", child, spouse)
+		upd.NationalityID, upd.Dob = ns[1].ID, dob.AddDate(1, 0, 0)
+		require.NoError(t, r.UpdateCustomer(ctx, id, upd))
+		got, _ := r.GetCustomer(ctx, id)
+		assert.Equal(t, "ALFA B", got.Name)
+		assert.Equal(t, ns[1].ID, got.NationalityID)
+		assert.True(t, upd.Dob.Equal(got.Dob))
+		require.Len(t, got.Family, 2, "update replaces the family")
+		assert.Equal(t, []string{"child", "spouse"}, []string{got.Family[0].Relation, got.Family[1].Relation})
+		assert.NotEqual(t, before.Family[0].ID, got.Family[1].ID, "members are re-inserted")
+
+		require.NoError(t, r.UpdateCustomer(ctx, id, cust("ALFA B", "a2@x.com")))
+		got, _ = r.GetCustomer(ctx, id)
+		assert.Empty(t, got.Family)
+
+		require.NoError(t, r.UpdateCustomer(ctx, id, cust("ALFA B", "a2@x.com", spouse, child)))
+		require.NoError(t, r.DeleteCustomer(ctx, id), "family goes with the customer")
+		got, err = r.GetCustomer(ctx, id)
+		assert.NoError(t, err)
+		assert.Nil(t, got)
+	})
+
+	t.Run("search", func(t *testing.T) {
+		r, _, cust := setup(t)
+		byName := map[string]int32{}
+		for _, n := range []string{"ALFA", "Albert", "BRAVO", "CALVIN"} {
+			id, err := r.CreateCustomer(ctx, cust(n, n+"@example.com"))
+			require.NoError(t, err)
+			byName[n] = id
+		}
+		emailFragment := []string{"BRAVO"}
+		if o.sealed {
+			emailFragment = []string{}
+		}
+		tests := []struct {
+			search string
+			want   []string
+		}{
+			{"", []string{"CALVIN", "BRAVO", "Albert", "ALFA"}},
+			{"  ", []string{"CALVIN", "BRAVO", "Albert", "ALFA"}},
+			{"al", []string{"CALVIN", "Albert", "ALFA"}},
+			{"ALB", []string{"Albert"}},
+			{"  bravo ", []string{"BRAVO"}},
+			{" bravo@EXAMPLE.com", []string{"BRAVO"}},
+			{"BRAVO@EXAMPLE", emailFragment},
+			{"+62811C", []string{"CALVIN"}},
+			{"+62811", []string{}},
+			{"zulu", []string{}},
+		}
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%q", tt.search), func(t *testing.T) {
+				want := []int32{}
+				for _, n := range tt.want {
+					want = append(want, byName[n])
+				}
+				rows, total, err := r.ListCustomers(ctx, tt.search, 10, 0)
+				require.NoError(t, err)
+				assert.Equal(t, want, ids(rows))
+				assert.Equal(t, int32(len(want)), total)
+				for _, c := range rows {
+					assert.Empty(t, c.Family, "lists do not load families")
+				}
+			})
+		}
+	})
+
+	t.Run("whole_email_search", func(t *testing.T) {
+		r, _, cust := setup(t)
+		id, err := r.CreateCustomer(ctx, cust("ALFA", "zed@example.com"))
+		require.NoError(t, err)
+		wctx := domain.WithWholeEmailSearch(ctx)
+		for search, want := range map[string][]int32{
+			" ZED@example.COM": {id},
+			"zed@example":      {},
+			"zed":              {},
+			"%@example.com":    {},
+			"alf":              {id},
+		} {
+			rows, total, err := r.ListCustomers(wctx, search, 10, 0)
+			require.NoError(t, err)
+			assert.Equal(t, want, ids(rows), search)
+			assert.Equal(t, int32(len(want)), total, search)
+			st, err := r.CustomerStats(wctx, domain.StatsFilter{Search: search, Interval: domain.IntervalDay, From: time.Now(), To: time.Now()})
+			require.NoError(t, err)
+			assert.Equal(t, len(want), st.Total, search)
+		}
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		r, _, cust := setup(t)
+		var created []int32
+		for i := 0; i < 7; i++ {
+			id, err := r.CreateCustomer(ctx, cust("ALFA", fmt.Sprintf("%d@x.com", i)))
+			require.NoError(t, err)
+			created = append([]int32{id}, created...)
+		}
+		var seen []int32
+		for offset := 0; offset < 9; offset += 3 {
+			rows, total, err := r.ListCustomers(ctx, "", 3, offset)
+			require.NoError(t, err)
+			assert.Equal(t, int32(7), total, "total ignores paging")
+			seen = append(seen, ids(rows)...)
+		}
+		assert.Equal(t, created, seen, "newest first, pages neither overlap nor skip")
+
+		rows, total, err := r.ListCustomers(ctx, "", 3, 20)
+		require.NoError(t, err)
+		assert.Empty(t, rows)
+		assert.Equal(t, int32(7), total)
+	})
+
+	t.Run("get_customers_and_families", func(t *testing.T) {
+		r, _, cust := setup(t)
+		a, err := r.CreateCustomer(ctx, cust("ALFA", "a@x.com", spouse, child))
+		require.NoError(t, err)
+		b, err := r.CreateCustomer(ctx, cust("BRAVO", "b@x.com"))
+		require.NoError(t, err)
+
+		got, err := r.GetCustomers(ctx, []int32{b, a + b + 100, a})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []int32{a, b}, ids(got), "unknown ids are left out")
+		for _, c := range got {
+			assert.Empty(t, c.Family)
+			if c.ID == a {
+				assert.Equal(t, "a@x.com", c.Email)
+				assert.True(t, dob.Equal(c.Dob))
+			}
+		}
+		got, err = r.GetCustomers(ctx, []int32{})
+		require.NoError(t, err)
+		assert.Empty(t, got)
+
+		fams, err := r.Families(ctx, []int32{a, b})
+		require.NoError(t, err)
+		require.Len(t, fams[a], 2)
+		assert.Empty(t, fams[b])
+		for i, want := range []domain.FamilyMember{spouse, child} {
+			f := fams[a][i]
+			assert.Equal(t, a, f.CustomerID)
+			assert.Equal(t, want.Relation, f.Relation)
+			assert.Equal(t, want.Name, f.Name)
+			assert.True(t, want.Dob.Equal(f.Dob))
+		}
+	})
+
+	t.Run("duplicate_candidates_strongest_first", func(t *testing.T) {
+		r, ns, _ := setup(t)
+		c := domain.Customer{ID: -1, NationalityID: ns[0].ID, Name: "Budi Santoso", Dob: dob, PhoneNum: "0812-3456-7890"}
+		add := func(name, email string, dob time.Time, phone string) int32 {
+			id, err := r.CreateCustomer(ctx, domain.Customer{NationalityID: ns[0].ID, Name: name, Dob: dob, PhoneNum: phone, Email: email})
+			require.NoError(t, err)
+			return id
+		}
+		other := time.Date(1970, 1, 20, 0, 0, 0, 0, time.UTC)
+		prefix := add("BUDIMAN", "p@x.com", other, "0000")
+		first := add("budi hartono", "f@x.com", other, "0000")
+		swapped := add("XENA", "s@x.com", time.Date(1992, 10, 5, 0, 0, 0, 0, time.UTC), "0000")
+		phone := add("YUSUF", "ph@x.com", other, "+62 812 3456 7890")
+		exact := add("B. SANTOSO", "e@x.com", dob, "62-812-3456-7890")
+		add("ZAINAL", "z@x.com", other, "0000")
+
+		got, err := r.DuplicateCandidates(ctx, c, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []int32{exact, phone, swapped, first, prefix}, ids(got))
+		got, err = r.DuplicateCandidates(ctx, c, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []int32{exact, phone}, ids(got), "the limit drops the weakest matches")
+	})
+
+	t.Run("tenant_isolation", func(t *testing.T) {
+		r, _, cust := setup(t)
+		id, err := r.CreateCustomer(ctx, cust("ALFA", "a@x.com"))
+		require.NoError(t, err)
+		acme := tenant.With(context.Background(), "acme")
+
+		// Backends without isolation refuse other tenants outright.
+		got, err := r.GetCustomer(acme, id)
+		if errors.Is(err, tenant.ErrUnsupported) {
+			_, _, err = r.ListCustomers(acme, "", 10, 0)
+			assert.ErrorIs(t, err, tenant.ErrUnsupported)
+			_, err = r.CreateCustomer(acme, cust("BRAVO", "b@x.com"))
+			assert.ErrorIs(t, err, tenant.ErrUnsupported)
+			assert.ErrorIs(t, r.DeleteCustomer(acme, id), tenant.ErrUnsupported)
+		} else {
+			require.NoError(t, err)
+			assert.Nil(t, got)
+			rows, total, err := r.ListCustomers(acme, "", 10, 0)
+			require.NoError(t, err)
+			assert.Zero(t, total, "%v", ids(rows))
+			_, err = r.CreateCustomer(acme, cust("ALFA", "a@x.com"))
+			require.NoError(t, err, "emails are unique per tenant")
+			assert.ErrorIs(t, r.DeleteCustomer(acme, id), domain.ErrNotFound)
+		}
+		kept, err := r.GetCustomer(ctx, id)
+		require.NoError(t, err)
+		require.NotNil(t, kept)
+		assert.Equal(t, "ALFA", kept.Name)
+	})
+
+	t.Run("merge_and_redirect", func(t *testing.T) {
+		r, _, cust := setup(t)
+		target, _ := r.CreateCustomer(ctx, cust("ALFA", "a@x.com", spouse))
+		source, _ := r.CreateCustomer(ctx, cust("ALFA S", "s@x.com", domain.FamilyMember{Relation: "Spouse", Name: "beta", Dob: spouse.Dob}, child))
+		older, _ := r.CreateCustomer(ctx, cust("OLD", "o@x.com"))
+		require.NoError(t, r.MergeCustomers(ctx, source, older, cust("ALFA S", "s@x.com")))
+
+		require.NoError(t, r.MergeCustomers(ctx, target, source, cust("ALFA", "s@x.com")))
+		got, _ := r.GetCustomer(ctx, target)
+		assert.Equal(t, "s@x.com", got.Email, "the target takes the source's email")
+		require.Len(t, got.Family, 2, "duplicate spouse stays behind")
+		assert.Equal(t, "CACA", got.Family[1].Name)
+		gone, _ := r.GetCustomer(ctx, source)
+		assert.Nil(t, gone)
+		for _, id := range []int32{source, older} {
+			to, err := r.Redirect(ctx, id)
+			require.NoError(t, err)
+			assert.Equal(t, target, to, "redirect of %d", id)
+		}
+
+		require.NoError(t, r.DeleteCustomer(ctx, target))
+		to, err := r.Redirect(ctx, source)
+		require.NoError(t, err)
+		assert.Zero(t, to, "redirects go with their target")
+	})
+
+	t.Run("export_and_anonymize", func(t *testing.T) {
+		r, _, cust := setup(t)
+		target, _ := r.CreateCustomer(ctx, cust("ALFA", "a@x.com", spouse, child))
+		source, _ := r.CreateCustomer(ctx, cust("ALFA S", "s@x.com"))
+		other, _ := r.CreateCustomer(ctx, cust("OTHER", "o@x.com"))
+		require.NoError(t, r.MergeCustomers(ctx, target, source, cust("ALFA", "a@x.com", spouse, child)))
+
+		x, err := r.ExportCustomer(ctx, target)
+		require.NoError(t, err)
+		require.NotNil(t, x)
+		assert.Equal(t, "a@x.com", x.Customer.Email)
+		assert.Len(t, x.Customer.Family, 2)
+		assert.Equal(t, []int32{source}, x.MergedIDs)
+		assert.NotNil(t, x.Events)
+		x, err = r.ExportCustomer(ctx, 999999)
+		require.NoError(t, err)
+		assert.Nil(t, x)
+
+		before, _ := r.GetCustomer(ctx, target)
+		anon, err := r.AnonymizeCustomer(ctx, target)
+		require.NoError(t, err)
+		got, _ := r.GetCustomer(ctx, target)
+		require.NotNil(t, got)
+		assert.Equal(t, domain.Anonymize(*before).Email, got.Email)
+		assert.Equal(t, anon.Email, got.Email)
+		assert.Equal(t, domain.AnonymizedName, got.Name)
+		assert.Empty(t, got.PhoneNum)
+		assert.True(t, time.Date(1992, 1, 1, 0, 0, 0, 0, time.UTC).Equal(got.Dob), "the birth year stays for statistics: %s", got.Dob)
+		require.Len(t, got.Family, 2)
+		assert.Equal(t, "spouse", got.Family[0].Relation)
+		assert.Equal(t, domain.AnonymizedName, got.Family[0].Name)
+		assert.Equal(t, before.Family[0].ID, got.Family[0].ID, "family rows are kept")
+
+		rows, total, err := r.ListCustomers(ctx, "a@x.com", 10, 0)
+		require.NoError(t, err)
+		assert.Zero(t, total, "the old email no longer finds it: %v", ids(rows))
+		kept, _ := r.GetCustomer(ctx, other)
+		assert.Equal(t, "OTHER", kept.Name)
+		to, _ := r.Redirect(ctx, source)
+		assert.Equal(t, target, to)
+
+		_, err = r.AnonymizeCustomer(ctx, 999999)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+}
@@ -0,0 +1,637 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"usrsvc/internal/domain"
+	"usrsvc/migrations"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	// sqliteTimeLayout matches strftime('%Y-%m-%dT%H:%M:%fZ') so created_at
+	// sorts and compares as text.
+	sqliteTimeLayout = "2006-01-02T15:04:05.000Z"
+)
+
+// sqliteBusyTimeout is how long a connection waits for another writer before
+// failing with SQLITE_BUSY.
+const sqliteBusyTimeout = 5 * time.Second
+
+// SqliteUserRepo is the single-node domain.UserRepository. Errors follow
+// PgUserRepo: ErrConflict on a taken email, ErrNotFound on missing rows,
+// nil customer from GetCustomer. Like MemoryUserRepo it writes no outbox and
+// serves tenant.Default only.
+type SqliteUserRepo struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+func NewSqliteUserRepo(db *sql.DB) *SqliteUserRepo { return &SqliteUserRepo{db: db, now: time.Now} }
+
+// OpenSQLite opens (creating if needed) the database at path in WAL mode with
+// foreign keys on, and applies the embedded schema. Write transactions start
+// with BEGIN IMMEDIATE so concurrent writers queue on the busy timeout instead
+// of failing when they upgrade their lock.
+func OpenSQLite(ctx context.Context, path string) (*sql.DB, error) {
+	dsn := "file:" + path + "?_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)&_pragma=foreign_keys(1)" +
+		"&_pragma=busy_timeout(" + strconv.Itoa(int(sqliteBusyTimeout.Milliseconds())) + ")&_txlock=immediate"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrateSQLite(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// migrateSQLite applies migrations/sqlite/NNNN_*.sql past PRAGMA user_version.
+func migrateSQLite(ctx context.Context, db *sql.DB) error {
+	names, err := fs.Glob(migrations.SQLite, "sqlite/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+	var version int
+	if err := db.QueryRowContext(ctx, `PRAGMA user_version`).Scan(&version); err != nil {
+		return err
+	}
+	for i, name := range names {
+		if i < version {
+			continue
+		}
+		b, err := migrations.SQLite.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, string(b)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if _, err := tx.ExecContext(ctx, `PRAGMA user_version = `+strconv.Itoa(i+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ageBucketExpr maps the age column a to its index in domain.AgeBuckets;
+// future dates of birth fall through to NULL.
+var ageBucketExpr = func() string {
+	var b strings.Builder
+	b.WriteString("CASE")
+	for i, ab := range domain.AgeBuckets {
+		if ab.Max == 0 {
+			fmt.Fprintf(&b, " WHEN a >= %d THEN %d", ab.Min, i)
+		} else {
+			fmt.Fprintf(&b, " WHEN a BETWEEN %d AND %d THEN %d", ab.Min, ab.Max, i)
+		}
+	}
+	b.WriteString(" END")
+	return b.String()
+}()
+
+// sqliteSearchCond is searchCond for SQLite, where every row is plaintext.
+func sqliteSearchCond(ctx context.Context) string {
+	if domain.WholeEmailSearch(ctx) {
+		return `(?1='' OR cst_name LIKE '%'||?1||'%' OR lower(cst_email)=lower(?1) OR cst_phone_e164=?1)`
+	}
+	return `(?1='' OR cst_name LIKE '%'||?1||'%' OR cst_email LIKE '%'||?1||'%' OR cst_phone_e164=?1)`
+}
+
+const sqliteCustomerCols = `cst_id,nationality_id,cst_name,cst_dob,cst_phoneNum,COALESCE(cst_phone_e164,''),cst_email`
+
+type sqliteQuerier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func (r *SqliteUserRepo) ListCustomers(ctx context.Context, search string, limit, offset int) ([]domain.Customer, int32, error) {
+	if err := defaultTenantOnly(ctx); err != nil {
+		return nil, 0, err
+	}
+	search = strings.TrimSpace(search)
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+sqliteCustomerCols+` FROM customer WHERE `+sqliteSearchCond(ctx)+`
+		 ORDER BY cst_id DESC LIMIT ?2 OFFSET ?3`, search, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	out, err := scanSqliteCustomers(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	var total int32
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM customer WHERE `+sqliteSearchCond(ctx), search).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	return out, total, nil
+}
+
+func (r *SqliteUserRepo) GetCustomer(ctx context.Context, id int32) (*domain.Customer, error) {
+	if err := defaultTenantOnly(ctx); err != nil {
+		return nil, err
+	}
+	return sqliteGetCustomer(ctx, r.db, id)
+}
+
+func sqliteGetCustomer(ctx context.Context, q sqliteQuerier, id int32) (*domain.Customer, error) {
+	rows, err := q.QueryContext(ctx, `SELECT `+sqliteCustomerCols+` FROM customer WHERE cst_id=?`, id)
+	if err != nil {
+		return nil, err
+	}
+	cs, err := scanSqliteCustomers(rows)
+	if err != nil || len(cs) == 0 {
+		return nil, err
+	}
+	c := cs[0]
+	fams, err := sqliteFamilies(ctx, q, []int32{id})
+	c.Family = fams[id]
+	return &c, err
+}
+
+// sqliteIDs binds ids as one JSON array parameter, read with json_each, so
+// the statement is the same whatever the number of ids.
+func sqliteIDs(ids []int32) string {
+	b, _ := json.Marshal(ids)
+	return string(b)
+}
+
+func (r *SqliteUserRepo) GetCustomers(ctx context.Context, ids []int32) ([]domain.Customer, error) {
+	if err := defaultTenantOnly(ctx); err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+sqliteCustomerCols+` FROM customer WHERE cst_id IN (SELECT value FROM json_each(?))`, sqliteIDs(ids))
+	if err != nil {
+		return nil, err
+	}
+	return scanSqliteCustomers(rows)
+}
+
+func (r *SqliteUserRepo) Families(ctx context.Context, customerIDs []int32) (map[int32][]domain.FamilyMember, error) {
+	if err := defaultTenantOnly(ctx); err != nil {
+		return nil, err
+	}
+	return sqliteFamilies(ctx, r.db, customerIDs)
+}
+
+func sqliteFamilies(ctx context.Context, q sqliteQuerier, ids []int32) (map[int32][]domain.FamilyMember, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT fl_id,cst_id,fl_relation,fl_name,fl_dob FROM family_list
+		 WHERE cst_id IN (SELECT value FROM json_each(?)) ORDER BY fl_id`, sqliteIDs(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[int32][]domain.FamilyMember{}
+	for rows.Next() {
+		var f domain.FamilyMember
+		var dob string
+		if err := rows.Scan(&f.ID, &f.CustomerID, &f.Relation, &f.Name, &dob); err != nil {
+			return nil, err
+		}
+		if f.Dob, err = time.Parse(dateLayout, dob); err != nil {
+			return nil, err
+		}
+		out[f.CustomerID] = append(out[f.CustomerID], f)
+	}
+	return out, rows.Err()
+}
+
+func (r *SqliteUserRepo) CreateCustomer(ctx context.Context, c domain.Customer) (int32, error) {
+	if err := defaultTenantOnly(ctx); err != nil {
+		return 0, err
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int32
+	if err := tx.QueryRowContext(ctx,
+		`INSERT INTO customer (nationality_id,cst_name,cst_dob,cst_phoneNum,cst_phone_e164,cst_email,created_at)
+		 VALUES (?,?,?,?,NULLIF(?,''),?,?) RETURNING cst_id`,
+		c.NationalityID, c.Name, c.Dob.Format(dateLayout), c.PhoneNum, c.PhoneE164, c.Email,
+		r.now().UTC().Format(sqliteTimeLayout),
+	).Scan(&id); err != nil {
+		return 0, mapSqliteErr(err)
+	}
+	if err := insertSqliteFamily(ctx, tx, id, c.Family); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+func (r *SqliteUserRepo) UpdateCustomer(ctx context.Context, id int32, c domain.Customer) error {
+	if err := defaultTenantOnly(ctx); err != nil {
+		return err
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE customer SET nationality_id=?,cst_name=?,cst_dob=?,cst_phoneNum=?,cst_phone_e164=NULLIF(?,''),cst_email=? WHERE cst_id=?`,
+		c.NationalityID, c.Name, c.Dob.Format(dateLayout), c.PhoneNum, c.PhoneE164, c.Email, id)
+	if err != nil {
+		return mapSqliteErr(err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrNotFound
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM family_list WHERE cst_id=?`, id); err != nil {
+		return err
+	}
+	if err := insertSqliteFamily(ctx, tx, id, c.Family); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *SqliteUserRepo) DeleteCustomer(ctx context.Context, id int32) error {
+	if err := defaultTenantOnly(ctx); err != nil {
+		return err
+	}
+	res, err := r.db.ExecContext(ctx, `DELETE FROM customer WHERE cst_id=?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *SqliteUserRepo) ListNationalities(ctx context.Context) ([]domain.Nationality, error) {
+	if err := defaultTenantOnly(ctx); err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT nationality_id,nationality_name,nationality_code FROM nationality ORDER BY nationality_name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []domain.Nationality
+	for rows.Next() {
+		var n domain.Nationality
+		if err := rows.Scan(&n.ID, &n.Name, &n.Code); err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+	}
+	return out, rows.Err()
+}
+
+func (r *SqliteUserRepo) GetNationality(ctx context.Context, id int32) (*domain.Nationality, error) {
+	if err := defaultTenantOnly(ctx); err != nil {
+		return nil, err
+	}
+	var n domain.Nationality
+	err := r.db.QueryRowContext(ctx,
+		`SELECT nationality_id,nationality_name,nationality_code FROM nationality WHERE nationality_id=?`, id).
+		Scan(&n.ID, &n.Name, &n.Code)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+func (r *SqliteUserRepo) DuplicateCandidates(ctx context.Context, c domain.Customer, limit int) ([]domain.Customer, error) {
+	if err := defaultTenantOnly(ctx); err != nil {
+		return nil, err
+	}
+	swapped := c.Dob
+	if d := c.Dob.Day(); d <= 12 {
+		swapped = time.Date(c.Dob.Year(), time.Month(d), int(c.Dob.Month()), 0, 0, 0, 0, time.UTC)
+	}
+	name := strings.ToLower(strings.TrimSpace(c.Name))
+	first, _, _ := strings.Cut(name, " ")
+
+	// SQLite has no regexp_replace; strip the separators people actually type.
+	// The weights are those of blockingStrength.
+	const (
+		phone = `(?4 <> '' AND substr(replace(replace(replace(replace(replace(replace(cst_phoneNum,' ',''),'-',''),'+',''),'(',''),')',''),'.',''),-7) = ?4)`
+		pre   = `lower(substr(trim(cst_name),1,3)) = ?5`
+		word  = `lower(CASE WHEN instr(trim(cst_name),' ') > 0 THEN substr(trim(cst_name),1,instr(trim(cst_name),' ')-1) ELSE trim(cst_name) END) = ?6`
+	)
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+sqliteCustomerCols+` FROM customer
+		 WHERE cst_id <> ?1 AND (cst_dob IN (?2,?3) OR `+phone+` OR `+pre+` OR `+word+`)
+		 ORDER BY
+		   CASE WHEN cst_dob = ?2 THEN 8 ELSE 0 END + CASE WHEN `+phone+` THEN 8 ELSE 0 END
+		   + CASE WHEN cst_dob = ?3 THEN 4 ELSE 0 END + CASE WHEN `+word+` THEN 2 ELSE 0 END
+		   + CASE WHEN `+pre+` THEN 1 ELSE 0 END DESC,
+		   cst_id
+		 LIMIT ?7`,
+		c.ID, c.Dob.Format(dateLayout), swapped.Format(dateLayout), phoneSuffix(c.PhoneNum), prefix3(name), first, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanSqliteCustomers(rows)
+}
+
+func (r *SqliteUserRepo) MergeCustomers(ctx context.Context, targetID, sourceID int32, merged domain.Customer) error {
+	if err := defaultTenantOnly(ctx); err != nil {
+		return err
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var found int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM customer WHERE cst_id IN (?,?)`, targetID, sourceID).Scan(&found); err != nil {
+		return err
+	}
+	if found != 2 {
+		return domain.ErrNotFound
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE family_list SET cst_id=?1
+		 WHERE cst_id=?2 AND NOT EXISTS (
+		   SELECT 1 FROM family_list t WHERE t.cst_id=?1
+		   AND lower(trim(t.fl_relation))=lower(trim(family_list.fl_relation))
+		   AND lower(trim(t.fl_name))=lower(trim(family_list.fl_name)) AND t.fl_dob=family_list.fl_dob)`,
+		targetID, sourceID); err != nil {
+		return err
+	}
+	for _, stmt := range []string{
+		`UPDATE customer_redirect SET new_id=?1 WHERE new_id=?2`,
+		`DELETE FROM customer WHERE cst_id=?2`,
+		`INSERT INTO customer_redirect (old_id,new_id) VALUES (?2,?1)`,
+	} {
+		if _, err := tx.ExecContext(ctx, stmt, targetID, sourceID); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE customer SET nationality_id=?,cst_name=?,cst_dob=?,cst_phoneNum=?,cst_phone_e164=NULLIF(?,''),cst_email=? WHERE cst_id=?`,
+		merged.NationalityID, merged.Name, merged.Dob.Format(dateLayout), merged.PhoneNum, merged.PhoneE164, merged.Email, targetID); err != nil {
+		return mapSqliteErr(err)
+	}
+	return tx.Commit()
+}
+
+func (r *SqliteUserRepo) Redirect(ctx context.Context, id int32) (int32, error) {
+	if err := defaultTenantOnly(ctx); err != nil {
+		return 0, err
+	}
+	var to int32
+	err := r.db.QueryRowContext(ctx, `SELECT new_id FROM customer_redirect WHERE old_id=?`, id).Scan(&to)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return to, err
+}
+
+// ExportCustomer has no events to return: SQLite storage keeps none.
+func (r *SqliteUserRepo) ExportCustomer(ctx context.Context, id int32) (*domain.DataExport, error) {
+	if err := defaultTenantOnly(ctx); err != nil {
+		return nil, err
+	}
+	c, err := sqliteGetCustomer(ctx, r.db, id)
+	if err != nil || c == nil {
+		return nil, err
+	}
+	x := &domain.DataExport{Customer: *c, MergedIDs: []int32{}, Events: []domain.Event{}}
+	rows, err := r.db.QueryContext(ctx, `SELECT old_id FROM customer_redirect WHERE new_id=? ORDER BY old_id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var from int32
+		if err := rows.Scan(&from); err != nil {
+			return nil, err
+		}
+		x.MergedIDs = append(x.MergedIDs, from)
+	}
+	return x, rows.Err()
+}
+
+func (r *SqliteUserRepo) AnonymizeCustomer(ctx context.Context, id int32) (*domain.Customer, error) {
+	if err := defaultTenantOnly(ctx); err != nil {
+		return nil, err
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	c, err := sqliteGetCustomer(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, domain.ErrNotFound
+	}
+	anon := domain.Anonymize(*c)
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE customer SET cst_name=?,cst_dob=?,cst_phoneNum=?,cst_phone_e164=NULLIF(?,''),cst_email=? WHERE cst_id=?`,
+		anon.Name, anon.Dob.Format(dateLayout), anon.PhoneNum, anon.PhoneE164, anon.Email, id); err != nil {
+		return nil, mapSqliteErr(err)
+	}
+	for _, f := range anon.Family {
+		if _, err := tx.ExecContext(ctx, `UPDATE family_list SET fl_name=?,fl_dob=? WHERE fl_id=?`, f.Name, f.Dob.Format(dateLayout), f.ID); err != nil {
+			return nil, err
+		}
+	}
+	return &anon, tx.Commit()
+}
+
+// sqlitePeriod truncates created_at like date_trunc: weeks start on Monday.
+var sqlitePeriod = map[string]string{
+	domain.IntervalDay:   `date(created_at)`,
+	domain.IntervalWeek:  `date(created_at, '-' || ((CAST(strftime('%w', created_at) AS INTEGER) + 6) % 7) || ' days')`,
+	domain.IntervalMonth: `strftime('%Y-%m-01', created_at)`,
+}
+
+// CustomerStats aggregates in SQLite inside one read transaction; only the
+// empty intervals of the created series are filled in Go.
+func (r *SqliteUserRepo) CustomerStats(ctx context.Context, f domain.StatsFilter) (*domain.CustomerStats, error) {
+	if err := defaultTenantOnly(ctx); err != nil {
+		return nil, err
+	}
+	period, ok := sqlitePeriod[f.Interval]
+	if !ok {
+		return nil, fmt.Errorf("sqlite stats: unknown interval %q", f.Interval)
+	}
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	search := strings.TrimSpace(f.Search)
+	out := &domain.CustomerStats{ByAge: make([]domain.BucketCount, len(domain.AgeBuckets))}
+	for i, ab := range domain.AgeBuckets {
+		out.ByAge[i].Label = ab.Label
+	}
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM customer WHERE `+sqliteSearchCond(ctx), search).Scan(&out.Total); err != nil {
+		return nil, err
+	}
+
+	each := func(query string, args []any, scan func(*sql.Rows) error) error {
+		rows, err := tx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			if err := scan(rows); err != nil {
+				return err
+			}
+		}
+		return rows.Err()
+	}
+
+	if err := each(`SELECT n.nationality_id, n.nationality_name, COUNT(*)
+		 FROM customer JOIN nationality n USING (nationality_id)
+		 WHERE `+sqliteSearchCond(ctx)+` GROUP BY 1, 2 ORDER BY 3 DESC, 1`, []any{search},
+		func(rows *sql.Rows) error {
+			var nc domain.NationalityCount
+			if err := rows.Scan(&nc.NationalityID, &nc.Name, &nc.Count); err != nil {
+				return err
+			}
+			out.ByNationality = append(out.ByNationality, nc)
+			return nil
+		}); err != nil {
+		return nil, err
+	}
+
+	today := r.now().UTC().Format(dateLayout)
+	if err := each(`SELECT `+ageBucketExpr+` AS b, COUNT(*) FROM (
+		   SELECT (CAST(strftime('%Y', ?2) AS INTEGER) - CAST(strftime('%Y', cst_dob) AS INTEGER))
+		          - (strftime('%m-%d', ?2) < strftime('%m-%d', cst_dob)) AS a
+		   FROM customer WHERE `+sqliteSearchCond(ctx)+`)
+		 GROUP BY b`, []any{search, today},
+		func(rows *sql.Rows) error {
+			var i sql.NullInt64
+			var n int
+			if err := rows.Scan(&i, &n); err != nil {
+				return err
+			}
+			if i.Valid {
+				out.ByAge[i.Int64].Count = n
+			}
+			return nil
+		}); err != nil {
+		return nil, err
+	}
+
+	if err := each(`SELECT (SELECT COUNT(*) FROM family_list f WHERE f.cst_id = customer.cst_id) AS members, COUNT(*)
+		 FROM customer WHERE `+sqliteSearchCond(ctx)+` GROUP BY members ORDER BY members`, []any{search},
+		func(rows *sql.Rows) error {
+			var fs domain.FamilySizeCount
+			if err := rows.Scan(&fs.Members, &fs.Count); err != nil {
+				return err
+			}
+			out.FamilySize = append(out.FamilySize, fs)
+			return nil
+		}); err != nil {
+		return nil, err
+	}
+
+	start, last := truncInterval(f.Interval, f.From), truncInterval(f.Interval, f.To)
+	counts := map[time.Time]int{}
+	if err := each(`SELECT `+period+` AS p, COUNT(*) FROM customer
+		 WHERE created_at >= ?2 AND created_at < ?3 AND `+sqliteSearchCond(ctx)+` GROUP BY p`,
+		[]any{search, start.Format(sqliteTimeLayout), truncDay(f.To).AddDate(0, 0, 1).Format(sqliteTimeLayout)},
+		func(rows *sql.Rows) error {
+			var p string
+			var n int
+			if err := rows.Scan(&p, &n); err != nil {
+				return err
+			}
+			t, err := time.Parse(dateLayout, p)
+			if err != nil {
+				return err
+			}
+			counts[t] = n
+			return nil
+		}); err != nil {
+		return nil, err
+	}
+	for t := start; !t.After(last); t = nextInterval(f.Interval, t) {
+		out.Created = append(out.Created, domain.PeriodCount{Start: t, Count: counts[t]})
+	}
+	return out, nil
+}
+
+func insertSqliteFamily(ctx context.Context, tx *sql.Tx, id int32, fs []domain.FamilyMember) error {
+	for _, f := range fs {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO family_list (cst_id,fl_relation,fl_name,fl_dob) VALUES (?,?,?,?)`,
+			id, f.Relation, f.Name, f.Dob.Format(dateLayout)); err != nil {
+			return mapSqliteErr(err)
+		}
+	}
+	return nil
+}
+
+func scanSqliteCustomers(rows *sql.Rows) ([]domain.Customer, error) {
+	defer rows.Close()
+	var out []domain.Customer
+	for rows.Next() {
+		var c domain.Customer
+		var dob string
+		if err := rows.Scan(&c.ID, &c.NationalityID, &c.Name, &dob, &c.PhoneNum, &c.PhoneE164, &c.Email); err != nil {
+			return nil, err
+		}
+		var err error
+		if c.Dob, err = time.Parse(dateLayout, dob); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// mapSqliteErr is mapErr for SQLite: unique violations become ErrConflict.
+// SQLite does not name the broken foreign key; nationality_id is the only one
+// a caller controls, the others point at rows the same transaction wrote.
+func mapSqliteErr(err error) error {
+	var se *sqlite.Error
+	if !errors.As(err, &se) {
+		return err
+	}
+	switch se.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE:
+		return domain.ErrConflict
+	case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+		return domain.UnknownNationality()
+	}
+	return err
+}
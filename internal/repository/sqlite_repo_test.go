@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"usrsvc/internal/domain"
+)
+
+func newSqliteRepo(t *testing.T) *SqliteUserRepo {
+	t.Helper()
+	db, err := OpenSQLite(context.Background(), filepath.Join(t.TempDir(), "usrsvc.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return NewSqliteUserRepo(db)
+}
+
+func TestOpenSQLite(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "usrsvc.db")
+	db, err := OpenSQLite(ctx, path)
+	require.NoError(t, err)
+
+	var mode string
+	var busy, fk int
+	require.NoError(t, db.QueryRowContext(ctx, `PRAGMA journal_mode`).Scan(&mode))
+	require.NoError(t, db.QueryRowContext(ctx, `PRAGMA busy_timeout`).Scan(&busy))
+	require.NoError(t, db.QueryRowContext(ctx, `PRAGMA foreign_keys`).Scan(&fk))
+	assert.Equal(t, "wal", mode)
+	assert.Equal(t, 5000, busy)
+	assert.Equal(t, 1, fk)
+
+	_, err = NewSqliteUserRepo(db).CreateCustomer(ctx, domain.Customer{NationalityID: 1, Name: "ALFA", Dob: time.Now(), PhoneNum: "0811", Email: "a@x.com"})
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	db, err = OpenSQLite(ctx, path)
+	require.NoError(t, err, "reopening skips applied migrations")
+	defer db.Close()
+	ns, err := NewSqliteUserRepo(db).ListNationalities(ctx)
+	require.NoError(t, err)
+	assert.Len(t, ns, 5)
+}
+
+func TestSqliteUserRepo(t *testing.T) {
+	ctx := context.Background()
+	dob := time.Date(1992, 5, 10, 0, 0, 0, 0, time.UTC)
+	cust := func(name, email string, family ...domain.FamilyMember) domain.Customer {
+		return domain.Customer{NationalityID: 1, Name: name, Dob: dob, PhoneNum: "0811", PhoneE164: "+62811" + name[:1], Email: email, Family: family}
+	}
+	spouse := domain.FamilyMember{Relation: "spouse", Name: "BETA", Dob: dob}
+
+	t.Run("crud_and_constraints", func(t *testing.T) {
+		r := newSqliteRepo(t)
+		id, err := r.CreateCustomer(ctx, cust("ALFA", "a@x.com", spouse))
+		require.NoError(t, err)
+		other, err := r.CreateCustomer(ctx, cust("BRAVO", "b@x.com"))
+		require.NoError(t, err)
+
+		got, err := r.GetCustomer(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, dob, got.Dob)
+		assert.Equal(t, "+62811A", got.PhoneE164)
+		require.Len(t, got.Family, 1)
+		assert.Equal(t, spouse.Dob, got.Family[0].Dob)
+		assert.Equal(t, id, got.Family[0].CustomerID)
+
+		_, err = r.CreateCustomer(ctx, cust("X", "A@X.COM"))
+		assert.ErrorIs(t, err, domain.ErrConflict)
+		assert.ErrorIs(t, r.UpdateCustomer(ctx, other, cust("BRAVO", "a@X.com")), domain.ErrConflict)
+		assert.ErrorIs(t, r.UpdateCustomer(ctx, 99, cust("X", "x@x.com")), domain.ErrNotFound)
+		bad := cust("X", "x@x.com")
+		bad.NationalityID = 42
+		_, err = r.CreateCustomer(ctx, bad)
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, domain.ErrConflict)
+
+		noPhone := cust("ALFA", "A@x.com")
+		noPhone.PhoneE164 = ""
+		require.NoError(t, r.UpdateCustomer(ctx, id, noPhone))
+		got, _ = r.GetCustomer(ctx, id)
+		assert.Empty(t, got.Family, "update replaces the family")
+		assert.Equal(t, "", got.PhoneE164)
+
+		require.NoError(t, r.DeleteCustomer(ctx, id))
+		got, err = r.GetCustomer(ctx, id)
+		assert.NoError(t, err)
+		assert.Nil(t, got)
+		assert.ErrorIs(t, r.DeleteCustomer(ctx, id), domain.ErrNotFound)
+	})
+
+	t.Run("list_search_order_paging", func(t *testing.T) {
+		r := newSqliteRepo(t)
+		for _, n := range []string{"ALFA", "ALBERT", "BRAVO", "CALVIN"} {
+			_, err := r.CreateCustomer(ctx, cust(n, n+"@x.com"))
+			require.NoError(t, err)
+		}
+		tests := []struct {
+			search        string
+			limit, offset int
+			want          []int32
+			total         int32
+		}{
+			{"", 10, 0, []int32{4, 3, 2, 1}, 4},
+			{"al", 10, 0, []int32{4, 2, 1}, 3},
+			{"al", 1, 1, []int32{2}, 3},
+			{"+62811C", 10, 0, []int32{4}, 1},
+			{"", 10, 9, []int32{}, 4},
+		}
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%q_%d_%d", tt.search, tt.limit, tt.offset), func(t *testing.T) {
+				rows, total, err := r.ListCustomers(ctx, tt.search, tt.limit, tt.offset)
+				require.NoError(t, err)
+				ids := []int32{}
+				for _, c := range rows {
+					ids = append(ids, c.ID)
+				}
+				assert.Equal(t, tt.want, ids)
+				assert.Equal(t, tt.total, total)
+			})
+		}
+	})
+
+	t.Run("merge_and_redirect", func(t *testing.T) {
+		r := newSqliteRepo(t)
+		kid := domain.FamilyMember{Relation: "child", Name: "CACA", Dob: dob.AddDate(25, 0, 0)}
+		target, _ := r.CreateCustomer(ctx, cust("ALFA", "a@x.com", spouse))
+		source, _ := r.CreateCustomer(ctx, cust("ALFA S", "s@x.com", domain.FamilyMember{Relation: "Spouse", Name: "beta", Dob: dob}, kid))
+		older, _ := r.CreateCustomer(ctx, cust("OLD", "o@x.com"))
+		require.NoError(t, r.MergeCustomers(ctx, source, older, cust("ALFA S", "s@x.com")))
+
+		require.NoError(t, r.MergeCustomers(ctx, target, source, cust("ALFA", "s@x.com")))
+		got, _ := r.GetCustomer(ctx, target)
+		assert.Equal(t, "s@x.com", got.Email)
+		require.Len(t, got.Family, 2, "duplicate spouse stays behind")
+		assert.Equal(t, "CACA", got.Family[1].Name)
+		for _, id := range []int32{source, older} {
+			to, err := r.Redirect(ctx, id)
+			require.NoError(t, err)
+			assert.Equal(t, target, to)
+		}
+		assert.ErrorIs(t, r.MergeCustomers(ctx, target, source, cust("ALFA", "s@x.com")), domain.ErrNotFound)
+
+		require.NoError(t, r.DeleteCustomer(ctx, target))
+		to, err := r.Redirect(ctx, source)
+		require.NoError(t, err)
+		assert.Zero(t, to, "redirects go with their target")
+	})
+
+	t.Run("duplicate_candidates", func(t *testing.T) {
+		r := newSqliteRepo(t)
+		a := cust("Budi Santoso", "a@x.com")
+		a.PhoneNum = "0812-3456-7890"
+		id, _ := r.CreateCustomer(ctx, a)
+		swapped := cust("Siti", "b@x.com")
+		swapped.Dob = time.Date(1992, 10, 5, 0, 0, 0, 0, time.UTC)
+		r.CreateCustomer(ctx, swapped)
+		phone := cust("Agus", "c@x.com")
+		phone.Dob, phone.PhoneNum = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC), "+62 (812) 3456.7890"
+		r.CreateCustomer(ctx, phone)
+		name := cust("budi", "d@x.com")
+		name.Dob, name.PhoneNum = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC), "0999"
+		r.CreateCustomer(ctx, name)
+		stranger := cust("Zed", "e@x.com")
+		stranger.Dob, stranger.PhoneNum = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC), "0999"
+		r.CreateCustomer(ctx, stranger)
+
+		a.ID = id
+		got, err := r.DuplicateCandidates(ctx, a, 10)
+		require.NoError(t, err)
+		ids := []int32{}
+		for _, c := range got {
+			ids = append(ids, c.ID)
+		}
+		assert.Equal(t, []int32{3, 2, 4}, ids, "phone, then swapped dob, then first name")
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		r := newSqliteRepo(t)
+		day := func(m time.Month, d int) time.Time { return time.Date(2026, m, d, 9, 0, 0, 0, time.UTC) }
+		for i, at := range []time.Time{day(9, 1), day(9, 2), day(9, 9), day(10, 1)} {
+			r.now = func() time.Time { return at }
+			c := cust("ALFA", fmt.Sprintf("%d@x.com", i))
+			if i == 3 {
+				c.NationalityID, c.Dob, c.Family = 2, time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC), []domain.FamilyMember{spouse}
+			}
+			_, err := r.CreateCustomer(ctx, c)
+			require.NoError(t, err)
+		}
+		r.now = func() time.Time { return day(10, 18) }
+
+		st, err := r.CustomerStats(ctx, domain.StatsFilter{Interval: domain.IntervalWeek,
+			From: time.Date(2026, 9, 2, 0, 0, 0, 0, time.UTC), To: time.Date(2026, 9, 14, 0, 0, 0, 0, time.UTC)})
+		require.NoError(t, err)
+		assert.Equal(t, 4, st.Total)
+		assert.Equal(t, []domain.NationalityCount{{NationalityID: 1, Name: "Indonesia", Count: 3}, {NationalityID: 2, Name: "Malaysia", Count: 1}}, st.ByNationality)
+		assert.Equal(t, 1, st.ByAge[0].Count)
+		assert.Equal(t, 3, st.ByAge[2].Count, "34 years old")
+		assert.Equal(t, []domain.FamilySizeCount{{Members: 0, Count: 3}, {Members: 1, Count: 1}}, st.FamilySize)
+		assert.Equal(t, []domain.PeriodCount{
+			{Start: time.Date(2026, 8, 31, 0, 0, 0, 0, time.UTC), Count: 2},
+			{Start: time.Date(2026, 9, 7, 0, 0, 0, 0, time.UTC), Count: 1},
+			{Start: time.Date(2026, 9, 14, 0, 0, 0, 0, time.UTC), Count: 0},
+		}, st.Created)
+
+		st, err = r.CustomerStats(ctx, domain.StatsFilter{Interval: domain.IntervalMonth,
+			From: time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)})
+		require.NoError(t, err)
+		assert.Equal(t, []domain.PeriodCount{
+			{Start: time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), Count: 3},
+			{Start: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), Count: 1},
+		}, st.Created, "the whole of the To day counts")
+	})
+
+	t.Run("concurrent_writers", func(t *testing.T) {
+		r := newSqliteRepo(t)
+		r.db.SetMaxOpenConns(8)
+		var wg sync.WaitGroup
+		errs := make(chan error, 50)
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := r.CreateCustomer(ctx, cust("ALFA", fmt.Sprintf("%d@x.com", i%25)))
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil {
+				assert.ErrorIs(t, err, domain.ErrConflict, "writers queue instead of failing with SQLITE_BUSY")
+			}
+		}
+		_, total, _ := r.ListCustomers(ctx, "", 100, 0)
+		assert.Equal(t, int32(25), total)
+	})
+}
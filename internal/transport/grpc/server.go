@@ -0,0 +1,346 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/pii"
+	"usrsvc/internal/pkg/auth"
+	"usrsvc/internal/pkg/db"
+	"usrsvc/internal/pkg/log"
+	"usrsvc/internal/pkg/tenant"
+	pb "usrsvc/pkg/pb/usrsvc/v1"
+)
+
+const dateLayout = "2006-01-02"
+
+type Server struct {
+	pb.UnimplementedCustomerServiceServer
+	UC      domain.UserUsecase
+	Tenants tenant.Resolver
+	Callers auth.Resolver
+	PII     pii.Policy
+
+	grpcOpts []grpc.ServerOption
+}
+
+type Option func(*Server)
+
+// WithTenants sets how calls name their tenant, from metadata x-tenant-id or
+// the authorization bearer token. Without it calls that name none belong to
+// tenant.Default.
+func WithTenants(r tenant.Resolver) Option { return func(s *Server) { s.Tenants = r } }
+
+// WithCallers sets how calls name their caller, from metadata x-actor,
+// x-role and x-scopes or the bearer token. Without it every caller is
+// anonymous.
+func WithCallers(r auth.Resolver) Option { return func(s *Server) { s.Callers = r } }
+
+// WithPII sets what callers see of customers. Without it PII is not masked.
+func WithPII(p pii.Policy) Option { return func(s *Server) { s.PII = p } }
+
+// WithServerOptions passes opts on to grpc.NewServer.
+func WithServerOptions(opts ...grpc.ServerOption) Option {
+	return func(s *Server) { s.grpcOpts = append(s.grpcOpts, opts...) }
+}
+
+// NewServer returns a grpc.Server exposing CustomerService plus the standard
+// health service and server reflection.
+func NewServer(uc domain.UserUsecase, opts ...Option) *grpc.Server {
+	srv := &Server{UC: uc}
+	for _, o := range opts {
+		o(srv)
+	}
+	s := grpc.NewServer(append([]grpc.ServerOption{grpc.ChainUnaryInterceptor(srv.resolveTenant, srv.resolveCaller, readYourWrites)}, srv.grpcOpts...)...)
+	pb.RegisterCustomerServiceServer(s, srv)
+
+	hs := health.NewServer()
+	hs.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	hs.SetServingStatus(pb.CustomerService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(s, hs)
+
+	reflection.Register(s)
+	return s
+}
+
+// readYourWrites gives each call a db.Session, so reads after a write in the
+// same call hit the primary; metadata x-read-primary: true forces it.
+func readYourWrites(ctx context.Context, req any, _ *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+	primary := false
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, v := range md.Get("x-read-primary") {
+			primary = primary || strings.EqualFold(v, "true")
+		}
+	}
+	ctx, _ = db.WithSession(ctx, primary)
+	return next(ctx, req)
+}
+
+func (s *Server) resolveTenant(ctx context.Context, req any, _ *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+	var header, auth string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get("x-tenant-id"); len(v) > 0 {
+			header = v[0]
+		}
+		if v := md.Get("authorization"); len(v) > 0 {
+			auth = v[0]
+		}
+	}
+	id, err := s.Tenants.Resolve(header, auth)
+	switch {
+	case errors.Is(err, tenant.ErrUnauthorized):
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	case err != nil:
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return next(tenant.With(ctx, id), req)
+}
+
+func (s *Server) resolveCaller(ctx context.Context, req any, _ *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	first := func(k string) string {
+		if v := md.Get(k); len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	c, err := s.Callers.Resolve(first("x-actor"), first("x-role"), first("x-scopes"), first("authorization"))
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	ctx = auth.With(ctx, c)
+	if s.PII.Masks(ctx) {
+		ctx = domain.WithWholeEmailSearch(ctx)
+	}
+	return next(ctx, req)
+}
+
+func (s *Server) masker(ctx context.Context, op string, ids ...int32) (pii.Masker, error) {
+	m, err := s.PII.For(ctx, op, ids...)
+	if err != nil {
+		log.Error.Printf("grpc %s audit_err ids=%v err=%v", op, ids, err)
+		return m, status.Error(codes.Internal, "internal error")
+	}
+	return m, nil
+}
+
+func (s *Server) ListCustomers(ctx context.Context, req *pb.ListCustomersRequest) (*pb.ListCustomersResponse, error) {
+	page, size := int(req.GetPage()), int(req.GetSize())
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 10
+	}
+	rows, total, err := s.UC.List(ctx, req.GetSearch(), page, size)
+	if err != nil {
+		return nil, toStatus("list_customers", err)
+	}
+	ids := make([]int32, 0, len(rows))
+	for _, c := range rows {
+		ids = append(ids, c.ID)
+	}
+	m, err := s.masker(ctx, "list_customers", ids...)
+	if err != nil {
+		return nil, err
+	}
+	out := &pb.ListCustomersResponse{Customers: make([]*pb.Customer, 0, len(rows)), Total: total}
+	for i := range rows {
+		out.Customers = append(out.Customers, toPB(&rows[i], m))
+	}
+	return out, nil
+}
+
+func (s *Server) GetCustomer(ctx context.Context, req *pb.GetCustomerRequest) (*pb.Customer, error) {
+	if req.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid id")
+	}
+	c, err := s.UC.Get(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus("get_customer", err)
+	}
+	if c == nil {
+		return nil, status.Error(codes.NotFound, "not found")
+	}
+	m, err := s.masker(ctx, "get_customer", c.ID)
+	if err != nil {
+		return nil, err
+	}
+	return toPB(c, m), nil
+}
+
+func (s *Server) CreateCustomer(ctx context.Context, req *pb.CreateCustomerRequest) (*pb.Customer, error) {
+	c, err := fromPB(req.GetCustomer())
+	if err != nil {
+		return nil, err
+	}
+	id, err := s.UC.Create(ctx, c)
+	if err != nil {
+		return nil, toStatus("create_customer", err)
+	}
+	c.ID = id
+	log.Info.Printf("grpc create_customer ok id=%d family=%d", id, len(c.Family))
+	m, err := s.masker(ctx, "create_customer", id)
+	if err != nil {
+		return nil, err
+	}
+	return toPB(&c, m), nil
+}
+
+func (s *Server) UpdateCustomer(ctx context.Context, req *pb.UpdateCustomerRequest) (*pb.Customer, error) {
+	if req.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid id")
+	}
+	c, err := fromPB(req.GetCustomer())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.UC.Update(ctx, req.GetId(), c); err != nil {
+		return nil, toStatus("update_customer", err)
+	}
+	c.ID = req.GetId()
+	log.Info.Printf("grpc update_customer ok id=%d family=%d", c.ID, len(c.Family))
+	m, err := s.masker(ctx, "update_customer", c.ID)
+	if err != nil {
+		return nil, err
+	}
+	return toPB(&c, m), nil
+}
+
+func (s *Server) DeleteCustomer(ctx context.Context, req *pb.DeleteCustomerRequest) (*pb.DeleteCustomerResponse, error) {
+	if req.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid id")
+	}
+	if err := s.UC.Delete(ctx, req.GetId()); err != nil {
+		return nil, toStatus("delete_customer", err)
+	}
+	log.Info.Printf("grpc delete_customer ok id=%d", req.GetId())
+	return &pb.DeleteCustomerResponse{}, nil
+}
+
+func (s *Server) ListNationalities(ctx context.Context, _ *pb.ListNationalitiesRequest) (*pb.ListNationalitiesResponse, error) {
+	ns, err := s.UC.ListNationality(ctx)
+	if err != nil {
+		return nil, toStatus("list_nationalities", err)
+	}
+	out := &pb.ListNationalitiesResponse{Nationalities: make([]*pb.Nationality, 0, len(ns))}
+	for _, n := range ns {
+		out.Nationalities = append(out.Nationalities, &pb.Nationality{Id: n.ID, Name: n.Name, Code: n.Code})
+	}
+	return out, nil
+}
+
+// toStatus maps domain errors to gRPC codes, mirroring the HTTP handler's
+// 404/409/422 mapping.
+func toStatus(op string, err error) error {
+	var re *domain.RuleError
+	var moved *domain.MovedError
+	switch {
+	case errors.As(err, &moved):
+		return status.Error(codes.NotFound, moved.Error())
+	case errors.Is(err, domain.ErrNotFound):
+		return status.Error(codes.NotFound, "not found")
+	case errors.Is(err, domain.ErrInvalidID):
+		return status.Error(codes.InvalidArgument, "invalid id")
+	case errors.Is(err, domain.ErrConflict):
+		return badRequest(codes.AlreadyExists, "conflict", map[string]string{"email": "already exists"})
+	case errors.Is(err, domain.ErrInvalidPhone):
+		return badRequest(codes.InvalidArgument, "validation error", map[string]string{"phone_num": err.Error()})
+	case errors.As(err, &re):
+		fields := make(map[string]string, len(re.Violations))
+		for _, v := range re.Violations {
+			fields[v.Field] = v.Rule
+		}
+		return badRequest(codes.InvalidArgument, "business rule violation", fields)
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	log.Error.Printf("grpc %s repo_err err=%v", op, err)
+	return status.Error(codes.Internal, "internal error")
+}
+
+func badRequest(code codes.Code, msg string, fields map[string]string) error {
+	st := status.New(code, msg)
+	br := &errdetails.BadRequest{}
+	for f, d := range fields {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: f, Description: d})
+	}
+	if withDetails, err := st.WithDetails(br); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+func fromPB(in *pb.CustomerInput) (domain.Customer, error) {
+	if in == nil {
+		return domain.Customer{}, badRequest(codes.InvalidArgument, "validation error", map[string]string{"customer": "required"})
+	}
+	bad := map[string]string{}
+	required := func(field, v string) {
+		if strings.TrimSpace(v) == "" {
+			bad[field] = "required"
+		}
+	}
+	required("name", in.GetName())
+	required("phone_num", in.GetPhoneNum())
+	required("email", in.GetEmail())
+	if in.GetNationalityId() <= 0 {
+		bad["nationality_id"] = "required"
+	}
+	dob, err := time.Parse(dateLayout, in.GetDob())
+	if err != nil {
+		bad["dob"] = "YYYY-MM-DD"
+	}
+	c := domain.Customer{
+		NationalityID: in.GetNationalityId(),
+		Name:          in.GetName(),
+		Dob:           dob,
+		PhoneNum:      in.GetPhoneNum(),
+		Email:         in.GetEmail(),
+	}
+	for i, f := range in.GetFamily() {
+		p := "family[" + strconv.Itoa(i) + "]."
+		required(p+"relation", f.GetRelation())
+		required(p+"name", f.GetName())
+		fdob, err := time.Parse(dateLayout, f.GetDob())
+		if err != nil {
+			bad[p+"dob"] = "YYYY-MM-DD"
+		}
+		c.Family = append(c.Family, domain.FamilyMember{Relation: f.GetRelation(), Name: f.GetName(), Dob: fdob})
+	}
+	if len(bad) > 0 {
+		return c, badRequest(codes.InvalidArgument, "validation error", bad)
+	}
+	return c, nil
+}
+
+func toPB(c *domain.Customer, m pii.Masker) *pb.Customer {
+	out := &pb.Customer{
+		Id:            c.ID,
+		NationalityId: c.NationalityID,
+		Name:          strings.TrimSpace(c.Name),
+		Dob:           m.Date(c.Dob),
+		PhoneNum:      m.Phone(c.PhoneNum),
+		PhoneE164:     m.Phone(c.PhoneE164),
+		Email:         m.Email(c.Email),
+	}
+	for _, f := range c.Family {
+		out.Family = append(out.Family, &pb.FamilyMember{Id: f.ID, Relation: f.Relation, Name: f.Name, Dob: m.Date(f.Dob)})
+	}
+	return out
+}
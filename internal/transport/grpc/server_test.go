@@ -0,0 +1,203 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/mocks"
+	"usrsvc/internal/pii"
+	"usrsvc/internal/pkg/auth"
+	"usrsvc/internal/pkg/tenant"
+	pb "usrsvc/pkg/pb/usrsvc/v1"
+)
+
+func dial(t *testing.T, uc domain.UserUsecase, opts ...Option) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	s := NewServer(uc, opts...)
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func fieldViolations(t *testing.T, err error) map[string]string {
+	t.Helper()
+	out := map[string]string{}
+	for _, d := range status.Convert(err).Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range br.GetFieldViolations() {
+				out[v.GetField()] = v.GetDescription()
+			}
+		}
+	}
+	return out
+}
+
+func TestServer_CustomerService(t *testing.T) {
+	ctx := context.Background()
+	dob := time.Date(1992, 5, 10, 0, 0, 0, 0, time.UTC)
+	code := "ID"
+	input := &pb.CustomerInput{
+		NationalityId: 1, Name: "ALFA", Dob: "1992-05-10", PhoneNum: "081234567890", Email: "a@example.com",
+		Family: []*pb.FamilyMember{{Relation: "spouse", Name: "BETA", Dob: "1993-07-01"}},
+	}
+
+	uc := new(mocks.UserUsecase)
+	uc.On("List", mock.Anything, "AL", 1, 10).
+		Return([]domain.Customer{{ID: 36, Name: "ALFA  ", Dob: dob}}, int32(1), nil).Once()
+	uc.On("Get", mock.Anything, int32(36)).
+		Return(&domain.Customer{ID: 36, Name: "ALFA", Dob: dob, Family: []domain.FamilyMember{{ID: 1, Relation: "spouse", Dob: dob}}}, nil).Once()
+	uc.On("Get", mock.Anything, int32(404)).Return(nil, domain.ErrNotFound).Once()
+	uc.On("Get", mock.Anything, int32(40)).Return(nil, &domain.MovedError{To: 36}).Once()
+	uc.On("Create", mock.Anything, mock.MatchedBy(func(c domain.Customer) bool {
+		return c.Email == "a@example.com" && c.Dob.Equal(dob) && len(c.Family) == 1
+	})).Return(int32(36), nil).Once()
+	uc.On("Update", mock.Anything, int32(36), mock.Anything).Return(domain.ErrConflict).Once()
+	uc.On("Update", mock.Anything, int32(37), mock.Anything).Return(&domain.RuleError{Violations: []domain.Violation{
+		{Field: "family[0].fl_relation", Rule: domain.RuleSingleSpouse},
+	}}).Once()
+	uc.On("Delete", mock.Anything, int32(36)).Return(nil).Once()
+	uc.On("Delete", mock.Anything, int32(37)).Return(assert.AnError).Once()
+	uc.On("ListNationality", mock.Anything).
+		Return([]domain.Nationality{{ID: 1, Name: "Indonesia", Code: &code}, {ID: 2, Name: "Other"}}, nil).Once()
+
+	cli := pb.NewCustomerServiceClient(dial(t, uc))
+
+	list, err := cli.ListCustomers(ctx, &pb.ListCustomersRequest{Search: "AL"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), list.GetTotal())
+	assert.Equal(t, "ALFA", list.GetCustomers()[0].GetName())
+
+	got, err := cli.GetCustomer(ctx, &pb.GetCustomerRequest{Id: 36})
+	require.NoError(t, err)
+	assert.Equal(t, "1992-05-10", got.GetDob())
+	assert.Len(t, got.GetFamily(), 1)
+
+	_, err = cli.GetCustomer(ctx, &pb.GetCustomerRequest{Id: 404})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = cli.GetCustomer(ctx, &pb.GetCustomerRequest{Id: 40})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, "customer merged into 36", status.Convert(err).Message())
+
+	_, err = cli.GetCustomer(ctx, &pb.GetCustomerRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	created, err := cli.CreateCustomer(ctx, &pb.CreateCustomerRequest{Customer: input})
+	require.NoError(t, err)
+	assert.Equal(t, int32(36), created.GetId())
+
+	_, err = cli.CreateCustomer(ctx, &pb.CreateCustomerRequest{Customer: &pb.CustomerInput{Dob: "10-05-1992",
+		Family: []*pb.FamilyMember{{Relation: "child", Dob: "2010"}}}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	fv := fieldViolations(t, err)
+	assert.Equal(t, "YYYY-MM-DD", fv["dob"])
+	assert.Equal(t, "required", fv["email"])
+	assert.Equal(t, "required", fv["family[0].name"])
+
+	_, err = cli.UpdateCustomer(ctx, &pb.UpdateCustomerRequest{Id: 36, Customer: input})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	_, err = cli.UpdateCustomer(ctx, &pb.UpdateCustomerRequest{Id: 37, Customer: input})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, domain.RuleSingleSpouse, fieldViolations(t, err)["family[0].fl_relation"])
+
+	_, err = cli.DeleteCustomer(ctx, &pb.DeleteCustomerRequest{Id: 36})
+	require.NoError(t, err)
+	_, err = cli.DeleteCustomer(ctx, &pb.DeleteCustomerRequest{Id: 37})
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	ns, err := cli.ListNationalities(ctx, &pb.ListNationalitiesRequest{})
+	require.NoError(t, err)
+	require.Len(t, ns.GetNationalities(), 2)
+	assert.Equal(t, "ID", ns.GetNationalities()[0].GetCode())
+	assert.Nil(t, ns.GetNationalities()[1].Code)
+
+	uc.AssertExpectations(t)
+}
+
+func TestServer_Tenants(t *testing.T) {
+	uc := new(mocks.UserUsecase)
+	var got string
+	uc.On("ListNationality", mock.Anything).Run(func(args mock.Arguments) {
+		got, _ = tenant.From(args.Get(0).(context.Context))
+	}).Return([]domain.Nationality{}, nil)
+	client := pb.NewCustomerServiceClient(dial(t, uc, WithTenants(tenant.Resolver{DefaultOnly: true})))
+
+	_, err := client.ListNationalities(context.Background(), &pb.ListNationalitiesRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, tenant.Default, got)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-tenant-id", "acme")
+	_, err = client.ListNationalities(ctx, &pb.ListNationalitiesRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestServer_PIIMasking(t *testing.T) {
+	uc := new(mocks.UserUsecase)
+	uc.On("Get", mock.Anything, int32(36)).Return(&domain.Customer{ID: 36, Name: "ALFA", Email: "alfa@x.com"}, nil)
+	policy := WithPII(pii.Policy{FullRoles: []string{"admin"}})
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-role", "admin")
+
+	tests := []struct {
+		name    string
+		callers auth.Resolver
+		want    string
+	}{
+		{name: "trusted_headers", callers: auth.Resolver{TrustHeaders: true}, want: "alfa@x.com"},
+		{name: "untrusted_headers", want: "a***@x.com"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := pb.NewCustomerServiceClient(dial(t, uc, policy, WithCallers(tc.callers)))
+			got, err := client.GetCustomer(ctx, &pb.GetCustomerRequest{Id: 36})
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got.GetEmail())
+		})
+	}
+}
+
+func TestServer_HealthAndReflection(t *testing.T) {
+	ctx := context.Background()
+	conn := dial(t, new(mocks.UserUsecase))
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: "usrsvc.v1.CustomerService"})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}))
+	r, err := stream.Recv()
+	require.NoError(t, err)
+	var names []string
+	for _, s := range r.GetListServicesResponse().GetService() {
+		names = append(names, s.GetName())
+	}
+	assert.Contains(t, names, "usrsvc.v1.CustomerService")
+	assert.Contains(t, names, "grpc.health.v1.Health")
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/pkg/log"
+	"usrsvc/internal/pkg/tenant"
+)
+
+// SSE timings; tests shorten them.
+var (
+	SSEHeartbeat = 15 * time.Second
+	SSERetry     = 3 * time.Second
+)
+
+// UserEvents streams customer events as Server-Sent Events. A Last-Event-ID
+// header resumes from the replay buffer; when that id has already left the
+// buffer the stream starts with a "reset" event so the client reloads. Only
+// the request tenant's events are sent.
+func (h *Handler) UserEvents(w http.ResponseWriter, r *http.Request) {
+	t, _ := tenant.From(r.Context())
+	if m, ok := h.masker(w, r, "user_events"); !ok {
+		return
+	} else if m.Masked() {
+		writeErr(w, r, StatusForbidden, MsgPIIForbidden, nil)
+		return
+	}
+	lastID, err := strconv.ParseInt(r.Header.Get("Last-Event-ID"), 10, 64)
+	resume := err == nil
+	sub, replay, complete := h.Feed.Subscribe(lastID, resume)
+	defer h.Feed.Unsubscribe(sub)
+
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", SSERetry.Milliseconds())
+	if resume && !complete {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	for _, e := range replay {
+		if e.Tenant == t {
+			writeSSE(w, e)
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+	log.Info.Printf("user_events subscribe ok resume=%t replayed=%d complete=%t", resume, len(replay), complete)
+
+	tick := time.NewTicker(SSEHeartbeat)
+	defer tick.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-tick.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case e, ok := <-sub.C:
+			if !ok {
+				log.Error.Printf("user_events dropped slow subscriber last_id=%d", lastID)
+				return
+			}
+			if e.Tenant != t {
+				continue
+			}
+			writeSSE(w, e)
+			lastID = e.ID
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeSSE(w http.ResponseWriter, e domain.Event) {
+	b, _ := json.Marshal(e)
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, b)
+}
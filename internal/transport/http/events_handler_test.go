@@ -0,0 +1,140 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/events"
+	"usrsvc/internal/mocks"
+	"usrsvc/internal/pkg/tenant"
+)
+
+// sseMessage is one blank-line terminated block; comments land in Comment.
+type sseMessage struct {
+	ID, Event, Data, Comment string
+}
+
+func readSSE(t *testing.T, r *bufio.Reader) sseMessage {
+	t.Helper()
+	var m sseMessage
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return m
+		}
+		k, v, _ := strings.Cut(line, ": ")
+		switch k {
+		case "id":
+			m.ID = v
+		case "event":
+			m.Event = v
+		case "data":
+			m.Data = v
+		case "":
+			m.Comment = v
+		}
+	}
+}
+
+// nextEvent skips heartbeats.
+func nextEvent(t *testing.T, r *bufio.Reader) sseMessage {
+	t.Helper()
+	for {
+		if m := readSSE(t, r); m.Comment == "" {
+			return m
+		}
+	}
+}
+
+func openFeed(t *testing.T, srv *httptest.Server, lastID string) *bufio.Reader {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/v1/users/events", nil)
+	require.NoError(t, err)
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = res.Body.Close() })
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	r := bufio.NewReader(res.Body)
+	assert.Equal(t, sseMessage{}, readSSE(t, r), "retry block")
+	return r
+}
+
+func TestHandler_UserEvents(t *testing.T) {
+	SSEHeartbeat = 20 * time.Millisecond
+	t.Cleanup(func() { SSEHeartbeat = 15 * time.Second })
+
+	ctx := context.Background()
+	b := events.NewBroker(2, 4)
+	srv := httptest.NewServer(NewRouter(NewHandler(new(mocks.UserUsecase), WithEventFeed(b)), nil))
+	t.Cleanup(srv.Close)
+	ev := func(id int64, typ string) domain.Event {
+		return domain.Event{ID: id, Type: typ, Tenant: tenant.Default, CustomerID: 36, Payload: json.RawMessage(`{}`)}
+	}
+
+	live := openFeed(t, srv, "")
+	require.NoError(t, b.Publish(ctx, ev(1, domain.EventCustomerCreated)))
+	m := nextEvent(t, live)
+	assert.Equal(t, "1", m.ID)
+	assert.Equal(t, domain.EventCustomerCreated, m.Event)
+	var got domain.Event
+	require.NoError(t, json.Unmarshal([]byte(m.Data), &got))
+	assert.Equal(t, int32(36), got.CustomerID)
+
+	t.Run("heartbeat", func(t *testing.T) {
+		assert.Equal(t, "ping", readSSE(t, live).Comment)
+	})
+
+	require.NoError(t, b.Publish(ctx, ev(2, domain.EventCustomerUpdated)))
+	require.NoError(t, b.Publish(ctx, ev(3, domain.EventCustomerDeleted)))
+
+	t.Run("resume_from_buffer", func(t *testing.T) {
+		r := openFeed(t, srv, "2")
+		m := nextEvent(t, r)
+		assert.Equal(t, "3", m.ID)
+		assert.Equal(t, domain.EventCustomerDeleted, m.Event)
+	})
+
+	t.Run("resume_too_old_sends_reset", func(t *testing.T) {
+		r := openFeed(t, srv, "1")
+		assert.Equal(t, "reset", nextEvent(t, r).Event)
+		assert.Equal(t, "2", nextEvent(t, r).ID)
+		assert.Equal(t, "3", nextEvent(t, r).ID)
+	})
+
+	t.Run("other_tenants_filtered", func(t *testing.T) {
+		assert.Equal(t, "2", nextEvent(t, live).ID)
+		assert.Equal(t, "3", nextEvent(t, live).ID)
+		other := ev(4, domain.EventCustomerCreated)
+		other.Tenant = "acme"
+		require.NoError(t, b.Publish(ctx, other))
+		require.NoError(t, b.Publish(ctx, ev(5, domain.EventCustomerUpdated)))
+		assert.Equal(t, "5", nextEvent(t, live).ID)
+	})
+
+	t.Run("slow_subscriber_dropped", func(t *testing.T) {
+		s, _, _ := b.Subscribe(0, false)
+		for id := int64(6); id <= 10; id++ {
+			require.NoError(t, b.Publish(ctx, ev(id, domain.EventCustomerCreated)))
+		}
+		assert.True(t, b.Dropped(s))
+	})
+}
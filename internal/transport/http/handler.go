@@ -4,28 +4,108 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"usrsvc/internal/pii"
+	"usrsvc/internal/pkg/auth"
+	"usrsvc/internal/pkg/i18n"
 	"usrsvc/internal/pkg/log"
+	"usrsvc/internal/pkg/tenant"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 
 	"usrsvc/internal/domain"
 	"usrsvc/internal/dto"
+	"usrsvc/internal/events"
+	"usrsvc/internal/jobs"
 )
 
 type Handler struct {
-	UC  domain.UserUsecase
-	Val *validator.Validate
+	UC   domain.UserUsecase
+	WH   domain.WebhookUsecase
+	Feed *events.Broker
+	Jobs *jobs.Scheduler
+	Val  *validator.Validate
+
+	Tenants tenant.Resolver
+	Callers auth.Resolver
+	PII     pii.Policy
+
+	LegacyDeprecated, LegacySunset time.Time
+	ReadYourWrites                 time.Duration
 }
 
-func NewHandler(uc domain.UserUsecase) *Handler { return &Handler{UC: uc, Val: validator.New()} }
+type HandlerOption func(*Handler)
+
+// WithWebhooks enables the /v1/webhooks routes.
+func WithWebhooks(wh domain.WebhookUsecase) HandlerOption { return func(h *Handler) { h.WH = wh } }
+
+// WithEventFeed enables GET /v1/users/events.
+func WithEventFeed(b *events.Broker) HandlerOption { return func(h *Handler) { h.Feed = b } }
+
+// WithJobs enables the /v1/admin/jobs routes, when WithCallers verifies
+// callers by token.
+func WithJobs(s *jobs.Scheduler) HandlerOption { return func(h *Handler) { h.Jobs = s } }
+
+// WithTenants sets how requests name their tenant. Without it requests
+// without X-Tenant-ID belong to tenant.Default.
+func WithTenants(r tenant.Resolver) HandlerOption { return func(h *Handler) { h.Tenants = r } }
+
+// WithCallers sets how requests name their caller. Without it every caller
+// is anonymous.
+func WithCallers(r auth.Resolver) HandlerOption { return func(h *Handler) { h.Callers = r } }
+
+// WithPII sets what callers see of customers. Without it PII is not masked.
+func WithPII(p pii.Policy) HandlerOption { return func(h *Handler) { h.PII = p } }
+
+// WithLegacyLifecycle adds the Deprecation and Sunset headers to the
+// unversioned routes.
+func WithLegacyLifecycle(deprecated, sunset time.Time) HandlerOption {
+	return func(h *Handler) { h.LegacyDeprecated, h.LegacySunset = deprecated, sunset }
+}
+
+// WithReadYourWrites keeps a client that wrote reading from the primary for
+// window, which should be the replica lag limit.
+func WithReadYourWrites(window time.Duration) HandlerOption {
+	return func(h *Handler) { h.ReadYourWrites = window }
+}
+
+func NewHandler(uc domain.UserUsecase, opts ...HandlerOption) *Handler {
+	h := &Handler{UC: uc, Val: i18n.Validator()}
+	for _, o := range opts {
+		o(h)
+	}
+	return h
+}
+
+// maxBatchIDs bounds the ids of one batch get.
+const maxBatchIDs = 100
+
+const includeFamily = "family"
 
+// ListUsers serves a batch get instead of a page when ids is given.
 func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
+	req, bad := batchQuery(q)
+	f, badFields := fieldsQuery(r)
+	if len(bad) > 0 || badFields != "" {
+		out := localize(i18n.FromRequest(r), bad)
+		if badFields != "" {
+			out["fields"] = badFields
+		}
+		writeLocalizedErr(w, r, StatusBadRequest, MsgValidation, out)
+		return
+	}
+	r = r.WithContext(domain.WithFields(r.Context(), f))
+	if len(req.IDs) > 0 {
+		h.batchGet(w, r, req, f)
+		return
+	}
 	page, _ := strconv.Atoi(q.Get("page"))
 	size, _ := strconv.Atoi(q.Get("size"))
 	if page < 1 {
@@ -35,75 +115,233 @@ func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
 		size = 10
 	}
 	search := q.Get("search")
+	family := withFamily(req, f)
 
 	rows, total, err := h.UC.List(r.Context(), search, page, size)
+	if err == nil && family {
+		err = h.UC.LoadFamilies(r.Context(), rows)
+	}
 	if err != nil {
 		log.Error.Printf("list_users repo_err err=%v", err)
-		writeErr(w, StatusInternalServerError, MsgInternal, nil)
+		writeErr(w, r, StatusInternalServerError, MsgInternal, nil)
 		return
 	}
 
+	m, ok := h.masker(w, r, "list_users", customerIDs(rows)...)
+	if !ok {
+		return
+	}
 	out := make([]dto.CustomerListItem, 0, len(rows))
 	for _, c := range rows {
-		out = append(out, dto.CustomerListItem{
-			CstID:         c.ID,
-			CstName:       strings.TrimSpace(c.Name),
-			CstDob:        c.Dob.Format("2006-01-02"),
-			NationalityID: c.NationalityID,
-			CstPhoneNum:   c.PhoneNum,
-			CstEmail:      c.Email,
-		})
+		out = append(out, toListItem(c, m, family))
 	}
 	log.Info.Printf("list_users ok total=%d", total)
-	writeJSON(w, StatusOK, dto.CustomerListResponse{Data: out, Total: int(total)})
+	writeJSON(w, StatusOK, sparseList(dto.CustomerListResponse{Data: out, Total: int(total)}, f))
+}
+
+// fieldsQuery reads ?fields=; bad is the localized error for an unknown name.
+func fieldsQuery(r *http.Request) (f domain.Fields, bad string) {
+	f, err := domain.ParseFields(r.URL.Query().Get("fields"))
+	var unknown *domain.UnknownFieldError
+	if errors.As(err, &unknown) {
+		return nil, i18n.T(i18n.FromRequest(r), FieldFields, unknown.Name, strings.Join(domain.CustomerFields, ", "))
+	}
+	return f, ""
+}
+
+// withFamily reports whether a list loads families: selecting a family field
+// asks for them as include=family does, and a selection without one skips
+// them.
+func withFamily(req dto.BatchGetRequest, f domain.Fields) bool {
+	if f != nil {
+		return f.Family()
+	}
+	return slices.Contains(req.Include, includeFamily)
+}
+
+// batchQuery reads ?ids=1,2,3&include=family into the batchGet body.
+func batchQuery(q url.Values) (dto.BatchGetRequest, map[string]string) {
+	var req dto.BatchGetRequest
+	bad := map[string]string{}
+	if v := q.Get("ids"); v != "" {
+		for _, p := range strings.Split(v, ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(p), 10, 32)
+			if err != nil || id < 1 {
+				bad["ids"] = FieldIDs
+				break
+			}
+			req.IDs = append(req.IDs, int32(id))
+		}
+		if len(req.IDs) > maxBatchIDs {
+			bad["ids"] = FieldIDs
+		}
+	}
+	if v := q.Get("include"); v != "" {
+		req.Include = strings.Split(v, ",")
+		for _, inc := range req.Include {
+			if inc != includeFamily {
+				bad["include"] = FieldInclude
+			}
+		}
+	}
+	return req, bad
+}
+
+func (h *Handler) BatchGetUsers(w http.ResponseWriter, r *http.Request) {
+	var req dto.BatchGetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error.Printf("batch_get_users decode_json err=%v", err)
+		writeErr(w, r, StatusBadRequest, MsgInvalidJSON, nil)
+		return
+	}
+	if err := h.Val.Struct(req); err != nil {
+		writeValidationErr(w, r, err)
+		return
+	}
+	h.batchGet(w, r, req, nil)
+}
+
+// batchGet answers in the list shape, customers in the order asked, with the
+// f fields only. Ids merged into another customer are reported missing;
+// GET /users/{id} redirects them.
+func (h *Handler) batchGet(w http.ResponseWriter, r *http.Request, req dto.BatchGetRequest, f domain.Fields) {
+	family := withFamily(req, f)
+	rows, missing, err := h.UC.GetMany(r.Context(), req.IDs, family)
+	if err != nil {
+		log.Error.Printf("batch_get_users repo_err ids=%d err=%v", len(req.IDs), err)
+		writeErr(w, r, StatusInternalServerError, MsgInternal, nil)
+		return
+	}
+	m, ok := h.masker(w, r, "batch_get_users", customerIDs(rows)...)
+	if !ok {
+		return
+	}
+	out := make([]dto.CustomerListItem, 0, len(rows))
+	for _, c := range rows {
+		out = append(out, toListItem(c, m, family))
+	}
+	log.Info.Printf("batch_get_users ok found=%d missing=%v", len(rows), missing)
+	writeJSON(w, StatusOK, sparseList(dto.CustomerListResponse{Data: out, Total: len(out), Missing: missing}, f))
+}
+
+// UserStats aggregates the customers matching the list filter; from/to only
+// bound the created series.
+func (h *Handler) UserStats(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := domain.StatsFilter{Search: q.Get("search"), Interval: q.Get("interval")}
+	bad := map[string]string{}
+	switch f.Interval {
+	case "", domain.IntervalDay, domain.IntervalWeek, domain.IntervalMonth:
+	default:
+		bad["interval"] = FieldInterval
+	}
+	for name, dst := range map[string]*time.Time{"from": &f.From, "to": &f.To} {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(dateLayout, v)
+			if err != nil {
+				bad[name] = FieldDateFormat
+				continue
+			}
+			*dst = t
+		}
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && f.From.After(f.To) {
+		bad["from"] = FieldDateOrder
+	}
+	if len(bad) > 0 {
+		writeErr(w, r, StatusBadRequest, MsgValidation, bad)
+		return
+	}
+	if !f.From.IsZero() {
+		to := f.To
+		if to.IsZero() {
+			to = time.Now().UTC()
+		}
+		if n, limit := domain.StatsPeriods(f.Interval, f.From, to); n > limit {
+			writeLocalizedErr(w, r, StatusBadRequest, MsgValidation,
+				map[string]string{"from": i18n.T(i18n.FromRequest(r), FieldStatsRange, strconv.Itoa(limit))})
+			return
+		}
+	}
+
+	st, err := h.UC.Stats(r.Context(), f)
+	if err != nil {
+		log.Error.Printf("user_stats repo_err err=%v", err)
+		writeErr(w, r, StatusInternalServerError, MsgInternal, nil)
+		return
+	}
+	log.Info.Printf("user_stats ok total=%d interval=%s", st.Total, st.Interval)
+	writeJSON(w, StatusOK, toStatsResponse(*st))
 }
 
 func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
+	f, bad := fieldsQuery(r)
+	if bad != "" {
+		writeLocalizedErr(w, r, StatusBadRequest, MsgValidation, map[string]string{"fields": bad})
+		return
+	}
+	r = r.WithContext(domain.WithFields(r.Context(), f))
+	if c := h.loadUser(w, r); c != nil {
+		if m, ok := h.masker(w, r, "get_user", c.ID); ok {
+			writeJSON(w, StatusOK, sparse(toCustomerResponse(*c, m), f))
+		}
+	}
+}
+
+// loadUser fetches the {id} customer, writing the error response itself and
+// returning nil when there is nothing to render.
+func (h *Handler) loadUser(w http.ResponseWriter, r *http.Request) *domain.Customer {
 	id, _ := strconv.Atoi(mux.Vars(r)["id"])
 	if id == 0 {
 		log.Error.Printf("get_user invalid_id")
-		writeErr(w, StatusBadRequest, MsgInvalidID, nil)
-		return
+		writeErr(w, r, StatusBadRequest, MsgInvalidID, nil)
+		return nil
 	}
 	c, err := h.UC.Get(r.Context(), int32(id))
 	if err != nil {
+		var moved *domain.MovedError
+		if errors.As(err, &moved) {
+			log.Info.Printf("get_user moved id=%d to=%d", id, moved.To)
+			writeMoved(w, r, int32(id), moved.To)
+			return nil
+		}
 		if errors.Is(err, domain.ErrNotFound) {
-			writeErr(w, StatusNotFound, MsgNotFound, nil)
-			return
+			writeErr(w, r, StatusNotFound, MsgNotFound, nil)
+			return nil
 		}
 		log.Error.Printf("get_user repo_err id=%d err=%v", id, err)
-		writeErr(w, StatusInternalServerError, MsgInternal, nil)
-		return
+		writeErr(w, r, StatusInternalServerError, MsgInternal, nil)
+		return nil
 	}
 	if c == nil {
-		writeErr(w, StatusNotFound, MsgNotFound, nil)
-		return
+		writeErr(w, r, StatusNotFound, MsgNotFound, nil)
+		return nil
 	}
 	log.Info.Printf("get_user ok id=%d", id)
-	writeJSON(w, StatusOK, c)
+	return c
 }
 
 func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	var req dto.CreateCustomerRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Error.Printf("create_user decode_json err=%v", err)
-		writeErr(w, StatusBadRequest, MsgInvalidJSON, nil)
+		writeErr(w, r, StatusBadRequest, MsgInvalidJSON, nil)
 		return
 	}
 	if err := h.Val.Struct(req); err != nil {
-		log.Error.Printf("create_user validate err=%v body=%+v", err, req)
-		writeErr(w, StatusUnprocessableEntity, MsgValidation, nil)
+		log.Error.Printf("create_user validate err=%v", err)
+		writeValidationErr(w, r, err)
 		return
 	}
-	if _, err := time.Parse("2006-01-02", req.CstDob); err != nil {
-		log.Error.Printf("create_user bad_dob err=%v dob=%s", err, req.CstDob)
-		writeErr(w, StatusUnprocessableEntity, "invalid cst_dob", map[string]string{"cst_dob": "YYYY-MM-DD"})
+	if _, err := time.Parse(dateLayout, req.CstDob); err != nil {
+		log.Error.Printf("create_user bad_dob")
+		writeErr(w, r, StatusUnprocessableEntity, MsgInvalidDob, map[string]string{"cst_dob": FieldDateFormat})
 		return
 	}
 	for i, f := range req.Family {
-		if _, err := time.Parse("2006-01-02", f.FlDob); err != nil {
-			log.Error.Printf("create_user bad_family_dob idx=%d err=%v dob=%s", i, err, f.FlDob)
-			writeErr(w, StatusUnprocessableEntity, "invalid fl_dob", map[string]string{"family[" + strconv.Itoa(i) + "].fl_dob": "YYYY-MM-DD"})
+		if _, err := time.Parse(dateLayout, f.FlDob); err != nil {
+			log.Error.Printf("create_user bad_family_dob idx=%d", i)
+			writeErr(w, r, StatusUnprocessableEntity, MsgInvalidFamilyDob, map[string]string{"family[" + strconv.Itoa(i) + "].fl_dob": FieldDateFormat})
 			return
 		}
 	}
@@ -123,58 +361,38 @@ func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 
 	id, err := h.UC.Create(r.Context(), c)
 	if err != nil {
-		if errors.Is(err, domain.ErrConflict) {
-			log.Info.Printf("create_user conflict email=%q", c.Email)
-			writeErr(w, StatusConflict, MsgConflict, map[string]string{"cst_email": "already exists"})
-			return
-		}
-		log.Error.Printf("create_user repo_err name=%q email=%q err=%v", c.Name, c.Email, err)
-		writeErr(w, StatusInternalServerError, MsgInternal, nil)
+		writeWriteErr(w, r, "create_user", 0, c, err)
 		return
 	}
 
-	resp := dto.CustomerResponse{
-		CstID:         id,
-		CstName:       c.Name,
-		CstDob:        c.Dob.Format("2006-01-02"),
-		NationalityID: c.NationalityID,
-		CstPhoneNum:   c.PhoneNum,
-		CstEmail:      c.Email,
-		Family:        make([]dto.FamilyMemberResponse, 0, len(c.Family)),
+	c.ID = id
+	log.Info.Printf("create_user ok id=%d family=%d", id, len(c.Family))
+	if m, ok := h.masker(w, r, "create_user", id); ok {
+		writeJSON(w, StatusCreated, toCustomerResponse(c, m))
 	}
-	for _, f := range c.Family {
-		resp.Family = append(resp.Family, dto.FamilyMemberResponse{
-			FlRelation: f.Relation,
-			FlName:     f.Name,
-			FlDob:      f.Dob.Format("2006-01-02"),
-		})
-	}
-
-	log.Info.Printf("create_user ok id=%d name=%q email=%q family=%d", id, c.Name, c.Email, len(c.Family))
-	writeJSON(w, StatusCreated, resp)
 }
 
 func (h *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	id, _ := strconv.Atoi(mux.Vars(r)["id"])
 	if id == 0 {
 		log.Error.Printf("update_user invalid_id")
-		writeErr(w, StatusBadRequest, MsgInvalidID, nil)
+		writeErr(w, r, StatusBadRequest, MsgInvalidID, nil)
 		return
 	}
 	var req dto.UpdateCustomerRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Error.Printf("update_user decode_json err=%v", err)
-		writeErr(w, StatusBadRequest, MsgInvalidJSON, nil)
+		writeErr(w, r, StatusBadRequest, MsgInvalidJSON, nil)
 		return
 	}
 	if err := h.Val.Struct(req); err != nil {
-		log.Error.Printf("update_user validate err=%v body=%+v", err, req)
-		writeErr(w, StatusUnprocessableEntity, MsgValidation, nil)
+		log.Error.Printf("update_user validate err=%v", err)
+		writeValidationErr(w, r, err)
 		return
 	}
-	if _, err := time.Parse("2006-01-02", req.CstDob); err != nil {
-		log.Error.Printf("update_user bad_dob err=%v dob=%s", err, req.CstDob)
-		writeErr(w, StatusUnprocessableEntity, "invalid cst_dob", map[string]string{"cst_dob": "YYYY-MM-DD"})
+	if _, err := time.Parse(dateLayout, req.CstDob); err != nil {
+		log.Error.Printf("update_user bad_dob")
+		writeErr(w, r, StatusUnprocessableEntity, MsgInvalidDob, map[string]string{"cst_dob": FieldDateFormat})
 		return
 	}
 
@@ -186,9 +404,9 @@ func (h *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		Email:         req.CstEmail,
 	}
 	for i, f := range req.Family {
-		if _, err := time.Parse("2006-01-02", f.FlDob); err != nil {
-			log.Error.Printf("update_user bad_family_dob idx=%d err=%v dob=%s", i, err, f.FlDob)
-			writeErr(w, StatusUnprocessableEntity, "invalid fl_dob", map[string]string{"family[" + strconv.Itoa(i) + "].fl_dob": "YYYY-MM-DD"})
+		if _, err := time.Parse(dateLayout, f.FlDob); err != nil {
+			log.Error.Printf("update_user bad_family_dob idx=%d", i)
+			writeErr(w, r, StatusUnprocessableEntity, MsgInvalidFamilyDob, map[string]string{"family[" + strconv.Itoa(i) + "].fl_dob": FieldDateFormat})
 			return
 		}
 		c.Family = append(c.Family, domain.FamilyMember{
@@ -197,16 +415,11 @@ func (h *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.UC.Update(r.Context(), int32(id), c); err != nil {
-		if errors.Is(err, domain.ErrNotFound) {
-			writeErr(w, StatusNotFound, MsgNotFound, nil)
-			return
-		}
-		log.Error.Printf("update_user repo_err id=%d name=%q email=%q err=%v", id, c.Name, c.Email, err)
-		writeErr(w, StatusInternalServerError, MsgInternal, nil)
+		writeWriteErr(w, r, "update_user", int32(id), c, err)
 		return
 	}
 	log.Info.Printf("update_user ok id=%d family=%d", id, len(c.Family))
-	writeJSON(w, StatusOK, map[string]string{"status": "ok"})
+	writeJSON(w, StatusOK, dto.StatusResponse{Status: "ok"})
 }
 
 func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
@@ -214,34 +427,153 @@ func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(idStr)
 	if err != nil || id <= 0 {
 		log.Error.Printf("delete_user invalid_id id=%q", idStr)
-		writeErr(w, StatusBadRequest, MsgInvalidID, nil)
+		writeErr(w, r, StatusBadRequest, MsgInvalidID, nil)
 		return
 	}
 	if err := h.UC.Delete(r.Context(), int32(id)); err != nil {
 		if errors.Is(err, domain.ErrNotFound) {
-			writeErr(w, StatusNotFound, MsgNotFound, nil)
+			writeErr(w, r, StatusNotFound, MsgNotFound, nil)
 			return
 		}
 		log.Error.Printf("delete_user repo_err id=%d err=%v", id, err)
-		writeErr(w, StatusInternalServerError, MsgInternal, nil)
+		writeErr(w, r, StatusInternalServerError, MsgInternal, nil)
 		return
 	}
 	log.Info.Printf("delete_user ok id=%d", id)
-	writeJSON(w, StatusOK, map[string]string{"status": "ok"})
+	writeJSON(w, StatusOK, dto.StatusResponse{Status: "ok"})
 }
 
 func (h *Handler) ListNationality(w http.ResponseWriter, r *http.Request) {
+	if n, ok := h.loadNationalities(w, r); ok {
+		writeJSON(w, StatusOK, toNationalityResponses(n))
+	}
+}
+
+func (h *Handler) loadNationalities(w http.ResponseWriter, r *http.Request) ([]domain.Nationality, bool) {
 	n, err := h.UC.ListNationality(r.Context())
 	if err != nil {
 		log.Error.Printf("list_nationality repo_err err=%v", err)
-		writeErr(w, StatusInternalServerError, MsgInternal, nil)
-		return
+		writeErr(w, r, StatusInternalServerError, MsgInternal, nil)
+		return nil, false
 	}
 	if n == nil {
 		n = []domain.Nationality{}
 	}
 	log.Info.Printf("list_nationality ok count=%d", len(n))
-	writeJSON(w, StatusOK, n)
+	return n, true
+}
+
+// writeWriteErr is the single mapping from usecase write errors to responses,
+// so create and update agree on 404/409/422.
+func writeWriteErr(w http.ResponseWriter, r *http.Request, op string, id int32, c domain.Customer, err error) {
+	var re *domain.RuleError
+	switch {
+	case errors.As(err, &re):
+		log.Info.Printf("%s rule_violation id=%d err=%v", op, id, err)
+		writeRuleErr(w, r, re)
+	case errors.Is(err, domain.ErrNotFound):
+		log.Info.Printf("%s not_found id=%d", op, id)
+		writeErr(w, r, StatusNotFound, MsgNotFound, nil)
+	case errors.Is(err, domain.ErrConflict):
+		log.Info.Printf("%s conflict id=%d field=cst_email", op, id)
+		writeErr(w, r, StatusConflict, MsgConflict, map[string]string{"cst_email": FieldExists})
+	case errors.Is(err, domain.ErrInvalidPhone):
+		log.Info.Printf("%s bad_phone id=%d nationality=%d", op, id, c.NationalityID)
+		writeErr(w, r, StatusUnprocessableEntity, MsgValidation, map[string]string{"cst_phoneNum": FieldInvalidPhone})
+	default:
+		log.Error.Printf("%s repo_err id=%d err=%v", op, id, err)
+		writeErr(w, r, StatusInternalServerError, MsgInternal, nil)
+	}
+}
+
+// writeMoved sends a permanent redirect from a merged customer's URL to the
+// same URL under the id it was merged into.
+func writeMoved(w http.ResponseWriter, r *http.Request, from, to int32) {
+	u := *r.URL
+	u.Path = strings.Replace(u.Path, "/users/"+strconv.Itoa(int(from)), "/users/"+strconv.Itoa(int(to)), 1)
+	w.Header().Set("Location", u.RequestURI())
+	writeErr(w, r, StatusPermanentRedirect, MsgMoved, nil)
 }
 
-func mustParse(s string) (t time.Time) { t, _ = time.Parse("2006-01-02", s); return }
+// writeRuleErr answers 422 with one translated message per violated field.
+func writeRuleErr(w http.ResponseWriter, r *http.Request, re *domain.RuleError) {
+	tr := i18n.FromRequest(r)
+	fields := make(map[string]string, len(re.Violations))
+	for _, v := range re.Violations {
+		if _, dup := fields[v.Field]; !dup {
+			fields[v.Field] = i18n.T(tr, v.Rule, v.Params...)
+		}
+	}
+	writeLocalizedErr(w, r, StatusUnprocessableEntity, MsgRuleViolation, fields)
+}
+
+func mustParse(s string) (t time.Time) { t, _ = time.Parse(dateLayout, s); return }
+
+func (h *Handler) ListDuplicates(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	if id <= 0 {
+		writeErr(w, r, StatusBadRequest, MsgInvalidID, nil)
+		return
+	}
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	minScore := 0.5
+	if v, err := strconv.ParseFloat(q.Get("min_score"), 64); err == nil && v >= 0 && v <= 1 {
+		minScore = v
+	}
+	ds, err := h.UC.Duplicates(r.Context(), int32(id), minScore, limit)
+	if err != nil {
+		var moved *domain.MovedError
+		switch {
+		case errors.As(err, &moved):
+			writeMoved(w, r, int32(id), moved.To)
+		case errors.Is(err, domain.ErrNotFound):
+			writeErr(w, r, StatusNotFound, MsgNotFound, nil)
+		default:
+			log.Error.Printf("list_duplicates repo_err id=%d err=%v", id, err)
+			writeErr(w, r, StatusInternalServerError, MsgInternal, nil)
+		}
+		return
+	}
+	ids := make([]int32, 0, len(ds))
+	for _, d := range ds {
+		ids = append(ids, d.Customer.ID)
+	}
+	m, ok := h.masker(w, r, "list_duplicates", ids...)
+	if !ok {
+		return
+	}
+	out := make([]dto.DuplicateResponse, 0, len(ds))
+	for _, d := range ds {
+		out = append(out, dto.DuplicateResponse{Customer: toListItem(d.Customer, m, false), Score: d.Score, Signals: d.Signals})
+	}
+	log.Info.Printf("list_duplicates ok id=%d found=%d", id, len(out))
+	writeJSON(w, StatusOK, out)
+}
+
+func (h *Handler) MergeUsers(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	if id <= 0 {
+		writeErr(w, r, StatusBadRequest, MsgInvalidID, nil)
+		return
+	}
+	var req dto.MergeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error.Printf("merge_users decode_json err=%v", err)
+		writeErr(w, r, StatusBadRequest, MsgInvalidJSON, nil)
+		return
+	}
+	if err := h.Val.Struct(req); err != nil {
+		writeValidationErr(w, r, err)
+		return
+	}
+	c, err := h.UC.Merge(r.Context(), int32(id), req.SourceID, domain.MergePolicy(req.Policy))
+	if err != nil {
+		writeWriteErr(w, r, "merge_users", int32(id), domain.Customer{}, err)
+		return
+	}
+	log.Info.Printf("merge_users ok target=%d source=%d policy=%v", id, req.SourceID, req.Policy)
+	if m, ok := h.masker(w, r, "merge_users", c.ID); ok {
+		writeJSON(w, StatusOK, toCustomerResponse(*c, m))
+	}
+}
@@ -2,6 +2,7 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"github.com/go-playground/validator/v10"
@@ -12,11 +13,16 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
 	"usrsvc/internal/domain" // ← sesuaikan module path
-	"usrsvc/internal/mocks"  // sesuaikan module path jika berbeda
+	"usrsvc/internal/dto"
+	"usrsvc/internal/mocks" // sesuaikan module path jika berbeda
+	"usrsvc/internal/pkg/cache"
+	"usrsvc/internal/repository"
+	"usrsvc/internal/usecase"
 )
 
 func TestHandler_ListNationality(t *testing.T) {
@@ -211,6 +217,48 @@ func TestHandler_CreateUser(t *testing.T) {
 				assert.Contains(t, string(body), "already exists")
 			},
 		},
+		{
+			name: "422_invalid_phone",
+			bodyObj: map[string]any{
+				"nationality_id": 1,
+				"cst_name":       "ALFA",
+				"cst_dob":        "1992-05-10",
+				"cst_phoneNum":   "0811",
+				"cst_email":      "a@example.com",
+			},
+			setupMock: func(m *mocks.UserUsecase) {
+				m.On("Create", mock.Anything, mock.AnythingOfType("domain.Customer")).
+					Return(int32(0), domain.ErrInvalidPhone).Once()
+			},
+			wantCode: http.StatusUnprocessableEntity,
+			checkBody: func(t *testing.T, body []byte) {
+				assert.Contains(t, string(body), "cst_phoneNum")
+			},
+		},
+		{
+			name: "422_rule_violation_with_field_paths",
+			bodyObj: map[string]any{
+				"nationality_id": 1,
+				"cst_name":       "ALFA",
+				"cst_dob":        "1992-05-10",
+				"cst_phoneNum":   "0811",
+				"cst_email":      "a@example.com",
+			},
+			setupMock: func(m *mocks.UserUsecase) {
+				m.On("Create", mock.Anything, mock.AnythingOfType("domain.Customer")).
+					Return(int32(0), &domain.RuleError{Violations: []domain.Violation{
+						{Field: "family[1].fl_relation", Rule: domain.RuleSingleSpouse},
+						{Field: "cst_dob", Rule: domain.RuleMinCustomerAge, Params: []string{"17"}},
+					}}).Once()
+			},
+			wantCode: http.StatusUnprocessableEntity,
+			checkBody: func(t *testing.T, body []byte) {
+				var got apiError
+				require.NoError(t, json.Unmarshal(body, &got))
+				assert.Equal(t, "only one spouse is allowed", got.Fields["family[1].fl_relation"])
+				assert.Equal(t, "customer must be at least 17 years old", got.Fields["cst_dob"])
+			},
+		},
 		{
 			name: "500_repo_error",
 			bodyObj: map[string]any{
@@ -356,8 +404,10 @@ func TestHandler_GetUser(t *testing.T) {
 	tests := []struct {
 		name      string
 		idVar     string
+		query     string
 		setupMock func(m *mocks.UserUsecase)
 		wantCode  int
+		wantBody  string
 	}{
 		{
 			name:  "400_invalid_id_zero",
@@ -416,6 +466,29 @@ func TestHandler_GetUser(t *testing.T) {
 			},
 			wantCode: http.StatusOK,
 		},
+		{
+			name:  "200_sparse_fields",
+			idVar: "127",
+			query: "fields=cst_name,%20family.fl_name",
+			setupMock: func(m *mocks.UserUsecase) {
+				selected := mock.MatchedBy(func(ctx context.Context) bool {
+					f := domain.FieldsFrom(ctx)
+					return f.Has("cst_name") && !f.Has("cst_email")
+				})
+				m.On("Get", selected, int32(127)).
+					Return(&domain.Customer{ID: 127, Name: "ALFA ", Email: "a@example.com",
+						Family: []domain.FamilyMember{{Relation: "child", Name: "BETA"}}}, nil).
+					Once()
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"cst_name":"ALFA","family":[{"fl_name":"BETA"}]}`,
+		},
+		{
+			name:     "400_unknown_field",
+			idVar:    "128",
+			query:    "fields=cst_id,cst_password",
+			wantCode: http.StatusBadRequest,
+		},
 	}
 
 	for _, tc := range tests {
@@ -427,7 +500,7 @@ func TestHandler_GetUser(t *testing.T) {
 
 			h := &Handler{UC: mockUC, Val: validator.New()}
 
-			req := httptest.NewRequest(http.MethodGet, "/users/"+tc.idVar, nil)
+			req := httptest.NewRequest(http.MethodGet, "/users/"+tc.idVar+"?"+tc.query, nil)
 			req = mux.SetURLVars(req, map[string]string{"id": tc.idVar})
 			rr := httptest.NewRecorder()
 
@@ -440,12 +513,41 @@ func TestHandler_GetUser(t *testing.T) {
 			if tc.wantCode == http.StatusOK {
 				assert.NotEmpty(t, rr.Body.Bytes())
 			}
+			if tc.wantBody != "" {
+				assert.JSONEq(t, tc.wantBody, rr.Body.String())
+			}
 
 			mockUC.AssertExpectations(t)
 		})
 	}
 }
 
+func TestHandler_GetUser_FieldsBypassCache(t *testing.T) {
+	c := &domain.Customer{ID: 36, NationalityID: 1, Name: "ALFA", Email: "a@x.com"}
+	repo := new(mocks.UserRepository)
+	repo.On("GetCustomer", mock.MatchedBy(func(ctx context.Context) bool { return domain.FieldsFrom(ctx) == nil }), int32(36)).
+		Return(c, nil).Once()
+	repo.On("GetCustomer", mock.MatchedBy(func(ctx context.Context) bool {
+		f := domain.FieldsFrom(ctx)
+		return f.Has("cst_name") && !f.Has("cst_email")
+	}), int32(36)).Return(&domain.Customer{ID: 36, Name: "ALFA"}, nil).Twice()
+	h := &Handler{UC: usecase.NewUserUC(repository.NewCachedRepo(repo, cache.NewLRU(10), time.Minute, nil)), Val: validator.New()}
+
+	for _, tc := range []struct{ query, want string }{
+		{"", `"cst_email":"a@x.com"`},
+		{"", `"cst_email":"a@x.com"`},
+		{"fields=cst_name", `{"cst_name":"ALFA"}`},
+		{"fields=cst_name", `{"cst_name":"ALFA"}`},
+	} {
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/v1/users/36?"+tc.query, nil), map[string]string{"id": "36"})
+		rr := httptest.NewRecorder()
+		h.GetUser(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Contains(t, rr.Body.String(), tc.want)
+	}
+	repo.AssertExpectations(t)
+}
+
 func TestHandler_ListNationality1(t *testing.T) {
 	type fields struct {
 		UC  domain.UserUsecase
@@ -571,6 +673,96 @@ func TestHandler_ListUsers(t *testing.T) {
 			wantCode:  http.StatusOK,
 			checkBody: func(t *testing.T, b []byte) {},
 		},
+		{
+			name:  "200_include_family",
+			query: url.Values{"include": {"family"}},
+			setupMock: func(m *mocks.UserUsecase) {
+				m.On("List", mock.Anything, "", 1, 10).
+					Return([]domain.Customer{{ID: 36, Dob: t1}, {ID: 37, Dob: t2}}, int32(2), nil).Once()
+				m.On("LoadFamilies", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+					args.Get(1).([]domain.Customer)[0].Family = []domain.FamilyMember{{Relation: "child", Name: "CHARLIE", Dob: t1}}
+				}).Once()
+			},
+			wantCode: http.StatusOK,
+			checkBody: func(t *testing.T, b []byte) {
+				var got dto.CustomerListResponse
+				require.NoError(t, json.Unmarshal(b, &got))
+				require.Len(t, got.Data, 2)
+				assert.Equal(t, []dto.FamilyMemberResponse{{FlRelation: "child", FlName: "CHARLIE", FlDob: "1992-05-10"}}, got.Data[0].Family)
+				assert.NotNil(t, got.Data[1].Family, "empty family is still rendered")
+				assert.Contains(t, string(b), `"family":[]`)
+				assert.NotContains(t, string(b), "missing")
+			},
+		},
+		{
+			name:  "200_batch_get_in_request_order",
+			query: url.Values{"ids": {"37, 9,36"}, "page": {"3"}, "search": {"ignored"}},
+			setupMock: func(m *mocks.UserUsecase) {
+				m.On("GetMany", mock.Anything, []int32{37, 9, 36}, false).
+					Return([]domain.Customer{{ID: 37, Dob: t2}, {ID: 36, Dob: t1}}, []int32{9}, nil).Once()
+			},
+			wantCode: http.StatusOK,
+			checkBody: func(t *testing.T, b []byte) {
+				var got dto.CustomerListResponse
+				require.NoError(t, json.Unmarshal(b, &got))
+				require.Len(t, got.Data, 2)
+				assert.EqualValues(t, 37, got.Data[0].CstID)
+				assert.EqualValues(t, 36, got.Data[1].CstID)
+				assert.Equal(t, 2, got.Total)
+				assert.Equal(t, []int32{9}, got.Missing)
+				assert.NotContains(t, string(b), "family")
+			},
+		},
+		{
+			name:  "200_sparse_fields_load_family",
+			query: url.Values{"fields": {"cst_id,cst_name,family.fl_name"}},
+			setupMock: func(m *mocks.UserUsecase) {
+				m.On("List", mock.Anything, "", 1, 10).
+					Return([]domain.Customer{{ID: 36, Name: "ALFA", Dob: t1}}, int32(1), nil).Once()
+				m.On("LoadFamilies", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+					args.Get(1).([]domain.Customer)[0].Family = []domain.FamilyMember{{Relation: "child", Name: "CHARLIE", Dob: t1}}
+				}).Once()
+			},
+			wantCode: http.StatusOK,
+			checkBody: func(t *testing.T, b []byte) {
+				assert.JSONEq(t, `{"data":[{"cst_id":36,"cst_name":"ALFA","family":[{"fl_name":"CHARLIE"}]}],"total":1}`, string(b))
+			},
+		},
+		{
+			name:  "200_sparse_fields_skip_family",
+			query: url.Values{"ids": {"36"}, "include": {"family"}, "fields": {"cst_email"}},
+			setupMock: func(m *mocks.UserUsecase) {
+				m.On("GetMany", mock.Anything, []int32{36}, false).
+					Return([]domain.Customer{{ID: 36, Email: "a@example.com", Dob: t1}}, []int32{}, nil).Once()
+			},
+			wantCode: http.StatusOK,
+			checkBody: func(t *testing.T, b []byte) {
+				assert.JSONEq(t, `{"data":[{"cst_email":"a@example.com"}],"total":1,"missing":[]}`, string(b))
+			},
+		},
+		{
+			name:      "400_unknown_field",
+			query:     url.Values{"fields": {"cst_id,cst_password"}},
+			setupMock: func(m *mocks.UserUsecase) {},
+			wantCode:  http.StatusBadRequest,
+			checkBody: func(t *testing.T, b []byte) {
+				assert.Contains(t, string(b), `unknown field cst_password; allowed: cst_id, cst_name`)
+			},
+		},
+		{
+			name:      "400_bad_ids",
+			query:     url.Values{"ids": {"1,0"}},
+			setupMock: func(m *mocks.UserUsecase) {},
+			wantCode:  http.StatusBadRequest,
+			checkBody: func(t *testing.T, b []byte) { assert.Contains(t, string(b), `"ids"`) },
+		},
+		{
+			name:      "400_too_many_ids",
+			query:     url.Values{"ids": {strings.Repeat("1,", maxBatchIDs) + "1"}},
+			setupMock: func(m *mocks.UserUsecase) {},
+			wantCode:  http.StatusBadRequest,
+			checkBody: func(t *testing.T, b []byte) { assert.Contains(t, string(b), `"ids"`) },
+		},
 		{
 			name:  "500_repo_error",
 			query: url.Values{"page": {"1"}, "size": {"10"}},
@@ -610,6 +802,98 @@ func TestHandler_ListUsers(t *testing.T) {
 	}
 }
 
+func TestHandler_UserStats(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 9, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name      string
+		query     url.Values
+		setupMock func(m *mocks.UserUsecase)
+		wantCode  int
+		checkBody func(t *testing.T, b []byte)
+	}{
+		{
+			name:  "200_ok",
+			query: url.Values{"search": {"AL"}, "interval": {"day"}, "from": {"2026-09-01"}, "to": {"2026-09-02"}},
+			setupMock: func(m *mocks.UserUsecase) {
+				m.On("Stats", mock.Anything, domain.StatsFilter{Search: "AL", Interval: "day", From: day(1), To: day(2)}).
+					Return(&domain.CustomerStats{
+						Total:    2,
+						ByAge:    []domain.BucketCount{{Label: "0-17"}, {Label: "18-24", Count: 2}},
+						Created:  []domain.PeriodCount{{Start: day(1)}, {Start: day(2), Count: 2}},
+						Interval: "day", From: day(1), To: day(2),
+					}, nil).
+					Once()
+			},
+			wantCode: http.StatusOK,
+			checkBody: func(t *testing.T, b []byte) {
+				assert.JSONEq(t, `{"total":2,"by_nationality":[],"family_size":[],
+					"by_age":[{"bucket":"0-17","count":0},{"bucket":"18-24","count":2}],
+					"created":{"interval":"day","from":"2026-09-01","to":"2026-09-02",
+						"buckets":[{"start":"2026-09-01","count":0},{"start":"2026-09-02","count":2}]}}`, string(b))
+			},
+		},
+		{
+			name:      "400_bad_params",
+			query:     url.Values{"interval": {"year"}, "to": {"02-09-2026"}},
+			setupMock: func(m *mocks.UserUsecase) {},
+			wantCode:  http.StatusBadRequest,
+			checkBody: func(t *testing.T, b []byte) {
+				var got apiError
+				require.NoError(t, json.Unmarshal(b, &got))
+				assert.Equal(t, "must be day, week or month", got.Fields["interval"])
+				assert.Equal(t, "YYYY-MM-DD", got.Fields["to"])
+			},
+		},
+		{
+			name:      "400_from_after_to",
+			query:     url.Values{"from": {"2026-09-02"}, "to": {"2026-09-01"}},
+			setupMock: func(m *mocks.UserUsecase) {},
+			wantCode:  http.StatusBadRequest,
+			checkBody: func(t *testing.T, b []byte) { assert.Contains(t, string(b), "must not be after to") },
+		},
+		{
+			name:      "400_too_many_periods",
+			query:     url.Values{"interval": {"day"}, "from": {"2025-09-01"}, "to": {"2026-09-02"}},
+			setupMock: func(m *mocks.UserUsecase) {},
+			wantCode:  http.StatusBadRequest,
+			checkBody: func(t *testing.T, b []byte) { assert.Contains(t, string(b), "more than 366 intervals") },
+		},
+		{
+			name:      "400_too_many_periods_until_now",
+			query:     url.Values{"from": {"2000-01-01"}},
+			setupMock: func(m *mocks.UserUsecase) {},
+			wantCode:  http.StatusBadRequest,
+			checkBody: func(t *testing.T, b []byte) { assert.Contains(t, string(b), "more than 120 intervals") },
+		},
+		{
+			name:  "500_repo_error",
+			query: url.Values{},
+			setupMock: func(m *mocks.UserUsecase) {
+				m.On("Stats", mock.Anything, domain.StatsFilter{}).Return(nil, assert.AnError).Once()
+			},
+			wantCode:  http.StatusInternalServerError,
+			checkBody: func(t *testing.T, b []byte) { assert.NotEmpty(t, b) },
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUC := new(mocks.UserUsecase)
+			tc.setupMock(mockUC)
+			h := &Handler{UC: mockUC, Val: validator.New()}
+
+			req := httptest.NewRequest(http.MethodGet, (&url.URL{Path: "/users/stats", RawQuery: tc.query.Encode()}).String(), nil)
+			rr := httptest.NewRecorder()
+			h.UserStats(rr, req)
+
+			assert.Equal(t, tc.wantCode, rr.Code)
+			tc.checkBody(t, rr.Body.Bytes())
+			mockUC.AssertExpectations(t)
+		})
+	}
+}
+
 func makeUpdateReq(idStr string, body []byte) (*http.Request, *httptest.ResponseRecorder) {
 	req := httptest.NewRequest(http.MethodPut, "/users/"+idStr, bytes.NewReader(body))
 	req = mux.SetURLVars(req, map[string]string{"id": idStr})
@@ -711,6 +995,25 @@ func TestHandler_UpdateUser(t *testing.T) {
 			},
 			checkBody: func(t *testing.T, b []byte) { assert.NotEmpty(t, b) },
 		},
+		{
+			name:  "409_conflict_email_taken",
+			idVar: "778",
+			bodyObj: map[string]any{
+				"nationality_id": 1,
+				"cst_name":       "GAMMA",
+				"cst_dob":        "1990-01-01",
+				"cst_phoneNum":   "0800",
+				"cst_email":      "Taken@Example.com",
+			},
+			wantCode: http.StatusConflict,
+			setupMock: func(m *mocks.UserUsecase) {
+				m.On("Update", mock.Anything, int32(778), mock.Anything).
+					Return(domain.ErrConflict).Once()
+			},
+			checkBody: func(t *testing.T, b []byte) {
+				assert.Contains(t, string(b), "already exists")
+			},
+		},
 		{
 			name:  "500_repo_error",
 			idVar: "888",
@@ -836,3 +1139,104 @@ func Test_mustParse(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_Localization(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       []byte
+		acceptLang string
+		wantCode   int
+		wantMsg    string
+		wantFields map[string]string
+	}{
+		{
+			name:     "default_english",
+			method:   http.MethodGet,
+			path:     "/v1/users/0",
+			wantCode: http.StatusBadRequest,
+			wantMsg:  "invalid id",
+		},
+		{
+			name:       "indonesian",
+			method:     http.MethodGet,
+			path:       "/v1/users/0",
+			acceptLang: "id-ID,id;q=0.9,en;q=0.8",
+			wantCode:   http.StatusBadRequest,
+			wantMsg:    "id tidak valid",
+		},
+		{
+			name:       "unsupported_falls_back_to_english",
+			method:     http.MethodGet,
+			path:       "/v1/users/0",
+			acceptLang: "fr-FR,de;q=0.5",
+			wantCode:   http.StatusBadRequest,
+			wantMsg:    "invalid id",
+		},
+		{
+			name:       "quality_order_wins",
+			method:     http.MethodGet,
+			path:       "/v1/users/0",
+			acceptLang: "en;q=0.3,id;q=0.9",
+			wantCode:   http.StatusBadRequest,
+			wantMsg:    "id tidak valid",
+		},
+		{
+			name:       "validation_fields_indonesian",
+			method:     http.MethodPost,
+			path:       "/v1/users",
+			body:       []byte(`{"nationality_id":1,"cst_name":"ALFA","cst_dob":"1992-05-10","cst_phoneNum":"0811","cst_email":"nope","family":[{"fl_relation":"Child","fl_dob":"2010-01-01"}]}`),
+			acceptLang: "id",
+			wantCode:   http.StatusUnprocessableEntity,
+			wantMsg:    "validasi gagal",
+			wantFields: map[string]string{
+				"cst_email":         "cst_email harus berupa alamat email yang valid",
+				"family[0].fl_name": "fl_name wajib diisi",
+			},
+		},
+		{
+			name:       "date_field_hint_indonesian",
+			method:     http.MethodPost,
+			path:       "/v1/users",
+			body:       []byte(`{"nationality_id":1,"cst_name":"ALFA","cst_dob":"10-05-1992","cst_phoneNum":"0811","cst_email":"a@example.com"}`),
+			acceptLang: "id",
+			wantCode:   http.StatusUnprocessableEntity,
+			wantMsg:    "cst_dob tidak valid",
+			wantFields: map[string]string{"cst_dob": "format tanggal harus YYYY-MM-DD"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router := NewRouter(NewHandler(new(mocks.UserUsecase)), nil)
+
+			req := httptest.NewRequest(tc.method, tc.path, bytes.NewReader(tc.body))
+			if tc.acceptLang != "" {
+				req.Header.Set("Accept-Language", tc.acceptLang)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.wantCode, rr.Code)
+			var got apiError
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+			assert.Equal(t, tc.wantMsg, got.Message)
+			for k, v := range tc.wantFields {
+				assert.Equal(t, v, got.Fields[k], k)
+			}
+		})
+	}
+}
+
+func TestWriteErr_CopiesFields(t *testing.T) {
+	fields := map[string]string{"cst_dob": FieldDateFormat}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Language", "id")
+	rr := httptest.NewRecorder()
+	writeErr(rr, r, http.StatusBadRequest, MsgValidation, fields)
+
+	assert.Equal(t, map[string]string{"cst_dob": FieldDateFormat}, fields)
+	assert.Contains(t, rr.Body.String(), `"cst_dob":"format tanggal harus YYYY-MM-DD"`)
+	assert.Equal(t, "id", rr.Header().Get("Content-Language"))
+}
@@ -0,0 +1,29 @@
+package http
+
+// Handlers for the unversioned routes that predate /v1. They keep the old
+// payloads that differ from /v1: GET /users/{id} returned domain.Customer
+// as-is and /nationalities the Go field names (ID/Name/Code). That payload
+// has no masked form, so callers that see masked PII get 403.
+
+import "net/http"
+
+func (h *Handler) LegacyGetUser(w http.ResponseWriter, r *http.Request) {
+	c := h.loadUser(w, r)
+	if c == nil {
+		return
+	}
+	m, ok := h.masker(w, r, "legacy_get_user", c.ID)
+	switch {
+	case !ok:
+	case m.Masked():
+		writeErr(w, r, StatusForbidden, MsgPIIForbidden, nil)
+	default:
+		writeJSON(w, StatusOK, c)
+	}
+}
+
+func (h *Handler) LegacyListNationality(w http.ResponseWriter, r *http.Request) {
+	if n, ok := h.loadNationalities(w, r); ok {
+		writeJSON(w, StatusOK, n)
+	}
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/mocks"
+)
+
+func TestRouter_LegacyCompatibility(t *testing.T) {
+	dob := time.Date(1992, 5, 10, 0, 0, 0, 0, time.UTC)
+	code := "ID"
+	cust := &domain.Customer{ID: 36, NationalityID: 1, Name: "ALFA  ", Dob: dob, Email: "a@example.com"}
+	nats := []domain.Nationality{{ID: 1, Name: "Indonesia", Code: &code}}
+
+	tests := []struct {
+		name           string
+		path           string
+		wantDeprecated bool
+		wantKeys       []string
+	}{
+		{name: "v1_user", path: "/v1/users/36", wantKeys: []string{"cst_id", "cst_name", "cst_dob", "family"}},
+		{name: "legacy_user", path: "/users/36", wantDeprecated: true, wantKeys: []string{"ID", "Name", "Dob", "Family"}},
+		{name: "v1_nationalities", path: "/v1/nationalities", wantKeys: []string{"id", "name", "code"}},
+		{name: "legacy_nationalities", path: "/nationalities", wantDeprecated: true, wantKeys: []string{"ID", "Name", "Code"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(mocks.UserUsecase)
+			m.On("Get", mock.Anything, int32(36)).Return(cust, nil).Maybe()
+			m.On("ListNationality", mock.Anything).Return(nats, nil).Maybe()
+
+			rr := httptest.NewRecorder()
+			h := NewHandler(m, WithLegacyLifecycle(time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), time.Date(2027, 4, 30, 0, 0, 0, 0, time.UTC)))
+			NewRouter(h, nil).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var raw any
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &raw))
+			obj, ok := raw.(map[string]any)
+			if !ok {
+				obj = raw.([]any)[0].(map[string]any)
+			}
+			for _, k := range tc.wantKeys {
+				assert.Contains(t, obj, k)
+			}
+
+			if !tc.wantDeprecated {
+				assert.Empty(t, rr.Header().Get("Deprecation"))
+				assert.Empty(t, rr.Header().Get("Sunset"))
+				return
+			}
+			assert.Equal(t, "@1792368000", rr.Header().Get("Deprecation"))
+			assert.Equal(t, "Fri, 30 Apr 2027 00:00:00 GMT", rr.Header().Get("Sunset"))
+			assert.Equal(t, "</v1"+tc.path+`>; rel="successor-version"`, rr.Header().Get("Link"))
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package http
+
+import (
+	"encoding/json"
+	"strings"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/dto"
+	"usrsvc/internal/pii"
+)
+
+const dateLayout = "2006-01-02"
+
+// toCustomerResponse is the one customer shape returned by /v1 endpoints;
+// m masks the PII fields for callers that may not see them.
+func toCustomerResponse(c domain.Customer, m pii.Masker) dto.CustomerResponse {
+	resp := dto.CustomerResponse{
+		CstID:         c.ID,
+		CstName:       strings.TrimSpace(c.Name),
+		CstDob:        m.Date(c.Dob),
+		NationalityID: c.NationalityID,
+		CstPhoneNum:   m.Phone(c.PhoneNum),
+		CstPhoneE164:  m.Phone(c.PhoneE164),
+		CstEmail:      m.Email(c.Email),
+		Family:        make([]dto.FamilyMemberResponse, 0, len(c.Family)),
+	}
+	for _, f := range c.Family {
+		resp.Family = append(resp.Family, dto.FamilyMemberResponse{
+			FlRelation: f.Relation,
+			FlName:     f.Name,
+			FlDob:      m.Date(f.Dob),
+		})
+	}
+	return resp
+}
+
+// toListItem carries the family only when asked to, so a list without
+// include=family keeps its shape.
+func toListItem(c domain.Customer, m pii.Masker, family bool) dto.CustomerListItem {
+	item := dto.CustomerListItem{
+		CstID:         c.ID,
+		CstName:       strings.TrimSpace(c.Name),
+		CstDob:        m.Date(c.Dob),
+		NationalityID: c.NationalityID,
+		CstPhoneNum:   m.Phone(c.PhoneNum),
+		CstPhoneE164:  m.Phone(c.PhoneE164),
+		CstEmail:      m.Email(c.Email),
+	}
+	if family {
+		item.Family = toCustomerResponse(c, m).Family
+	}
+	return item
+}
+
+// sparse renders a customer with only the keys f selects, and only the
+// selected keys of each family member. A nil f returns c as is.
+func sparse(c any, f domain.Fields) any {
+	if f == nil {
+		return c
+	}
+	b, _ := json.Marshal(c)
+	var out map[string]json.RawMessage
+	_ = json.Unmarshal(b, &out)
+	for k, v := range out {
+		switch {
+		case k == domain.FieldFamily && f.Family():
+			var members []map[string]json.RawMessage
+			_ = json.Unmarshal(v, &members)
+			for _, m := range members {
+				for mk := range m {
+					if !f.Has(domain.FieldFamily + "." + mk) {
+						delete(m, mk)
+					}
+				}
+			}
+			out[k], _ = json.Marshal(members)
+		case !f.Has(k):
+			delete(out, k)
+		}
+	}
+	return out
+}
+
+// sparseList is sparse for each customer of a list.
+func sparseList(resp dto.CustomerListResponse, f domain.Fields) any {
+	if f == nil {
+		return resp
+	}
+	data := make([]any, 0, len(resp.Data))
+	for _, item := range resp.Data {
+		data = append(data, sparse(item, f))
+	}
+	return struct {
+		dto.CustomerListResponse
+		Data []any `json:"data"`
+	}{resp, data}
+}
+
+func customerIDs(cs []domain.Customer) []int32 {
+	ids := make([]int32, 0, len(cs))
+	for _, c := range cs {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+func toNationalityResponses(ns []domain.Nationality) []dto.NationalityResponse {
+	out := make([]dto.NationalityResponse, 0, len(ns))
+	for _, n := range ns {
+		out = append(out, dto.NationalityResponse{ID: n.ID, Name: n.Name, Code: n.Code})
+	}
+	return out
+}
+
+func toStatsResponse(st domain.CustomerStats) dto.CustomerStatsResponse {
+	out := dto.CustomerStatsResponse{
+		Total:         st.Total,
+		ByNationality: make([]dto.NationalityCount, 0, len(st.ByNationality)),
+		ByAge:         make([]dto.AgeBucketCount, 0, len(st.ByAge)),
+		FamilySize:    make([]dto.FamilySizeCount, 0, len(st.FamilySize)),
+		Created: dto.CreatedSeries{
+			Interval: st.Interval,
+			From:     st.From.Format(dateLayout),
+			To:       st.To.Format(dateLayout),
+			Buckets:  make([]dto.PeriodCount, 0, len(st.Created)),
+		},
+	}
+	for _, n := range st.ByNationality {
+		out.ByNationality = append(out.ByNationality, dto.NationalityCount{NationalityID: n.NationalityID, Name: n.Name, Count: n.Count})
+	}
+	for _, b := range st.ByAge {
+		out.ByAge = append(out.ByAge, dto.AgeBucketCount{Bucket: b.Label, Count: b.Count})
+	}
+	for _, f := range st.FamilySize {
+		out.FamilySize = append(out.FamilySize, dto.FamilySizeCount{Members: f.Members, Count: f.Count})
+	}
+	for _, p := range st.Created {
+		out.Created.Buckets = append(out.Created.Buckets, dto.PeriodCount{Start: p.Start.Format(dateLayout), Count: p.Count})
+	}
+	return out
+}
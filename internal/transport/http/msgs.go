@@ -1,10 +1,138 @@
 package http
 
+import (
+	"usrsvc/internal/domain"
+	"usrsvc/internal/pkg/i18n"
+)
+
+// Message keys; the texts live in the per-locale catalogs below.
 const (
-	MsgInvalidID   = "invalid id"
-	MsgInvalidJSON = "invalid JSON"
-	MsgValidation  = "validation error"
-	MsgNotFound    = "not found"
-	MsgInternal    = "internal error"
-	MsgConflict    = "conflict"
+	MsgInvalidID        = "invalid_id"
+	MsgInvalidJSON      = "invalid_json"
+	MsgValidation       = "validation_error"
+	MsgNotFound         = "not_found"
+	MsgInternal         = "internal_error"
+	MsgConflict         = "conflict"
+	MsgInvalidDob       = "invalid_cst_dob"
+	MsgInvalidFamilyDob = "invalid_fl_dob"
+	MsgRuleViolation    = "rule_violation"
+	MsgMoved            = "moved"
+	MsgTenantMissing    = "tenant_missing"
+	MsgTenantInvalid    = "tenant_invalid"
+	MsgSingleTenant     = "tenant_unsupported"
+	MsgUnauthorized     = "unauthorized"
+	MsgPIIForbidden     = "pii_forbidden"
+	MsgJobsForbidden    = "jobs_forbidden"
+	MsgHooksForbidden   = "webhooks_forbidden"
+	MsgEraseForbidden   = "erase_forbidden"
+	MsgJobBusy          = "job_busy"
+
+	FieldDateFormat   = "field_date_format"
+	FieldExists       = "field_exists"
+	FieldInvalidPhone = "field_invalid_phone"
+	FieldInterval     = "field_interval"
+	FieldDateOrder    = "field_date_order"
+	FieldStatsRange   = "field_stats_range"
+	FieldIDs          = "field_ids"
+	FieldInclude      = "field_include"
+	FieldFields       = "field_fields"
 )
+
+var msgsEN = map[string]string{
+	MsgInvalidID:        "invalid id",
+	MsgInvalidJSON:      "invalid JSON",
+	MsgValidation:       "validation error",
+	MsgNotFound:         "not found",
+	MsgInternal:         "internal error",
+	MsgConflict:         "conflict",
+	MsgInvalidDob:       "invalid cst_dob",
+	MsgInvalidFamilyDob: "invalid fl_dob",
+	MsgRuleViolation:    "business rule violation",
+	MsgMoved:            "customer was merged into another; follow Location",
+	MsgTenantMissing:    "X-Tenant-ID header is required",
+	MsgTenantInvalid:    "tenant id must be lowercase letters, digits, _ or -",
+	MsgSingleTenant:     "this storage serves the default tenant only",
+	MsgUnauthorized:     "missing or invalid bearer token",
+	MsgPIIForbidden:     "your role sees masked customer data, which this endpoint cannot return; use /v1/users or the pii:unmask scope",
+	MsgJobsForbidden:    "the jobs:admin scope is required",
+	MsgHooksForbidden:   "the webhooks:admin scope and a role that sees unmasked customer data are required",
+	MsgEraseForbidden:   "the pii:erase scope and a role that sees unmasked customer data are required",
+	MsgJobBusy:          "too many runs are queued; try again shortly",
+
+	FieldDateFormat:   "YYYY-MM-DD",
+	FieldExists:       "already exists",
+	FieldInvalidPhone: "not a valid phone number for the customer's nationality",
+	FieldInterval:     "must be day, week or month",
+	FieldDateOrder:    "must not be after to",
+	FieldStatsRange:   "from..to spans more than {0} intervals",
+	FieldIDs:          "comma-separated positive ids, at most 100",
+	FieldInclude:      "must be family",
+	FieldFields:       "unknown field {0}; allowed: {1}",
+
+	domain.RuleDobInFuture:        "date of birth is in the future",
+	domain.RuleMinCustomerAge:     "customer must be at least {0} years old",
+	domain.RuleUnknownRelation:    "relation must be one of: {0}",
+	domain.RuleSingleSpouse:       "only one spouse is allowed",
+	domain.RuleChildTooOld:        "a child must be at least {0} years younger than the customer",
+	domain.RuleParentTooYoung:     "a parent must be at least {0} years older than the customer",
+	domain.RuleMaxFamilyMembers:   "at most {0} family members are allowed",
+	domain.RuleWebhookURL:         "must be an absolute http or https URL",
+	domain.RuleWebhookHost:        "host must resolve to public addresses only",
+	domain.RuleMergeSelf:          "a customer cannot be merged into itself",
+	domain.RuleReasonRequired:     "a reason is required",
+	domain.RuleUnknownNationality: "nationality does not exist",
+}
+
+var msgsID = map[string]string{
+	MsgInvalidID:        "id tidak valid",
+	MsgInvalidJSON:      "JSON tidak valid",
+	MsgValidation:       "validasi gagal",
+	MsgNotFound:         "data tidak ditemukan",
+	MsgInternal:         "terjadi kesalahan internal",
+	MsgConflict:         "data sudah ada",
+	MsgInvalidDob:       "cst_dob tidak valid",
+	MsgInvalidFamilyDob: "fl_dob tidak valid",
+	MsgRuleViolation:    "melanggar aturan bisnis",
+	MsgMoved:            "pelanggan sudah digabung ke data lain; ikuti Location",
+	MsgTenantMissing:    "header X-Tenant-ID wajib diisi",
+	MsgTenantInvalid:    "id tenant hanya boleh huruf kecil, angka, _ atau -",
+	MsgSingleTenant:     "penyimpanan ini hanya melayani tenant default",
+	MsgUnauthorized:     "bearer token tidak ada atau tidak valid",
+	MsgPIIForbidden:     "peran Anda hanya melihat data pelanggan yang disamarkan, yang tidak bisa dikembalikan endpoint ini; gunakan /v1/users atau scope pii:unmask",
+	MsgJobsForbidden:    "scope jobs:admin diperlukan",
+	MsgHooksForbidden:   "diperlukan scope webhooks:admin dan peran yang melihat data pelanggan tanpa disamarkan",
+	MsgEraseForbidden:   "diperlukan scope pii:erase dan peran yang melihat data pelanggan tanpa disamarkan",
+	MsgJobBusy:          "terlalu banyak eksekusi dalam antrean; coba lagi sebentar lagi",
+
+	FieldDateFormat:   "format tanggal harus YYYY-MM-DD",
+	FieldExists:       "sudah terdaftar",
+	FieldInvalidPhone: "nomor telepon tidak valid untuk kewarganegaraan pelanggan",
+	FieldInterval:     "harus day, week, atau month",
+	FieldDateOrder:    "tidak boleh setelah to",
+	FieldStatsRange:   "from..to mencakup lebih dari {0} interval",
+	FieldIDs:          "id positif dipisah koma, maksimal 100",
+	FieldInclude:      "harus family",
+	FieldFields:       "field {0} tidak dikenal; yang diizinkan: {1}",
+
+	domain.RuleDobInFuture:        "tanggal lahir tidak boleh di masa depan",
+	domain.RuleMinCustomerAge:     "usia pelanggan minimal {0} tahun",
+	domain.RuleUnknownRelation:    "hubungan harus salah satu dari: {0}",
+	domain.RuleSingleSpouse:       "hanya boleh ada satu pasangan",
+	domain.RuleChildTooOld:        "anak harus minimal {0} tahun lebih muda dari pelanggan",
+	domain.RuleParentTooYoung:     "orang tua harus minimal {0} tahun lebih tua dari pelanggan",
+	domain.RuleMaxFamilyMembers:   "maksimal {0} anggota keluarga",
+	domain.RuleWebhookURL:         "harus berupa URL http atau https yang lengkap",
+	domain.RuleWebhookHost:        "host hanya boleh mengarah ke alamat publik",
+	domain.RuleMergeSelf:          "pelanggan tidak dapat digabung dengan dirinya sendiri",
+	domain.RuleReasonRequired:     "alasan wajib diisi",
+	domain.RuleUnknownNationality: "kewarganegaraan tidak ditemukan",
+}
+
+func init() {
+	if err := i18n.AddCatalog("en", msgsEN); err != nil {
+		panic(err)
+	}
+	if err := i18n.AddCatalog("id", msgsID); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,426 @@
+package http
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/dto"
+)
+
+// apiOp documents one route registered in NewRouter. Bodies are Go values
+// whose types are reflected into JSON Schema, so field names always follow
+// the json tags that the handlers actually encode.
+type apiOp struct {
+	Method, Path, ID, Summary string
+	Query                     []apiParam
+	Body                      any
+	Responses                 map[int]any // noBody = status without a JSON payload
+	Deprecated                bool
+}
+
+type apiParam struct {
+	Name, Type, Description string
+}
+
+// noBody marks a response without a JSON payload.
+var noBody = struct{}{}
+
+var errBody = apiError{}
+
+// fieldsDoc describes the sparse fieldset parameter of the customer reads.
+var fieldsDoc = "comma-separated keys to return, e.g. cst_id,cst_name,family.fl_name; family selects every member key. " +
+	"Responses then carry only those keys. Allowed: " + strings.Join(domain.CustomerFields, ", ")
+
+// eventStream marks a text/event-stream response.
+var eventStream = struct{ SSE bool }{true}
+
+var rootOps = []apiOp{
+	{Method: http.MethodGet, Path: "/healthz", ID: "health", Summary: "Liveness probe",
+		Responses: map[int]any{StatusOK: noBody}},
+	{Method: http.MethodGet, Path: "/openapi.json", ID: "openapi", Summary: "This document",
+		Responses: map[int]any{StatusOK: map[string]any{}}},
+	{Method: http.MethodGet, Path: "/docs", ID: "docs", Summary: "Swagger UI",
+		Responses: map[int]any{StatusOK: noBody}},
+}
+
+var v1Ops = []apiOp{
+	{Method: http.MethodGet, Path: "/v1/nationalities", ID: "listNationalities", Summary: "List all nationalities",
+		Responses: map[int]any{StatusOK: []dto.NationalityResponse{}, StatusInternalServerError: errBody}},
+	{Method: http.MethodGet, Path: "/v1/users", ID: "listUsers", Summary: "Paginated customer list with optional search",
+		Query: []apiParam{
+			{"page", "integer", "1-based page, defaults to 1"},
+			{"size", "integer", "page size 1..100, defaults to 10"},
+			{"search", "string", "name/email fragment (whole email once encrypted), or a phone number matched on its E.164 form"},
+			{"ids", "string", "comma-separated ids, at most 100; returns those customers in that order, ignoring page, size and search, and lists the ids not found in missing"},
+			{"include", "string", "family adds each customer's family members"},
+			{"fields", "string", fieldsDoc},
+		},
+		Responses: map[int]any{StatusOK: dto.CustomerListResponse{}, StatusBadRequest: errBody, StatusInternalServerError: errBody}},
+	{Method: http.MethodPost, Path: "/v1/users", ID: "createUser", Summary: "Create a customer with optional family",
+		Body: dto.CreateCustomerRequest{},
+		Responses: map[int]any{StatusCreated: dto.CustomerResponse{}, StatusBadRequest: errBody,
+			StatusConflict: errBody, StatusUnprocessableEntity: errBody, StatusInternalServerError: errBody}},
+	{Method: http.MethodGet, Path: "/v1/users/{id}", ID: "getUser", Summary: "Get one customer with family; merged ids redirect (308) to the surviving customer",
+		Query: []apiParam{{"fields", "string", fieldsDoc}},
+		Responses: map[int]any{StatusOK: dto.CustomerResponse{}, StatusPermanentRedirect: errBody, StatusBadRequest: errBody,
+			StatusNotFound: errBody, StatusInternalServerError: errBody}},
+	{Method: http.MethodPut, Path: "/v1/users/{id}", ID: "updateUser", Summary: "Replace a customer and their family",
+		Body: dto.UpdateCustomerRequest{},
+		Responses: map[int]any{StatusOK: dto.StatusResponse{}, StatusBadRequest: errBody, StatusNotFound: errBody,
+			StatusConflict: errBody, StatusUnprocessableEntity: errBody, StatusInternalServerError: errBody}},
+	{Method: http.MethodDelete, Path: "/v1/users/{id}", ID: "deleteUser", Summary: "Delete a customer and their family",
+		Responses: map[int]any{StatusOK: dto.StatusResponse{}, StatusBadRequest: errBody,
+			StatusNotFound: errBody, StatusInternalServerError: errBody}},
+}
+
+// customerOps are /v1 additions without a legacy twin.
+var customerOps = []apiOp{
+	{Method: http.MethodGet, Path: "/v1/users/stats", ID: "userStats", Summary: "Customer counts by nationality, age, family size and creation interval",
+		Query: []apiParam{
+			{"search", "string", "same filter as listUsers"},
+			{"interval", "string", "created series granularity: day, week or month (default)"},
+			{"from", "string", "YYYY-MM-DD, first day of the created series; defaults to 11 intervals before to"},
+			{"to", "string", "YYYY-MM-DD, last day of the created series; defaults to today (UTC)"},
+		},
+		Responses: map[int]any{StatusOK: dto.CustomerStatsResponse{}, StatusBadRequest: errBody, StatusInternalServerError: errBody}},
+	{Method: http.MethodPost, Path: "/v1/users:batchGet", ID: "batchGetUsers", Summary: "Customers by id in the order asked, with the ids not found in missing",
+		Body: dto.BatchGetRequest{},
+		Responses: map[int]any{StatusOK: dto.CustomerListResponse{}, StatusBadRequest: errBody,
+			StatusUnprocessableEntity: errBody, StatusInternalServerError: errBody}},
+	{Method: http.MethodGet, Path: "/v1/users/{id}/duplicates", ID: "listDuplicates", Summary: "Customers that may be the same person, best match first",
+		Query: []apiParam{
+			{"min_score", "number", "0..1, defaults to 0.5"},
+			{"limit", "integer", "1..50, defaults to 10"},
+		},
+		Responses: map[int]any{StatusOK: []dto.DuplicateResponse{}, StatusPermanentRedirect: errBody, StatusBadRequest: errBody,
+			StatusNotFound: errBody, StatusInternalServerError: errBody}},
+	{Method: http.MethodPost, Path: "/v1/users/{id}:merge", ID: "mergeUsers", Summary: "Fold source_id into this customer and redirect the source id here",
+		Body: dto.MergeRequest{},
+		Responses: map[int]any{StatusOK: dto.CustomerResponse{}, StatusBadRequest: errBody, StatusNotFound: errBody,
+			StatusConflict: errBody, StatusUnprocessableEntity: errBody, StatusInternalServerError: errBody}},
+	{Method: http.MethodGet, Path: "/v1/users/{id}/data-export", ID: "exportUser",
+		Summary: "Everything stored about a customer (subject access request); audited with the caller and reason",
+		Query:   []apiParam{{"reason", "string", "required, stored in the audit log"}},
+		Responses: map[int]any{StatusOK: dto.DataExportResponse{}, StatusPermanentRedirect: errBody, StatusBadRequest: errBody,
+			StatusForbidden: errBody, StatusNotFound: errBody, StatusUnprocessableEntity: errBody, StatusInternalServerError: errBody}},
+	{Method: http.MethodPost, Path: "/v1/users/{id}:anonymize", ID: "anonymizeUser",
+		Summary: "Irreversibly scrub a customer's PII, keeping the row; needs the pii:erase scope, audited with the caller and reason",
+		Body:    dto.AnonymizeRequest{},
+		Responses: map[int]any{StatusOK: dto.CustomerResponse{}, StatusPermanentRedirect: errBody, StatusBadRequest: errBody,
+			StatusForbidden: errBody, StatusNotFound: errBody, StatusUnprocessableEntity: errBody, StatusInternalServerError: errBody}},
+}
+
+// feedOps are served only when the handler has an event feed.
+var feedOps = []apiOp{
+	{Method: http.MethodGet, Path: "/v1/users/events", ID: "userEvents",
+		Summary:   "Server-Sent Events of customer changes (id = outbox id, event = type, data = event JSON); send Last-Event-ID to resume",
+		Responses: map[int]any{StatusOK: eventStream}},
+}
+
+// webhookOps are served only when the handler has a WebhookUsecase; all
+// need the webhooks:admin scope and a caller that sees PII unmasked.
+var webhookOps = []apiOp{
+	{Method: http.MethodPost, Path: "/v1/webhooks", ID: "createWebhook", Summary: "Subscribe a URL to customer events; the response carries the signing secret",
+		Body: dto.CreateWebhookRequest{},
+		Responses: map[int]any{StatusCreated: dto.WebhookResponse{}, StatusBadRequest: errBody,
+			StatusUnprocessableEntity: errBody, StatusForbidden: errBody, StatusInternalServerError: errBody}},
+	{Method: http.MethodGet, Path: "/v1/webhooks", ID: "listWebhooks", Summary: "List webhook subscriptions",
+		Responses: map[int]any{StatusOK: []dto.WebhookResponse{}, StatusForbidden: errBody, StatusInternalServerError: errBody}},
+	{Method: http.MethodGet, Path: "/v1/webhooks/{id}", ID: "getWebhook", Summary: "Get one webhook subscription",
+		Responses: map[int]any{StatusOK: dto.WebhookResponse{}, StatusBadRequest: errBody,
+			StatusNotFound: errBody, StatusForbidden: errBody, StatusInternalServerError: errBody}},
+	{Method: http.MethodDelete, Path: "/v1/webhooks/{id}", ID: "deleteWebhook", Summary: "Delete a subscription and its deliveries",
+		Responses: map[int]any{StatusOK: dto.StatusResponse{}, StatusBadRequest: errBody,
+			StatusNotFound: errBody, StatusForbidden: errBody, StatusInternalServerError: errBody}},
+	{Method: http.MethodGet, Path: "/v1/webhooks/{id}/deliveries", ID: "listDeliveries", Summary: "Newest deliveries with their attempt log",
+		Query: []apiParam{{"limit", "integer", "1..100, defaults to 20"}},
+		Responses: map[int]any{StatusOK: []dto.DeliveryResponse{}, StatusBadRequest: errBody,
+			StatusNotFound: errBody, StatusForbidden: errBody, StatusInternalServerError: errBody}},
+	{Method: http.MethodPost, Path: "/v1/webhooks/{id}/deliveries/{deliveryId}:redeliver", ID: "redeliver",
+		Summary: "Queue a delivery again with a fresh attempt budget, including dead-lettered ones",
+		Responses: map[int]any{StatusAccepted: dto.StatusResponse{}, StatusBadRequest: errBody,
+			StatusNotFound: errBody, StatusForbidden: errBody, StatusInternalServerError: errBody}},
+}
+
+// jobOps are served only when the handler has a job scheduler and verifies
+// callers by token; both need the jobs:admin scope.
+var jobOps = []apiOp{
+	{Method: http.MethodGet, Path: "/v1/admin/jobs", ID: "listJobs", Summary: "Scheduled jobs with their next run here and latest runs on any instance",
+		Responses: map[int]any{StatusOK: []dto.JobResponse{}, StatusForbidden: errBody, StatusInternalServerError: errBody}},
+	{Method: http.MethodPost, Path: "/v1/admin/jobs/{name}:run", ID: "runJob",
+		Summary:   "Run a job now on this instance; skipped if another instance is running it",
+		Responses: map[int]any{StatusAccepted: dto.StatusResponse{}, StatusForbidden: errBody, StatusNotFound: errBody, StatusConflict: errBody}},
+}
+
+// legacyBodies are the 200 payloads that the unversioned routes kept when
+// /v1 switched to the dto shapes.
+var legacyBodies = map[string]any{
+	"getUser":           domain.Customer{},
+	"listNationalities": []domain.Nationality{},
+}
+
+var apiOps = concatOps(rootOps, v1Ops, customerOps, feedOps, webhookOps, jobOps, legacyOps(v1Ops))
+
+func concatOps(lists ...[]apiOp) []apiOp {
+	var out []apiOp
+	for _, l := range lists {
+		out = append(out, l...)
+	}
+	return out
+}
+
+// legacyOps documents the deprecated unversioned twin of every /v1 route.
+func legacyOps(v1 []apiOp) []apiOp {
+	out := make([]apiOp, 0, len(v1))
+	for _, op := range v1 {
+		resps := make(map[int]any, len(op.Responses))
+		for c, v := range op.Responses {
+			resps[c] = v
+		}
+		if v, ok := legacyBodies[op.ID]; ok {
+			resps[StatusOK] = v
+			// fields selects dto keys, which these payloads do not have.
+			op.Query = slices.DeleteFunc(slices.Clone(op.Query), func(p apiParam) bool { return p.Name == "fields" })
+		}
+		op.Path = strings.TrimPrefix(op.Path, "/v1")
+		op.ID = legacyID(op.ID)
+		op.Summary += " (deprecated, use /v1" + op.Path + ")"
+		op.Responses = resps
+		op.Deprecated = true
+		out = append(out, op)
+	}
+	return out
+}
+
+func legacyID(id string) string { return "legacy" + strings.ToUpper(id[:1]) + id[1:] }
+
+// schemaNames renames unexported or ambiguous Go types in components.
+var schemaNames = map[reflect.Type]string{
+	reflect.TypeOf(apiError{}): "Error",
+}
+
+var (
+	specOnce sync.Once
+	specDoc  map[string]any
+)
+
+// OpenAPI returns the OpenAPI 3.1 document for the routes in apiOps.
+func OpenAPI() map[string]any {
+	specOnce.Do(func() { specDoc = buildOpenAPI(apiOps) })
+	return specDoc
+}
+
+func (h *Handler) OpenAPISpec(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, StatusOK, OpenAPI())
+}
+
+func (h *Handler) SwaggerUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(StatusOK)
+	_, _ = w.Write([]byte(swaggerHTML))
+}
+
+const swaggerHTML = `<!doctype html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>usrsvc API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });</script>
+</body>
+</html>
+`
+
+func buildOpenAPI(ops []apiOp) map[string]any {
+	s := &schemaGen{components: map[string]any{}}
+	paths := map[string]any{}
+	for _, op := range ops {
+		item, _ := paths[op.Path].(map[string]any)
+		if item == nil {
+			item = map[string]any{}
+			paths[op.Path] = item
+		}
+		o := map[string]any{"operationId": op.ID, "summary": op.Summary}
+		if op.Deprecated {
+			o["deprecated"] = true
+		}
+
+		var params []any
+		for _, m := range pathParam.FindAllStringSubmatch(op.Path, -1) {
+			format := "int32"
+			if m[1] != "id" {
+				format = "int64"
+			}
+			params = append(params, map[string]any{
+				"name": m[1], "in": "path", "required": true,
+				"schema": map[string]any{"type": "integer", "format": format, "minimum": 1},
+			})
+		}
+		for _, q := range op.Query {
+			params = append(params, map[string]any{
+				"name": q.Name, "in": "query", "description": q.Description,
+				"schema": map[string]any{"type": q.Type},
+			})
+		}
+		if params != nil {
+			o["parameters"] = params
+		}
+		if op.Body != nil {
+			o["requestBody"] = map[string]any{
+				"required": true,
+				"content":  map[string]any{"application/json": map[string]any{"schema": s.of(reflect.TypeOf(op.Body), true)}},
+			}
+		}
+
+		codes := make([]int, 0, len(op.Responses))
+		for c := range op.Responses {
+			codes = append(codes, c)
+		}
+		sort.Ints(codes)
+		resps := map[string]any{}
+		for _, c := range codes {
+			resp := map[string]any{"description": http.StatusText(c)}
+			switch v := op.Responses[c]; v {
+			case noBody:
+			case eventStream:
+				resp["content"] = map[string]any{"text/event-stream": map[string]any{"schema": map[string]any{"type": "string"}}}
+			default:
+				resp["content"] = map[string]any{"application/json": map[string]any{"schema": s.of(reflect.TypeOf(v), false)}}
+			}
+			if op.Deprecated {
+				resp["headers"] = deprecationHeaders
+			}
+			resps[strconv.Itoa(c)] = resp
+		}
+		o["responses"] = resps
+		item[strings.ToLower(op.Method)] = o
+	}
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":       "usrsvc",
+			"version":     "1.1.0",
+			"description": "Customers and nationalities. Use the /v1 routes; the unversioned ones are deprecated and answer with Deprecation/Sunset headers. Error messages are localized via Accept-Language (en, id).",
+		},
+		"paths":      paths,
+		"components": map[string]any{"schemas": s.components},
+	}
+}
+
+var deprecationHeaders = map[string]any{
+	"Deprecation": map[string]any{"description": "RFC 9745 date the route was deprecated", "schema": map[string]any{"type": "string"}},
+	"Sunset":      map[string]any{"description": "RFC 8594 date after which the route may be removed", "schema": map[string]any{"type": "string"}},
+	"Link":        map[string]any{"description": "successor-version link to the /v1 route", "schema": map[string]any{"type": "string"}},
+}
+
+// pathParam finds {name} segments; all path parameters are positive ids.
+var pathParam = regexp.MustCompile(`\{(\w+)\}`)
+
+type schemaGen struct{ components map[string]any }
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// of returns a schema (or $ref) for t. request switches "required" to come
+// from validate tags instead of the absence of omitempty.
+func (s *schemaGen) of(t reflect.Type, request bool) map[string]any {
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Pointer:
+		inner := s.of(t.Elem(), request)
+		if typ, ok := inner["type"].(string); ok {
+			inner["type"] = []any{typ, "null"}
+			return inner
+		}
+		return map[string]any{"oneOf": []any{inner, map[string]any{"type": "null"}}}
+	case t.Kind() == reflect.Slice:
+		return map[string]any{"type": "array", "items": s.of(t.Elem(), request)}
+	case t.Kind() == reflect.Map:
+		return map[string]any{"type": "object"}
+	case t.Kind() == reflect.String:
+		return map[string]any{"type": "string"}
+	case t.Kind() == reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return map[string]any{"type": "number"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		sch := map[string]any{"type": "integer"}
+		if t.Kind() == reflect.Int32 {
+			sch["format"] = "int32"
+		}
+		return sch
+	case t.Kind() == reflect.Struct:
+		name := schemaNames[t]
+		if name == "" {
+			name = t.Name()
+		}
+		if name == "" {
+			return s.object(t, request)
+		}
+		if _, ok := s.components[name]; !ok {
+			s.components[name] = map[string]any{} // placeholder for recursive types
+			s.components[name] = s.object(t, request)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	}
+	return map[string]any{}
+}
+
+func (s *schemaGen) object(t reflect.Type, request bool) map[string]any {
+	props := map[string]any{}
+	var required []any
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		sch := s.of(f.Type, request)
+		// encoding/json writes nil slices as null.
+		if f.Type.Kind() == reflect.Slice && !request {
+			sch = map[string]any{"oneOf": []any{sch, map[string]any{"type": "null"}}}
+		}
+		validate := f.Tag.Get("validate")
+		for _, rule := range strings.Split(validate, ",") {
+			switch {
+			case rule == "email":
+				sch["format"] = "email"
+			case strings.HasPrefix(rule, "gt="):
+				if n, err := strconv.Atoi(strings.TrimPrefix(rule, "gt=")); err == nil {
+					sch["exclusiveMinimum"] = n
+				}
+			}
+		}
+		props[name] = sch
+		if request && strings.Contains(validate, "required") ||
+			!request && !strings.Contains(opts, "omitempty") && !strings.Contains(opts, "omitzero") {
+			required = append(required, name)
+		}
+	}
+	obj := map[string]any{"type": "object", "properties": props}
+	if !request {
+		obj["additionalProperties"] = false
+	}
+	if required != nil {
+		obj["required"] = required
+	}
+	return obj
+}
@@ -0,0 +1,450 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/events"
+	"usrsvc/internal/jobs"
+	"usrsvc/internal/mocks"
+	"usrsvc/internal/pii"
+	"usrsvc/internal/pkg/auth"
+	"usrsvc/internal/webhooks"
+)
+
+// loadSpec fetches /openapi.json from the real router and compiles it so
+// response schemas can be addressed by JSON pointer.
+func loadSpec(t *testing.T) (map[string]any, *jsonschema.Compiler) {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	NewRouter(NewHandler(new(mocks.UserUsecase)), nil).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(rr.Body.Bytes()))
+	require.NoError(t, err)
+	c := jsonschema.NewCompiler()
+	c.DefaultDraft(jsonschema.Draft2020)
+	c.AssertFormat()
+	require.NoError(t, c.AddResource("openapi.json", doc))
+	return doc.(map[string]any), c
+}
+
+// muxPattern strips inline patterns, {deliveryId:[0-9]+} → {deliveryId}.
+var muxPattern = regexp.MustCompile(`\{(\w+):[^}]+\}`)
+
+// testSecret signs the callers of testRouter; see bearer.
+var testSecret = []byte("s3cret")
+
+// bearer is an Authorization header for a caller holding scopes.
+func bearer(scopes string) http.Header {
+	return http.Header{"Authorization": {"Bearer " + signHS256(testSecret, `{"sub":"ops-1","scope":"`+scopes+`"}`)}}
+}
+
+func testRouter(uc *mocks.UserUsecase, wh *mocks.WebhookUsecase) *mux.Router {
+	sched := jobs.NewScheduler(stubJobStore{}, "test")
+	_ = sched.Add("purge_outbox", "@daily", func(context.Context) error { return nil })
+	return NewRouter(NewHandler(uc, WithWebhooks(wh), WithEventFeed(events.NewBroker(10, 10)), WithJobs(sched), WithLegacyLifecycle(time.Now(), time.Now().AddDate(0, 6, 0)),
+		WithCallers(auth.Resolver{JWTSecret: testSecret})), nil).(*mux.Router)
+}
+
+// stubJobStore has one finished and one failed run of every job.
+type stubJobStore struct{}
+
+func (stubJobStore) Lock(ctx context.Context, _ string, fn func(context.Context) error) (bool, error) {
+	return true, fn(ctx)
+}
+func (stubJobStore) Start(context.Context, *jobs.Run) (bool, error)         { return true, nil }
+func (stubJobStore) Finish(context.Context, int64, time.Time, string) error { return nil }
+func (stubJobStore) Recent(_ context.Context, job string, _ int) ([]jobs.Run, error) {
+	at := time.Date(2026, 10, 1, 3, 0, 0, 0, time.UTC)
+	return []jobs.Run{
+		{ID: 2, Job: job, Trigger: jobs.TriggerManual, Instance: "api-1", StartedAt: at.Add(time.Hour), FinishedAt: at.Add(time.Hour), Error: "db gone"},
+		{ID: 1, Job: job, Trigger: jobs.TriggerSchedule, ScheduledFor: at, Instance: "api-2", StartedAt: at, FinishedAt: at.Add(time.Second)},
+	}, nil
+}
+
+// routeTemplate returns the OpenAPI path key of the route serving req.
+func routeTemplate(t *testing.T, r *mux.Router, req *http.Request) string {
+	t.Helper()
+	var m mux.RouteMatch
+	require.True(t, r.Match(req, &m), "no route for %s %s", req.Method, req.URL)
+	tpl, err := m.Route.GetPathTemplate()
+	require.NoError(t, err)
+	return muxPattern.ReplaceAllString(tpl, "{$1}")
+}
+
+func pointerEscape(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+func TestOpenAPI_MatchesRouter(t *testing.T) {
+	spec, _ := loadSpec(t)
+	paths := spec["paths"].(map[string]any)
+
+	routed := map[string]bool{}
+	r := testRouter(new(mocks.UserUsecase), new(mocks.WebhookUsecase))
+	require.NoError(t, r.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		tpl, err := route.GetPathTemplate()
+		if err != nil || route.GetHandler() == nil {
+			return nil
+		}
+		tpl = muxPattern.ReplaceAllString(tpl, "{$1}")
+		methods, err := route.GetMethods()
+		if err != nil {
+			methods = []string{http.MethodGet}
+		}
+		for _, m := range methods {
+			key := strings.ToLower(m) + " " + tpl
+			routed[key] = true
+			item, _ := paths[tpl].(map[string]any)
+			assert.Contains(t, item, strings.ToLower(m), "route %s missing from openapi.json", key)
+		}
+		return nil
+	}))
+	for p, item := range paths {
+		for m := range item.(map[string]any) {
+			assert.True(t, routed[m+" "+p], "openapi.json documents %s %s which NewRouter does not serve", m, p)
+		}
+	}
+}
+
+func TestOpenAPI_Contract(t *testing.T) {
+	dob := time.Date(1992, 5, 10, 0, 0, 0, 0, time.UTC)
+	full := &domain.Customer{
+		ID: 36, NationalityID: 1, Name: "ALFA", Dob: dob, PhoneNum: "0811", PhoneE164: "+62811", Email: "a@example.com",
+		Family: []domain.FamilyMember{{ID: 1, CustomerID: 36, Relation: "spouse", Name: "BETA", Dob: dob}},
+	}
+	validBody := `{"nationality_id":1,"cst_name":"ALFA","cst_dob":"1992-05-10","cst_phoneNum":"081234567890","cst_email":"a@example.com","family":[{"fl_relation":"spouse","fl_name":"BETA","fl_dob":"1993-07-01"}]}`
+
+	// Cases without root run against /v1 and, unless v1Only, again against
+	// the deprecated unversioned route, whose operationId is legacyID(opID).
+	created := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	jobsAdmin := bearer(jobs.ScopeAdmin)
+	hooksAdmin := bearer(webhooks.ScopeAdmin)
+	eraser := bearer(pii.ScopeErase)
+	hook := &domain.Webhook{ID: 3, URL: "https://crm.example.com/hook", Secret: "0123456789abcdef", Active: true, CreatedAt: created}
+	tests := []struct {
+		root     bool
+		v1Only   bool
+		opID     string
+		method   string
+		path     string
+		body     string
+		setup    func(m *mocks.UserUsecase)
+		whSetup  func(m *mocks.WebhookUsecase)
+		header   http.Header
+		wantCode int
+		stream   bool // long-lived response; served with an already cancelled context
+	}{
+		{root: true, opID: "health", method: http.MethodGet, path: "/healthz", wantCode: 200},
+		{root: true, opID: "openapi", method: http.MethodGet, path: "/openapi.json", wantCode: 200},
+		{root: true, opID: "docs", method: http.MethodGet, path: "/docs", wantCode: 200},
+		{opID: "listNationalities", method: http.MethodGet, path: "/nationalities", wantCode: 200,
+			setup: func(m *mocks.UserUsecase) {
+				code := "ID"
+				m.On("ListNationality", mock.Anything).Return([]domain.Nationality{{ID: 1, Name: "Indonesia", Code: &code}, {ID: 2, Name: "Other"}}, nil)
+			}},
+		{opID: "listNationalities", method: http.MethodGet, path: "/nationalities", wantCode: 500,
+			setup: func(m *mocks.UserUsecase) {
+				m.On("ListNationality", mock.Anything).Return(nil, assert.AnError)
+			}},
+		{opID: "listUsers", method: http.MethodGet, path: "/users?page=1&size=2", wantCode: 200,
+			setup: func(m *mocks.UserUsecase) {
+				m.On("List", mock.Anything, "", 1, 2).Return([]domain.Customer{*full}, int32(1), nil)
+			}},
+		{opID: "listUsers", method: http.MethodGet, path: "/users?include=family", wantCode: 200,
+			setup: func(m *mocks.UserUsecase) {
+				m.On("List", mock.Anything, "", 1, 10).Return([]domain.Customer{{ID: 36, Dob: dob}, {ID: 37, Dob: dob}}, int32(2), nil)
+				m.On("LoadFamilies", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+					args.Get(1).([]domain.Customer)[0].Family = full.Family
+				})
+			}},
+		{opID: "listUsers", method: http.MethodGet, path: "/users?ids=36,9&include=family", wantCode: 200,
+			setup: func(m *mocks.UserUsecase) {
+				m.On("GetMany", mock.Anything, []int32{36, 9}, true).Return([]domain.Customer{*full}, []int32{9}, nil)
+			}},
+		{opID: "listUsers", method: http.MethodGet, path: "/users?ids=1,x&include=friends", wantCode: 400},
+		{opID: "listUsers", method: http.MethodGet, path: "/users?fields=cst_id,cst_password", wantCode: 400},
+		{opID: "listUsers", method: http.MethodGet, path: "/users?ids=36", wantCode: 500,
+			setup: func(m *mocks.UserUsecase) {
+				m.On("GetMany", mock.Anything, []int32{36}, false).Return(nil, nil, assert.AnError)
+			}},
+		{v1Only: true, opID: "batchGetUsers", method: http.MethodPost, path: "/users:batchGet", body: `{"ids":[36,36,9]}`, wantCode: 200,
+			setup: func(m *mocks.UserUsecase) {
+				m.On("GetMany", mock.Anything, []int32{36, 36, 9}, false).Return([]domain.Customer{*full}, []int32{9}, nil)
+			}},
+		{v1Only: true, opID: "batchGetUsers", method: http.MethodPost, path: "/users:batchGet", body: "{", wantCode: 400},
+		{v1Only: true, opID: "batchGetUsers", method: http.MethodPost, path: "/users:batchGet", body: `{"ids":[],"include":["friends"]}`, wantCode: 422},
+		{v1Only: true, opID: "batchGetUsers", method: http.MethodPost, path: "/users:batchGet", body: `{"ids":[36],"include":["family"]}`, wantCode: 500,
+			setup: func(m *mocks.UserUsecase) {
+				m.On("GetMany", mock.Anything, []int32{36}, true).Return(nil, nil, assert.AnError)
+			}},
+		{opID: "getUser", method: http.MethodGet, path: "/users/36", wantCode: 200,
+			setup: func(m *mocks.UserUsecase) { m.On("Get", mock.Anything, int32(36)).Return(full, nil) }},
+		{opID: "getUser", method: http.MethodGet, path: "/users/37", wantCode: 200,
+			setup: func(m *mocks.UserUsecase) {
+				m.On("Get", mock.Anything, int32(37)).Return(&domain.Customer{ID: 37, Dob: dob}, nil)
+			}},
+		{opID: "getUser", method: http.MethodGet, path: "/users/0", wantCode: 400},
+		{v1Only: true, opID: "getUser", method: http.MethodGet, path: "/users/36?fields=family.fl_age", wantCode: 400},
+		{opID: "getUser", method: http.MethodGet, path: "/users/40", wantCode: 308,
+			setup: func(m *mocks.UserUsecase) {
+				m.On("Get", mock.Anything, int32(40)).Return(nil, &domain.MovedError{To: 36})
+			}},
+		{opID: "getUser", method: http.MethodGet, path: "/users/9", wantCode: 404,
+			setup: func(m *mocks.UserUsecase) { m.On("Get", mock.Anything, int32(9)).Return(nil, domain.ErrNotFound) }},
+		{opID: "createUser", method: http.MethodPost, path: "/users", body: validBody, wantCode: 201,
+			setup: func(m *mocks.UserUsecase) { m.On("Create", mock.Anything, mock.Anything).Return(int32(36), nil) }},
+		{opID: "createUser", method: http.MethodPost, path: "/users", body: "{", wantCode: 400},
+		{opID: "createUser", method: http.MethodPost, path: "/users", body: `{}`, wantCode: 422},
+		{opID: "createUser", method: http.MethodPost, path: "/users", body: validBody, wantCode: 409,
+			setup: func(m *mocks.UserUsecase) {
+				m.On("Create", mock.Anything, mock.Anything).Return(int32(0), domain.ErrConflict)
+			}},
+		{opID: "updateUser", method: http.MethodPut, path: "/users/36", body: validBody, wantCode: 200,
+			setup: func(m *mocks.UserUsecase) { m.On("Update", mock.Anything, int32(36), mock.Anything).Return(nil) }},
+		{opID: "updateUser", method: http.MethodPut, path: "/users/9", body: validBody, wantCode: 404,
+			setup: func(m *mocks.UserUsecase) {
+				m.On("Update", mock.Anything, int32(9), mock.Anything).Return(domain.ErrNotFound)
+			}},
+		{opID: "deleteUser", method: http.MethodDelete, path: "/users/36", wantCode: 200,
+			setup: func(m *mocks.UserUsecase) { m.On("Delete", mock.Anything, int32(36)).Return(nil) }},
+		{opID: "deleteUser", method: http.MethodDelete, path: "/users/9", wantCode: 404,
+			setup: func(m *mocks.UserUsecase) { m.On("Delete", mock.Anything, int32(9)).Return(domain.ErrNotFound) }},
+		{v1Only: true, opID: "userStats", method: http.MethodGet, path: "/users/stats?search=AL&interval=week&from=2026-09-01&to=2026-09-14", wantCode: 200,
+			setup: func(m *mocks.UserUsecase) {
+				m.On("Stats", mock.Anything, domain.StatsFilter{Search: "AL", Interval: "week",
+					From: time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2026, 9, 14, 0, 0, 0, 0, time.UTC)}).
+					Return(&domain.CustomerStats{
+						Total:         3,
+						ByNationality: []domain.NationalityCount{{NationalityID: 1, Name: "Indonesia", Count: 3}},
+						ByAge:         []domain.BucketCount{{Label: "25-34", Count: 3}},
+						FamilySize:    []domain.FamilySizeCount{{Members: 0, Count: 1}, {Members: 2, Count: 2}},
+						Created: []domain.PeriodCount{{Start: time.Date(2026, 8, 31, 0, 0, 0, 0, time.UTC), Count: 1},
+							{Start: time.Date(2026, 9, 7, 0, 0, 0, 0, time.UTC), Count: 2}},
+						Interval: "week", From: time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2026, 9, 14, 0, 0, 0, 0, time.UTC),
+					}, nil)
+			}},
+		{v1Only: true, opID: "userStats", method: http.MethodGet, path: "/users/stats?interval=year&from=2026-09-14&to=2026-09-01", wantCode: 400},
+		{v1Only: true, opID: "userStats", method: http.MethodGet, path: "/users/stats", wantCode: 500,
+			setup: func(m *mocks.UserUsecase) {
+				m.On("Stats", mock.Anything, domain.StatsFilter{}).Return(nil, assert.AnError)
+			}},
+		{v1Only: true, opID: "listDuplicates", method: http.MethodGet, path: "/users/36/duplicates?min_score=0.6", wantCode: 200,
+			setup: func(m *mocks.UserUsecase) {
+				m.On("Duplicates", mock.Anything, int32(36), 0.6, 0).Return([]domain.DuplicateCandidate{{
+					Customer: domain.Customer{ID: 37, Name: "ALFA", Dob: dob}, Score: 0.875,
+					Signals: map[string]float64{"name": 1, "dob": 1, "phone": 0.5},
+				}}, nil)
+			}},
+		{v1Only: true, opID: "listDuplicates", method: http.MethodGet, path: "/users/40/duplicates", wantCode: 308,
+			setup: func(m *mocks.UserUsecase) {
+				m.On("Duplicates", mock.Anything, int32(40), 0.5, 0).Return(nil, &domain.MovedError{To: 36})
+			}},
+		{v1Only: true, opID: "listDuplicates", method: http.MethodGet, path: "/users/x/duplicates", wantCode: 400},
+		{v1Only: true, opID: "listDuplicates", method: http.MethodGet, path: "/users/9/duplicates", wantCode: 404,
+			setup: func(m *mocks.UserUsecase) {
+				m.On("Duplicates", mock.Anything, int32(9), 0.5, 0).Return(nil, domain.ErrNotFound)
+			}},
+		{v1Only: true, opID: "mergeUsers", method: http.MethodPost, path: "/users/36:merge", wantCode: 200,
+			body: `{"source_id":37,"policy":{"cst_email":"source"}}`,
+			setup: func(m *mocks.UserUsecase) {
+				m.On("Merge", mock.Anything, int32(36), int32(37), domain.MergePolicy{"cst_email": "source"}).Return(full, nil)
+			}},
+		{v1Only: true, opID: "mergeUsers", method: http.MethodPost, path: "/users/36:merge", body: "{", wantCode: 400},
+		{v1Only: true, opID: "mergeUsers", method: http.MethodPost, path: "/users/36:merge", wantCode: 422,
+			body: `{"source_id":37,"policy":{"family":"source"}}`},
+		{v1Only: true, opID: "mergeUsers", method: http.MethodPost, path: "/users/36:merge", wantCode: 404,
+			body: `{"source_id":99}`,
+			setup: func(m *mocks.UserUsecase) {
+				m.On("Merge", mock.Anything, int32(36), int32(99), domain.MergePolicy(nil)).Return(nil, domain.ErrNotFound)
+			}},
+		{v1Only: true, opID: "mergeUsers", method: http.MethodPost, path: "/users/36:merge", wantCode: 409,
+			body: `{"source_id":37,"policy":{"cst_email":"source"}}`,
+			setup: func(m *mocks.UserUsecase) {
+				m.On("Merge", mock.Anything, int32(36), int32(37), mock.Anything).Return(nil, domain.ErrConflict)
+			}},
+		{v1Only: true, opID: "exportUser", method: http.MethodGet, path: "/users/36/data-export?reason=ticket+42", wantCode: 200,
+			setup: func(m *mocks.UserUsecase) {
+				m.On("Export", mock.Anything, int32(36), "ticket 42").Return(&domain.DataExport{Customer: *full, MergedIDs: []int32{40},
+					Events: []domain.Event{{ID: 7, Type: domain.EventCustomerCreated, Tenant: "default", CustomerID: 36, OccurredAt: created, Payload: []byte(`{"customer":{}}`)}}}, nil)
+			}},
+		{v1Only: true, opID: "exportUser", method: http.MethodGet, path: "/users/40/data-export?reason=x", wantCode: 308,
+			setup: func(m *mocks.UserUsecase) {
+				m.On("Export", mock.Anything, int32(40), "x").Return(nil, &domain.MovedError{To: 36})
+			}},
+		{v1Only: true, opID: "exportUser", method: http.MethodGet, path: "/users/x/data-export", wantCode: 400},
+		{v1Only: true, opID: "exportUser", method: http.MethodGet, path: "/users/9/data-export?reason=x", wantCode: 404,
+			setup: func(m *mocks.UserUsecase) {
+				m.On("Export", mock.Anything, int32(9), "x").Return(nil, domain.ErrNotFound)
+			}},
+		{v1Only: true, opID: "exportUser", method: http.MethodGet, path: "/users/36/data-export", wantCode: 422,
+			setup: func(m *mocks.UserUsecase) {
+				m.On("Export", mock.Anything, int32(36), "").Return(nil, &domain.RuleError{Violations: []domain.Violation{{Field: "reason", Rule: domain.RuleReasonRequired}}})
+			}},
+		{v1Only: true, header: eraser, opID: "anonymizeUser", method: http.MethodPost, path: "/users/36:anonymize", body: `{"reason":"erasure request"}`, wantCode: 200,
+			setup: func(m *mocks.UserUsecase) {
+				anon := domain.Anonymize(*full)
+				m.On("Anonymize", mock.Anything, int32(36), "erasure request").Return(&anon, nil)
+			}},
+		{v1Only: true, header: eraser, opID: "anonymizeUser", method: http.MethodPost, path: "/users/36:anonymize", body: "{", wantCode: 400},
+		{v1Only: true, opID: "anonymizeUser", method: http.MethodPost, path: "/users/36:anonymize", body: `{"reason":"x"}`, wantCode: 403},
+		{v1Only: true, header: eraser, opID: "anonymizeUser", method: http.MethodPost, path: "/users/36:anonymize", body: `{}`, wantCode: 422},
+		{v1Only: true, header: eraser, opID: "anonymizeUser", method: http.MethodPost, path: "/users/9:anonymize", body: `{"reason":"x"}`, wantCode: 404,
+			setup: func(m *mocks.UserUsecase) {
+				m.On("Anonymize", mock.Anything, int32(9), "x").Return(nil, domain.ErrNotFound)
+			}},
+		{v1Only: true, stream: true, opID: "userEvents", method: http.MethodGet, path: "/users/events", wantCode: 200},
+		{v1Only: true, header: hooksAdmin, opID: "createWebhook", method: http.MethodPost, path: "/webhooks", wantCode: 201,
+			body:    `{"url":"https://crm.example.com/hook","event_types":["customer.created"]}`,
+			whSetup: func(m *mocks.WebhookUsecase) { m.On("Create", mock.Anything, mock.Anything).Return(hook, nil) }},
+		{v1Only: true, header: hooksAdmin, opID: "createWebhook", method: http.MethodPost, path: "/webhooks", body: "{", wantCode: 400},
+		{v1Only: true, header: hooksAdmin, opID: "createWebhook", method: http.MethodPost, path: "/webhooks", wantCode: 422,
+			body: `{"url":"https://crm.example.com/hook","event_types":["customer.archived"]}`},
+		{v1Only: true, header: hooksAdmin, opID: "createWebhook", method: http.MethodPost, path: "/webhooks", wantCode: 422,
+			body: `{"url":"ftp://crm.example.com/hook"}`,
+			whSetup: func(m *mocks.WebhookUsecase) {
+				m.On("Create", mock.Anything, mock.Anything).Return(nil, &domain.RuleError{Violations: []domain.Violation{{Field: "url", Rule: domain.RuleWebhookURL}}})
+			}},
+		{v1Only: true, header: hooksAdmin, opID: "listWebhooks", method: http.MethodGet, path: "/webhooks", wantCode: 200,
+			whSetup: func(m *mocks.WebhookUsecase) { m.On("List", mock.Anything).Return([]domain.Webhook{*hook}, nil) }},
+		{v1Only: true, header: hooksAdmin, opID: "getWebhook", method: http.MethodGet, path: "/webhooks/3", wantCode: 200,
+			whSetup: func(m *mocks.WebhookUsecase) { m.On("Get", mock.Anything, int32(3)).Return(hook, nil) }},
+		{v1Only: true, header: hooksAdmin, opID: "getWebhook", method: http.MethodGet, path: "/webhooks/4", wantCode: 404,
+			whSetup: func(m *mocks.WebhookUsecase) { m.On("Get", mock.Anything, int32(4)).Return(nil, domain.ErrNotFound) }},
+		{v1Only: true, header: hooksAdmin, opID: "deleteWebhook", method: http.MethodDelete, path: "/webhooks/3", wantCode: 200,
+			whSetup: func(m *mocks.WebhookUsecase) { m.On("Delete", mock.Anything, int32(3)).Return(nil) }},
+		{v1Only: true, header: hooksAdmin, opID: "listDeliveries", method: http.MethodGet, path: "/webhooks/3/deliveries?limit=5", wantCode: 200,
+			whSetup: func(m *mocks.WebhookUsecase) {
+				m.On("Deliveries", mock.Anything, int32(3), 5).Return([]domain.Delivery{{
+					ID: 10, WebhookID: 3, EventID: 7, EventType: domain.EventCustomerCreated, Status: domain.DeliveryDead,
+					Attempts: 8, NextAttemptAt: created, CreatedAt: created,
+					Log: []domain.DeliveryAttempt{{At: created, StatusCode: 500, Error: "500 Internal Server Error"}, {At: created, Error: "timeout"}},
+				}}, nil)
+			}},
+		{v1Only: true, header: hooksAdmin, opID: "redeliver", method: http.MethodPost, path: "/webhooks/3/deliveries/10:redeliver", wantCode: 202,
+			whSetup: func(m *mocks.WebhookUsecase) { m.On("Redeliver", mock.Anything, int32(3), int64(10)).Return(nil) }},
+		{v1Only: true, header: hooksAdmin, opID: "redeliver", method: http.MethodPost, path: "/webhooks/3/deliveries/11:redeliver", wantCode: 404,
+			whSetup: func(m *mocks.WebhookUsecase) {
+				m.On("Redeliver", mock.Anything, int32(3), int64(11)).Return(domain.ErrNotFound)
+			}},
+		{v1Only: true, opID: "createWebhook", method: http.MethodPost, path: "/webhooks", wantCode: 403,
+			body: `{"url":"https://crm.example.com/hook"}`},
+		{v1Only: true, header: jobsAdmin, opID: "listWebhooks", method: http.MethodGet, path: "/webhooks", wantCode: 403},
+		{v1Only: true, opID: "getWebhook", method: http.MethodGet, path: "/webhooks/3", wantCode: 403},
+		{v1Only: true, opID: "deleteWebhook", method: http.MethodDelete, path: "/webhooks/3", wantCode: 403},
+		{v1Only: true, opID: "listDeliveries", method: http.MethodGet, path: "/webhooks/3/deliveries", wantCode: 403},
+		{v1Only: true, opID: "redeliver", method: http.MethodPost, path: "/webhooks/3/deliveries/10:redeliver", wantCode: 403},
+		{v1Only: true, opID: "listJobs", method: http.MethodGet, path: "/admin/jobs", header: jobsAdmin, wantCode: 200},
+		{v1Only: true, opID: "listJobs", method: http.MethodGet, path: "/admin/jobs", wantCode: 403},
+		{v1Only: true, opID: "runJob", method: http.MethodPost, path: "/admin/jobs/purge_outbox:run", header: jobsAdmin, wantCode: 202},
+		{v1Only: true, opID: "runJob", method: http.MethodPost, path: "/admin/jobs/nope:run", header: jobsAdmin, wantCode: 404},
+		{v1Only: true, opID: "runJob", method: http.MethodPost, path: "/admin/jobs/purge_outbox:run", wantCode: 403},
+	}
+
+	spec, compiler := loadSpec(t)
+	covered := map[string]bool{}
+
+	for _, tc := range tests {
+		prefixes := []string{"/v1", ""}
+		switch {
+		case tc.root:
+			prefixes = []string{""}
+		case tc.v1Only:
+			prefixes = []string{"/v1"}
+		}
+		for _, prefix := range prefixes {
+			opID, path := tc.opID, prefix+tc.path
+			if !tc.root && prefix == "" {
+				opID = legacyID(opID)
+			}
+			t.Run(opID+"_"+strconv.Itoa(tc.wantCode), func(t *testing.T) {
+				m, wh := new(mocks.UserUsecase), new(mocks.WebhookUsecase)
+				if tc.setup != nil {
+					tc.setup(m)
+				}
+				if tc.whSetup != nil {
+					tc.whSetup(wh)
+				}
+				rr := httptest.NewRecorder()
+				req := httptest.NewRequest(tc.method, path, strings.NewReader(tc.body))
+				req.Header["Authorization"] = bearer("")["Authorization"]
+				for k, v := range tc.header {
+					req.Header[k] = v
+				}
+				if tc.stream {
+					ctx, cancel := context.WithCancel(req.Context())
+					cancel()
+					req = req.WithContext(ctx)
+				}
+				router := testRouter(m, wh)
+				router.ServeHTTP(rr, req)
+				require.Equal(t, tc.wantCode, rr.Code, rr.Body.String())
+
+				tpl := routeTemplate(t, router, req)
+				op, ok := spec["paths"].(map[string]any)[tpl].(map[string]any)[strings.ToLower(tc.method)].(map[string]any)
+				require.True(t, ok, "no operation for %s %s", tc.method, tpl)
+				assert.Equal(t, opID, op["operationId"])
+				resp, ok := op["responses"].(map[string]any)[strconv.Itoa(tc.wantCode)].(map[string]any)
+				require.True(t, ok, "status %d not documented for %s", tc.wantCode, opID)
+				covered[opID] = true
+				if op["deprecated"] == true {
+					assert.NotEmpty(t, rr.Header().Get("Sunset"))
+				}
+
+				content, _ := resp["content"].(map[string]any)
+				if _, isJSON := content["application/json"]; !isJSON {
+					return
+				}
+				ptr := "/paths/" + pointerEscape(tpl) + "/" + strings.ToLower(tc.method) +
+					"/responses/" + strconv.Itoa(tc.wantCode) + "/content/application~1json/schema"
+				sch, err := compiler.Compile("openapi.json#" + ptr)
+				require.NoError(t, err)
+				body, err := jsonschema.UnmarshalJSON(bytes.NewReader(rr.Body.Bytes()))
+				require.NoError(t, err)
+				assert.NoError(t, sch.Validate(body), rr.Body.String())
+			})
+		}
+	}
+
+	for _, op := range apiOps {
+		assert.True(t, covered[op.ID], "operation %s has no contract test", op.ID)
+	}
+}
+
+func TestRouter_JobsNeedVerifiedCallers(t *testing.T) {
+	sched := jobs.NewScheduler(stubJobStore{}, "test")
+	tests := []struct {
+		name       string
+		callers    auth.Resolver
+		header     http.Header
+		wantStatus int
+	}{
+		{name: "trusted_headers_not_served", callers: auth.Resolver{TrustHeaders: true}, header: http.Header{ScopesHeader: {jobs.ScopeAdmin}}, wantStatus: http.StatusNotFound},
+		{name: "token", callers: auth.Resolver{JWTSecret: testSecret}, header: bearer(jobs.ScopeAdmin), wantStatus: http.StatusOK},
+		{name: "token_without_scope", callers: auth.Resolver{JWTSecret: testSecret}, header: bearer(""), wantStatus: http.StatusForbidden},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/admin/jobs", nil)
+			req.Header = tc.header
+			rr := httptest.NewRecorder()
+			NewRouter(NewHandler(new(mocks.UserUsecase), WithJobs(sched), WithCallers(tc.callers)), nil).ServeHTTP(rr, req)
+			assert.Equal(t, tc.wantStatus, rr.Code, rr.Body.String())
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/pii"
+	"usrsvc/internal/pkg/auth"
+	"usrsvc/internal/pkg/log"
+)
+
+// Caller headers, honoured only by a Resolver with TrustHeaders, see
+// WithCallers.
+const (
+	ActorHeader  = "X-Actor"
+	RoleHeader   = "X-Role"
+	ScopesHeader = "X-Scopes"
+)
+
+func (h *Handler) resolveCaller(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := h.Callers.Resolve(r.Header.Get(ActorHeader), r.Header.Get(RoleHeader), r.Header.Get(ScopesHeader), r.Header.Get("Authorization"))
+		if errors.Is(err, auth.ErrUnauthorized) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeErr(w, r, StatusUnauthorized, MsgUnauthorized, nil)
+			return
+		}
+		ctx := auth.With(r.Context(), c)
+		if h.PII.Masks(ctx) {
+			ctx = domain.WithWholeEmailSearch(ctx)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// masker returns how to render the customers ids for the caller, writing a
+// 500 itself when an unmasking caller's audit record cannot be stored.
+func (h *Handler) masker(w http.ResponseWriter, r *http.Request, op string, ids ...int32) (pii.Masker, bool) {
+	m, err := h.PII.For(r.Context(), op, ids...)
+	if err != nil {
+		log.Error.Printf("%s audit_err ids=%v err=%v", op, ids, err)
+		writeErr(w, r, StatusInternalServerError, MsgInternal, nil)
+		return m, false
+	}
+	return m, true
+}
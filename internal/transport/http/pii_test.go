@@ -0,0 +1,155 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/mocks"
+	"usrsvc/internal/pii"
+	"usrsvc/internal/pkg/auth"
+	"usrsvc/internal/webhooks"
+)
+
+func TestRouter_PIIMasking(t *testing.T) {
+	c := &domain.Customer{ID: 7, NationalityID: 1, Name: "ALPHA", Dob: time.Date(1992, 3, 4, 0, 0, 0, 0, time.UTC),
+		PhoneNum: "081234561234", PhoneE164: "+6281234561234", Email: "alpha@x.com"}
+	full := `"cst_dob":"1992-03-04","nationality_id":1,"cst_phoneNum":"081234561234","cst_phone_e164":"+6281234561234","cst_email":"alpha@x.com"`
+	masked := `"cst_dob":"1992-**-**","nationality_id":1,"cst_phoneNum":"****1234","cst_phone_e164":"+62****1234","cst_email":"a***@x.com"`
+
+	tests := []struct {
+		name       string
+		fullRoles  []string
+		path       string
+		role       string
+		scopes     string
+		untrusted  bool
+		auditErr   error
+		wantStatus int
+		wantBody   string
+		wantAudit  bool
+	}{
+		{name: "masking_off", path: "/v1/users/7", role: "agent", wantStatus: http.StatusOK, wantBody: full},
+		{name: "full_role", fullRoles: []string{"admin"}, path: "/v1/users/7", role: "admin", wantStatus: http.StatusOK, wantBody: full},
+		{name: "agent_masked", fullRoles: []string{"admin"}, path: "/v1/users/7", role: "agent", wantStatus: http.StatusOK, wantBody: masked},
+		{name: "no_role_masked", fullRoles: []string{"admin"}, path: "/v1/users/7", wantStatus: http.StatusOK, wantBody: masked},
+		{name: "unmask_scope_audited", fullRoles: []string{"admin"}, path: "/v1/users/7", role: "agent", scopes: "users:read pii:unmask", wantStatus: http.StatusOK, wantBody: full, wantAudit: true},
+		{name: "audit_failure", fullRoles: []string{"admin"}, path: "/v1/users/7", role: "agent", scopes: pii.ScopeUnmask, auditErr: errors.New("db down"), wantStatus: http.StatusInternalServerError, wantAudit: true},
+		{name: "legacy_masked_forbidden", fullRoles: []string{"admin"}, path: "/users/7", role: "agent", wantStatus: http.StatusForbidden, wantBody: "pii:unmask"},
+		{name: "export_masked_forbidden", fullRoles: []string{"admin"}, path: "/v1/users/7/data-export?reason=x", role: "agent", wantStatus: http.StatusForbidden, wantBody: "pii:unmask"},
+		{name: "untrusted_headers_masked", fullRoles: []string{"admin"}, path: "/v1/users/7", role: "admin", scopes: pii.ScopeUnmask, untrusted: true, wantStatus: http.StatusOK, wantBody: masked},
+		{name: "legacy_full_role", fullRoles: []string{"admin"}, path: "/users/7", role: "admin", wantStatus: http.StatusOK, wantBody: `"Email":"alpha@x.com"`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			audit := new(mocks.AuditLog)
+			if tc.wantAudit {
+				audit.On("Record", mock.Anything, mock.MatchedBy(func(e domain.AuditEntry) bool {
+					return e.Action == domain.AuditPIIUnmask && e.Operation == "get_user" && e.Actor == "ops-1" && e.Role == "agent" && assert.ObjectsAreEqual([]int32{7}, e.CustomerIDs)
+				})).Return(tc.auditErr).Once()
+			}
+			m := new(mocks.UserUsecase)
+			m.On("Get", mock.Anything, int32(7)).Return(c, nil)
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set(ActorHeader, "ops-1")
+			req.Header.Set(RoleHeader, tc.role)
+			req.Header.Set(ScopesHeader, tc.scopes)
+			rr := httptest.NewRecorder()
+			h := NewHandler(m, WithCallers(auth.Resolver{TrustHeaders: !tc.untrusted}), WithPII(pii.Policy{FullRoles: tc.fullRoles, Audit: audit}))
+			NewRouter(h, nil).ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantStatus, rr.Code, rr.Body.String())
+			assert.Contains(t, rr.Body.String(), tc.wantBody)
+			audit.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRouter_WebhooksNeedUnmaskedCaller(t *testing.T) {
+	tests := []struct {
+		name, role, scopes string
+		wantStatus         int
+	}{
+		{name: "masked_admin_scope", role: "agent", scopes: webhooks.ScopeAdmin, wantStatus: http.StatusForbidden},
+		{name: "full_role_without_scope", role: "admin", wantStatus: http.StatusForbidden},
+		{name: "full_role", role: "admin", scopes: webhooks.ScopeAdmin, wantStatus: http.StatusOK},
+		{name: "unmask_scope", role: "agent", scopes: webhooks.ScopeAdmin + " " + pii.ScopeUnmask, wantStatus: http.StatusOK},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			wh := new(mocks.WebhookUsecase)
+			wh.On("List", mock.Anything).Return([]domain.Webhook{}, nil).Maybe()
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/webhooks", nil)
+			req.Header.Set(RoleHeader, tc.role)
+			req.Header.Set(ScopesHeader, tc.scopes)
+			rr := httptest.NewRecorder()
+			h := NewHandler(new(mocks.UserUsecase), WithWebhooks(wh), WithCallers(auth.Resolver{TrustHeaders: true}), WithPII(pii.Policy{FullRoles: []string{"admin"}}))
+			NewRouter(h, nil).ServeHTTP(rr, req)
+			assert.Equal(t, tc.wantStatus, rr.Code, rr.Body.String())
+		})
+	}
+}
+
+func TestRouter_AnonymizeNeedsEraseScope(t *testing.T) {
+	tests := []struct {
+		name, role, scopes string
+		wantStatus         int
+	}{
+		{name: "masked_erase_scope", role: "agent", scopes: pii.ScopeErase, wantStatus: http.StatusForbidden},
+		{name: "full_role_without_scope", role: "admin", wantStatus: http.StatusForbidden},
+		{name: "full_role", role: "admin", scopes: pii.ScopeErase, wantStatus: http.StatusOK},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(mocks.UserUsecase)
+			m.On("Anonymize", mock.Anything, int32(7), "erasure").Return(&domain.Customer{ID: 7}, nil).Maybe()
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/users/7:anonymize", strings.NewReader(`{"reason":"erasure"}`))
+			req.Header.Set(RoleHeader, tc.role)
+			req.Header.Set(ScopesHeader, tc.scopes)
+			rr := httptest.NewRecorder()
+			h := NewHandler(m, WithCallers(auth.Resolver{TrustHeaders: true}), WithPII(pii.Policy{FullRoles: []string{"admin"}}))
+			NewRouter(h, nil).ServeHTTP(rr, req)
+			assert.Equal(t, tc.wantStatus, rr.Code, rr.Body.String())
+		})
+	}
+}
+
+func TestRouter_MaskedCallersSearchWholeEmails(t *testing.T) {
+	tests := []struct {
+		name, role, scopes string
+		wantWhole          bool
+	}{
+		{name: "masked", role: "agent", wantWhole: true},
+		{name: "unmask_scope", role: "agent", scopes: pii.ScopeUnmask},
+		{name: "full_role", role: "admin"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(mocks.UserUsecase)
+			m.On("List", mock.MatchedBy(func(ctx context.Context) bool { return domain.WholeEmailSearch(ctx) == tc.wantWhole }), "alp", 1, 10).
+				Return([]domain.Customer{}, int32(0), nil).Once()
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/users?search=alp", nil)
+			req.Header.Set(RoleHeader, tc.role)
+			req.Header.Set(ScopesHeader, tc.scopes)
+			rr := httptest.NewRecorder()
+			h := NewHandler(m, WithCallers(auth.Resolver{TrustHeaders: true}), WithPII(pii.Policy{FullRoles: []string{"admin"}}))
+			NewRouter(h, nil).ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+			m.AssertExpectations(t)
+		})
+	}
+}
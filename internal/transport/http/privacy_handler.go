@@ -0,0 +1,91 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/dto"
+	"usrsvc/internal/pii"
+	"usrsvc/internal/pkg/auth"
+	"usrsvc/internal/pkg/log"
+)
+
+// ExportUser answers a subject access request with everything stored about
+// the customer, unmasked, so callers that see masked PII get 403. The
+// ?reason= is audited.
+func (h *Handler) ExportUser(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	if id <= 0 {
+		writeErr(w, r, StatusBadRequest, MsgInvalidID, nil)
+		return
+	}
+	if h.PII.Masks(r.Context()) {
+		writeErr(w, r, StatusForbidden, MsgPIIForbidden, nil)
+		return
+	}
+	x, err := h.UC.Export(r.Context(), int32(id), r.URL.Query().Get("reason"))
+	if err != nil {
+		writePrivacyErr(w, r, "data_export", int32(id), err)
+		return
+	}
+	out := dto.DataExportResponse{
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		Customer:   toCustomerResponse(x.Customer, pii.Masker{}),
+		MergedIDs:  x.MergedIDs,
+		Events:     make([]dto.EventResponse, 0, len(x.Events)),
+	}
+	for _, e := range x.Events {
+		out.Events = append(out.Events, dto.EventResponse{
+			ID: e.ID, Type: e.Type, CustomerID: e.CustomerID, OccurredAt: e.OccurredAt.UTC().Format(time.RFC3339), Payload: e.Payload,
+		})
+	}
+	log.Info.Printf("data_export ok id=%d merged=%d events=%d", id, len(x.MergedIDs), len(x.Events))
+	writeJSON(w, StatusOK, out)
+}
+
+// AnonymizeUser scrubs the customer's PII for good and returns what is left.
+// It needs pii.ScopeErase and a caller that sees PII unmasked.
+func (h *Handler) AnonymizeUser(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	if id <= 0 {
+		writeErr(w, r, StatusBadRequest, MsgInvalidID, nil)
+		return
+	}
+	if !auth.From(r.Context()).HasScope(pii.ScopeErase) || h.PII.Masks(r.Context()) {
+		writeErr(w, r, StatusForbidden, MsgEraseForbidden, nil)
+		return
+	}
+	var req dto.AnonymizeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error.Printf("anonymize_user decode_json err=%v", err)
+		writeErr(w, r, StatusBadRequest, MsgInvalidJSON, nil)
+		return
+	}
+	if err := h.Val.Struct(req); err != nil {
+		writeValidationErr(w, r, err)
+		return
+	}
+	c, err := h.UC.Anonymize(r.Context(), int32(id), req.Reason)
+	if err != nil {
+		writePrivacyErr(w, r, "anonymize_user", int32(id), err)
+		return
+	}
+	log.Info.Printf("anonymize_user ok id=%d family=%d", id, len(c.Family))
+	writeJSON(w, StatusOK, toCustomerResponse(*c, pii.Masker{}))
+}
+
+func writePrivacyErr(w http.ResponseWriter, r *http.Request, op string, id int32, err error) {
+	var moved *domain.MovedError
+	if errors.As(err, &moved) {
+		log.Info.Printf("%s moved id=%d to=%d", op, id, moved.To)
+		writeMoved(w, r, id, moved.To)
+		return
+	}
+	writeWriteErr(w, r, op, id, domain.Customer{}, err)
+}
@@ -3,6 +3,10 @@ package http
 import (
 	"encoding/json"
 	"net/http"
+
+	ut "github.com/go-playground/universal-translator"
+
+	"usrsvc/internal/pkg/i18n"
 )
 
 type apiError struct {
@@ -17,6 +21,31 @@ func writeJSON(w http.ResponseWriter, status int, v any) {
 	_ = json.NewEncoder(w).Encode(v)
 }
 
-func writeErr(w http.ResponseWriter, status int, msg string, fields map[string]string) {
-	writeJSON(w, status, apiError{Error: true, Message: msg, Fields: fields})
+// writeErr localizes msg and the field values (both catalog keys or literal
+// text) using the request's Accept-Language. fields is not modified.
+func writeErr(w http.ResponseWriter, r *http.Request, status int, msg string, fields map[string]string) {
+	writeLocalizedErr(w, r, status, msg, localize(i18n.FromRequest(r), fields))
+}
+
+// localize returns fields with each value translated.
+func localize(tr ut.Translator, fields map[string]string) map[string]string {
+	out := make(map[string]string, len(fields))
+	for k, v := range fields {
+		out[k] = i18n.T(tr, v)
+	}
+	return out
+}
+
+// writeLocalizedErr is writeErr for field messages that are already
+// localized, e.g. by i18n.FieldErrors.
+func writeLocalizedErr(w http.ResponseWriter, r *http.Request, status int, msg string, fields map[string]string) {
+	tr := i18n.FromRequest(r)
+	w.Header().Set("Content-Language", tr.Locale())
+	writeJSON(w, status, apiError{Error: true, Message: i18n.T(tr, msg), Fields: fields})
+}
+
+// writeValidationErr answers 422 with the localized messages of a validator
+// error.
+func writeValidationErr(w http.ResponseWriter, r *http.Request, err error) {
+	writeLocalizedErr(w, r, StatusUnprocessableEntity, MsgValidation, i18n.FieldErrors(i18n.FromRequest(r), err))
 }
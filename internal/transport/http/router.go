@@ -10,15 +10,54 @@ import (
 func NewRouter(h *Handler, allowOrigins []string) http.Handler {
 	r := mux.NewRouter()
 	r.Use(middleware.CORS(allowOrigins))
+	r.Use(middleware.ReadYourWrites(h.ReadYourWrites))
 
 	r.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request){ w.WriteHeader(200) })
+	r.HandleFunc("/openapi.json", h.OpenAPISpec).Methods(http.MethodGet)
+	r.HandleFunc("/docs", h.SwaggerUI).Methods(http.MethodGet)
 
-	r.HandleFunc("/users", h.ListUsers).Methods(http.MethodGet)
-	r.HandleFunc("/users/{id}", h.GetUser).Methods(http.MethodGet)
-	r.HandleFunc("/users", h.CreateUser).Methods(http.MethodPost)
-	r.HandleFunc("/users/{id}", h.UpdateUser).Methods(http.MethodPut)
-	r.HandleFunc("/users/{id}", h.DeleteUser).Methods(http.MethodDelete)
+	v1 := r.PathPrefix("/v1").Subrouter()
+	v1.Use(h.resolveTenant)
+	v1.Use(h.resolveCaller)
+	v1.HandleFunc("/users", h.ListUsers).Methods(http.MethodGet)
+	v1.HandleFunc("/users/stats", h.UserStats).Methods(http.MethodGet)
+	if h.Feed != nil {
+		v1.HandleFunc("/users/events", h.UserEvents).Methods(http.MethodGet)
+	}
+	v1.HandleFunc("/users/{id}", h.GetUser).Methods(http.MethodGet)
+	v1.HandleFunc("/users", h.CreateUser).Methods(http.MethodPost)
+	v1.HandleFunc("/users:batchGet", h.BatchGetUsers).Methods(http.MethodPost)
+	v1.HandleFunc("/users/{id}", h.UpdateUser).Methods(http.MethodPut)
+	v1.HandleFunc("/users/{id}", h.DeleteUser).Methods(http.MethodDelete)
+	v1.HandleFunc("/users/{id}/duplicates", h.ListDuplicates).Methods(http.MethodGet)
+	v1.HandleFunc("/users/{id:[0-9]+}:merge", h.MergeUsers).Methods(http.MethodPost)
+	v1.HandleFunc("/users/{id}/data-export", h.ExportUser).Methods(http.MethodGet)
+	v1.HandleFunc("/users/{id:[0-9]+}:anonymize", h.AnonymizeUser).Methods(http.MethodPost)
+	v1.HandleFunc("/nationalities", h.ListNationality).Methods(http.MethodGet)
+	if h.WH != nil {
+		v1.HandleFunc("/webhooks", h.requireWebhooksAdmin(h.CreateWebhook)).Methods(http.MethodPost)
+		v1.HandleFunc("/webhooks", h.requireWebhooksAdmin(h.ListWebhooks)).Methods(http.MethodGet)
+		v1.HandleFunc("/webhooks/{id}", h.requireWebhooksAdmin(h.GetWebhook)).Methods(http.MethodGet)
+		v1.HandleFunc("/webhooks/{id}", h.requireWebhooksAdmin(h.DeleteWebhook)).Methods(http.MethodDelete)
+		v1.HandleFunc("/webhooks/{id}/deliveries", h.requireWebhooksAdmin(h.ListDeliveries)).Methods(http.MethodGet)
+		v1.HandleFunc("/webhooks/{id}/deliveries/{deliveryId:[0-9]+}:redeliver", h.requireWebhooksAdmin(h.Redeliver)).Methods(http.MethodPost)
+	}
+	if h.Jobs != nil && h.Callers.Verified() {
+		v1.HandleFunc("/admin/jobs", requireJobsAdmin(h.ListJobs)).Methods(http.MethodGet)
+		v1.HandleFunc("/admin/jobs/{name}:run", requireJobsAdmin(h.RunJob)).Methods(http.MethodPost)
+	}
 
-	r.HandleFunc("/nationalities", h.ListNationality).Methods(http.MethodGet)
+	legacy := r.NewRoute().Subrouter()
+	if !h.LegacyDeprecated.IsZero() {
+		legacy.Use(middleware.Deprecated(h.LegacyDeprecated, h.LegacySunset, "/v1"))
+	}
+	legacy.Use(h.resolveTenant)
+	legacy.Use(h.resolveCaller)
+	legacy.HandleFunc("/users", h.ListUsers).Methods(http.MethodGet)
+	legacy.HandleFunc("/users/{id}", h.LegacyGetUser).Methods(http.MethodGet)
+	legacy.HandleFunc("/users", h.CreateUser).Methods(http.MethodPost)
+	legacy.HandleFunc("/users/{id}", h.UpdateUser).Methods(http.MethodPut)
+	legacy.HandleFunc("/users/{id}", h.DeleteUser).Methods(http.MethodDelete)
+	legacy.HandleFunc("/nationalities", h.LegacyListNationality).Methods(http.MethodGet)
 	return r
 }
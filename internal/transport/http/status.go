@@ -5,7 +5,11 @@ import "net/http"
 const (
 	StatusOK                  = http.StatusOK // 200
 	StatusCreated             = http.StatusCreated
+	StatusAccepted            = http.StatusAccepted
+	StatusPermanentRedirect   = http.StatusPermanentRedirect
 	StatusBadRequest          = http.StatusBadRequest          // 400
+	StatusUnauthorized        = http.StatusUnauthorized        // 401
+	StatusForbidden           = http.StatusForbidden           // 403
 	StatusUnprocessableEntity = http.StatusUnprocessableEntity // 422
 	StatusNotFound            = http.StatusNotFound            // 404
 	StatusInternalServerError = http.StatusInternalServerError // 500
@@ -0,0 +1,31 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"usrsvc/internal/pkg/tenant"
+)
+
+const TenantHeader = "X-Tenant-ID"
+
+// resolveTenant puts the tenant of every /v1 and legacy request in its
+// context, see WithTenants.
+func (h *Handler) resolveTenant(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := h.Tenants.Resolve(r.Header.Get(TenantHeader), r.Header.Get("Authorization"))
+		switch {
+		case errors.Is(err, tenant.ErrUnauthorized):
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeErr(w, r, StatusUnauthorized, MsgUnauthorized, nil)
+		case errors.Is(err, tenant.ErrMissing):
+			writeErr(w, r, StatusBadRequest, MsgTenantMissing, nil)
+		case errors.Is(err, tenant.ErrUnsupported):
+			writeErr(w, r, StatusBadRequest, MsgSingleTenant, nil)
+		case err != nil:
+			writeErr(w, r, StatusBadRequest, MsgTenantInvalid, nil)
+		default:
+			next.ServeHTTP(w, r.WithContext(tenant.With(r.Context(), id)))
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package http
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/mocks"
+	"usrsvc/internal/pkg/tenant"
+)
+
+// signHS256 returns an HS256 JWT of the claims JSON.
+func signHS256(secret []byte, claims string) string {
+	enc := base64.RawURLEncoding.EncodeToString
+	body := enc([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + enc([]byte(claims))
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(body))
+	return body + "." + enc(mac.Sum(nil))
+}
+
+func TestRouter_ResolveTenant(t *testing.T) {
+	secret := []byte("s3cret")
+	token := signHS256(secret, `{"tenant_id":"acme"}`)
+
+	tests := []struct {
+		name       string
+		resolver   tenant.Resolver
+		header     string
+		auth       string
+		wantStatus int
+		wantTenant string
+	}{
+		{name: "default", wantStatus: http.StatusOK, wantTenant: tenant.Default},
+		{name: "header", header: "acme", wantStatus: http.StatusOK, wantTenant: "acme"},
+		{name: "invalid_header", header: "Acme Corp", wantStatus: http.StatusBadRequest},
+		{name: "strict_missing", resolver: tenant.Resolver{Strict: true}, wantStatus: http.StatusBadRequest},
+		{name: "jwt_missing", resolver: tenant.Resolver{JWTSecret: secret}, header: "acme", wantStatus: http.StatusUnauthorized},
+		{name: "default_only", resolver: tenant.Resolver{DefaultOnly: true}, header: "acme", wantStatus: http.StatusBadRequest},
+		{name: "jwt_claim_wins", resolver: tenant.Resolver{JWTSecret: secret}, header: "globex", auth: "Bearer " + token, wantStatus: http.StatusOK, wantTenant: "acme"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(mocks.UserUsecase)
+			var got string
+			m.On("ListNationality", mock.Anything).Run(func(args mock.Arguments) {
+				got, _ = tenant.From(args.Get(0).(context.Context))
+			}).Return([]domain.Nationality{}, nil).Maybe()
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/nationalities", nil)
+			if tc.header != "" {
+				req.Header.Set(TenantHeader, tc.header)
+			}
+			if tc.auth != "" {
+				req.Header.Set("Authorization", tc.auth)
+			}
+			rr := httptest.NewRecorder()
+			NewRouter(NewHandler(m, WithTenants(tc.resolver)), nil).ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantStatus, rr.Code, rr.Body.String())
+			assert.Equal(t, tc.wantTenant, got)
+			if tc.wantStatus == http.StatusUnauthorized {
+				assert.Equal(t, "Bearer", rr.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/dto"
+	"usrsvc/internal/pkg/auth"
+	"usrsvc/internal/pkg/log"
+	"usrsvc/internal/webhooks"
+)
+
+// requireWebhooksAdmin guards the webhook routes. Deliveries carry customer
+// data unmasked to wherever the URL points, so the caller must also be one
+// that sees it unmasked.
+func (h *Handler) requireWebhooksAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !auth.From(r.Context()).HasScope(webhooks.ScopeAdmin) || h.PII.Masks(r.Context()) {
+			writeErr(w, r, StatusForbidden, MsgHooksForbidden, nil)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req dto.CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error.Printf("create_webhook decode_json err=%v", err)
+		writeErr(w, r, StatusBadRequest, MsgInvalidJSON, nil)
+		return
+	}
+	if err := h.Val.Struct(req); err != nil {
+		writeValidationErr(w, r, err)
+		return
+	}
+	wh, err := h.WH.Create(r.Context(), domain.Webhook{URL: req.URL, EventTypes: req.EventTypes, Secret: req.Secret})
+	if err != nil {
+		h.writeWebhookErr(w, r, "create_webhook", 0, err)
+		return
+	}
+	log.Info.Printf("create_webhook ok id=%d url=%q events=%v", wh.ID, wh.URL, wh.EventTypes)
+	resp := toWebhookResponse(*wh)
+	resp.Secret = wh.Secret
+	writeJSON(w, StatusCreated, resp)
+}
+
+func (h *Handler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	ws, err := h.WH.List(r.Context())
+	if err != nil {
+		h.writeWebhookErr(w, r, "list_webhooks", 0, err)
+		return
+	}
+	out := make([]dto.WebhookResponse, 0, len(ws))
+	for _, wh := range ws {
+		out = append(out, toWebhookResponse(wh))
+	}
+	writeJSON(w, StatusOK, out)
+}
+
+func (h *Handler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookID(w, r)
+	if !ok {
+		return
+	}
+	wh, err := h.WH.Get(r.Context(), id)
+	if err != nil {
+		h.writeWebhookErr(w, r, "get_webhook", id, err)
+		return
+	}
+	writeJSON(w, StatusOK, toWebhookResponse(*wh))
+}
+
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.WH.Delete(r.Context(), id); err != nil {
+		h.writeWebhookErr(w, r, "delete_webhook", id, err)
+		return
+	}
+	log.Info.Printf("delete_webhook ok id=%d", id)
+	writeJSON(w, StatusOK, dto.StatusResponse{Status: "ok"})
+}
+
+func (h *Handler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookID(w, r)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	ds, err := h.WH.Deliveries(r.Context(), id, limit)
+	if err != nil {
+		h.writeWebhookErr(w, r, "list_deliveries", id, err)
+		return
+	}
+	out := make([]dto.DeliveryResponse, 0, len(ds))
+	for _, d := range ds {
+		out = append(out, toDeliveryResponse(d))
+	}
+	writeJSON(w, StatusOK, out)
+}
+
+func (h *Handler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookID(w, r)
+	if !ok {
+		return
+	}
+	did, _ := strconv.ParseInt(mux.Vars(r)["deliveryId"], 10, 64)
+	if did <= 0 {
+		writeErr(w, r, StatusBadRequest, MsgInvalidID, nil)
+		return
+	}
+	if err := h.WH.Redeliver(r.Context(), id, did); err != nil {
+		h.writeWebhookErr(w, r, "redeliver", id, err)
+		return
+	}
+	log.Info.Printf("redeliver ok webhook=%d delivery=%d", id, did)
+	writeJSON(w, StatusAccepted, dto.StatusResponse{Status: "queued"})
+}
+
+func webhookID(w http.ResponseWriter, r *http.Request) (int32, bool) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	if id <= 0 {
+		writeErr(w, r, StatusBadRequest, MsgInvalidID, nil)
+		return 0, false
+	}
+	return int32(id), true
+}
+
+func (h *Handler) writeWebhookErr(w http.ResponseWriter, r *http.Request, op string, id int32, err error) {
+	var re *domain.RuleError
+	switch {
+	case errors.As(err, &re):
+		log.Info.Printf("%s rule_violation id=%d err=%v", op, id, err)
+		writeRuleErr(w, r, re)
+	case errors.Is(err, domain.ErrNotFound):
+		writeErr(w, r, StatusNotFound, MsgNotFound, nil)
+	default:
+		log.Error.Printf("%s repo_err id=%d err=%v", op, id, err)
+		writeErr(w, r, StatusInternalServerError, MsgInternal, nil)
+	}
+}
+
+func toWebhookResponse(wh domain.Webhook) dto.WebhookResponse {
+	types := wh.EventTypes
+	if types == nil {
+		types = []string{}
+	}
+	return dto.WebhookResponse{
+		ID: wh.ID, URL: wh.URL, EventTypes: types, Active: wh.Active,
+		CreatedAt: wh.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func toDeliveryResponse(d domain.Delivery) dto.DeliveryResponse {
+	out := dto.DeliveryResponse{
+		ID: d.ID, EventID: d.EventID, EventType: d.EventType, Status: d.Status, Attempts: d.Attempts,
+		NextAttemptAt: d.NextAttemptAt.UTC().Format(time.RFC3339),
+		CreatedAt:     d.CreatedAt.UTC().Format(time.RFC3339),
+		Log:           make([]dto.DeliveryAttemptResponse, 0, len(d.Log)),
+	}
+	for _, a := range d.Log {
+		ar := dto.DeliveryAttemptResponse{At: a.At.UTC().Format(time.RFC3339), Error: a.Error, DurationMS: a.Duration.Milliseconds()}
+		if a.StatusCode != 0 {
+			code := a.StatusCode
+			ar.StatusCode = &code
+		}
+		out.Log = append(out.Log, ar)
+	}
+	return out
+}
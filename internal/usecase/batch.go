@@ -0,0 +1,59 @@
+package usecase
+
+import (
+	"context"
+
+	"usrsvc/internal/domain"
+)
+
+func (u *userUC) GetMany(ctx context.Context, ids []int32, family bool) ([]domain.Customer, []int32, error) {
+	seen := make(map[int32]bool, len(ids))
+	uniq := make([]int32, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			uniq = append(uniq, id)
+		}
+	}
+	found, err := u.repo.GetCustomers(ctx, uniq)
+	if err != nil {
+		return nil, nil, err
+	}
+	byID := make(map[int32]domain.Customer, len(found))
+	for _, c := range found {
+		byID[c.ID] = c
+	}
+	out := make([]domain.Customer, 0, len(found))
+	missing := []int32{}
+	for _, id := range uniq {
+		if c, ok := byID[id]; ok {
+			out = append(out, c)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	if family {
+		if err := u.LoadFamilies(ctx, out); err != nil {
+			return nil, nil, err
+		}
+	}
+	return out, missing, nil
+}
+
+func (u *userUC) LoadFamilies(ctx context.Context, cs []domain.Customer) error {
+	if len(cs) == 0 {
+		return nil
+	}
+	ids := make([]int32, len(cs))
+	for i, c := range cs {
+		ids[i] = c.ID
+	}
+	fams, err := u.repo.Families(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range cs {
+		cs[i].Family = fams[cs[i].ID]
+	}
+	return nil
+}
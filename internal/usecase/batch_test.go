@@ -0,0 +1,69 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/mocks"
+)
+
+func TestUserUC_GetMany(t *testing.T) {
+	ctx := context.Background()
+	kid := []domain.FamilyMember{{ID: 1, CustomerID: 36, Relation: "child", Name: "BETA"}}
+
+	tests := []struct {
+		name        string
+		ids         []int32
+		family      bool
+		setup       func(r *mocks.UserRepository)
+		want        []int32
+		wantFamily  map[int32][]domain.FamilyMember
+		wantMissing []int32
+		wantErr     error
+	}{
+		{name: "request_order_without_duplicates", ids: []int32{37, 9, 36, 37},
+			setup: func(r *mocks.UserRepository) {
+				r.On("GetCustomers", ctx, []int32{37, 9, 36}).Return([]domain.Customer{{ID: 36}, {ID: 37}}, nil)
+			},
+			want: []int32{37, 36}, wantMissing: []int32{9}},
+		{name: "with_family", ids: []int32{36, 37}, family: true,
+			setup: func(r *mocks.UserRepository) {
+				r.On("GetCustomers", ctx, []int32{36, 37}).Return([]domain.Customer{{ID: 37}, {ID: 36}}, nil)
+				r.On("Families", ctx, []int32{36, 37}).Return(map[int32][]domain.FamilyMember{36: kid}, nil)
+			},
+			want: []int32{36, 37}, wantFamily: map[int32][]domain.FamilyMember{36: kid}, wantMissing: []int32{}},
+		{name: "none_found_skips_families", ids: []int32{9}, family: true,
+			setup: func(r *mocks.UserRepository) {
+				r.On("GetCustomers", ctx, []int32{9}).Return(nil, nil)
+			},
+			want: []int32{}, wantMissing: []int32{9}},
+		{name: "repo_error", ids: []int32{36},
+			setup: func(r *mocks.UserRepository) {
+				r.On("GetCustomers", ctx, []int32{36}).Return(nil, assert.AnError)
+			},
+			wantErr: assert.AnError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := mocks.NewUserRepository(t)
+			tt.setup(r)
+			got, missing, err := NewUserUC(r).GetMany(ctx, tt.ids, tt.family)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			ids := []int32{}
+			for _, c := range got {
+				ids = append(ids, c.ID)
+				assert.Equal(t, tt.wantFamily[c.ID], c.Family, "family of %d", c.ID)
+			}
+			assert.Equal(t, tt.want, ids)
+			assert.Equal(t, tt.wantMissing, missing)
+		})
+	}
+}
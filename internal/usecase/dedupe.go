@@ -0,0 +1,197 @@
+package usecase
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+
+	"usrsvc/internal/domain"
+)
+
+// Signal weights in the duplicate score; they sum to 1.
+const (
+	weightName  = 0.5
+	weightDob   = 0.25
+	weightPhone = 0.25
+)
+
+// maxCandidates bounds the customers scored per lookup; the repository
+// returns the strongest blocking matches first.
+const maxCandidates = 200
+
+func (u *userUC) Duplicates(ctx context.Context, id int32, minScore float64, limit int) ([]domain.DuplicateCandidate, error) {
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+	c, err := u.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, domain.ErrNotFound
+	}
+	cands, err := u.repo.DuplicateCandidates(ctx, *c, maxCandidates)
+	if err != nil {
+		return nil, err
+	}
+	var out []domain.DuplicateCandidate
+	for _, o := range cands {
+		if o.ID == c.ID {
+			continue
+		}
+		if d := scoreDuplicate(*c, o); d.Score >= minScore {
+			out = append(out, d)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func scoreDuplicate(c, o domain.Customer) domain.DuplicateCandidate {
+	s := map[string]float64{
+		"name":  nameSimilarity(c.Name, o.Name),
+		"dob":   dobSimilarity(c.Dob, o.Dob),
+		"phone": phoneSimilarity(c, o),
+	}
+	score := weightName*s["name"] + weightDob*s["dob"] + weightPhone*s["phone"]
+	return domain.DuplicateCandidate{Customer: o, Score: float64(int(score*1000+0.5)) / 1000, Signals: s}
+}
+
+var foldAccents = transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+// normalizeName lowercases, drops accents and punctuation and sorts the
+// words, so "Budi  Santoso" and "santoso, búdi" compare equal.
+func normalizeName(s string) string {
+	s, _, _ = transform.String(foldAccents, strings.ToLower(s))
+	words := strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+// nameSimilarity is 1 - normalized Levenshtein distance.
+func nameSimilarity(a, b string) float64 {
+	x, y := []rune(normalizeName(a)), []rune(normalizeName(b))
+	n := max(len(x), len(y))
+	if n == 0 {
+		return 0
+	}
+	return 1 - float64(levenshtein(x, y))/float64(n)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// dobSimilarity scores exact matches, day/month swaps (a common entry
+// mistake) and near misses.
+func dobSimilarity(a, b time.Time) float64 {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	switch {
+	case ay == by && am == bm && ad == bd:
+		return 1
+	case ay == by && int(am) == bd && ad == int(bm):
+		return 0.8
+	case ay == by && (am == bm || ad == bd), am == bm && ad == bd:
+		return 0.4
+	}
+	return 0
+}
+
+// phoneSimilarity compares E.164 forms, falling back to the last seven
+// digits when the country parts differ or one side failed to normalize.
+func phoneSimilarity(a, b domain.Customer) float64 {
+	if a.PhoneE164 != "" && a.PhoneE164 == b.PhoneE164 {
+		return 1
+	}
+	x, y := digits(a.PhoneNum), digits(b.PhoneNum)
+	if len(x) >= 7 && len(y) >= 7 && x[len(x)-7:] == y[len(y)-7:] {
+		return 0.7
+	}
+	return 0
+}
+
+func digits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// Merge folds source into target. Family members move to the target unless
+// the target already has the same (relation, name, dob); the merged result
+// has to pass the usual rules.
+func (u *userUC) Merge(ctx context.Context, targetID, sourceID int32, p domain.MergePolicy) (*domain.Customer, error) {
+	if targetID == sourceID {
+		return nil, &domain.RuleError{Violations: []domain.Violation{{Field: "source_id", Rule: domain.RuleMergeSelf}}}
+	}
+	target, err := u.repo.GetCustomer(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	source, err := u.repo.GetCustomer(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil || source == nil {
+		return nil, domain.ErrNotFound
+	}
+
+	merged := p.Apply(*target, *source)
+	merged.Family = mergeFamily(target.Family, source.Family)
+	if err := u.rules.Check(merged, slices.Concat(target.Family, source.Family)...); err != nil {
+		return nil, err
+	}
+	if err := u.repo.MergeCustomers(ctx, targetID, sourceID, merged); err != nil {
+		return nil, err
+	}
+	return u.repo.GetCustomer(ctx, targetID)
+}
+
+// familyKey identifies a family row by what it says, not by its id.
+func familyKey(f domain.FamilyMember) string {
+	return strings.ToLower(strings.TrimSpace(f.Relation)) + "|" + normalizeName(f.Name) + "|" + f.Dob.Format("2006-01-02")
+}
+
+func mergeFamily(target, source []domain.FamilyMember) []domain.FamilyMember {
+	seen := map[string]bool{}
+	out := append([]domain.FamilyMember(nil), target...)
+	for _, f := range target {
+		seen[familyKey(f)] = true
+	}
+	for _, f := range source {
+		if !seen[familyKey(f)] {
+			seen[familyKey(f)] = true
+			out = append(out, f)
+		}
+	}
+	return out
+}
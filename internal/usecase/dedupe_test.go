@@ -0,0 +1,104 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/mocks"
+)
+
+func Test_scoreDuplicate(t *testing.T) {
+	dob := time.Date(1992, 5, 10, 0, 0, 0, 0, time.UTC)
+	base := domain.Customer{Name: "Budi Santoso", Dob: dob, PhoneNum: "0812-3456-7890", PhoneE164: "+6281234567890"}
+
+	tests := []struct {
+		name      string
+		other     domain.Customer
+		wantScore float64
+		signals   map[string]float64
+	}{
+		{"same_person_reordered_name", domain.Customer{Name: "santoso, BÚDI", Dob: dob, PhoneE164: "+6281234567890"},
+			1, map[string]float64{"name": 1, "dob": 1, "phone": 1}},
+		{"day_month_swapped", domain.Customer{Name: "Budi Santoso", Dob: time.Date(1992, 10, 5, 0, 0, 0, 0, time.UTC)},
+			0.7, map[string]float64{"name": 1, "dob": 0.8, "phone": 0}},
+		{"local_vs_foreign_prefix", domain.Customer{Name: "Budi Santoso", Dob: dob, PhoneNum: "+62 812 3456 7890"},
+			0.925, map[string]float64{"name": 1, "dob": 1, "phone": 0.7}},
+		{"stranger", domain.Customer{Name: "Siti Aminah", Dob: time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC), PhoneNum: "0811"},
+			0.042, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := scoreDuplicate(base, tt.other)
+			assert.InDelta(t, tt.wantScore, got.Score, 0.001)
+			if tt.signals != nil {
+				assert.InDeltaMapValues(t, tt.signals, got.Signals, 0.001)
+			}
+		})
+	}
+}
+
+func Test_userUC_Duplicates(t *testing.T) {
+	ctx := context.Background()
+	dob := time.Date(1992, 5, 10, 0, 0, 0, 0, time.UTC)
+	c := &domain.Customer{ID: 36, Name: "Budi Santoso", Dob: dob, PhoneE164: "+6281234567890"}
+
+	repo := mocks.NewUserRepository(t)
+	repo.On("GetCustomer", ctx, int32(36)).Return(c, nil).Once()
+	repo.On("DuplicateCandidates", ctx, *c, 200).Return([]domain.Customer{
+		*c,
+		{ID: 37, Name: "Budi Santosa", Dob: dob},
+		{ID: 38, Name: "BUDI SANTOSO", Dob: dob, PhoneE164: "+6281234567890"},
+		{ID: 39, Name: "Bambang", Dob: dob},
+	}, nil).Once()
+
+	got, err := NewUserUC(repo).Duplicates(ctx, 36, 0.5, 0)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, int32(38), got[0].Customer.ID)
+	assert.Equal(t, int32(37), got[1].Customer.ID)
+}
+
+func Test_userUC_Merge(t *testing.T) {
+	ctx := context.Background()
+	dob := time.Date(1992, 5, 10, 0, 0, 0, 0, time.UTC)
+	kid := domain.FamilyMember{Relation: "child", Name: "Caca", Dob: time.Date(2015, 1, 2, 0, 0, 0, 0, time.UTC)}
+	target := &domain.Customer{ID: 36, NationalityID: 1, Name: "Budi", Dob: dob, Email: "old@x.com", Family: []domain.FamilyMember{kid}}
+	source := &domain.Customer{ID: 37, NationalityID: 1, Name: "Budi S", Dob: dob, Email: "new@x.com",
+		Family: []domain.FamilyMember{{Relation: "Child", Name: "caca", Dob: kid.Dob}, {Relation: "spouse", Name: "Dewi", Dob: dob}}}
+
+	t.Run("ok", func(t *testing.T) {
+		repo := mocks.NewUserRepository(t)
+		repo.On("GetCustomer", ctx, int32(36)).Return(target, nil).Once()
+		repo.On("GetCustomer", ctx, int32(37)).Return(source, nil).Once()
+		repo.On("MergeCustomers", ctx, int32(36), int32(37), mock.MatchedBy(func(m domain.Customer) bool {
+			return m.ID == 36 && m.Name == "Budi" && m.Email == "new@x.com" && len(m.Family) == 2
+		})).Return(nil).Once()
+		repo.On("GetCustomer", ctx, int32(36)).Return(target, nil).Once()
+
+		got, err := NewUserUC(repo).Merge(ctx, 36, 37, domain.MergePolicy{"cst_email": domain.KeepSource})
+		require.NoError(t, err)
+		assert.Equal(t, int32(36), got.ID)
+	})
+
+	t.Run("self", func(t *testing.T) {
+		_, err := NewUserUC(mocks.NewUserRepository(t)).Merge(ctx, 36, 36, nil)
+		var re *domain.RuleError
+		require.ErrorAs(t, err, &re)
+		assert.Equal(t, domain.RuleMergeSelf, re.Violations[0].Rule)
+	})
+
+	t.Run("source_missing", func(t *testing.T) {
+		repo := mocks.NewUserRepository(t)
+		repo.On("GetCustomer", ctx, int32(36)).Return(target, nil).Once()
+		repo.On("GetCustomer", ctx, int32(99)).Return((*domain.Customer)(nil), nil).Once()
+
+		_, err := NewUserUC(repo).Merge(ctx, 36, 99, nil)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+}
@@ -0,0 +1,84 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/pkg/auth"
+	"usrsvc/internal/pkg/log"
+	"usrsvc/internal/pkg/tenant"
+)
+
+// Export reports a *domain.MovedError for ids that were merged away.
+func (u *userUC) Export(ctx context.Context, id int32, reason string) (*domain.DataExport, error) {
+	if err := requireReason(reason); err != nil {
+		return nil, err
+	}
+	x, err := u.repo.ExportCustomer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if x == nil {
+		return nil, u.missing(ctx, id)
+	}
+	if err := u.record(ctx, domain.AuditExport, "data_export", reason, append([]int32{id}, x.MergedIDs...)); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// Anonymize scrubs the customer with domain.Anonymize and returns what is
+// left. It cannot be undone, so it is audited only once it has happened; if
+// the record then fails the call fails, and a retry records it again.
+func (u *userUC) Anonymize(ctx context.Context, id int32, reason string) (*domain.Customer, error) {
+	if err := requireReason(reason); err != nil {
+		return nil, err
+	}
+	c, err := u.repo.GetCustomer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, u.missing(ctx, id)
+	}
+	anon, err := u.repo.AnonymizeCustomer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.record(ctx, domain.AuditAnonymize, "anonymize", reason, []int32{id}); err != nil {
+		return nil, err
+	}
+	return anon, nil
+}
+
+func requireReason(reason string) error {
+	if strings.TrimSpace(reason) == "" {
+		return &domain.RuleError{Violations: []domain.Violation{{Field: "reason", Rule: domain.RuleReasonRequired}}}
+	}
+	return nil
+}
+
+// missing is the error for an id without a customer: moved or not found.
+func (u *userUC) missing(ctx context.Context, id int32) error {
+	to, err := u.repo.Redirect(ctx, id)
+	switch {
+	case err != nil:
+		return err
+	case to != 0:
+		return &domain.MovedError{To: to}
+	}
+	return domain.ErrNotFound
+}
+
+func (u *userUC) record(ctx context.Context, action, op, reason string, ids []int32) error {
+	c := auth.From(ctx)
+	e := domain.AuditEntry{Action: action, Operation: op, Actor: c.Subject, Role: c.Role, CustomerIDs: ids, Reason: strings.TrimSpace(reason), At: time.Now()}
+	t, _ := tenant.From(ctx)
+	log.Info.Printf("audit action=%s op=%s tenant=%s actor=%q role=%q ids=%v reason=%q", action, op, t, c.Subject, c.Role, ids, e.Reason)
+	if u.audit == nil {
+		return nil
+	}
+	return u.audit.Record(ctx, e)
+}
@@ -0,0 +1,119 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/mocks"
+	"usrsvc/internal/pkg/auth"
+)
+
+func TestUserUC_Export(t *testing.T) {
+	ctx := auth.With(context.Background(), auth.Caller{Subject: "dpo-1", Role: "compliance"})
+	x := &domain.DataExport{Customer: domain.Customer{ID: 36}, MergedIDs: []int32{12}}
+	entry := mock.MatchedBy(func(e domain.AuditEntry) bool {
+		return e.Action == domain.AuditExport && e.Actor == "dpo-1" && e.Role == "compliance" &&
+			e.Reason == "ticket 42" && assert.ObjectsAreEqual([]int32{36, 12}, e.CustomerIDs)
+	})
+
+	tests := []struct {
+		name     string
+		id       int32
+		reason   string
+		setup    func(r *mocks.UserRepository, a *mocks.AuditLog)
+		want     *domain.DataExport
+		wantErr  error
+		wantRule string
+	}{
+		{name: "ok", id: 36, reason: " ticket 42 ", want: x, setup: func(r *mocks.UserRepository, a *mocks.AuditLog) {
+			r.On("ExportCustomer", ctx, int32(36)).Return(x, nil)
+			a.On("Record", ctx, entry).Return(nil)
+		}},
+		{name: "no_reason", id: 36, reason: " ", wantRule: domain.RuleReasonRequired},
+		{name: "moved", id: 12, reason: "ticket 42", wantErr: domain.ErrMoved, setup: func(r *mocks.UserRepository, a *mocks.AuditLog) {
+			r.On("ExportCustomer", ctx, int32(12)).Return(nil, nil)
+			r.On("Redirect", ctx, int32(12)).Return(int32(36), nil)
+		}},
+		{name: "not_found", id: 9, reason: "ticket 42", wantErr: domain.ErrNotFound, setup: func(r *mocks.UserRepository, a *mocks.AuditLog) {
+			r.On("ExportCustomer", ctx, int32(9)).Return(nil, nil)
+			r.On("Redirect", ctx, int32(9)).Return(int32(0), nil)
+		}},
+		{name: "audit_failure_withholds_data", id: 36, reason: "ticket 42", wantErr: assert.AnError, setup: func(r *mocks.UserRepository, a *mocks.AuditLog) {
+			r.On("ExportCustomer", ctx, int32(36)).Return(x, nil)
+			a.On("Record", ctx, entry).Return(assert.AnError)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, a := mocks.NewUserRepository(t), mocks.NewAuditLog(t)
+			if tt.setup != nil {
+				tt.setup(r, a)
+			}
+			got, err := NewUserUC(r, WithAudit(a)).Export(ctx, tt.id, tt.reason)
+			switch {
+			case tt.wantRule != "":
+				var re *domain.RuleError
+				require.ErrorAs(t, err, &re)
+				assert.Equal(t, tt.wantRule, re.Violations[0].Rule)
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, got)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestUserUC_Anonymize(t *testing.T) {
+	ctx := auth.With(context.Background(), auth.Caller{Subject: "dpo-1", Role: "compliance"})
+	c := &domain.Customer{ID: 36, Name: "ALFA", Email: "a@x.com"}
+	anon := domain.Anonymize(*c)
+
+	t.Run("audited_after_scrubbing", func(t *testing.T) {
+		r, a := mocks.NewUserRepository(t), mocks.NewAuditLog(t)
+		r.On("GetCustomer", ctx, int32(36)).Return(c, nil)
+		scrub := r.On("AnonymizeCustomer", ctx, int32(36)).Return(&anon, nil)
+		a.On("Record", ctx, mock.MatchedBy(func(e domain.AuditEntry) bool {
+			return e.Action == domain.AuditAnonymize && e.Reason == "erasure request" && assert.ObjectsAreEqual([]int32{36}, e.CustomerIDs)
+		})).Return(nil).NotBefore(scrub)
+
+		got, err := NewUserUC(r, WithAudit(a)).Anonymize(ctx, 36, "erasure request")
+		require.NoError(t, err)
+		assert.Equal(t, &anon, got)
+	})
+
+	t.Run("failed_scrub_not_audited", func(t *testing.T) {
+		r, a := mocks.NewUserRepository(t), mocks.NewAuditLog(t)
+		r.On("GetCustomer", ctx, int32(36)).Return(c, nil)
+		r.On("AnonymizeCustomer", ctx, int32(36)).Return(nil, assert.AnError)
+
+		_, err := NewUserUC(r, WithAudit(a)).Anonymize(ctx, 36, "erasure request")
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+
+	t.Run("audit_failure_fails_call", func(t *testing.T) {
+		r, a := mocks.NewUserRepository(t), mocks.NewAuditLog(t)
+		r.On("GetCustomer", ctx, int32(36)).Return(c, nil)
+		r.On("AnonymizeCustomer", ctx, int32(36)).Return(&anon, nil)
+		a.On("Record", ctx, mock.Anything).Return(assert.AnError)
+
+		_, err := NewUserUC(r, WithAudit(a)).Anonymize(ctx, 36, "erasure request")
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		r := mocks.NewUserRepository(t)
+		r.On("GetCustomer", ctx, int32(9)).Return(nil, nil)
+		r.On("Redirect", ctx, int32(9)).Return(int32(0), nil)
+
+		_, err := NewUserUC(r).Anonymize(ctx, 9, "erasure request")
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+}
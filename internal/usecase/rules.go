@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"usrsvc/internal/domain"
+)
+
+// Rules holds the business checks applied on create and update. Zero values
+// disable the corresponding limit.
+type Rules struct {
+	MinCustomerAge int // years
+	MaxFamily      int
+	MinParentGap   int // years a parent must be older than their child
+	Now            func() time.Time
+}
+
+func DefaultRules() Rules {
+	return Rules{MinCustomerAge: 17, MaxFamily: 20, MinParentGap: 12, Now: time.Now}
+}
+
+// Check returns a *domain.RuleError listing every violation, or nil. Family
+// rows equal to one in stored are already on file, so a legacy free-text
+// relation ("Wife", "Anak") on them is kept rather than refused.
+func (r Rules) Check(c domain.Customer, stored ...domain.FamilyMember) error {
+	now := time.Now()
+	if r.Now != nil {
+		now = r.Now()
+	}
+	var vs []domain.Violation
+	add := func(field, rule string, params ...string) {
+		vs = append(vs, domain.Violation{Field: field, Rule: rule, Params: params})
+	}
+
+	if c.Dob.After(now) {
+		add("cst_dob", domain.RuleDobInFuture)
+	} else if r.MinCustomerAge > 0 && age(c.Dob, now) < r.MinCustomerAge {
+		add("cst_dob", domain.RuleMinCustomerAge, strconv.Itoa(r.MinCustomerAge))
+	}
+	if r.MaxFamily > 0 && len(c.Family) > r.MaxFamily {
+		add("family", domain.RuleMaxFamilyMembers, strconv.Itoa(r.MaxFamily))
+	}
+
+	legacy := make(map[string]bool, len(stored))
+	for _, f := range stored {
+		legacy[familyKey(f)] = true
+	}
+	spouses := 0
+	for i, f := range c.Family {
+		p := "family[" + strconv.Itoa(i) + "]."
+		if f.Dob.After(now) {
+			add(p+"fl_dob", domain.RuleDobInFuture)
+		}
+		switch rel := strings.ToLower(strings.TrimSpace(f.Relation)); {
+		case !knownRelation(rel):
+			if legacy[familyKey(f)] {
+				continue
+			}
+			add(p+"fl_relation", domain.RuleUnknownRelation, strings.Join(domain.Relations, ", "))
+		case rel == domain.RelationSpouse:
+			if spouses++; spouses > 1 {
+				add(p+"fl_relation", domain.RuleSingleSpouse)
+			}
+		case rel == domain.RelationChild && r.MinParentGap > 0:
+			if c.Dob.AddDate(r.MinParentGap, 0, 0).After(f.Dob) {
+				add(p+"fl_dob", domain.RuleChildTooOld, strconv.Itoa(r.MinParentGap))
+			}
+		case rel == domain.RelationParent && r.MinParentGap > 0:
+			if f.Dob.AddDate(r.MinParentGap, 0, 0).After(c.Dob) {
+				add(p+"fl_dob", domain.RuleParentTooYoung, strconv.Itoa(r.MinParentGap))
+			}
+		}
+	}
+	if len(vs) > 0 {
+		return &domain.RuleError{Violations: vs}
+	}
+	return nil
+}
+
+// unknownRelation reports whether err refuses a family relation, the one
+// violation stored rows may be excused from.
+func unknownRelation(err error) bool {
+	var re *domain.RuleError
+	if !errors.As(err, &re) {
+		return false
+	}
+	for _, v := range re.Violations {
+		if v.Rule == domain.RuleUnknownRelation {
+			return true
+		}
+	}
+	return false
+}
+
+func knownRelation(rel string) bool {
+	for _, r := range domain.Relations {
+		if r == rel {
+			return true
+		}
+	}
+	return false
+}
+
+// age is the number of full years between dob and now.
+func age(dob, now time.Time) int {
+	years := now.Year() - dob.Year()
+	if now.Month() < dob.Month() || (now.Month() == dob.Month() && now.Day() < dob.Day()) {
+		years--
+	}
+	return years
+}
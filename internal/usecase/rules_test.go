@@ -0,0 +1,135 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/mocks"
+)
+
+func TestRules_Check(t *testing.T) {
+	now := time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC)
+	rules := Rules{MinCustomerAge: 17, MaxFamily: 3, MinParentGap: 12, Now: func() time.Time { return now }}
+	d := func(y, m, day int) time.Time { return time.Date(y, time.Month(m), day, 0, 0, 0, 0, time.UTC) }
+	base := domain.Customer{Name: "ALFA", Dob: d(1980, 1, 1)}
+
+	tests := []struct {
+		name   string
+		family []domain.FamilyMember
+		stored []domain.FamilyMember
+		dob    time.Time
+		want   []domain.Violation
+	}{
+		{
+			name: "ok",
+			family: []domain.FamilyMember{
+				{Relation: "Spouse", Dob: d(1982, 1, 1)},
+				{Relation: "child", Dob: d(2010, 1, 1)},
+				{Relation: "PARENT", Dob: d(1950, 1, 1)},
+			},
+		},
+		{
+			name: "customer_dob_in_future",
+			dob:  d(2090, 1, 1),
+			want: []domain.Violation{{Field: "cst_dob", Rule: domain.RuleDobInFuture}},
+		},
+		{
+			name: "customer_too_young",
+			dob:  d(2008, 6, 16),
+			want: []domain.Violation{{Field: "cst_dob", Rule: domain.RuleMinCustomerAge, Params: []string{"17"}}},
+		},
+		{
+			name: "customer_exactly_min_age",
+			dob:  d(2008, 6, 15),
+		},
+		{
+			name:   "family_dob_in_future",
+			family: []domain.FamilyMember{{Relation: "sibling", Dob: d(2030, 1, 1)}},
+			want:   []domain.Violation{{Field: "family[0].fl_dob", Rule: domain.RuleDobInFuture}},
+		},
+		{
+			name:   "unknown_relation",
+			family: []domain.FamilyMember{{Relation: "neighbour", Dob: d(1980, 1, 1)}},
+			want: []domain.Violation{{Field: "family[0].fl_relation", Rule: domain.RuleUnknownRelation,
+				Params: []string{"spouse, child, parent, sibling, grandparent, grandchild, other"}}},
+		},
+		{
+			name:   "legacy_relation_kept",
+			family: []domain.FamilyMember{{Relation: "Wife", Name: "BETA", Dob: d(1982, 1, 1)}},
+			stored: []domain.FamilyMember{{ID: 7, Relation: "wife", Name: "Beta", Dob: d(1982, 1, 1)}},
+		},
+		{
+			name:   "legacy_relation_changed",
+			family: []domain.FamilyMember{{Relation: "Anak", Name: "CACA", Dob: d(2012, 1, 1)}},
+			stored: []domain.FamilyMember{{Relation: "Anak", Name: "CACA", Dob: d(2010, 1, 1)}},
+			want: []domain.Violation{{Field: "family[0].fl_relation", Rule: domain.RuleUnknownRelation,
+				Params: []string{"spouse, child, parent, sibling, grandparent, grandchild, other"}}},
+		},
+		{
+			name: "two_spouses",
+			family: []domain.FamilyMember{
+				{Relation: "spouse", Dob: d(1980, 1, 1)},
+				{Relation: "Spouse", Dob: d(1981, 1, 1)},
+			},
+			want: []domain.Violation{{Field: "family[1].fl_relation", Rule: domain.RuleSingleSpouse}},
+		},
+		{
+			name:   "child_older_than_parent",
+			family: []domain.FamilyMember{{Relation: "child", Dob: d(1970, 1, 1)}},
+			want:   []domain.Violation{{Field: "family[0].fl_dob", Rule: domain.RuleChildTooOld, Params: []string{"12"}}},
+		},
+		{
+			name:   "parent_too_young",
+			family: []domain.FamilyMember{{Relation: "parent", Dob: d(1975, 1, 1)}},
+			want:   []domain.Violation{{Field: "family[0].fl_dob", Rule: domain.RuleParentTooYoung, Params: []string{"12"}}},
+		},
+		{
+			name: "too_many_members",
+			family: []domain.FamilyMember{
+				{Relation: "sibling", Dob: d(1981, 1, 1)},
+				{Relation: "sibling", Dob: d(1982, 1, 1)},
+				{Relation: "sibling", Dob: d(1983, 1, 1)},
+				{Relation: "sibling", Dob: d(1984, 1, 1)},
+			},
+			want: []domain.Violation{{Field: "family", Rule: domain.RuleMaxFamilyMembers, Params: []string{"3"}}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := base
+			if !tc.dob.IsZero() {
+				c.Dob = tc.dob
+			}
+			c.Family = tc.family
+
+			err := rules.Check(c, tc.stored...)
+			if tc.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, domain.ErrRuleViolation)
+			var re *domain.RuleError
+			require.True(t, errors.As(err, &re))
+			assert.Equal(t, tc.want, re.Violations)
+		})
+	}
+}
+
+func Test_userUC_Create_RuleViolation(t *testing.T) {
+	repo := mocks.NewUserRepository(t) // no repo calls expected
+	uc := NewUserUC(repo)
+
+	_, err := uc.Create(context.Background(), domain.Customer{
+		NationalityID: 1,
+		Dob:           time.Now().AddDate(1, 0, 0),
+		PhoneNum:      "081234567890",
+	})
+	assert.ErrorIs(t, err, domain.ErrRuleViolation)
+}
@@ -0,0 +1,49 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"usrsvc/internal/domain"
+)
+
+// statsPeriods is how many intervals the created series covers when the
+// caller gives no From.
+const statsPeriods = 12
+
+func (u *userUC) Stats(ctx context.Context, f domain.StatsFilter) (*domain.CustomerStats, error) {
+	f.Search = normalizeSearch(f.Search)
+	if f.Interval == "" {
+		f.Interval = domain.IntervalMonth
+	}
+	if f.To.IsZero() {
+		now := time.Now()
+		if u.rules.Now != nil {
+			now = u.rules.Now()
+		}
+		f.To = now.UTC()
+	}
+	f.To = truncateDay(f.To)
+	if f.From.IsZero() {
+		switch f.Interval {
+		case domain.IntervalDay:
+			f.From = f.To.AddDate(0, 0, 1-statsPeriods)
+		case domain.IntervalWeek:
+			f.From = f.To.AddDate(0, 0, 7*(1-statsPeriods))
+		default:
+			f.From = f.To.AddDate(0, 1-statsPeriods, 0)
+		}
+	}
+	f.From = truncateDay(f.From)
+	st, err := u.repo.CustomerStats(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	st.Interval, st.From, st.To = f.Interval, f.From, f.To
+	return st, nil
+}
+
+func truncateDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,49 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/mocks"
+)
+
+func Test_userUC_Stats(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 18, 15, 4, 5, 0, time.FixedZone("WIB", 7*3600))
+	date := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name string
+		in   domain.StatsFilter
+		want domain.StatsFilter
+	}{
+		{"defaults_to_last_12_months", domain.StatsFilter{},
+			domain.StatsFilter{Interval: "month", From: date(2025, 11, 18), To: date(2026, 10, 18)}},
+		{"last_12_days", domain.StatsFilter{Interval: "day"},
+			domain.StatsFilter{Interval: "day", From: date(2026, 10, 7), To: date(2026, 10, 18)}},
+		{"last_12_weeks", domain.StatsFilter{Interval: "week"},
+			domain.StatsFilter{Interval: "week", From: date(2026, 8, 2), To: date(2026, 10, 18)}},
+		{"explicit_range_and_phone_search", domain.StatsFilter{Search: "0812-3456-7890", Interval: "week", From: date(2026, 1, 1), To: date(2026, 2, 1)},
+			domain.StatsFilter{Search: "+6281234567890", Interval: "week", From: date(2026, 1, 1), To: date(2026, 2, 1)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewUserRepository(t)
+			repo.On("CustomerStats", ctx, tt.want).Return(&domain.CustomerStats{Total: 4}, nil).Once()
+
+			r := DefaultRules()
+			r.Now = func() time.Time { return now }
+			got, err := NewUserUC(repo, WithRules(r)).Stats(ctx, tt.in)
+			require.NoError(t, err)
+			assert.Equal(t, 4, got.Total)
+			assert.Equal(t, tt.want.Interval, got.Interval)
+			assert.Equal(t, tt.want.From, got.From)
+			assert.Equal(t, tt.want.To, got.To)
+		})
+	}
+}
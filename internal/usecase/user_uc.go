@@ -2,13 +2,38 @@ package usecase
 
 import (
 	"context"
+	"strings"
 
 	"usrsvc/internal/domain"
+	"usrsvc/internal/pkg/phone"
 )
 
-type userUC struct{ repo domain.UserRepository }
+// DefaultRegion is used to read phone-like search terms that lack a country
+// code.
+const DefaultRegion = "ID"
 
-func NewUserUC(r domain.UserRepository) domain.UserUsecase { return &userUC{repo: r} }
+type userUC struct {
+	repo  domain.UserRepository
+	rules Rules
+	audit domain.AuditLog
+}
+
+type Option func(*userUC)
+
+// WithRules replaces DefaultRules.
+func WithRules(r Rules) Option { return func(u *userUC) { u.rules = r } }
+
+// WithAudit stores the audit entries of Export and Anonymize; without it they
+// are only logged.
+func WithAudit(a domain.AuditLog) Option { return func(u *userUC) { u.audit = a } }
+
+func NewUserUC(r domain.UserRepository, opts ...Option) domain.UserUsecase {
+	u := &userUC{repo: r, rules: DefaultRules()}
+	for _, o := range opts {
+		o(u)
+	}
+	return u
+}
 
 func (u *userUC) List(ctx context.Context, search string, page, size int) ([]domain.Customer, int32, error) {
 	if size <= 0 {
@@ -18,18 +43,65 @@ func (u *userUC) List(ctx context.Context, search string, page, size int) ([]dom
 		page = 1
 	}
 	offset := (page - 1) * size
-	return u.repo.ListCustomers(ctx, search, size, offset)
+	return u.repo.ListCustomers(ctx, normalizeSearch(search), size, offset)
 }
 
+// normalizeSearch turns phone-like terms into E.164 so the repository can
+// match them exactly.
+func normalizeSearch(search string) string {
+	if phone.LooksLike(search) {
+		if e164, err := phone.Normalize(search, DefaultRegion); err == nil {
+			return e164
+		}
+	}
+	return search
+}
+
+// Get reports a *domain.MovedError for ids that were merged away.
 func (u *userUC) Get(ctx context.Context, id int32) (*domain.Customer, error) {
-	return u.repo.GetCustomer(ctx, id)
+	c, err := u.repo.GetCustomer(ctx, id)
+	if err != nil || c != nil {
+		return c, err
+	}
+	to, err := u.repo.Redirect(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if to != 0 {
+		return nil, &domain.MovedError{To: to}
+	}
+	return nil, nil
 }
 
 func (u *userUC) Create(ctx context.Context, c domain.Customer) (int32, error) {
+	if err := u.rules.Check(c); err != nil {
+		return 0, err
+	}
+	if err := u.normalizePhone(ctx, &c); err != nil {
+		return 0, err
+	}
 	return u.repo.CreateCustomer(ctx, c)
 }
 
+// Update reads the stored family only when a relation is refused, so rows
+// kept from before the relation enum still save.
 func (u *userUC) Update(ctx context.Context, id int32, c domain.Customer) error {
+	err := u.rules.Check(c)
+	if unknownRelation(err) {
+		cur, gerr := u.repo.GetCustomer(ctx, id)
+		if gerr != nil {
+			return gerr
+		}
+		if cur != nil {
+			err = u.rules.Check(c, cur.Family...)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if err := u.normalizePhone(ctx, &c); err != nil {
+		return err
+	}
 	return u.repo.UpdateCustomer(ctx, id, c)
 }
 
@@ -40,3 +112,27 @@ func (u *userUC) Delete(ctx context.Context, id int32) error {
 func (u *userUC) ListNationality(ctx context.Context) ([]domain.Nationality, error) {
 	return u.repo.ListNationalities(ctx)
 }
+
+// normalizePhone fills PhoneE164, reading national numbers in the region of
+// the customer's nationality.
+func (u *userUC) normalizePhone(ctx context.Context, c *domain.Customer) error {
+	region, err := u.regionOf(ctx, c.NationalityID)
+	if err != nil {
+		return err
+	}
+	e164, err := phone.Normalize(c.PhoneNum, region)
+	if err != nil {
+		return domain.ErrInvalidPhone
+	}
+	c.PhoneNum = strings.TrimSpace(c.PhoneNum)
+	c.PhoneE164 = e164
+	return nil
+}
+
+func (u *userUC) regionOf(ctx context.Context, nationalityID int32) (string, error) {
+	n, err := u.repo.GetNationality(ctx, nationalityID)
+	if err != nil || n == nil || n.Code == nil {
+		return "", err
+	}
+	return *n.Code, nil
+}
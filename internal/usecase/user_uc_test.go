@@ -30,15 +30,18 @@ func Test_userUC_Create(t *testing.T) {
 		NationalityID: 1,
 		Name:          "ALFA",
 		Dob:           time.Date(1992, 5, 10, 0, 0, 0, 0, time.UTC),
-		PhoneNum:      "0811",
+		PhoneNum:      "0812-3456-7890",
 		Email:         "alfa@example.com",
 		Family:        []domain.FamilyMember{{Relation: "Spouse", Name: "BETA"}},
 	}
+	stored := c
+	stored.PhoneE164 = "+6281234567890"
 
 	t.Run("ok", func(t *testing.T) {
 		repo := mocks.NewUserRepository(t)
+		repo.On("GetNationality", ctx, int32(1)).Return(&nationalities[0], nil).Once()
 		repo.
-			On("CreateCustomer", ctx, c).
+			On("CreateCustomer", ctx, stored).
 			Return(int32(123), nil).
 			Once()
 
@@ -50,8 +53,9 @@ func Test_userUC_Create(t *testing.T) {
 
 	t.Run("conflict", func(t *testing.T) {
 		repo := mocks.NewUserRepository(t)
+		repo.On("GetNationality", ctx, int32(1)).Return(&nationalities[0], nil).Once()
 		repo.
-			On("CreateCustomer", ctx, c).
+			On("CreateCustomer", ctx, stored).
 			Return(int32(0), domain.ErrConflict).
 			Once()
 
@@ -61,6 +65,55 @@ func Test_userUC_Create(t *testing.T) {
 		assert.True(t, errors.Is(err, domain.ErrConflict))
 		assert.Equal(t, int32(0), id)
 	})
+
+	t.Run("region_from_nationality", func(t *testing.T) {
+		my := c
+		my.NationalityID = 2
+		my.PhoneNum = "012-345 6789"
+		want := my
+		want.PhoneE164 = "+60123456789"
+
+		repo := mocks.NewUserRepository(t)
+		repo.On("GetNationality", ctx, int32(2)).Return(&nationalities[1], nil).Once()
+		repo.On("CreateCustomer", ctx, want).Return(int32(7), nil).Once()
+
+		uc := NewUserUC(repo)
+		id, err := uc.Create(ctx, my)
+		require.NoError(t, err)
+		assert.Equal(t, int32(7), id)
+	})
+
+	t.Run("unknown_nationality_needs_country_code", func(t *testing.T) {
+		intl := c
+		intl.NationalityID = 99
+		intl.PhoneNum = "+62 812-3456-7890"
+		want := intl
+		want.PhoneNum, want.PhoneE164 = "+62 812-3456-7890", "+6281234567890"
+
+		repo := mocks.NewUserRepository(t)
+		repo.On("GetNationality", ctx, int32(99)).Return((*domain.Nationality)(nil), nil).Once()
+		repo.On("CreateCustomer", ctx, want).Return(int32(8), nil).Once()
+
+		_, err := NewUserUC(repo).Create(ctx, intl)
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid_phone", func(t *testing.T) {
+		bad := c
+		bad.PhoneNum = "0811"
+
+		repo := mocks.NewUserRepository(t)
+		repo.On("GetNationality", ctx, int32(1)).Return(&nationalities[0], nil).Once()
+
+		uc := NewUserUC(repo)
+		_, err := uc.Create(ctx, bad)
+		assert.ErrorIs(t, err, domain.ErrInvalidPhone)
+	})
+}
+
+var nationalities = []domain.Nationality{
+	{ID: 1, Name: "Indonesia", Code: strPtr("ID")},
+	{ID: 2, Name: "Malaysia", Code: strPtr("MY")},
 }
 
 func Test_userUC_Get(t *testing.T) {
@@ -93,6 +146,30 @@ func Test_userUC_Get(t *testing.T) {
 		assert.True(t, errors.Is(err, domain.ErrNotFound))
 		assert.Nil(t, got)
 	})
+
+	t.Run("merged_away", func(t *testing.T) {
+		repo := mocks.NewUserRepository(t)
+		repo.On("GetCustomer", ctx, int32(40)).Return((*domain.Customer)(nil), nil).Once()
+		repo.On("Redirect", ctx, int32(40)).Return(int32(36), nil).Once()
+
+		uc := NewUserUC(repo)
+		got, err := uc.Get(ctx, 40)
+		var moved *domain.MovedError
+		require.ErrorAs(t, err, &moved)
+		assert.Equal(t, int32(36), moved.To)
+		assert.ErrorIs(t, err, domain.ErrMoved)
+		assert.Nil(t, got)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		repo := mocks.NewUserRepository(t)
+		repo.On("GetCustomer", ctx, int32(41)).Return((*domain.Customer)(nil), nil).Once()
+		repo.On("Redirect", ctx, int32(41)).Return(int32(0), nil).Once()
+
+		got, err := NewUserUC(repo).Get(ctx, 41)
+		require.NoError(t, err)
+		assert.Nil(t, got)
+	})
 }
 
 func Test_userUC_List(t *testing.T) {
@@ -131,6 +208,19 @@ func Test_userUC_List(t *testing.T) {
 		assert.Equal(t, int32(0), total)
 	})
 
+	t.Run("phone_search_normalized", func(t *testing.T) {
+		repo := mocks.NewUserRepository(t)
+		repo.
+			On("ListCustomers", ctx, "+6281234567890", 10, 0).
+			Return([]domain.Customer{{ID: 36}}, int32(1), nil).
+			Once()
+
+		uc := NewUserUC(repo)
+		rows, _, err := uc.List(ctx, "0812 3456 7890", 1, 10)
+		require.NoError(t, err)
+		assert.Len(t, rows, 1)
+	})
+
 	t.Run("repo_error", func(t *testing.T) {
 		repo := mocks.NewUserRepository(t)
 		repo.
@@ -182,12 +272,15 @@ func Test_userUC_ListNationality(t *testing.T) {
 
 func Test_userUC_Update(t *testing.T) {
 	ctx := context.Background()
-	in := domain.Customer{Name: "NEW", Email: "new@example.com"}
+	in := domain.Customer{NationalityID: 1, Name: "NEW", PhoneNum: "6281234567890", Email: "new@example.com"}
+	stored := in
+	stored.PhoneE164 = "+6281234567890"
 
 	t.Run("ok", func(t *testing.T) {
 		repo := mocks.NewUserRepository(t)
+		repo.On("GetNationality", ctx, int32(1)).Return(&nationalities[0], nil).Once()
 		repo.
-			On("UpdateCustomer", ctx, int32(36), in).
+			On("UpdateCustomer", ctx, int32(36), stored).
 			Return(nil).
 			Once()
 
@@ -197,8 +290,9 @@ func Test_userUC_Update(t *testing.T) {
 
 	t.Run("not_found", func(t *testing.T) {
 		repo := mocks.NewUserRepository(t)
+		repo.On("GetNationality", ctx, int32(1)).Return(&nationalities[0], nil).Once()
 		repo.
-			On("UpdateCustomer", ctx, int32(36), in).
+			On("UpdateCustomer", ctx, int32(36), stored).
 			Return(domain.ErrNotFound).
 			Once()
 
@@ -207,6 +301,26 @@ func Test_userUC_Update(t *testing.T) {
 		require.Error(t, err)
 		assert.True(t, errors.Is(err, domain.ErrNotFound))
 	})
+
+	t.Run("legacy_relation_kept", func(t *testing.T) {
+		wife := domain.FamilyMember{Relation: "Wife", Name: "BETA", Dob: time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)}
+		legacy := in
+		legacy.Dob = time.Date(1985, 1, 1, 0, 0, 0, 0, time.UTC)
+		legacy.Family = []domain.FamilyMember{wife}
+		want := legacy
+		want.PhoneE164 = stored.PhoneE164
+		repo := mocks.NewUserRepository(t)
+		repo.On("GetCustomer", ctx, int32(36)).Return(&domain.Customer{ID: 36, Family: []domain.FamilyMember{wife}}, nil).Once()
+		repo.On("GetNationality", ctx, int32(1)).Return(&nationalities[0], nil).Once()
+		repo.On("UpdateCustomer", ctx, int32(36), want).Return(nil).Once()
+
+		uc := NewUserUC(repo)
+		require.NoError(t, uc.Update(ctx, 36, legacy))
+
+		legacy.Family = append(legacy.Family, domain.FamilyMember{Relation: "Anak", Name: "CACA", Dob: time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)})
+		repo.On("GetCustomer", ctx, int32(36)).Return(&domain.Customer{ID: 36, Family: []domain.FamilyMember{wife}}, nil).Once()
+		assert.ErrorIs(t, uc.Update(ctx, 36, legacy), domain.ErrRuleViolation, "a new row must use the enum")
+	})
 }
 
 func Test_userUC_Delete(t *testing.T) {
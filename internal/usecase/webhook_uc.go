@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"time"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/pkg/log"
+	"usrsvc/internal/pkg/netguard"
+)
+
+type webhookUC struct {
+	repo      domain.WebhookRepository
+	now       func() time.Time
+	checkHost func(ctx context.Context, host string) error
+}
+
+func NewWebhookUC(r domain.WebhookRepository) domain.WebhookUsecase {
+	return &webhookUC{repo: r, now: time.Now, checkHost: netguard.CheckHost}
+}
+
+func (u *webhookUC) Create(ctx context.Context, w domain.Webhook) (*domain.Webhook, error) {
+	p, err := url.Parse(w.URL)
+	if err != nil || (p.Scheme != "http" && p.Scheme != "https") || p.Host == "" {
+		return nil, &domain.RuleError{Violations: []domain.Violation{{Field: "url", Rule: domain.RuleWebhookURL}}}
+	}
+	// The dispatcher refuses non-public addresses again when it dials, as
+	// the name may resolve differently by then.
+	if err := u.checkHost(ctx, p.Hostname()); err != nil {
+		log.Info.Printf("create_webhook host_rejected host=%s err=%v", p.Hostname(), err)
+		return nil, &domain.RuleError{Violations: []domain.Violation{{Field: "url", Rule: domain.RuleWebhookHost}}}
+	}
+	seen := map[string]bool{}
+	types := w.EventTypes[:0:0]
+	for _, t := range w.EventTypes {
+		if !seen[t] {
+			seen[t] = true
+			types = append(types, t)
+		}
+	}
+	w.EventTypes = types
+	if w.Secret == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		w.Secret = hex.EncodeToString(b)
+	}
+	w.Active = true
+	w.CreatedAt = u.now().UTC()
+	id, err := u.repo.CreateWebhook(ctx, w)
+	if err != nil {
+		return nil, err
+	}
+	w.ID = id
+	return &w, nil
+}
+
+func (u *webhookUC) List(ctx context.Context) ([]domain.Webhook, error) {
+	return u.repo.ListWebhooks(ctx)
+}
+
+func (u *webhookUC) Get(ctx context.Context, id int32) (*domain.Webhook, error) {
+	w, err := u.repo.GetWebhook(ctx, id)
+	if err == nil && w == nil {
+		return nil, domain.ErrNotFound
+	}
+	return w, err
+}
+
+func (u *webhookUC) Delete(ctx context.Context, id int32) error {
+	return u.repo.DeleteWebhook(ctx, id)
+}
+
+func (u *webhookUC) Deliveries(ctx context.Context, webhookID int32, limit int) ([]domain.Delivery, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if _, err := u.Get(ctx, webhookID); err != nil {
+		return nil, err
+	}
+	return u.repo.ListDeliveries(ctx, webhookID, limit)
+}
+
+func (u *webhookUC) Redeliver(ctx context.Context, webhookID int32, deliveryID int64) error {
+	return u.repo.Redeliver(ctx, webhookID, deliveryID, u.now())
+}
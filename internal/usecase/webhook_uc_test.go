@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/mocks"
+	"usrsvc/internal/pkg/netguard"
+)
+
+func Test_webhookUC_Create(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	// crm.example.com stands in for a public host without a DNS lookup.
+	checkHost := func(ctx context.Context, host string) error {
+		if host == "crm.example.com" {
+			return nil
+		}
+		return netguard.CheckHost(ctx, host)
+	}
+
+	tests := []struct {
+		name    string
+		in      domain.Webhook
+		setup   func(m *mocks.WebhookRepository)
+		check   func(t *testing.T, w *domain.Webhook)
+		wantErr error
+	}{
+		{
+			name: "generates_secret_and_dedupes_types",
+			in:   domain.Webhook{URL: "https://crm.example.com/hook", EventTypes: []string{"customer.created", "customer.created"}},
+			setup: func(m *mocks.WebhookRepository) {
+				m.On("CreateWebhook", ctx, mock.MatchedBy(func(w domain.Webhook) bool {
+					return len(w.Secret) == 64 && w.Active && w.CreatedAt.Equal(now) && len(w.EventTypes) == 1
+				})).Return(int32(3), nil).Once()
+			},
+			check: func(t *testing.T, w *domain.Webhook) {
+				assert.Equal(t, int32(3), w.ID)
+				assert.Len(t, w.Secret, 64)
+			},
+		},
+		{
+			name: "keeps_given_secret",
+			in:   domain.Webhook{URL: "http://crm.example.com:9000/cb", Secret: "0123456789abcdef"},
+			setup: func(m *mocks.WebhookRepository) {
+				m.On("CreateWebhook", ctx, mock.MatchedBy(func(w domain.Webhook) bool { return w.Secret == "0123456789abcdef" })).
+					Return(int32(4), nil).Once()
+			},
+		},
+		{name: "rejects_non_http_scheme", in: domain.Webhook{URL: "ftp://crm.example.com/hook"}, wantErr: domain.ErrRuleViolation},
+		{name: "rejects_relative_url", in: domain.Webhook{URL: "/hook"}, wantErr: domain.ErrRuleViolation},
+		{name: "rejects_loopback", in: domain.Webhook{URL: "http://localhost:9000/cb"}, wantErr: domain.ErrRuleViolation},
+		{name: "rejects_metadata_address", in: domain.Webhook{URL: "http://169.254.169.254/latest/meta-data"}, wantErr: domain.ErrRuleViolation},
+		{name: "rejects_private_address", in: domain.Webhook{URL: "https://[fd00::1]/hook"}, wantErr: domain.ErrRuleViolation},
+		{
+			name: "repo_error",
+			in:   domain.Webhook{URL: "https://crm.example.com/hook"},
+			setup: func(m *mocks.WebhookRepository) {
+				m.On("CreateWebhook", ctx, mock.Anything).Return(int32(0), errors.New("db down")).Once()
+			},
+			wantErr: errors.New("db down"),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := mocks.NewWebhookRepository(t)
+			if tc.setup != nil {
+				tc.setup(repo)
+			}
+			uc := &webhookUC{repo: repo, now: func() time.Time { return now }, checkHost: checkHost}
+			got, err := uc.Create(ctx, tc.in)
+			if tc.wantErr != nil {
+				if errors.Is(tc.wantErr, domain.ErrRuleViolation) {
+					assert.ErrorIs(t, err, tc.wantErr)
+				} else {
+					assert.EqualError(t, err, tc.wantErr.Error())
+				}
+				return
+			}
+			require.NoError(t, err)
+			if tc.check != nil {
+				tc.check(t, got)
+			}
+		})
+	}
+}
+
+func Test_webhookUC_Deliveries(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("unknown_webhook", func(t *testing.T) {
+		repo := mocks.NewWebhookRepository(t)
+		repo.On("GetWebhook", ctx, int32(9)).Return(nil, nil).Once()
+		_, err := NewWebhookUC(repo).Deliveries(ctx, 9, 0)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("limit_defaults", func(t *testing.T) {
+		repo := mocks.NewWebhookRepository(t)
+		repo.On("GetWebhook", ctx, int32(3)).Return(&domain.Webhook{ID: 3}, nil).Once()
+		repo.On("ListDeliveries", ctx, int32(3), 20).Return([]domain.Delivery{{ID: 1}}, nil).Once()
+		ds, err := NewWebhookUC(repo).Deliveries(ctx, 3, 500)
+		require.NoError(t, err)
+		assert.Len(t, ds, 1)
+	})
+}
@@ -0,0 +1,162 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/pkg/log"
+	"usrsvc/internal/pkg/netguard"
+)
+
+// ScopeAdmin lets a caller manage webhooks and read their deliveries.
+const ScopeAdmin = "webhooks:admin"
+
+// Job is a claimed delivery together with where and how to send it.
+type Job struct {
+	Delivery domain.Delivery
+	URL      string
+	Secret   string
+}
+
+// Store is the delivery queue as seen by the dispatcher and the enqueuer.
+type Store interface {
+	// Enqueue creates one pending delivery per active webhook subscribed to
+	// e.Type; enqueuing the same event twice is a no-op.
+	Enqueue(ctx context.Context, e domain.Event) error
+	// ClaimDue returns pending deliveries whose next attempt is due and hides
+	// them from other dispatchers for lease.
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]Job, error)
+	// RecordAttempt appends a to the delivery log and sets its new status
+	// and next attempt time.
+	RecordAttempt(ctx context.Context, deliveryID int64, a domain.DeliveryAttempt, status string, next time.Time) error
+}
+
+// Publisher feeds the outbox relay into the delivery queue.
+type Publisher struct{ Store Store }
+
+func (p Publisher) Publish(ctx context.Context, e domain.Event) error { return p.Store.Enqueue(ctx, e) }
+
+// Dispatcher sends due deliveries. A non-2xx answer or a transport error is
+// retried after an exponential, jittered backoff; after MaxAttempts the
+// delivery is dead-lettered. The default Client refuses to connect to
+// non-public addresses.
+type Dispatcher struct {
+	Store       Store
+	Client      *http.Client
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Timeout     time.Duration
+	Interval    time.Duration
+	Batch       int
+
+	now    func() time.Time
+	jitter func(d time.Duration) time.Duration
+}
+
+func NewDispatcher(s Store) *Dispatcher {
+	return &Dispatcher{
+		Store: s, Client: netguard.Client(), MaxAttempts: 8,
+		BaseDelay: 10 * time.Second, MaxDelay: time.Hour, Timeout: 10 * time.Second,
+		Interval: time.Second, Batch: 20,
+		now:    time.Now,
+		jitter: func(d time.Duration) time.Duration { return time.Duration(rand.Int64N(int64(d))) + d/2 },
+	}
+}
+
+// Run polls until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	t := time.NewTicker(d.Interval)
+	defer t.Stop()
+	for {
+		if _, err := d.Once(ctx); err != nil && ctx.Err() == nil {
+			log.Error.Printf("webhook dispatch err=%v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Once claims one batch, sends it and returns how many were attempted.
+func (d *Dispatcher) Once(ctx context.Context) (int, error) {
+	// The lease outlives every attempt in the batch, so a crashed
+	// dispatcher's jobs come back after it expires.
+	lease := time.Duration(d.Batch+1) * d.Timeout
+	jobs, err := d.Store.ClaimDue(ctx, d.Batch, lease)
+	if err != nil {
+		return 0, err
+	}
+	for _, j := range jobs {
+		a := d.send(ctx, j)
+		status, next := d.outcome(j.Delivery.Attempts+1, a)
+		if err := d.Store.RecordAttempt(ctx, j.Delivery.ID, a, status, next); err != nil {
+			return 0, err
+		}
+		log.Info.Printf("webhook deliver id=%d webhook=%d status=%s code=%d attempt=%d",
+			j.Delivery.ID, j.Delivery.WebhookID, status, a.StatusCode, j.Delivery.Attempts+1)
+	}
+	return len(jobs), nil
+}
+
+func (d *Dispatcher) send(ctx context.Context, j Job) domain.DeliveryAttempt {
+	start := d.now()
+	a := domain.DeliveryAttempt{At: start.UTC()}
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, j.URL, bytes.NewReader(j.Delivery.Payload))
+	if err != nil {
+		a.Error = err.Error()
+		return a
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "usrsvc-webhooks")
+	req.Header.Set(HeaderEvent, j.Delivery.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(j.Delivery.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(start.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(j.Secret, start, j.Delivery.Payload))
+
+	resp, err := d.Client.Do(req)
+	a.Duration = d.now().Sub(start)
+	if err != nil {
+		a.Error = err.Error()
+		return a
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	a.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		a.Error = resp.Status
+	}
+	return a
+}
+
+func (d *Dispatcher) outcome(attempt int, a domain.DeliveryAttempt) (string, time.Time) {
+	now := d.now()
+	switch {
+	case a.Error == "":
+		return domain.DeliverySucceeded, now
+	case attempt >= d.MaxAttempts:
+		return domain.DeliveryDead, now
+	}
+	return domain.DeliveryPending, now.Add(d.backoff(attempt))
+}
+
+// backoff doubles BaseDelay per attempt up to MaxDelay, spread over
+// [d/2, 3d/2) so receivers that come back up are not hit all at once.
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	wait := d.BaseDelay << (attempt - 1)
+	if wait > d.MaxDelay || wait <= 0 {
+		wait = d.MaxDelay
+	}
+	return d.jitter(wait)
+}
@@ -0,0 +1,55 @@
+// Package webhooks fans outbox events out to subscribed URLs and delivers
+// them with HMAC signatures and retries.
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers sent with every delivery.
+const (
+	HeaderEvent     = "X-Usrsvc-Event"
+	HeaderDelivery  = "X-Usrsvc-Delivery"
+	HeaderTimestamp = "X-Usrsvc-Timestamp"
+	HeaderSignature = "X-Usrsvc-Signature"
+)
+
+var (
+	ErrBadSignature = errors.New("webhooks: signature mismatch")
+	ErrStale        = errors.New("webhooks: timestamp outside tolerance")
+)
+
+// Sign returns the X-Usrsvc-Signature value: "sha256=" followed by the hex
+// HMAC-SHA256 of "<unix timestamp>.<body>" under secret. Binding the
+// timestamp lets receivers reject replays.
+func Sign(secret string, ts time.Time, body []byte) string {
+	m := hmac.New(sha256.New, []byte(secret))
+	m.Write([]byte(strconv.FormatInt(ts.Unix(), 10)))
+	m.Write([]byte{'.'})
+	m.Write(body)
+	return "sha256=" + hex.EncodeToString(m.Sum(nil))
+}
+
+// Verify checks a delivery on the receiving side. tolerance bounds how far
+// the timestamp header may be from now.
+func Verify(secret, timestamp, signature string, body []byte, now time.Time, tolerance time.Duration) error {
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrStale
+	}
+	ts := time.Unix(sec, 0)
+	if d := now.Sub(ts); d > tolerance || d < -tolerance {
+		return ErrStale
+	}
+	want := Sign(secret, ts, body)
+	if !strings.HasPrefix(signature, "sha256=") || !hmac.Equal([]byte(want), []byte(signature)) {
+		return ErrBadSignature
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"usrsvc/internal/domain"
+)
+
+func TestSignVerify(t *testing.T) {
+	now := time.Unix(1_800_000_000, 0)
+	body := []byte(`{"id":1}`)
+	sig := Sign("s3cret", now, body)
+	ts := "1800000000"
+
+	tests := []struct {
+		name    string
+		secret  string
+		ts      string
+		sig     string
+		body    []byte
+		now     time.Time
+		wantErr error
+	}{
+		{name: "valid", secret: "s3cret", ts: ts, sig: sig, body: body, now: now},
+		{name: "wrong_secret", secret: "other", ts: ts, sig: sig, body: body, now: now, wantErr: ErrBadSignature},
+		{name: "tampered_body", secret: "s3cret", ts: ts, sig: sig, body: []byte(`{"id":2}`), now: now, wantErr: ErrBadSignature},
+		{name: "replayed_later", secret: "s3cret", ts: ts, sig: sig, body: body, now: now.Add(10 * time.Minute), wantErr: ErrStale},
+		{name: "timestamp_swapped", secret: "s3cret", ts: "1800000001", sig: sig, body: body, now: now, wantErr: ErrBadSignature},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Verify(tc.secret, tc.ts, tc.sig, tc.body, tc.now, 5*time.Minute)
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
+
+type attempt struct {
+	id     int64
+	a      domain.DeliveryAttempt
+	status string
+	next   time.Time
+}
+
+type fakeStore struct {
+	jobs     []Job
+	recorded []attempt
+	enqueued []domain.Event
+}
+
+func (s *fakeStore) Enqueue(_ context.Context, e domain.Event) error {
+	s.enqueued = append(s.enqueued, e)
+	return nil
+}
+
+func (s *fakeStore) ClaimDue(_ context.Context, limit int, _ time.Duration) ([]Job, error) {
+	j := s.jobs
+	s.jobs = nil
+	return j, nil
+}
+
+func (s *fakeStore) RecordAttempt(_ context.Context, id int64, a domain.DeliveryAttempt, status string, next time.Time) error {
+	s.recorded = append(s.recorded, attempt{id, a, status, next})
+	return nil
+}
+
+func TestDispatcher(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1_800_000_000, 0)
+	payload := []byte(`{"id":7,"type":"customer.created"}`)
+
+	var got *http.Request
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		gotBody, _ = io.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	newDispatcher := func(s Store) *Dispatcher {
+		d := NewDispatcher(s)
+		d.Client = srv.Client()
+		d.now = func() time.Time { return now }
+		d.jitter = func(d time.Duration) time.Duration { return d }
+		return d
+	}
+	job := func(path string, attempts int) Job {
+		return Job{URL: srv.URL + path, Secret: "s3cret", Delivery: domain.Delivery{
+			ID: 10, WebhookID: 3, EventID: 7, EventType: domain.EventCustomerCreated, Payload: payload, Attempts: attempts,
+		}}
+	}
+
+	t.Run("success_is_signed", func(t *testing.T) {
+		s := &fakeStore{jobs: []Job{job("/ok", 0)}}
+		n, err := newDispatcher(s).Once(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+
+		require.Len(t, s.recorded, 1)
+		assert.Equal(t, domain.DeliverySucceeded, s.recorded[0].status)
+		assert.Equal(t, http.StatusNoContent, s.recorded[0].a.StatusCode)
+		assert.Equal(t, payload, gotBody)
+		assert.Equal(t, domain.EventCustomerCreated, got.Header.Get(HeaderEvent))
+		assert.Equal(t, "10", got.Header.Get(HeaderDelivery))
+		assert.NoError(t, Verify("s3cret", got.Header.Get(HeaderTimestamp), got.Header.Get(HeaderSignature), gotBody, now, time.Minute))
+	})
+
+	t.Run("private_address_is_refused", func(t *testing.T) {
+		s := &fakeStore{jobs: []Job{job("/ok", 0)}}
+		d := newDispatcher(s)
+		d.Client = NewDispatcher(s).Client
+		_, err := d.Once(ctx)
+		require.NoError(t, err)
+		require.Len(t, s.recorded, 1)
+		assert.Equal(t, domain.DeliveryPending, s.recorded[0].status)
+		assert.Contains(t, s.recorded[0].a.Error, "not public")
+	})
+
+	t.Run("failure_backs_off_exponentially", func(t *testing.T) {
+		for attempts, wantWait := range map[int]time.Duration{0: 10 * time.Second, 2: 40 * time.Second, 6: 10*time.Minute + 40*time.Second} {
+			s := &fakeStore{jobs: []Job{job("/down", attempts)}}
+			d := newDispatcher(s)
+			_, err := d.Once(ctx)
+			require.NoError(t, err)
+			require.Len(t, s.recorded, 1)
+			assert.Equal(t, domain.DeliveryPending, s.recorded[0].status)
+			assert.Equal(t, "503 Service Unavailable", s.recorded[0].a.Error)
+			assert.Equal(t, now.Add(wantWait), s.recorded[0].next, "after attempt %d", attempts+1)
+		}
+	})
+
+	t.Run("backoff_capped", func(t *testing.T) {
+		d := newDispatcher(&fakeStore{})
+		d.MaxDelay = time.Minute
+		assert.Equal(t, time.Minute, d.backoff(30))
+		assert.Equal(t, time.Minute, d.backoff(80))
+	})
+
+	t.Run("dead_letter_after_max_attempts", func(t *testing.T) {
+		s := &fakeStore{jobs: []Job{job("/down", 7)}}
+		_, err := newDispatcher(s).Once(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, domain.DeliveryDead, s.recorded[0].status)
+	})
+
+	t.Run("transport_error_recorded", func(t *testing.T) {
+		s := &fakeStore{jobs: []Job{{URL: "http://127.0.0.1:1/", Secret: "x", Delivery: domain.Delivery{ID: 11, Payload: payload}}}}
+		_, err := newDispatcher(s).Once(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, s.recorded[0].a.StatusCode)
+		assert.NotEmpty(t, s.recorded[0].a.Error)
+		assert.Equal(t, domain.DeliveryPending, s.recorded[0].status)
+	})
+
+	t.Run("publisher_enqueues", func(t *testing.T) {
+		s := &fakeStore{}
+		require.NoError(t, Publisher{Store: s}.Publish(ctx, domain.Event{ID: 7}))
+		assert.Len(t, s.enqueued, 1)
+	})
+}
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_customer_phone_e164;
+ALTER TABLE customer DROP COLUMN IF EXISTS cst_phone_e164;
@@ -0,0 +1,6 @@
+-- Normalized (E.164) copy of cst_phoneNum, filled by the app on create/update.
+-- Rows written before this migration keep NULL until they are next saved or
+-- `make backfill-phone` (cmd/backfillphone) fills them.
+ALTER TABLE customer ADD COLUMN cst_phone_e164 VARCHAR(16);
+
+CREATE INDEX idx_customer_phone_e164 ON customer(cst_phone_e164);
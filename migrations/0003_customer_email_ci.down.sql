@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS uniq_customer_email_lower;
+ALTER TABLE customer ADD CONSTRAINT customer_cst_email_key UNIQUE (cst_email);
@@ -0,0 +1,20 @@
+-- Email uniqueness becomes case-insensitive. Trim stray whitespace first, then
+-- refuse to continue if existing rows already collide ignoring case: those
+-- need a human to decide which customer keeps the address.
+UPDATE customer SET cst_email = btrim(cst_email) WHERE cst_email <> btrim(cst_email);
+
+DO $$
+DECLARE dups TEXT;
+BEGIN
+  SELECT string_agg(e || ' (' || ids || ')', ', ') INTO dups
+  FROM (
+    SELECT lower(cst_email) AS e, string_agg(cst_id::TEXT, ',' ORDER BY cst_id) AS ids
+    FROM customer GROUP BY lower(cst_email) HAVING COUNT(*) > 1
+  ) d;
+  IF dups IS NOT NULL THEN
+    RAISE EXCEPTION 'case-insensitive duplicate emails, resolve before migrating: %', dups;
+  END IF;
+END $$;
+
+ALTER TABLE customer DROP CONSTRAINT IF EXISTS customer_cst_email_key;
+CREATE UNIQUE INDEX uniq_customer_email_lower ON customer (lower(cst_email));
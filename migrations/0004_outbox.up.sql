@@ -0,0 +1,14 @@
+-- Domain events written in the same transaction as the customer change and
+-- relayed to publishers afterwards (at-least-once, ordered by id).
+CREATE TABLE outbox (
+  id           BIGSERIAL   PRIMARY KEY,
+  event_type   TEXT        NOT NULL,
+  customer_id  INT         NOT NULL,
+  payload      JSONB       NOT NULL,
+  occurred_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+  published_at TIMESTAMPTZ,
+  attempts     INT         NOT NULL DEFAULT 0,
+  last_error   TEXT
+);
+
+CREATE INDEX idx_outbox_pending ON outbox (id) WHERE published_at IS NULL;
@@ -0,0 +1,35 @@
+CREATE TABLE webhook (
+  id          SERIAL      PRIMARY KEY,
+  url         TEXT        NOT NULL,
+  event_types TEXT[]      NOT NULL DEFAULT '{}', -- empty = every event
+  secret      TEXT        NOT NULL,
+  active      BOOLEAN     NOT NULL DEFAULT true,
+  created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- One row per (webhook, event). status 'dead' is the dead letter queue.
+CREATE TABLE webhook_delivery (
+  id              BIGSERIAL   PRIMARY KEY,
+  webhook_id      INT         NOT NULL REFERENCES webhook(id) ON DELETE CASCADE,
+  event_id        BIGINT      NOT NULL,
+  event_type      TEXT        NOT NULL,
+  payload         JSONB       NOT NULL,
+  status          TEXT        NOT NULL DEFAULT 'pending' CHECK (status IN ('pending','succeeded','dead')),
+  attempts        INT         NOT NULL DEFAULT 0,
+  next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+  UNIQUE (webhook_id, event_id)
+);
+
+CREATE INDEX idx_webhook_delivery_due ON webhook_delivery (next_attempt_at) WHERE status = 'pending';
+
+CREATE TABLE webhook_attempt (
+  id           BIGSERIAL   PRIMARY KEY,
+  delivery_id  BIGINT      NOT NULL REFERENCES webhook_delivery(id) ON DELETE CASCADE,
+  attempted_at TIMESTAMPTZ NOT NULL,
+  status_code  INT,
+  error        TEXT        NOT NULL DEFAULT '',
+  duration_ms  INT         NOT NULL
+);
+
+CREATE INDEX idx_webhook_attempt_delivery ON webhook_attempt (delivery_id);
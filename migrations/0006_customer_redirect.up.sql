@@ -0,0 +1,8 @@
+-- Ids of customers merged away, so old references still resolve.
+CREATE TABLE customer_redirect (
+  old_id    INT         PRIMARY KEY,
+  new_id    INT         NOT NULL REFERENCES customer(cst_id) ON DELETE CASCADE,
+  merged_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_customer_redirect_new ON customer_redirect (new_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_customer_created_at;
+ALTER TABLE customer DROP COLUMN IF EXISTS created_at;
@@ -0,0 +1,5 @@
+-- Creation time for the stats endpoint. Rows that predate this migration get
+-- the migration time; there is no better source for them.
+ALTER TABLE customer ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX idx_customer_created_at ON customer (created_at);
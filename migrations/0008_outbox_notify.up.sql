@@ -0,0 +1,12 @@
+-- Wake the change feed on every instance when an event commits; the payload
+-- is the outbox id, listeners read the row themselves (NOTIFY payloads are
+-- capped at 8000 bytes).
+CREATE FUNCTION outbox_notify() RETURNS trigger AS $$
+BEGIN
+  PERFORM pg_notify('usrsvc_events', NEW.id::text);
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER outbox_notify AFTER INSERT ON outbox
+  FOR EACH ROW EXECUTE FUNCTION outbox_notify();
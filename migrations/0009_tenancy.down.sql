@@ -0,0 +1,25 @@
+DROP INDEX IF EXISTS idx_webhook_tenant;
+ALTER TABLE webhook DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE outbox  DROP COLUMN IF EXISTS tenant_id;
+
+DROP TABLE IF EXISTS nationality_override;
+
+DO $$
+DECLARE t TEXT;
+BEGIN
+  FOREACH t IN ARRAY ARRAY['customer', 'family_list', 'customer_redirect'] LOOP
+    EXECUTE format('DROP POLICY IF EXISTS tenant_isolation ON %I', t);
+    EXECUTE format('ALTER TABLE %I NO FORCE ROW LEVEL SECURITY', t);
+    EXECUTE format('ALTER TABLE %I DISABLE ROW LEVEL SECURITY', t);
+  END LOOP;
+END $$;
+
+-- Fails if two tenants hold the same email; merge or rename those first.
+DROP INDEX IF EXISTS idx_customer_tenant;
+DROP INDEX IF EXISTS uniq_customer_tenant_email_lower;
+CREATE UNIQUE INDEX uniq_customer_email_lower ON customer (lower(cst_email));
+
+ALTER TABLE customer_redirect DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE family_list       DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE customer          DROP COLUMN IF EXISTS tenant_id;
+DROP FUNCTION IF EXISTS app_tenant();
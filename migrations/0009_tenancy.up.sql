@@ -0,0 +1,46 @@
+-- Tenancy. Every customer row belongs to a tenant; existing rows go to
+-- 'default'. The service sets the tenant per transaction with
+-- SET LOCAL app.tenant_id (set_config(..., true)) and row-level security
+-- hides and refuses rows of other tenants. FORCE applies the policies to the
+-- table owner too; only superusers and BYPASSRLS roles skip them, so the
+-- service must not connect as one.
+CREATE FUNCTION app_tenant() RETURNS TEXT LANGUAGE sql STABLE
+  AS $$ SELECT NULLIF(current_setting('app.tenant_id', true), '') $$;
+
+ALTER TABLE customer          ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE family_list       ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE customer_redirect ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+-- New rows take the transaction's tenant; without one the insert fails.
+ALTER TABLE customer          ALTER COLUMN tenant_id SET DEFAULT app_tenant();
+ALTER TABLE family_list       ALTER COLUMN tenant_id SET DEFAULT app_tenant();
+ALTER TABLE customer_redirect ALTER COLUMN tenant_id SET DEFAULT app_tenant();
+
+DROP INDEX uniq_customer_email_lower;
+CREATE UNIQUE INDEX uniq_customer_tenant_email_lower ON customer (tenant_id, lower(cst_email));
+CREATE INDEX idx_customer_tenant ON customer (tenant_id, cst_id);
+
+-- Per-tenant display names for the shared nationality list.
+CREATE TABLE nationality_override (
+  tenant_id        TEXT NOT NULL DEFAULT app_tenant(),
+  nationality_id   INT  NOT NULL REFERENCES nationality(nationality_id) ON DELETE CASCADE,
+  nationality_name TEXT NOT NULL,
+  PRIMARY KEY (tenant_id, nationality_id)
+);
+
+DO $$
+DECLARE t TEXT;
+BEGIN
+  FOREACH t IN ARRAY ARRAY['customer', 'family_list', 'customer_redirect', 'nationality_override'] LOOP
+    EXECUTE format('ALTER TABLE %I ENABLE ROW LEVEL SECURITY', t);
+    EXECUTE format('ALTER TABLE %I FORCE ROW LEVEL SECURITY', t);
+    EXECUTE format('CREATE POLICY tenant_isolation ON %I USING (tenant_id = app_tenant()) WITH CHECK (tenant_id = app_tenant())', t);
+  END LOOP;
+END $$;
+
+-- Events and webhook subscriptions are read by the relay and dispatcher
+-- across tenants, so they carry the tenant without RLS.
+ALTER TABLE outbox  ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE outbox  ALTER COLUMN tenant_id SET DEFAULT app_tenant();
+ALTER TABLE webhook ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE webhook ALTER COLUMN tenant_id DROP DEFAULT;
+CREATE INDEX idx_webhook_tenant ON webhook (tenant_id);
@@ -0,0 +1,40 @@
+-- Refuses while sealed rows exist: run `go run ./cmd/reencrypt -decrypt` for
+-- every tenant first. NO FORCE lets the owner see all tenants for the check.
+DO $$
+BEGIN
+  ALTER TABLE customer NO FORCE ROW LEVEL SECURITY;
+  ALTER TABLE family_list NO FORCE ROW LEVEL SECURITY;
+  IF EXISTS (SELECT 1 FROM customer WHERE pii_kid IS NOT NULL)
+     OR EXISTS (SELECT 1 FROM family_list WHERE pii_kid IS NOT NULL) THEN
+    RAISE EXCEPTION 'encrypted customer PII present; run cmd/reencrypt -decrypt first';
+  END IF;
+  ALTER TABLE customer FORCE ROW LEVEL SECURITY;
+  ALTER TABLE family_list FORCE ROW LEVEL SECURITY;
+END $$;
+
+ALTER TABLE family_list
+  DROP CONSTRAINT IF EXISTS family_list_pii_sealed,
+  DROP COLUMN IF EXISTS fl_dob_bidx,
+  DROP COLUMN IF EXISTS fl_dob_enc,
+  DROP COLUMN IF EXISTS pii_kid,
+  ALTER COLUMN fl_dob SET NOT NULL;
+
+DROP INDEX IF EXISTS idx_customer_dob_bidx;
+DROP INDEX IF EXISTS idx_customer_phone_sfx_bidx;
+DROP INDEX IF EXISTS idx_customer_phone_e164_bidx;
+DROP INDEX IF EXISTS uniq_customer_tenant_email_bidx;
+
+ALTER TABLE customer
+  DROP CONSTRAINT IF EXISTS customer_pii_sealed,
+  DROP COLUMN IF EXISTS cst_email_bidx,
+  DROP COLUMN IF EXISTS cst_phone_e164_bidx,
+  DROP COLUMN IF EXISTS cst_phone_sfx_bidx,
+  DROP COLUMN IF EXISTS cst_dob_bidx,
+  DROP COLUMN IF EXISTS cst_email_enc,
+  DROP COLUMN IF EXISTS cst_phone_e164_enc,
+  DROP COLUMN IF EXISTS cst_phone_enc,
+  DROP COLUMN IF EXISTS cst_dob_enc,
+  DROP COLUMN IF EXISTS pii_kid,
+  ALTER COLUMN cst_email    SET NOT NULL,
+  ALTER COLUMN cst_phoneNum SET NOT NULL,
+  ALTER COLUMN cst_dob      SET NOT NULL;
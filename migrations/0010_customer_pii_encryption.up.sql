@@ -0,0 +1,39 @@
+-- Envelope-encrypted PII. The service seals email, phone and date of birth
+-- with its keyring (PII_KEYRING) into the *_enc columns and writes blind
+-- indexes (keyed hashes of the normalized value) for uniqueness and exact
+-- lookups. pii_kid is the key a row was sealed with; rows with pii_kid NULL
+-- are still plaintext until cmd/reencrypt converts them, and the checks keep
+-- a row from holding both forms.
+ALTER TABLE customer
+  ALTER COLUMN cst_dob      DROP NOT NULL,
+  ALTER COLUMN cst_phoneNum DROP NOT NULL,
+  ALTER COLUMN cst_email    DROP NOT NULL,
+  ADD COLUMN pii_kid             TEXT,
+  ADD COLUMN cst_dob_enc         BYTEA,
+  ADD COLUMN cst_phone_enc       BYTEA,
+  ADD COLUMN cst_phone_e164_enc  BYTEA,
+  ADD COLUMN cst_email_enc       BYTEA,
+  ADD COLUMN cst_dob_bidx        BYTEA,
+  ADD COLUMN cst_phone_sfx_bidx  BYTEA, -- last 7 digits, for duplicate detection
+  ADD COLUMN cst_phone_e164_bidx BYTEA,
+  ADD COLUMN cst_email_bidx      BYTEA,
+  ADD CONSTRAINT customer_pii_sealed CHECK (
+    CASE WHEN pii_kid IS NULL
+      THEN cst_dob IS NOT NULL AND cst_phoneNum IS NOT NULL AND cst_email IS NOT NULL
+      ELSE cst_dob IS NULL AND cst_phoneNum IS NULL AND cst_phone_e164 IS NULL AND cst_email IS NULL
+        AND cst_dob_enc IS NOT NULL AND cst_phone_enc IS NOT NULL AND cst_email_enc IS NOT NULL
+    END);
+
+CREATE UNIQUE INDEX uniq_customer_tenant_email_bidx ON customer (tenant_id, cst_email_bidx);
+CREATE INDEX idx_customer_phone_e164_bidx ON customer (tenant_id, cst_phone_e164_bidx);
+CREATE INDEX idx_customer_phone_sfx_bidx  ON customer (tenant_id, cst_phone_sfx_bidx);
+CREATE INDEX idx_customer_dob_bidx        ON customer (tenant_id, cst_dob_bidx);
+
+ALTER TABLE family_list
+  ALTER COLUMN fl_dob DROP NOT NULL,
+  ADD COLUMN pii_kid     TEXT,
+  ADD COLUMN fl_dob_enc  BYTEA,
+  ADD COLUMN fl_dob_bidx BYTEA,
+  ADD CONSTRAINT family_list_pii_sealed CHECK (
+    CASE WHEN pii_kid IS NULL THEN fl_dob IS NOT NULL
+      ELSE fl_dob IS NULL AND fl_dob_enc IS NOT NULL END);
@@ -0,0 +1,20 @@
+-- Audit trail of privileged access to customer PII. Rows are only ever
+-- inserted: with RLS forced and no UPDATE or DELETE policy, the service
+-- cannot change or remove them.
+CREATE TABLE audit_log (
+  id           BIGSERIAL   PRIMARY KEY,
+  tenant_id    TEXT        NOT NULL DEFAULT app_tenant(),
+  action       TEXT        NOT NULL,
+  operation    TEXT        NOT NULL,
+  actor        TEXT        NOT NULL,
+  role         TEXT        NOT NULL,
+  customer_ids INT[]       NOT NULL DEFAULT '{}',
+  reason       TEXT        NOT NULL DEFAULT '',
+  at           TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX idx_audit_log_tenant_at ON audit_log (tenant_id, at);
+
+ALTER TABLE audit_log ENABLE ROW LEVEL SECURITY;
+ALTER TABLE audit_log FORCE ROW LEVEL SECURITY;
+CREATE POLICY audit_read   ON audit_log FOR SELECT USING (tenant_id = app_tenant());
+CREATE POLICY audit_append ON audit_log FOR INSERT WITH CHECK (tenant_id = app_tenant());
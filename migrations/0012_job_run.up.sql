@@ -0,0 +1,16 @@
+-- Run history of the in-process job scheduler. A scheduled run claims its
+-- (job, scheduled_for) slot, so a slot runs once however many replicas see it
+-- due; manual runs have no slot.
+CREATE TABLE job_run (
+  id            BIGSERIAL   PRIMARY KEY,
+  job           TEXT        NOT NULL,
+  triggered_by  TEXT        NOT NULL CHECK (triggered_by IN ('schedule','manual')),
+  scheduled_for TIMESTAMPTZ,
+  instance      TEXT        NOT NULL,
+  started_at    TIMESTAMPTZ NOT NULL,
+  finished_at   TIMESTAMPTZ,
+  error         TEXT        NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX idx_job_run_slot ON job_run (job, scheduled_for);
+CREATE INDEX idx_job_run_recent ON job_run (job, id DESC);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_customer_phone_sfx;
+DROP INDEX IF EXISTS idx_customer_dob;
+DROP INDEX IF EXISTS idx_customer_name_first;
+DROP INDEX IF EXISTS idx_customer_name_prefix;
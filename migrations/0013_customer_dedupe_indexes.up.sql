@@ -0,0 +1,7 @@
+-- Blocking keys of DuplicateCandidates on plaintext columns; sealed rows use
+-- the blind indexes from 0010. The expressions must match the query text.
+CREATE INDEX idx_customer_name_prefix ON customer (tenant_id, lower(left(btrim(cst_name),3)));
+CREATE INDEX idx_customer_name_first  ON customer (tenant_id, lower(split_part(btrim(cst_name),' ',1)));
+CREATE INDEX idx_customer_dob         ON customer (tenant_id, cst_dob) WHERE pii_kid IS NULL;
+CREATE INDEX idx_customer_phone_sfx   ON customer (tenant_id, right(regexp_replace(cst_phoneNum,'[^0-9]','','g'),7))
+  WHERE pii_kid IS NULL;
@@ -0,0 +1,12 @@
+// Package migrations embeds the schema. The Postgres migrations are applied
+// with golang-migrate (make migrate-up); Postgres is embedded for tests that
+// build a throwaway schema, SQLite for repository.OpenSQLite.
+package migrations
+
+import "embed"
+
+//go:embed *.up.sql
+var Postgres embed.FS
+
+//go:embed sqlite/*.sql
+var SQLite embed.FS
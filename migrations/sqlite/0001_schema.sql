@@ -0,0 +1,49 @@
+-- SQLite port of the customer tables in ../0001-0003, 0006 and 0007, plus the
+-- nationality seed. The outbox and webhook tables (0004, 0005, 0008) are not
+-- ported: their relay, dispatcher and change feed are Postgres-only.
+-- Dates are stored as TEXT (YYYY-MM-DD, created_at as RFC 3339 UTC).
+
+CREATE TABLE nationality (
+  nationality_id   INTEGER PRIMARY KEY AUTOINCREMENT,
+  nationality_name TEXT NOT NULL,
+  nationality_code TEXT
+);
+CREATE UNIQUE INDEX uniq_nationality_code ON nationality (nationality_code);
+
+CREATE TABLE customer (
+  cst_id         INTEGER PRIMARY KEY AUTOINCREMENT,
+  nationality_id INTEGER NOT NULL REFERENCES nationality (nationality_id),
+  cst_name       TEXT NOT NULL,
+  cst_dob        TEXT NOT NULL,
+  cst_phoneNum   TEXT NOT NULL,
+  cst_phone_e164 TEXT,
+  cst_email      TEXT NOT NULL,
+  created_at     TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+);
+-- lower() only folds ASCII in SQLite; Postgres folds all of Unicode.
+CREATE UNIQUE INDEX uniq_customer_email_lower ON customer (lower(cst_email));
+CREATE INDEX idx_customer_phone_e164 ON customer (cst_phone_e164);
+CREATE INDEX idx_customer_created_at ON customer (created_at);
+
+CREATE TABLE family_list (
+  fl_id       INTEGER PRIMARY KEY AUTOINCREMENT,
+  cst_id      INTEGER NOT NULL REFERENCES customer (cst_id) ON DELETE CASCADE,
+  fl_relation TEXT NOT NULL,
+  fl_name     TEXT NOT NULL,
+  fl_dob      TEXT NOT NULL
+);
+CREATE INDEX idx_family_cst_id ON family_list (cst_id);
+
+CREATE TABLE customer_redirect (
+  old_id    INTEGER PRIMARY KEY,
+  new_id    INTEGER NOT NULL REFERENCES customer (cst_id) ON DELETE CASCADE,
+  merged_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+);
+CREATE INDEX idx_customer_redirect_new ON customer_redirect (new_id);
+
+INSERT INTO nationality (nationality_name, nationality_code) VALUES
+  ('Indonesia', 'ID'),
+  ('Malaysia', 'MY'),
+  ('Singapore', 'SG'),
+  ('Thailand', 'TH'),
+  ('Philippines', 'PH');
@@ -0,0 +1,5 @@
+-- SQLite port of ../0013: the blocking keys of DuplicateCandidates. The
+-- expressions must match the query text.
+CREATE INDEX idx_customer_name_prefix ON customer (lower(substr(trim(cst_name),1,3)));
+CREATE INDEX idx_customer_name_first ON customer (lower(CASE WHEN instr(trim(cst_name),' ') > 0 THEN substr(trim(cst_name),1,instr(trim(cst_name),' ')-1) ELSE trim(cst_name) END));
+CREATE INDEX idx_customer_dob ON customer (cst_dob);
@@ -0,0 +1,289 @@
+// Package client is a typed Go client for the usrsvc HTTP API.
+//
+//	c := client.New("http://usrsvc:8080", client.WithAuth(client.BearerToken(tok)))
+//	u, err := c.GetUser(ctx, 36)
+//	if errors.Is(err, client.ErrNotFound) { ... }
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"usrsvc/internal/dto"
+)
+
+// Wire types, shared with the server so both sides encode the same fields.
+type (
+	CreateCustomerRequest = dto.CreateCustomerRequest
+	UpdateCustomerRequest = dto.UpdateCustomerRequest
+	FamilyMemberRequest   = dto.FamilyMemberRequest
+	CustomerResponse      = dto.CustomerResponse
+	CustomerListResponse  = dto.CustomerListResponse
+	CustomerListItem      = dto.CustomerListItem
+	FamilyMemberResponse  = dto.FamilyMemberResponse
+	Nationality           = dto.NationalityResponse
+)
+
+// Authenticator decorates outgoing requests with credentials. It is called
+// again for every retry attempt, so short-lived tokens can be refreshed.
+type Authenticator interface {
+	Authenticate(r *http.Request) error
+}
+
+type AuthFunc func(r *http.Request) error
+
+func (f AuthFunc) Authenticate(r *http.Request) error { return f(r) }
+
+// BearerToken sends "Authorization: Bearer <token>".
+func BearerToken(token string) Authenticator {
+	return AuthFunc(func(r *http.Request) error {
+		r.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	})
+}
+
+// Retry controls backoff for idempotent calls (GET, PUT, DELETE). Attempts
+// counts the first try; 1 disables retries.
+type Retry struct {
+	Attempts int
+	Base     time.Duration
+	Max      time.Duration
+}
+
+var DefaultRetry = Retry{Attempts: 3, Base: 100 * time.Millisecond, Max: 2 * time.Second}
+
+type Client struct {
+	base      *url.URL
+	hc        *http.Client
+	auth      Authenticator
+	retry     Retry
+	timeout   time.Duration
+	lang      string
+	userAgent string
+}
+
+type Option func(*Client)
+
+func WithHTTPClient(hc *http.Client) Option { return func(c *Client) { c.hc = hc } }
+func WithAuth(a Authenticator) Option       { return func(c *Client) { c.auth = a } }
+func WithRetry(r Retry) Option              { return func(c *Client) { c.retry = r } }
+
+// WithTimeout bounds each call whose context has no deadline of its own.
+func WithTimeout(d time.Duration) Option { return func(c *Client) { c.timeout = d } }
+
+// WithLanguage sets Accept-Language so error messages come back localized.
+func WithLanguage(lang string) Option { return func(c *Client) { c.lang = lang } }
+
+func WithUserAgent(ua string) Option { return func(c *Client) { c.userAgent = ua } }
+
+// New builds a client for baseURL, e.g. "http://localhost:8080". It panics
+// on an unparsable URL, which is a programming error.
+func New(baseURL string, opts ...Option) *Client {
+	u, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil {
+		panic(fmt.Sprintf("client: bad base url %q: %v", baseURL, err))
+	}
+	c := &Client{base: u, hc: http.DefaultClient, retry: DefaultRetry, timeout: 30 * time.Second, userAgent: "usrsvc-go-client"}
+	for _, o := range opts {
+		o(c)
+	}
+	if c.retry.Attempts < 1 {
+		c.retry.Attempts = 1
+	}
+	return c
+}
+
+type ListUsersParams struct {
+	Page   int
+	Size   int
+	Search string
+}
+
+func (c *Client) ListUsers(ctx context.Context, p ListUsersParams) (*CustomerListResponse, error) {
+	q := url.Values{}
+	if p.Page > 0 {
+		q.Set("page", strconv.Itoa(p.Page))
+	}
+	if p.Size > 0 {
+		q.Set("size", strconv.Itoa(p.Size))
+	}
+	if p.Search != "" {
+		q.Set("search", p.Search)
+	}
+	var out CustomerListResponse
+	if err := c.do(ctx, http.MethodGet, "/v1/users", q, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) GetUser(ctx context.Context, id int32) (*CustomerResponse, error) {
+	var out CustomerResponse
+	if err := c.do(ctx, http.MethodGet, userPath(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateUser is not retried: a lost response could otherwise create the
+// customer twice (the second attempt would then fail with ErrConflict).
+func (c *Client) CreateUser(ctx context.Context, req CreateCustomerRequest) (*CustomerResponse, error) {
+	var out CustomerResponse
+	if err := c.do(ctx, http.MethodPost, "/v1/users", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) UpdateUser(ctx context.Context, id int32, req UpdateCustomerRequest) error {
+	return c.do(ctx, http.MethodPut, userPath(id), nil, req, nil)
+}
+
+func (c *Client) DeleteUser(ctx context.Context, id int32) error {
+	return c.do(ctx, http.MethodDelete, userPath(id), nil, nil, nil)
+}
+
+func (c *Client) ListNationalities(ctx context.Context) ([]Nationality, error) {
+	var out []Nationality
+	if err := c.do(ctx, http.MethodGet, "/v1/nationalities", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Health returns nil when /healthz answers 200.
+func (c *Client) Health(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/healthz", nil, nil, nil)
+}
+
+func userPath(id int32) string { return "/v1/users/" + strconv.Itoa(int(id)) }
+
+func idempotent(method string) bool {
+	return method == http.MethodGet || method == http.MethodPut || method == http.MethodDelete
+}
+
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusBadGateway ||
+		status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout
+}
+
+func (c *Client) do(ctx context.Context, method, path string, q url.Values, in, out any) error {
+	if _, ok := ctx.Deadline(); !ok && c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	var body []byte
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("client: encode %s %s: %w", method, path, err)
+		}
+		body = b
+	}
+	u := *c.base
+	u.Path += path
+	u.RawQuery = q.Encode()
+
+	attempts := 1
+	if idempotent(method) {
+		attempts = c.retry.Attempts
+	}
+	var lastErr error
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			if err := sleep(ctx, c.backoff(i)); err != nil {
+				return errors.Join(lastErr, err)
+			}
+		}
+		status, err := c.once(ctx, method, u.String(), body, out)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			return err
+		}
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && !retryable(status) {
+			return err
+		}
+	}
+	return lastErr
+}
+
+func (c *Client) once(ctx context.Context, method, u string, body []byte, out any) (int, error) {
+	var rd io.Reader
+	if body != nil {
+		rd = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, rd)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.lang != "" {
+		req.Header.Set("Accept-Language", c.lang)
+	}
+	if c.auth != nil {
+		if err := c.auth.Authenticate(req); err != nil {
+			return 0, fmt.Errorf("client: auth: %w", err)
+		}
+	}
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode, decodeError(resp.StatusCode, raw)
+	}
+	if out != nil && len(raw) > 0 {
+		if err := json.Unmarshal(raw, out); err != nil {
+			return resp.StatusCode, fmt.Errorf("client: decode %s %s: %w", method, u, err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff doubles per attempt up to Retry.Max and spreads each wait over
+// [d/2, 3d/2) so many clients do not retry in lockstep.
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.retry.Base << (attempt - 1)
+	if c.retry.Max > 0 && (d > c.retry.Max || d <= 0) {
+		d = c.retry.Max
+	}
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(d))) + d/2
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,205 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"usrsvc/internal/domain"
+	"usrsvc/internal/mocks"
+	th "usrsvc/internal/transport/http"
+)
+
+// newServer runs the real router over a mocked usecase. wrap may intercept
+// requests before they reach the router.
+func newServer(t *testing.T, uc *mocks.UserUsecase, wrap func(http.Handler) http.Handler) *httptest.Server {
+	t.Helper()
+	var h http.Handler = th.NewRouter(th.NewHandler(uc), nil)
+	if wrap != nil {
+		h = wrap(h)
+	}
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+var fastRetry = WithRetry(Retry{Attempts: 3, Base: time.Millisecond, Max: 5 * time.Millisecond})
+
+func TestClient_Routes(t *testing.T) {
+	ctx := context.Background()
+	dob := time.Date(1992, 5, 10, 0, 0, 0, 0, time.UTC)
+	code := "ID"
+
+	uc := new(mocks.UserUsecase)
+	uc.On("List", mock.Anything, "AL", 2, 5).
+		Return([]domain.Customer{{ID: 36, Name: "ALFA", Dob: dob, Email: "a@example.com"}}, int32(6), nil).Once()
+	uc.On("Get", mock.Anything, int32(36)).
+		Return(&domain.Customer{ID: 36, Name: "ALFA", Dob: dob}, nil).Once()
+	uc.On("Create", mock.Anything, mock.MatchedBy(func(c domain.Customer) bool {
+		return c.Email == "a@example.com" && len(c.Family) == 1
+	})).Return(int32(36), nil).Once()
+	uc.On("Update", mock.Anything, int32(36), mock.Anything).Return(nil).Once()
+	uc.On("Delete", mock.Anything, int32(36)).Return(nil).Once()
+	uc.On("ListNationality", mock.Anything).
+		Return([]domain.Nationality{{ID: 1, Name: "Indonesia", Code: &code}}, nil).Once()
+
+	c := New(newServer(t, uc, nil).URL)
+
+	list, err := c.ListUsers(ctx, ListUsersParams{Page: 2, Size: 5, Search: "AL"})
+	require.NoError(t, err)
+	assert.Equal(t, 6, list.Total)
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, "ALFA", list.Data[0].CstName)
+
+	got, err := c.GetUser(ctx, 36)
+	require.NoError(t, err)
+	assert.Equal(t, int32(36), got.CstID)
+	assert.Equal(t, "1992-05-10", got.CstDob)
+
+	req := CreateCustomerRequest{
+		CstName: "ALFA", CstDob: "1992-05-10", NationalityID: 1, CstPhoneNum: "081234567890", CstEmail: "a@example.com",
+		Family: []FamilyMemberRequest{{FlRelation: "spouse", FlName: "BETA", FlDob: "1993-07-01"}},
+	}
+	created, err := c.CreateUser(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, int32(36), created.CstID)
+
+	require.NoError(t, c.UpdateUser(ctx, 36, req))
+	require.NoError(t, c.DeleteUser(ctx, 36))
+
+	ns, err := c.ListNationalities(ctx)
+	require.NoError(t, err)
+	require.Len(t, ns, 1)
+	assert.Equal(t, "Indonesia", ns[0].Name)
+
+	require.NoError(t, c.Health(ctx))
+	uc.AssertExpectations(t)
+}
+
+func TestClient_TypedErrors(t *testing.T) {
+	ctx := context.Background()
+	uc := new(mocks.UserUsecase)
+	uc.On("Get", mock.Anything, int32(404)).Return(nil, domain.ErrNotFound)
+	uc.On("Create", mock.Anything, mock.Anything).Return(int32(0), domain.ErrConflict)
+	c := New(newServer(t, uc, nil).URL, WithLanguage("id"))
+
+	_, err := c.GetUser(ctx, 404)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = c.CreateUser(ctx, CreateCustomerRequest{
+		CstName: "A", CstDob: "1992-05-10", NationalityID: 1, CstPhoneNum: "0811", CstEmail: "a@example.com",
+	})
+	assert.ErrorIs(t, err, ErrConflict)
+
+	_, err = c.CreateUser(ctx, CreateCustomerRequest{CstEmail: "nope"})
+	require.ErrorIs(t, err, ErrValidation)
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+	assert.Equal(t, "validasi gagal", apiErr.Message)
+	assert.Contains(t, apiErr.Fields, "cst_email")
+	assert.Contains(t, apiErr.Fields, "cst_name")
+
+	_, err = c.GetUser(ctx, 0)
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func flaky(failures int32, status int, calls *int32) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(calls, 1) <= failures {
+				w.WriteHeader(status)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestClient_Retries(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("idempotent_get_retried_until_success", func(t *testing.T) {
+		var calls int32
+		uc := new(mocks.UserUsecase)
+		uc.On("ListNationality", mock.Anything).Return([]domain.Nationality{}, nil).Once()
+		c := New(newServer(t, uc, flaky(2, http.StatusServiceUnavailable, &calls)).URL, fastRetry)
+
+		_, err := c.ListNationalities(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), calls)
+	})
+
+	t.Run("gives_up_after_attempts", func(t *testing.T) {
+		var calls int32
+		c := New(newServer(t, new(mocks.UserUsecase), flaky(10, http.StatusBadGateway, &calls)).URL, fastRetry)
+
+		err := c.DeleteUser(ctx, 1)
+		assert.ErrorIs(t, err, ErrUnavailable)
+		assert.Equal(t, int32(3), calls)
+	})
+
+	t.Run("post_not_retried", func(t *testing.T) {
+		var calls int32
+		c := New(newServer(t, new(mocks.UserUsecase), flaky(10, http.StatusServiceUnavailable, &calls)).URL, fastRetry)
+
+		_, err := c.CreateUser(ctx, CreateCustomerRequest{})
+		assert.ErrorIs(t, err, ErrUnavailable)
+		assert.Equal(t, int32(1), calls)
+	})
+
+	t.Run("client_errors_not_retried", func(t *testing.T) {
+		var calls int32
+		uc := new(mocks.UserUsecase)
+		uc.On("Get", mock.Anything, int32(7)).Return(nil, domain.ErrNotFound).Once()
+		c := New(newServer(t, uc, flaky(0, 0, &calls)).URL, fastRetry)
+
+		_, err := c.GetUser(ctx, 7)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Equal(t, int32(1), calls)
+	})
+}
+
+func TestClient_ContextDeadline(t *testing.T) {
+	slow := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+		})
+	}
+	c := New(newServer(t, new(mocks.UserUsecase), slow).URL, fastRetry)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.ListNationalities(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestClient_Auth(t *testing.T) {
+	var seen []string
+	capture := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seen = append(seen, r.Header.Get("Authorization"))
+			next.ServeHTTP(w, r)
+		})
+	}
+	c := New(newServer(t, new(mocks.UserUsecase), capture).URL, WithAuth(BearerToken("s3cret")))
+	require.NoError(t, c.Health(context.Background()))
+	assert.Equal(t, []string{"Bearer s3cret"}, seen)
+
+	failing := New("http://127.0.0.1:1", WithAuth(AuthFunc(func(*http.Request) error { return errors.New("no token") })), WithRetry(Retry{Attempts: 1}))
+	assert.ErrorContains(t, failing.Health(context.Background()), "no token")
+}
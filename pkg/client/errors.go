@@ -0,0 +1,63 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// Sentinels for errors.Is; the concrete error is always *APIError.
+var (
+	ErrBadRequest  = errors.New("usrsvc: bad request")
+	ErrNotFound    = errors.New("usrsvc: not found")
+	ErrConflict    = errors.New("usrsvc: conflict")
+	ErrValidation  = errors.New("usrsvc: validation failed")
+	ErrServer      = errors.New("usrsvc: server error")
+	ErrUnavailable = errors.New("usrsvc: unavailable")
+)
+
+// APIError is the server's error payload plus the HTTP status. Fields maps
+// request paths such as "cst_email" or "family[0].fl_dob" to messages.
+type APIError struct {
+	StatusCode int
+	Message    string
+	Fields     map[string]string
+}
+
+func (e *APIError) Error() string {
+	s := "usrsvc: " + strconv.Itoa(e.StatusCode) + " " + e.Message
+	for k, v := range e.Fields {
+		s += "; " + k + ": " + v
+	}
+	return s
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrValidation:
+		return e.StatusCode == http.StatusUnprocessableEntity
+	case ErrUnavailable:
+		return retryable(e.StatusCode)
+	case ErrServer:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+func decodeError(status int, raw []byte) error {
+	var body struct {
+		Message string            `json:"message"`
+		Fields  map[string]string `json:"fields"`
+	}
+	if json.Unmarshal(raw, &body) != nil || body.Message == "" {
+		body.Message = http.StatusText(status)
+	}
+	return &APIError{StatusCode: status, Message: body.Message, Fields: body.Fields}
+}
@@ -0,0 +1,886 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: usrsvc/v1/customer.proto
+
+package usrsvcv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type FamilyMember struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Relation      string                 `protobuf:"bytes,2,opt,name=relation,proto3" json:"relation,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Dob           string                 `protobuf:"bytes,4,opt,name=dob,proto3" json:"dob,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FamilyMember) Reset() {
+	*x = FamilyMember{}
+	mi := &file_usrsvc_v1_customer_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FamilyMember) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FamilyMember) ProtoMessage() {}
+
+func (x *FamilyMember) ProtoReflect() protoreflect.Message {
+	mi := &file_usrsvc_v1_customer_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FamilyMember.ProtoReflect.Descriptor instead.
+func (*FamilyMember) Descriptor() ([]byte, []int) {
+	return file_usrsvc_v1_customer_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *FamilyMember) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *FamilyMember) GetRelation() string {
+	if x != nil {
+		return x.Relation
+	}
+	return ""
+}
+
+func (x *FamilyMember) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *FamilyMember) GetDob() string {
+	if x != nil {
+		return x.Dob
+	}
+	return ""
+}
+
+type Customer struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	NationalityId int32                  `protobuf:"varint,2,opt,name=nationality_id,json=nationalityId,proto3" json:"nationality_id,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Dob           string                 `protobuf:"bytes,4,opt,name=dob,proto3" json:"dob,omitempty"`
+	PhoneNum      string                 `protobuf:"bytes,5,opt,name=phone_num,json=phoneNum,proto3" json:"phone_num,omitempty"`
+	PhoneE164     string                 `protobuf:"bytes,6,opt,name=phone_e164,json=phoneE164,proto3" json:"phone_e164,omitempty"`
+	Email         string                 `protobuf:"bytes,7,opt,name=email,proto3" json:"email,omitempty"`
+	Family        []*FamilyMember        `protobuf:"bytes,8,rep,name=family,proto3" json:"family,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Customer) Reset() {
+	*x = Customer{}
+	mi := &file_usrsvc_v1_customer_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Customer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Customer) ProtoMessage() {}
+
+func (x *Customer) ProtoReflect() protoreflect.Message {
+	mi := &file_usrsvc_v1_customer_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Customer.ProtoReflect.Descriptor instead.
+func (*Customer) Descriptor() ([]byte, []int) {
+	return file_usrsvc_v1_customer_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Customer) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Customer) GetNationalityId() int32 {
+	if x != nil {
+		return x.NationalityId
+	}
+	return 0
+}
+
+func (x *Customer) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Customer) GetDob() string {
+	if x != nil {
+		return x.Dob
+	}
+	return ""
+}
+
+func (x *Customer) GetPhoneNum() string {
+	if x != nil {
+		return x.PhoneNum
+	}
+	return ""
+}
+
+func (x *Customer) GetPhoneE164() string {
+	if x != nil {
+		return x.PhoneE164
+	}
+	return ""
+}
+
+func (x *Customer) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *Customer) GetFamily() []*FamilyMember {
+	if x != nil {
+		return x.Family
+	}
+	return nil
+}
+
+// CustomerInput is the writable part of Customer; ids are ignored.
+type CustomerInput struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NationalityId int32                  `protobuf:"varint,1,opt,name=nationality_id,json=nationalityId,proto3" json:"nationality_id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Dob           string                 `protobuf:"bytes,3,opt,name=dob,proto3" json:"dob,omitempty"`
+	PhoneNum      string                 `protobuf:"bytes,4,opt,name=phone_num,json=phoneNum,proto3" json:"phone_num,omitempty"`
+	Email         string                 `protobuf:"bytes,5,opt,name=email,proto3" json:"email,omitempty"`
+	Family        []*FamilyMember        `protobuf:"bytes,6,rep,name=family,proto3" json:"family,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CustomerInput) Reset() {
+	*x = CustomerInput{}
+	mi := &file_usrsvc_v1_customer_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CustomerInput) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CustomerInput) ProtoMessage() {}
+
+func (x *CustomerInput) ProtoReflect() protoreflect.Message {
+	mi := &file_usrsvc_v1_customer_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CustomerInput.ProtoReflect.Descriptor instead.
+func (*CustomerInput) Descriptor() ([]byte, []int) {
+	return file_usrsvc_v1_customer_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CustomerInput) GetNationalityId() int32 {
+	if x != nil {
+		return x.NationalityId
+	}
+	return 0
+}
+
+func (x *CustomerInput) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CustomerInput) GetDob() string {
+	if x != nil {
+		return x.Dob
+	}
+	return ""
+}
+
+func (x *CustomerInput) GetPhoneNum() string {
+	if x != nil {
+		return x.PhoneNum
+	}
+	return ""
+}
+
+func (x *CustomerInput) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *CustomerInput) GetFamily() []*FamilyMember {
+	if x != nil {
+		return x.Family
+	}
+	return nil
+}
+
+type Nationality struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Code          *string                `protobuf:"bytes,3,opt,name=code,proto3,oneof" json:"code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Nationality) Reset() {
+	*x = Nationality{}
+	mi := &file_usrsvc_v1_customer_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Nationality) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Nationality) ProtoMessage() {}
+
+func (x *Nationality) ProtoReflect() protoreflect.Message {
+	mi := &file_usrsvc_v1_customer_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Nationality.ProtoReflect.Descriptor instead.
+func (*Nationality) Descriptor() ([]byte, []int) {
+	return file_usrsvc_v1_customer_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Nationality) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Nationality) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Nationality) GetCode() string {
+	if x != nil && x.Code != nil {
+		return *x.Code
+	}
+	return ""
+}
+
+type ListCustomersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Page          int32                  `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
+	Size          int32                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	Search        string                 `protobuf:"bytes,3,opt,name=search,proto3" json:"search,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCustomersRequest) Reset() {
+	*x = ListCustomersRequest{}
+	mi := &file_usrsvc_v1_customer_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCustomersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCustomersRequest) ProtoMessage() {}
+
+func (x *ListCustomersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_usrsvc_v1_customer_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCustomersRequest.ProtoReflect.Descriptor instead.
+func (*ListCustomersRequest) Descriptor() ([]byte, []int) {
+	return file_usrsvc_v1_customer_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListCustomersRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListCustomersRequest) GetSize() int32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *ListCustomersRequest) GetSearch() string {
+	if x != nil {
+		return x.Search
+	}
+	return ""
+}
+
+type ListCustomersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Customers     []*Customer            `protobuf:"bytes,1,rep,name=customers,proto3" json:"customers,omitempty"`
+	Total         int32                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCustomersResponse) Reset() {
+	*x = ListCustomersResponse{}
+	mi := &file_usrsvc_v1_customer_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCustomersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCustomersResponse) ProtoMessage() {}
+
+func (x *ListCustomersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_usrsvc_v1_customer_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCustomersResponse.ProtoReflect.Descriptor instead.
+func (*ListCustomersResponse) Descriptor() ([]byte, []int) {
+	return file_usrsvc_v1_customer_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListCustomersResponse) GetCustomers() []*Customer {
+	if x != nil {
+		return x.Customers
+	}
+	return nil
+}
+
+func (x *ListCustomersResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+type GetCustomerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCustomerRequest) Reset() {
+	*x = GetCustomerRequest{}
+	mi := &file_usrsvc_v1_customer_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCustomerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCustomerRequest) ProtoMessage() {}
+
+func (x *GetCustomerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_usrsvc_v1_customer_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCustomerRequest.ProtoReflect.Descriptor instead.
+func (*GetCustomerRequest) Descriptor() ([]byte, []int) {
+	return file_usrsvc_v1_customer_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetCustomerRequest) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type CreateCustomerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Customer      *CustomerInput         `protobuf:"bytes,1,opt,name=customer,proto3" json:"customer,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateCustomerRequest) Reset() {
+	*x = CreateCustomerRequest{}
+	mi := &file_usrsvc_v1_customer_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateCustomerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateCustomerRequest) ProtoMessage() {}
+
+func (x *CreateCustomerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_usrsvc_v1_customer_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateCustomerRequest.ProtoReflect.Descriptor instead.
+func (*CreateCustomerRequest) Descriptor() ([]byte, []int) {
+	return file_usrsvc_v1_customer_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CreateCustomerRequest) GetCustomer() *CustomerInput {
+	if x != nil {
+		return x.Customer
+	}
+	return nil
+}
+
+type UpdateCustomerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Customer      *CustomerInput         `protobuf:"bytes,2,opt,name=customer,proto3" json:"customer,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateCustomerRequest) Reset() {
+	*x = UpdateCustomerRequest{}
+	mi := &file_usrsvc_v1_customer_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateCustomerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateCustomerRequest) ProtoMessage() {}
+
+func (x *UpdateCustomerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_usrsvc_v1_customer_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateCustomerRequest.ProtoReflect.Descriptor instead.
+func (*UpdateCustomerRequest) Descriptor() ([]byte, []int) {
+	return file_usrsvc_v1_customer_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *UpdateCustomerRequest) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateCustomerRequest) GetCustomer() *CustomerInput {
+	if x != nil {
+		return x.Customer
+	}
+	return nil
+}
+
+type DeleteCustomerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteCustomerRequest) Reset() {
+	*x = DeleteCustomerRequest{}
+	mi := &file_usrsvc_v1_customer_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteCustomerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteCustomerRequest) ProtoMessage() {}
+
+func (x *DeleteCustomerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_usrsvc_v1_customer_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteCustomerRequest.ProtoReflect.Descriptor instead.
+func (*DeleteCustomerRequest) Descriptor() ([]byte, []int) {
+	return file_usrsvc_v1_customer_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DeleteCustomerRequest) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type DeleteCustomerResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteCustomerResponse) Reset() {
+	*x = DeleteCustomerResponse{}
+	mi := &file_usrsvc_v1_customer_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteCustomerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteCustomerResponse) ProtoMessage() {}
+
+func (x *DeleteCustomerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_usrsvc_v1_customer_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteCustomerResponse.ProtoReflect.Descriptor instead.
+func (*DeleteCustomerResponse) Descriptor() ([]byte, []int) {
+	return file_usrsvc_v1_customer_proto_rawDescGZIP(), []int{10}
+}
+
+type ListNationalitiesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListNationalitiesRequest) Reset() {
+	*x = ListNationalitiesRequest{}
+	mi := &file_usrsvc_v1_customer_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListNationalitiesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListNationalitiesRequest) ProtoMessage() {}
+
+func (x *ListNationalitiesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_usrsvc_v1_customer_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListNationalitiesRequest.ProtoReflect.Descriptor instead.
+func (*ListNationalitiesRequest) Descriptor() ([]byte, []int) {
+	return file_usrsvc_v1_customer_proto_rawDescGZIP(), []int{11}
+}
+
+type ListNationalitiesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Nationalities []*Nationality         `protobuf:"bytes,1,rep,name=nationalities,proto3" json:"nationalities,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListNationalitiesResponse) Reset() {
+	*x = ListNationalitiesResponse{}
+	mi := &file_usrsvc_v1_customer_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListNationalitiesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListNationalitiesResponse) ProtoMessage() {}
+
+func (x *ListNationalitiesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_usrsvc_v1_customer_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListNationalitiesResponse.ProtoReflect.Descriptor instead.
+func (*ListNationalitiesResponse) Descriptor() ([]byte, []int) {
+	return file_usrsvc_v1_customer_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ListNationalitiesResponse) GetNationalities() []*Nationality {
+	if x != nil {
+		return x.Nationalities
+	}
+	return nil
+}
+
+var File_usrsvc_v1_customer_proto protoreflect.FileDescriptor
+
+const file_usrsvc_v1_customer_proto_rawDesc = "" +
+	"\n" +
+	"\x18usrsvc/v1/customer.proto\x12\tusrsvc.v1\"`\n" +
+	"\fFamilyMember\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x1a\n" +
+	"\brelation\x18\x02 \x01(\tR\brelation\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x10\n" +
+	"\x03dob\x18\x04 \x01(\tR\x03dob\"\xea\x01\n" +
+	"\bCustomer\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12%\n" +
+	"\x0enationality_id\x18\x02 \x01(\x05R\rnationalityId\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x10\n" +
+	"\x03dob\x18\x04 \x01(\tR\x03dob\x12\x1b\n" +
+	"\tphone_num\x18\x05 \x01(\tR\bphoneNum\x12\x1d\n" +
+	"\n" +
+	"phone_e164\x18\x06 \x01(\tR\tphoneE164\x12\x14\n" +
+	"\x05email\x18\a \x01(\tR\x05email\x12/\n" +
+	"\x06family\x18\b \x03(\v2\x17.usrsvc.v1.FamilyMemberR\x06family\"\xc0\x01\n" +
+	"\rCustomerInput\x12%\n" +
+	"\x0enationality_id\x18\x01 \x01(\x05R\rnationalityId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x10\n" +
+	"\x03dob\x18\x03 \x01(\tR\x03dob\x12\x1b\n" +
+	"\tphone_num\x18\x04 \x01(\tR\bphoneNum\x12\x14\n" +
+	"\x05email\x18\x05 \x01(\tR\x05email\x12/\n" +
+	"\x06family\x18\x06 \x03(\v2\x17.usrsvc.v1.FamilyMemberR\x06family\"S\n" +
+	"\vNationality\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x17\n" +
+	"\x04code\x18\x03 \x01(\tH\x00R\x04code\x88\x01\x01B\a\n" +
+	"\x05_code\"V\n" +
+	"\x14ListCustomersRequest\x12\x12\n" +
+	"\x04page\x18\x01 \x01(\x05R\x04page\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x05R\x04size\x12\x16\n" +
+	"\x06search\x18\x03 \x01(\tR\x06search\"`\n" +
+	"\x15ListCustomersResponse\x121\n" +
+	"\tcustomers\x18\x01 \x03(\v2\x13.usrsvc.v1.CustomerR\tcustomers\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x05R\x05total\"$\n" +
+	"\x12GetCustomerRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\"M\n" +
+	"\x15CreateCustomerRequest\x124\n" +
+	"\bcustomer\x18\x01 \x01(\v2\x18.usrsvc.v1.CustomerInputR\bcustomer\"]\n" +
+	"\x15UpdateCustomerRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x124\n" +
+	"\bcustomer\x18\x02 \x01(\v2\x18.usrsvc.v1.CustomerInputR\bcustomer\"'\n" +
+	"\x15DeleteCustomerRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\"\x18\n" +
+	"\x16DeleteCustomerResponse\"\x1a\n" +
+	"\x18ListNationalitiesRequest\"Y\n" +
+	"\x19ListNationalitiesResponse\x12<\n" +
+	"\rnationalities\x18\x01 \x03(\v2\x16.usrsvc.v1.NationalityR\rnationalities2\xf1\x03\n" +
+	"\x0fCustomerService\x12R\n" +
+	"\rListCustomers\x12\x1f.usrsvc.v1.ListCustomersRequest\x1a .usrsvc.v1.ListCustomersResponse\x12A\n" +
+	"\vGetCustomer\x12\x1d.usrsvc.v1.GetCustomerRequest\x1a\x13.usrsvc.v1.Customer\x12G\n" +
+	"\x0eCreateCustomer\x12 .usrsvc.v1.CreateCustomerRequest\x1a\x13.usrsvc.v1.Customer\x12G\n" +
+	"\x0eUpdateCustomer\x12 .usrsvc.v1.UpdateCustomerRequest\x1a\x13.usrsvc.v1.Customer\x12U\n" +
+	"\x0eDeleteCustomer\x12 .usrsvc.v1.DeleteCustomerRequest\x1a!.usrsvc.v1.DeleteCustomerResponse\x12^\n" +
+	"\x11ListNationalities\x12#.usrsvc.v1.ListNationalitiesRequest\x1a$.usrsvc.v1.ListNationalitiesResponseB\"Z usrsvc/pkg/pb/usrsvc/v1;usrsvcv1b\x06proto3"
+
+var (
+	file_usrsvc_v1_customer_proto_rawDescOnce sync.Once
+	file_usrsvc_v1_customer_proto_rawDescData []byte
+)
+
+func file_usrsvc_v1_customer_proto_rawDescGZIP() []byte {
+	file_usrsvc_v1_customer_proto_rawDescOnce.Do(func() {
+		file_usrsvc_v1_customer_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_usrsvc_v1_customer_proto_rawDesc), len(file_usrsvc_v1_customer_proto_rawDesc)))
+	})
+	return file_usrsvc_v1_customer_proto_rawDescData
+}
+
+var file_usrsvc_v1_customer_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_usrsvc_v1_customer_proto_goTypes = []any{
+	(*FamilyMember)(nil),              // 0: usrsvc.v1.FamilyMember
+	(*Customer)(nil),                  // 1: usrsvc.v1.Customer
+	(*CustomerInput)(nil),             // 2: usrsvc.v1.CustomerInput
+	(*Nationality)(nil),               // 3: usrsvc.v1.Nationality
+	(*ListCustomersRequest)(nil),      // 4: usrsvc.v1.ListCustomersRequest
+	(*ListCustomersResponse)(nil),     // 5: usrsvc.v1.ListCustomersResponse
+	(*GetCustomerRequest)(nil),        // 6: usrsvc.v1.GetCustomerRequest
+	(*CreateCustomerRequest)(nil),     // 7: usrsvc.v1.CreateCustomerRequest
+	(*UpdateCustomerRequest)(nil),     // 8: usrsvc.v1.UpdateCustomerRequest
+	(*DeleteCustomerRequest)(nil),     // 9: usrsvc.v1.DeleteCustomerRequest
+	(*DeleteCustomerResponse)(nil),    // 10: usrsvc.v1.DeleteCustomerResponse
+	(*ListNationalitiesRequest)(nil),  // 11: usrsvc.v1.ListNationalitiesRequest
+	(*ListNationalitiesResponse)(nil), // 12: usrsvc.v1.ListNationalitiesResponse
+}
+var file_usrsvc_v1_customer_proto_depIdxs = []int32{
+	0,  // 0: usrsvc.v1.Customer.family:type_name -> usrsvc.v1.FamilyMember
+	0,  // 1: usrsvc.v1.CustomerInput.family:type_name -> usrsvc.v1.FamilyMember
+	1,  // 2: usrsvc.v1.ListCustomersResponse.customers:type_name -> usrsvc.v1.Customer
+	2,  // 3: usrsvc.v1.CreateCustomerRequest.customer:type_name -> usrsvc.v1.CustomerInput
+	2,  // 4: usrsvc.v1.UpdateCustomerRequest.customer:type_name -> usrsvc.v1.CustomerInput
+	3,  // 5: usrsvc.v1.ListNationalitiesResponse.nationalities:type_name -> usrsvc.v1.Nationality
+	4,  // 6: usrsvc.v1.CustomerService.ListCustomers:input_type -> usrsvc.v1.ListCustomersRequest
+	6,  // 7: usrsvc.v1.CustomerService.GetCustomer:input_type -> usrsvc.v1.GetCustomerRequest
+	7,  // 8: usrsvc.v1.CustomerService.CreateCustomer:input_type -> usrsvc.v1.CreateCustomerRequest
+	8,  // 9: usrsvc.v1.CustomerService.UpdateCustomer:input_type -> usrsvc.v1.UpdateCustomerRequest
+	9,  // 10: usrsvc.v1.CustomerService.DeleteCustomer:input_type -> usrsvc.v1.DeleteCustomerRequest
+	11, // 11: usrsvc.v1.CustomerService.ListNationalities:input_type -> usrsvc.v1.ListNationalitiesRequest
+	5,  // 12: usrsvc.v1.CustomerService.ListCustomers:output_type -> usrsvc.v1.ListCustomersResponse
+	1,  // 13: usrsvc.v1.CustomerService.GetCustomer:output_type -> usrsvc.v1.Customer
+	1,  // 14: usrsvc.v1.CustomerService.CreateCustomer:output_type -> usrsvc.v1.Customer
+	1,  // 15: usrsvc.v1.CustomerService.UpdateCustomer:output_type -> usrsvc.v1.Customer
+	10, // 16: usrsvc.v1.CustomerService.DeleteCustomer:output_type -> usrsvc.v1.DeleteCustomerResponse
+	12, // 17: usrsvc.v1.CustomerService.ListNationalities:output_type -> usrsvc.v1.ListNationalitiesResponse
+	12, // [12:18] is the sub-list for method output_type
+	6,  // [6:12] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_usrsvc_v1_customer_proto_init() }
+func file_usrsvc_v1_customer_proto_init() {
+	if File_usrsvc_v1_customer_proto != nil {
+		return
+	}
+	file_usrsvc_v1_customer_proto_msgTypes[3].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_usrsvc_v1_customer_proto_rawDesc), len(file_usrsvc_v1_customer_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   13,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_usrsvc_v1_customer_proto_goTypes,
+		DependencyIndexes: file_usrsvc_v1_customer_proto_depIdxs,
+		MessageInfos:      file_usrsvc_v1_customer_proto_msgTypes,
+	}.Build()
+	File_usrsvc_v1_customer_proto = out.File
+	file_usrsvc_v1_customer_proto_goTypes = nil
+	file_usrsvc_v1_customer_proto_depIdxs = nil
+}